@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func main() {
+	var dbPath string
+	var since time.Duration
+	var outPath string
+	flag.StringVar(&dbPath, "db", audit.DefaultSQLitePath, "sqlite path")
+	flag.DurationVar(&since, "since", 0, "only include gate calls newer than this (0 = all)")
+	flag.StringVar(&outPath, "out", "", "output report path")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		exitErr(err)
+	}
+	db, err := sqlite.OpenReadOnly(dbPath, cfg)
+	if err != nil {
+		exitErr(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if err := sqlite.RequireMigrated(db); err != nil {
+		exitErr(err)
+	}
+	sinceMs := int64(0)
+	if since > 0 {
+		sinceMs = time.Now().Add(-since).UnixMilli()
+	}
+	report, err := aigate.BuildEvaluationReport(context.Background(), db, sinceMs)
+	if err != nil {
+		exitErr(err)
+	}
+	buf, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		exitErr(err)
+	}
+	if outPath != "" {
+		if err := os.WriteFile(outPath, buf, 0o600); err != nil {
+			exitErr(err)
+		}
+	}
+	fmt.Printf("%s\n", string(buf))
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func main() {
+	var dbPath string
+	var jsonlDir string
+	var keyPath string
+	var asJSON bool
+	flag.StringVar(&dbPath, "db", audit.DefaultSQLitePath, "sqlite path")
+	flag.StringVar(&jsonlDir, "jsonl-dir", audit.DefaultJSONLDir, "jsonl audit directory")
+	flag.StringVar(&keyPath, "key", "", "manifest key path; the .pub file for ed25519 (default from config)")
+	flag.BoolVar(&asJSON, "json", false, "print the report as json")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		exitErr(err)
+	}
+	if keyPath == "" {
+		keyPath = cfg.AuditManifestKeyPath
+		if cfg.AuditManifestAlg == audit.ManifestAlgEd25519 {
+			keyPath = audit.ManifestPublicKeyPath(keyPath)
+		}
+	}
+	key, err := audit.LoadManifestVerifyKey(keyPath, cfg.AuditManifestAlg)
+	if err != nil {
+		exitErr(err)
+	}
+	db, err := sqlite.OpenReadOnly(dbPath, cfg)
+	if err != nil {
+		exitErr(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if err := sqlite.RequireMigrated(db); err != nil {
+		exitErr(err)
+	}
+	report, err := audit.Verify(context.Background(), audit.VerifyOptions{DB: db, JSONLDir: jsonlDir, Key: key})
+	if err != nil {
+		exitErr(err)
+	}
+	if asJSON {
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			exitErr(err)
+		}
+		fmt.Printf("%s\n", string(buf))
+	} else {
+		for _, finding := range report.Findings {
+			fmt.Println(finding.String())
+		}
+		fmt.Printf("sqlite_records=%d sqlite_unchained=%d jsonl_records=%d jsonl_unchained=%d jsonl_days=%d manifests_verified=%d\n",
+			report.SQLiteRecords, report.SQLiteUnchained, report.JSONLRecords, report.JSONLUnchained, report.JSONLDays, report.ManifestsVerified)
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(2)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+const usage = `usage: audit <events|intents|gate|trace> [flags]
+
+  events            audit_events from SQLite and the rotated JSONL files
+  intents           order_intents
+  gate              ai_gate_events
+  trace <id>        every row that carries the id (run, cycle, decision, snapshot,
+                    order intent or client order id) plus rows of the same decision
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	var (
+		dbPath   string
+		jsonlDir string
+		source   string
+		format   string
+		from     string
+		to       string
+		filter   audit.QueryFilter
+	)
+	fs.StringVar(&dbPath, "db", audit.DefaultSQLitePath, "sqlite path")
+	fs.StringVar(&jsonlDir, "jsonl-dir", audit.DefaultJSONLDir, "jsonl audit directory")
+	fs.StringVar(&source, "source", "all", "events source: all, sqlite or jsonl")
+	fs.StringVar(&format, "format", audit.QueryFormatTable, "output format: table, jsonl or csv")
+	fs.StringVar(&filter.RunID, "run", "", "run_id")
+	fs.StringVar(&filter.CycleID, "cycle", "", "cycle_id")
+	fs.StringVar(&filter.DecisionID, "decision", "", "decision_id")
+	fs.StringVar(&filter.OrderIntentID, "intent", "", "order_intent_id")
+	fs.StringVar(&filter.Symbol, "symbol", "", "symbol")
+	fs.StringVar(&filter.EventType, "type", "", "event_type (audit_events, ai_gate_events) or action (order_intents)")
+	fs.StringVar(&filter.Reason, "reason", "", "reason code")
+	fs.StringVar(&from, "from", "", "start time, inclusive (unix ms, YYYY-MM-DD or RFC3339)")
+	fs.StringVar(&to, "to", "", "end time, exclusive (unix ms, YYYY-MM-DD or RFC3339)")
+	fs.IntVar(&filter.Limit, "limit", 200, "keep the most recent N rows (0 = all)")
+	args := os.Args[2:]
+	traceID := ""
+	if command == "trace" {
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			exitErr(fmt.Errorf("trace requires an id"))
+		}
+		traceID, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		exitErr(err)
+	}
+	var err error
+	if filter.FromMs, err = audit.ParseQueryTime(from); err != nil {
+		exitErr(err)
+	}
+	if filter.ToMs, err = audit.ParseQueryTime(to); err != nil {
+		exitErr(err)
+	}
+	sources := []string{audit.QuerySourceSQLite, audit.QuerySourceJSONL}
+	if source != "all" {
+		sources = []string{source}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		exitErr(err)
+	}
+	db, err := sqlite.OpenReadOnly(dbPath, cfg)
+	if err != nil {
+		exitErr(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if err := sqlite.RequireMigrated(db); err != nil {
+		exitErr(err)
+	}
+	querier := audit.Querier{DB: db, JSONLDir: jsonlDir}
+	ctx := context.Background()
+	var rows []audit.QueryRow
+	switch command {
+	case "events":
+		rows, err = querier.Events(ctx, filter, sources)
+	case "intents":
+		rows, err = querier.Intents(ctx, filter)
+	case "gate":
+		rows, err = querier.Gate(ctx, filter)
+	case "trace":
+		rows, err = querier.Trace(ctx, traceID, filter, sources)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		exitErr(err)
+	}
+	if err := audit.WriteQueryRows(os.Stdout, format, rows); err != nil {
+		exitErr(err)
+	}
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
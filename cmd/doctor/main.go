@@ -1,30 +1,64 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	"github.com/RodrigoBeloyanis/livespot/internal/doctor"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/RodrigoBeloyanis/livespot/internal/secrets"
 )
 
 func main() {
+	var jsonOut bool
+	var baseURL string
+	flag.BoolVar(&jsonOut, "json", false, "print machine-readable JSON report")
+	flag.StringVar(&baseURL, "binance-url", "", "binance REST base url")
+	flag.Parse()
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Printf("FAIL config: %v\n", err)
 		os.Exit(1)
 	}
-	results := doctor.RunAll(doctor.Runner{Cfg: cfg})
-	exit := 0
-	for _, result := range results {
-		status := "PASS"
-		if !result.OK {
-			status = "FAIL"
-			exit = 1
+	provider, err := secrets.Load(cfg)
+	if err != nil {
+		fmt.Printf("FAIL secrets: %v\n", err)
+		os.Exit(1)
+	}
+	signer, _ := binance.NewSigner(provider.Getenv(secrets.BinanceAPISecret), cfg.BinancePrivateKeyPath)
+	client, err := binance.NewClient(cfg, binance.Options{
+		BaseURL: baseURL,
+		APIKey:  provider.Getenv(secrets.BinanceAPIKey),
+		Signer:  signer,
+	})
+	if err != nil {
+		fmt.Printf("FAIL binance: %v\n", err)
+		os.Exit(1)
+	}
+	results := doctor.RunAll(doctor.Runner{Cfg: cfg, Exchange: client, Getenv: provider.Getenv})
+	provider.Scrub()
+	report := doctor.NewReport(results, time.Now())
+	if jsonOut {
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Printf("FAIL report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", string(buf))
+	} else {
+		for _, result := range results {
+			status := "PASS"
+			if !result.OK {
+				status = "FAIL"
+			}
+			fmt.Printf("%s %s: %s\n", status, result.Name, result.Details)
 		}
-		fmt.Printf("%s %s: %s\n", status, result.Name, result.Details)
 	}
-	if exit != 0 {
-		os.Exit(exit)
+	if !report.OK {
+		os.Exit(1)
 	}
 }
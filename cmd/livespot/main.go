@@ -4,32 +4,59 @@ import (
 	"context"
 	"flag"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/app"
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/reports"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
 	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
 	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability/alerts"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
+	"github.com/RodrigoBeloyanis/livespot/internal/secrets"
 	"github.com/RodrigoBeloyanis/livespot/internal/webui"
 )
 
 func main() {
 	dryRun := flag.Bool("dry-run", false, "run a single dry-run cycle with audit events")
+	reportDay := flag.String("report-day", "", "write the daily summary report for a UTC day (YYYY-MM-DD) and exit")
+	live := flag.Bool("live", false, "run LIVE; trading stages stay in PAUSE until the live checklist passes")
+	liveSymbols := flag.String("live-symbols", "BTCUSDT", "comma separated symbols probed by the live checklist")
+	binanceURL := flag.String("binance-url", "", "binance REST base url")
+	binanceWSURL := flag.String("binance-ws-url", "", "binance WS base url")
+	binanceWSAPIURL := flag.String("binance-ws-api-url", "", "binance WS API url for order placement")
 	flag.Parse()
 
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("config load failed: %v", err)
 	}
-	writer, err := audit.NewWriter(cfg, audit.WriterOptions{})
+	if *reportDay != "" {
+		if err := writeDailyReport(cfg, *reportDay); err != nil {
+			log.Fatalf("daily report failed: %v", err)
+		}
+		return
+	}
+	if cfg.Mode == "LIVE" && !*live && !*dryRun {
+		log.Fatalf("LIVE mode requires --live")
+	}
+	provider, err := secrets.Load(cfg)
 	if err != nil {
-		log.Fatalf("audit writer init failed: %v", err)
+		log.Fatalf("secrets load failed: %v", err)
+	}
+	runLock, err := ops.AcquireRunLock(cfg.RunLockPath, time.Now())
+	if err != nil {
+		log.Fatalf("run lock failed: %v", err)
 	}
 	defer func() {
-		_ = writer.Close()
+		_ = runLock.Release()
 	}()
-
 	webDB, err := sqlite.Open(audit.DefaultSQLitePath, cfg)
 	if err != nil {
 		log.Fatalf("webui db open failed: %v", err)
@@ -37,13 +64,118 @@ func main() {
 	defer func() {
 		_ = webDB.Close()
 	}()
-	if err := sqlite.Migrate(webDB, time.Now()); err != nil {
-		log.Fatalf("webui db migrate failed: %v", err)
+	migrated, err := ops.MigrateWithBackup(context.Background(), webDB, audit.DefaultSQLitePath, cfg.BackupDir, time.Now())
+	if err != nil {
+		log.Fatalf("db migrate failed: %v", err)
+	}
+	if migrated.Backup != nil {
+		log.Printf("pre-migration backup written to %s", migrated.Backup.Path)
+	}
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{})
+	if err != nil {
+		log.Fatalf("audit writer init failed: %v", err)
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+
+	loop, err := app.NewLoop(cfg, writer, observability.ConsoleStageReporter{}, time.Now)
+	if err != nil {
+		log.Fatalf("loop init failed: %v", err)
+	}
+	quarantine, err := app.NewQuarantineService(context.Background(), cfg, webDB, writer, time.Now)
+	if err != nil {
+		log.Fatalf("quarantine init failed: %v", err)
+	}
+	loop.EnableQuarantine(quarantine)
+	var feed *app.MarketFeed
+	var session *app.OrderSession
+	if *live {
+		signer, err := binance.NewSigner(provider.Getenv(secrets.BinanceAPISecret), cfg.BinancePrivateKeyPath)
+		if err != nil {
+			log.Fatalf("binance signer init failed: %v", err)
+		}
+		rest, err := binance.NewClient(cfg, binance.Options{
+			BaseURL: *binanceURL,
+			APIKey:  provider.Getenv(secrets.BinanceAPIKey),
+			Signer:  signer,
+		})
+		if err != nil {
+			log.Fatalf("binance client init failed: %v", err)
+		}
+		ws := binance.NewWSClient(binance.WSOptions{BaseURL: *binanceWSURL, OnDisconnect: quarantine.RecordWSDisconnect})
+		probes := app.NewBinanceLiveProbes(cfg, webDB, rest, loop.WSLastMsgMs, strings.Split(*liveSymbols, ","), time.Now)
+		checker, err := app.NewLiveChecker(cfg, probes, os.Stat)
+		if err != nil {
+			log.Fatalf("live checklist init failed: %v", err)
+		}
+		loop.EnableLiveChecklist(checker)
+		loop.EnableRateLimitMetrics(rest)
+		feed = app.NewMarketFeed(ws, strings.Split(*liveSymbols, ","), loop, time.Now)
+		wsAPI := binance.NewWSAPIClient(cfg, binance.WSAPIOptions{
+			URL:           *binanceWSAPIURL,
+			APIKey:        provider.Getenv(secrets.BinanceAPIKey),
+			Signer:        signer,
+			ClockOffsetMs: rest.ClockOffsetMs,
+		})
+		session = app.NewOrderSession(wsAPI)
+		ledger := executor.NewLedger(webDB, time.Now)
+		ledger.Outcomes = quarantine
+		loop.EnableExecution(ledger, app.NewOrderClient(binance.NewOrderTransport(rest, wsAPI)))
 	}
 	webServer, err := webui.NewServer(cfg, webDB, writer, time.Now)
 	if err != nil {
 		log.Fatalf("webui init failed: %v", err)
 	}
+	if cfg.WebuiControlEnabled {
+		if err := webServer.EnableControl(loop); err != nil {
+			log.Fatalf("webui control init failed: %v", err)
+		}
+	}
+	if cfg.AlertNotifyEnabled {
+		notifier, err := alerts.NewNotifier(cfg, writer, alerts.SinksFromConfig(cfg, provider.Getenv, nil), time.Now)
+		if err != nil {
+			log.Fatalf("alert notifier init failed: %v", err)
+		}
+		defer func() {
+			_ = notifier.Close()
+		}()
+		loop.EnableNotifier(notifier)
+	}
+	if cfg.WebuiMetricsEnabled {
+		registry := observability.NewRegistry()
+		loop.EnableMetrics(registry)
+		webServer.EnableMetrics(registry)
+	}
+	if cfg.ReportDailyEnabled {
+		generator, err := reports.NewGenerator(cfg, webDB, writer, time.Now)
+		if err != nil {
+			log.Fatalf("daily report init failed: %v", err)
+		}
+		loop.EnableDailyReport(generator)
+		webServer.EnableReports(cfg.ReportDir)
+	}
+	if cfg.RetentionEnabled {
+		retainer, err := ops.NewRetainer(cfg, webDB, writer, time.Now)
+		if err != nil {
+			log.Fatalf("retention init failed: %v", err)
+		}
+		loop.EnableRetention(retainer)
+	}
+	if cfg.BackupEnabled {
+		backuper, err := ops.NewBackuper(cfg, webDB, writer, audit.DefaultSQLitePath, time.Now)
+		if err != nil {
+			log.Fatalf("backup init failed: %v", err)
+		}
+		loop.EnableBackup(backuper)
+	}
+	aiPolicy, err := aigate.NewGatePolicy(cfg, provider.Getenv, webDB, writer, time.Now)
+	if err != nil {
+		log.Fatalf("ai gate init failed: %v", err)
+	}
+	loop.EnableAIGate(aiPolicy)
+	loop.EnableAIGateOutcomes(webDB)
+	provider.Scrub()
 	if err := webServer.Start(); err != nil {
 		log.Fatalf("webui start failed: %v", err)
 	}
@@ -51,10 +183,6 @@ func main() {
 		_ = webServer.Close()
 	}()
 
-	loop, err := app.NewLoop(cfg, writer, observability.ConsoleStageReporter{}, time.Now)
-	if err != nil {
-		log.Fatalf("loop init failed: %v", err)
-	}
 	if *dryRun {
 		if err := loop.RunDryRun(); err != nil {
 			log.Fatalf("dry run failed: %v", err)
@@ -62,7 +190,59 @@ func main() {
 		return
 	}
 
-	if err := loop.Run(context.Background()); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if feed != nil {
+		go feed.Run(ctx)
+	}
+	if session != nil {
+		go session.Run(ctx)
+	}
+	if err := loop.Run(ctx); err != nil {
 		log.Fatalf("loop failed: %v", err)
 	}
 }
+
+// writeDailyReport serves --report-day. It reads the database read-only and takes no run lock,
+// so a report can be written while the bot is running.
+func writeDailyReport(cfg config.Config, value string) error {
+	day, err := reports.ParseDay(value)
+	if err != nil {
+		return err
+	}
+	db, err := sqlite.OpenReadOnly(audit.DefaultSQLitePath, cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if err := sqlite.RequireMigrated(db); err != nil {
+		return err
+	}
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = writer.Close()
+	}()
+	generator, err := reports.NewGenerator(cfg, db, writer, time.Now)
+	if err != nil {
+		return err
+	}
+	runID, err := observability.NewRunID(time.Now())
+	if err != nil {
+		return err
+	}
+	cycleID, err := observability.NewCycleID(time.Now())
+	if err != nil {
+		return err
+	}
+	result, err := generator.Generate(context.Background(), day, runID, cycleID)
+	if err != nil {
+		return err
+	}
+	log.Printf("daily report written: %s %s", result.JSONPath, result.MarkdownPath)
+	return nil
+}
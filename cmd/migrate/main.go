@@ -1,27 +1,98 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
 )
 
 func main() {
+	var dbPath string
+	var dryRun bool
+	var status bool
+	var noBackup bool
+	flag.StringVar(&dbPath, "db", audit.DefaultSQLitePath, "sqlite path to migrate")
+	flag.BoolVar(&dryRun, "dry-run", false, "print the migrations that would run and exit without changes")
+	flag.BoolVar(&status, "status", false, "list every migration with its state and checksum")
+	flag.BoolVar(&noBackup, "no-backup", false, "skip the backup taken before pending migrations are applied")
+	flag.Parse()
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("config load failed: %v", err)
+		exitErr(err)
+	}
+	if status || dryRun {
+		if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) {
+			exitErr(fmt.Errorf("database %s does not exist; run migrate without -status or -dry-run to create it", dbPath))
+		}
+		db, err := sqlite.OpenReadOnly(dbPath, cfg)
+		if err != nil {
+			exitErr(err)
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+		statuses, err := sqlite.MigrationPlan(db)
+		if err != nil {
+			exitErr(err)
+		}
+		if status {
+			for _, s := range statuses {
+				fmt.Printf("%-45s %-4s %-17s %s\n", s.Name, s.Kind, s.State, shortSum(s.Checksum))
+			}
+		}
+		if dryRun {
+			pending := sqlite.PendingMigrations(statuses)
+			for _, s := range pending {
+				fmt.Printf("would apply %s (%s) %s\n", s.Name, s.Kind, shortSum(s.Checksum))
+			}
+			fmt.Printf("%d pending migration(s)\n", len(pending))
+		}
+		if err := sqlite.CheckMigrations(statuses); err != nil {
+			exitErr(err)
+		}
+		return
 	}
-	db, err := sqlite.Open(audit.DefaultSQLitePath, cfg)
+	db, err := sqlite.Open(dbPath, cfg)
 	if err != nil {
-		log.Fatalf("sqlite open failed: %v", err)
+		exitErr(err)
 	}
 	defer func() {
 		_ = db.Close()
 	}()
-	if err := audit.EnsureSchema(db, time.Now()); err != nil {
-		log.Fatalf("migrate failed: %v", err)
+	backupDir := cfg.BackupDir
+	if noBackup {
+		backupDir = ""
+	}
+	result, err := ops.MigrateWithBackup(context.Background(), db, dbPath, backupDir, time.Now())
+	if err != nil {
+		exitErr(err)
+	}
+	if result.Backup != nil {
+		fmt.Printf("backup written to %s\n", result.Backup.Path)
+	}
+	for _, s := range result.Applied {
+		fmt.Printf("applied %s (%s)\n", s.Name, s.Kind)
 	}
+	fmt.Printf("%d migration(s) applied\n", len(result.Applied))
+}
+
+func shortSum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
 }
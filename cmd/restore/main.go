@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
+)
+
+func main() {
+	var backupPath string
+	var dbPath string
+	var verifyOnly bool
+	flag.StringVar(&backupPath, "backup", "", "backup file (<db>.<YYYYMMDD-HHMMSS>.gz) with its .json manifest")
+	flag.StringVar(&dbPath, "db", audit.DefaultSQLitePath, "sqlite path to replace")
+	flag.BoolVar(&verifyOnly, "verify-only", false, "verify the backup and exit without restoring")
+	flag.Parse()
+
+	if backupPath == "" {
+		exitErr(fmt.Errorf("-backup is required"))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		exitErr(err)
+	}
+	ctx := context.Background()
+	if verifyOnly {
+		manifest, rawPath, err := ops.VerifyBackup(ctx, backupPath, filepath.Dir(backupPath))
+		if err != nil {
+			exitErr(err)
+		}
+		_ = os.Remove(rawPath)
+		fmt.Printf("backup ok: %s tables=%d raw_bytes=%d created_at_ms=%d\n", manifest.File, len(manifest.Tables), manifest.RawBytes, manifest.CreatedAtMs)
+		return
+	}
+	result, err := ops.RestoreSQLite(ctx, cfg, backupPath, dbPath, time.Now())
+	if err != nil {
+		exitErr(err)
+	}
+	fmt.Printf("restored %s from %s\n", result.DBPath, result.Manifest.File)
+	if result.PreviousPath != "" {
+		fmt.Printf("previous database kept at %s\n", result.PreviousPath)
+	}
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/secrets"
+)
+
+func main() {
+	var keygen bool
+	var encryptPath string
+	var check bool
+	var identityPath string
+	var outPath string
+	flag.BoolVar(&keygen, "keygen", false, "create a new identity file")
+	flag.StringVar(&encryptPath, "encrypt", "", "encrypt a NAME=value file into the secrets file")
+	flag.BoolVar(&check, "check", false, "report which secrets resolve and from which sources")
+	flag.StringVar(&identityPath, "identity", "", "identity file (default secrets_identity_path)")
+	flag.StringVar(&outPath, "out", "", "encrypted output file (default secrets_file_path)")
+	flag.Parse()
+	cfg, err := config.Load()
+	if err != nil {
+		exitErr(err)
+	}
+	if identityPath == "" {
+		identityPath = cfg.SecretsIdentityPath
+	}
+	if outPath == "" {
+		outPath = cfg.SecretsFilePath
+	}
+	switch {
+	case keygen:
+		if _, err := secrets.GenerateIdentity(identityPath); err != nil {
+			exitErr(err)
+		}
+		fmt.Printf("identity written to %s\n", identityPath)
+	case encryptPath != "":
+		if err := encryptFile(encryptPath, identityPath, outPath); err != nil {
+			exitErr(err)
+		}
+		fmt.Printf("secrets encrypted to %s\n", outPath)
+	case check:
+		provider, err := secrets.Load(cfg)
+		if err != nil {
+			exitErr(err)
+		}
+		defer provider.Scrub()
+		fmt.Printf("sources: %v\n", provider.Sources())
+		missing := 0
+		for _, name := range secrets.KnownNames {
+			status := "present"
+			if _, err := provider.Get(name); err != nil {
+				status = "missing"
+				missing++
+			}
+			fmt.Printf("%s: %s\n", name, status)
+		}
+		if missing > 0 {
+			provider.Scrub()
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func encryptFile(inPath string, identityPath string, outPath string) error {
+	id, err := secrets.LoadIdentity(identityPath)
+	if err != nil {
+		return fmt.Errorf("identity: %w", err)
+	}
+	plain, err := os.ReadFile(inPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for i := range plain {
+			plain[i] = 0
+		}
+	}()
+	if _, err := secrets.ParseDotEnv(plain); err != nil {
+		return fmt.Errorf("%s: %w", inPath, err)
+	}
+	buf, err := secrets.Encrypt(plain, id.Recipient())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, buf, 0o600)
+}
+
+func exitErr(err error) {
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
+}
@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	"github.com/RodrigoBeloyanis/livespot/internal/e2e"
 	"github.com/RodrigoBeloyanis/livespot/internal/observability"
@@ -17,79 +17,64 @@ import (
 
 func main() {
 	var duration time.Duration
-	var interval time.Duration
+	var step time.Duration
+	var throttle time.Duration
+	var chaos bool
+	var chaosEvery time.Duration
+	var symbols string
+	var seed int64
+	var verbose bool
 	var dbPath string
 	var jsonlDir string
 	var outPath string
-	flag.DurationVar(&duration, "duration", 24*time.Hour, "soak duration")
-	flag.DurationVar(&interval, "interval", time.Second, "soak tick interval")
+	flag.DurationVar(&duration, "duration", 24*time.Hour, "simulated soak duration")
+	flag.DurationVar(&step, "step", 500*time.Millisecond, "simulated time per loop cycle")
+	flag.DurationVar(&throttle, "throttle", time.Millisecond, "real pause between cycles")
+	flag.BoolVar(&chaos, "chaos", true, "inject exchange faults during the soak")
+	flag.DurationVar(&chaosEvery, "chaos-every", 30*time.Minute, "simulated time between injected faults")
+	flag.StringVar(&symbols, "symbols", "BTCUSDT,ETHUSDT", "comma separated synthetic symbols")
+	flag.Int64Var(&seed, "seed", 1, "synthetic market seed")
+	flag.BoolVar(&verbose, "verbose", false, "print every stage change")
 	flag.StringVar(&dbPath, "db", filepath.Join("var", "soak", "audit.sqlite"), "sqlite path")
 	flag.StringVar(&jsonlDir, "jsonl", filepath.Join("var", "soak", "logs"), "jsonl dir")
 	flag.StringVar(&outPath, "out", "", "output report path")
-	flag.Parse()
-
 	cfg, err := config.Load()
 	if err != nil {
 		exitErr(err)
 	}
-	writer, err := audit.NewWriter(cfg, audit.WriterOptions{
-		DBPath:   dbPath,
-		JSONLDir: jsonlDir,
-		Now:      time.Now,
-	})
-	if err != nil {
-		exitErr(err)
-	}
-	defer writer.Close()
+	slo := e2e.DefaultSoakSLO(cfg)
+	flag.Int64Var(&slo.MaxCycleP99Ms, "slo-cycle-p99-ms", slo.MaxCycleP99Ms, "max p99 cycle latency")
+	flag.Int64Var(&slo.MaxHeapGrowthBytes, "slo-heap-growth-bytes", slo.MaxHeapGrowthBytes, "max heap growth after warm-up")
+	flag.IntVar(&slo.MaxAuditQueuePct, "slo-audit-queue-pct", slo.MaxAuditQueuePct, "audit queue fill that fails the soak")
+	flag.Float64Var(&slo.MaxSysModeChangesPerHour, "slo-sysmode-changes-per-hour", slo.MaxSysModeChangesPerHour, "max SysMode changes per simulated hour")
+	flag.Float64Var(&slo.MaxPausePct, "slo-pause-pct", slo.MaxPausePct, "max percent of cycles in PAUSE")
+	flag.IntVar(&slo.MaxGoroutineLeak, "slo-goroutine-leak", slo.MaxGoroutineLeak, "max goroutines left after teardown")
+	flag.Parse()
 
+	var reporter observability.StageReporter
+	if verbose {
+		reporter = observability.ConsoleStageReporter{}
+	}
+	runner := e2e.LoopSoakRunner{Options: e2e.LoopSoakOptions{
+		Config:     cfg,
+		DBPath:     dbPath,
+		JSONLDir:   jsonlDir,
+		Symbols:    strings.Split(symbols, ","),
+		Duration:   duration,
+		Step:       step,
+		Throttle:   throttle,
+		Chaos:      chaos,
+		ChaosEvery: chaosEvery,
+		Seed:       seed,
+		Reporter:   reporter,
+	}}
 	now := time.Now()
 	runID := fmt.Sprintf("soak_%s", now.UTC().Format("20060102_150405"))
-	cycleID := fmt.Sprintf("cyc_%s", now.UTC().Format("20060102_150405"))
-
-	exchange := e2e.NewMockExchange(time.Now)
-	exchange.FiltersJSON = []byte(`{"rateLimits":[]}`)
-	exchange.DepthBySymbol["BTCUSDT"] = []byte(`{"lastUpdateId":1,"bids":[["100.0","1.0"]],"asks":[["100.1","1.0"]]}`)
-	exchange.BalancesJSON = []byte(`{"balances":[{"asset":"USDT","free":"1000","locked":"0"}]}`)
-
-	snapshot, err := e2e.SampleSnapshot(now)
-	if err != nil {
-		exitErr(err)
-	}
-	snapshotHash := snapshot.Metadata.SnapshotHash
-	decision, err := e2e.SampleDecision(now, snapshot, snapshotHash, cycleID)
-	if err != nil {
-		exitErr(err)
-	}
-
-	pipeline, err := e2e.NewPipeline(cfg, writer, observability.ConsoleStageReporter{})
-	if err != nil {
-		exitErr(err)
-	}
-
-	input := e2e.PipelineInput{
-		RunID:          runID,
-		CycleID:        cycleID,
-		Mode:           cfg.Mode,
-		Snapshot:       snapshot,
-		SnapshotHash:   snapshotHash,
-		Decision:       decision,
-		DecisionID:     decision.DecisionID,
-		Exchange:       exchange,
-		Now:            time.Now,
-		DisableEntries: true,
-	}
-	runner := e2e.SoakRunner{
-		Pipeline: pipeline,
-		Config:   cfg,
-		Input:    input,
-		Duration: duration,
-		Interval: interval,
-	}
 	result, err := runner.Run(context.Background())
 	if err != nil {
 		exitErr(err)
 	}
-	report := e2e.BuildReadinessReport(runID, result, true, true)
+	report := e2e.BuildLoopReadinessReport(runID, result, slo, true)
 	buf, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		exitErr(err)
@@ -100,6 +85,9 @@ func main() {
 		}
 	}
 	fmt.Printf("%s\n", string(buf))
+	if !report.Ready {
+		os.Exit(2)
+	}
 }
 
 func exitErr(err error) {
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/reports"
+)
+
+func (l *Loop) EnableAIGate(policy *aigate.Policy) {
+	l.aiPolicy = policy
+}
+
+// EnableAIGateOutcomes makes the daily jobs write realized and counterfactual outcomes for the
+// gate evaluation report.
+func (l *Loop) EnableAIGateOutcomes(db *sql.DB) {
+	l.aiOutcomesDB = db
+}
+
+type proposedDecision struct {
+	decision contracts.Decision
+	snapshot contracts.Snapshot
+}
+
+// ProposeDecision hands a strategy decision and the snapshot it was built from to the current
+// cycle, where AIGATE_CALL runs it through the AI_DEC policy. Call it from the loop goroutine.
+func (l *Loop) ProposeDecision(decision contracts.Decision, snapshot contracts.Snapshot) {
+	l.proposals = append(l.proposals, proposedDecision{decision: decision, snapshot: snapshot})
+}
+
+// runAIGate applies the AI_DEC policy to the cycle's proposals. PROCEED and PROCEED_TIGHTENED
+// decisions, as the policy returned them, are kept for RISK_VERDICT; BLOCK drops the decision.
+// A pause request drops the rest of the cycle and latches PAUSE until an operator resumes.
+// Without a policy, or in DEGRADE, new entries never reach the gate.
+func (l *Loop) runAIGate(ctx context.Context, runID string, cycleID string) string {
+	proposals := l.proposals
+	l.proposals = nil
+	if l.aiPolicy == nil {
+		if len(proposals) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("ai gate not enabled: %d decisions dropped", len(proposals))
+	}
+	proceed, tightened, blocked := 0, 0, 0
+	callCtx := aigate.CallContext{RunID: runID, CycleID: cycleID}
+	for _, p := range proposals {
+		if l.sysMode == health.SysModeDegrade && p.decision.Intent == contracts.IntentEntry {
+			blocked++
+			continue
+		}
+		outcome, err := l.aiPolicy.Apply(ctx, callCtx, p.decision, p.snapshot)
+		if outcome.PauseRequested {
+			l.requestAIGatePause()
+			detail := string(outcome.Result.Verdict)
+			if len(outcome.Result.Reasons) > 0 {
+				detail = string(outcome.Result.Reasons[0])
+			}
+			if err != nil {
+				detail += ": " + err.Error()
+			}
+			return "ai gate pause requested: " + detail
+		}
+		switch outcome.Action {
+		case aigate.PolicyProceed:
+			proceed++
+			l.gated = append(l.gated, outcome.Decision)
+		case aigate.PolicyProceedTightened:
+			tightened++
+			l.gated = append(l.gated, outcome.Decision)
+		default:
+			blocked++
+		}
+	}
+	summary := ""
+	if len(proposals) > 0 {
+		summary = fmt.Sprintf("ai gate: %d proceed, %d tightened, %d blocked", proceed, tightened, blocked)
+	}
+	if l.aiPolicy.CircuitOpen() {
+		if summary != "" {
+			summary += "; "
+		}
+		summary += "ai gate circuit open: entries blocked"
+	}
+	return summary
+}
+
+func (l *Loop) runAIGateOutcomes(ctx context.Context, runID string, cycleID string) string {
+	if l.aiOutcomesDB == nil {
+		return ""
+	}
+	key := reports.DayStart(l.now()).Format(reports.DayLayout)
+	if l.lastOutcomesDay == key {
+		return ""
+	}
+	l.lastOutcomesDay = key
+	realized, err := aigate.RecordRealizedOutcomes(ctx, l.aiOutcomesDB, l.now().UnixMilli())
+	if err != nil {
+		return "ai gate outcomes failed: " + err.Error()
+	}
+	counterfactual, err := aigate.RecordCounterfactualOutcomes(ctx, l.aiOutcomesDB, l.cfg, l.now().UnixMilli())
+	if err != nil {
+		return "ai gate counterfactual outcomes failed: " + err.Error()
+	}
+	if realized == 0 && counterfactual == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ai gate outcomes recorded: %d realized, %d counterfactual", realized, counterfactual)
+}
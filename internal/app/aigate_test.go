@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+)
+
+func TestAIGateErrorPausesLoopWhenMandatory(t *testing.T) {
+	cfg := config.Default()
+	loop := newAIGateTestLoop(t, cfg)
+
+	loop.ProposeDecision(contracts.Decision{DecisionID: "dec_1", Symbol: "BTCUSDT", Intent: contracts.IntentEntry}, contracts.Snapshot{Symbol: "BTCUSDT"})
+	summary := loop.runAIGate(context.Background(), "run", "cycle")
+	if !strings.Contains(summary, "pause requested") || len(loop.gated) != 0 {
+		t.Fatalf("expected pause request and no gated decision, got %q %d", summary, len(loop.gated))
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModePause || !containsReasonCode(loop.sysModeReasons, reasoncodes.AIGATE_PAUSE_REQUESTED) {
+		t.Fatalf("expected PAUSE with AIGATE_PAUSE_REQUESTED, got %s %v", loop.sysMode, loop.sysModeReasons)
+	}
+
+	if err := loop.OperatorResume("alice"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModeNormal {
+		t.Fatalf("expected resume to clear the ai gate pause, got %s", loop.sysMode)
+	}
+}
+
+func TestAIGateErrorBlocksWhenOptional(t *testing.T) {
+	cfg := config.Default()
+	cfg.AiDec = 1
+	loop := newAIGateTestLoop(t, cfg)
+
+	loop.ProposeDecision(contracts.Decision{DecisionID: "dec_1", Symbol: "BTCUSDT", Intent: contracts.IntentEntry}, contracts.Snapshot{Symbol: "BTCUSDT"})
+	summary := loop.runAIGate(context.Background(), "run", "cycle")
+	if summary != "ai gate: 0 proceed, 0 tightened, 1 blocked" || len(loop.gated) != 0 {
+		t.Fatalf("expected the decision blocked, got %q %d", summary, len(loop.gated))
+	}
+	if loop.aiGatePauseRequested() {
+		t.Fatalf("expected no pause with ai_dec=1")
+	}
+}
+
+func newAIGateTestLoop(t *testing.T, cfg config.Config) *Loop {
+	t.Helper()
+	cfg.AIGateProvider = "stub"
+	cfg.DiskFreeDegradeBytes = -1
+	cfg.DiskFreePauseBytes = -1
+	tmp := t.TempDir()
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: filepath.Join(tmp, "data", "audit.sqlite"), JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	t.Cleanup(func() { _ = writer.Close() })
+	now := time.Now()
+	clock := func() time.Time { return now }
+	loop, err := NewLoop(cfg, writer, nil, clock)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	gate, err := aigate.NewGate(cfg, aigate.NewStubProvider(aigate.StubTable{Default: &aigate.StubRule{Error: "timeout"}}), nil, clock)
+	if err != nil {
+		t.Fatalf("new gate: %v", err)
+	}
+	policy, err := aigate.NewPolicy(cfg, gate, clock)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	loop.EnableAIGate(policy)
+	loop.UpdateWSLastMsg(now)
+	loop.UpdateRESTLastSuccess(now)
+	loop.lastProgressMs = now.UnixMilli()
+	return loop
+}
+
+func containsReasonCode(reasons []reasoncodes.ReasonCode, target reasoncodes.ReasonCode) bool {
+	for _, reason := range reasons {
+		if reason == target {
+			return true
+		}
+	}
+	return false
+}
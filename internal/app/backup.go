@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
+)
+
+func (l *Loop) EnableBackup(backuper *ops.Backuper) {
+	l.backup = backuper
+}
+
+func (l *Loop) runBackup(ctx context.Context, runID string, cycleID string) string {
+	if l.backup == nil {
+		return ""
+	}
+	key := l.now().UTC().Format("2006-01-02")
+	if l.lastBackupDay == key {
+		return ""
+	}
+	if l.sysMode != health.SysModeNormal {
+		return ""
+	}
+	if l.diskFreeBytes > 0 && l.diskFreeBytes < l.cfg.DiskFreeDegradeBytes {
+		return ""
+	}
+	l.lastBackupDay = key
+	result, err := l.backup.Run(ctx, runID, cycleID)
+	if err != nil {
+		return "backup failed: " + err.Error()
+	}
+	return fmt.Sprintf("backup written: %s (%d bytes)", result.Manifest.File, result.Manifest.Bytes)
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+)
+
+const intentResolveBatch = 20
+
+// EnableExecution gives the loop the intent ledger and the order client. RECONCILE_REST then
+// resolves SENT_UNKNOWN intents by client order id before anything else touches their symbols.
+func (l *Loop) EnableExecution(ledger *executor.LedgerService, orders *OrderClient) {
+	l.ledger = ledger
+	l.orders = orders
+}
+
+func (l *Loop) runIntentResolution(ctx context.Context) string {
+	if l.ledger == nil || l.orders == nil {
+		return ""
+	}
+	pending, err := l.ledger.PendingSentUnknown(ctx, intentResolveBatch)
+	if err != nil {
+		return "sent_unknown lookup failed: " + err.Error()
+	}
+	if len(pending) == 0 {
+		return ""
+	}
+	resolved, unknown := 0, 0
+	for _, intent := range pending {
+		err := executor.ResolveSentUnknown(ctx, l.cfg, l.ledger, l.orders, intent)
+		switch {
+		case err == nil:
+			resolved++
+		case errors.Is(err, executor.ErrSentUnknown):
+			unknown++
+		default:
+			return "sent_unknown resolve failed: " + err.Error()
+		}
+	}
+	return fmt.Sprintf("sent_unknown intents: %d resolved, %d still unknown", resolved, unknown)
+}
@@ -1,12 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
 )
 
+const liveProbeTimeout = 10 * time.Second
+
 type LiveRuntimeStatus struct {
 	DBOK               bool
 	FiltersLoaded      bool
@@ -19,10 +32,35 @@ type LiveChecklistResult struct {
 	OK      bool
 	Reasons []reasoncodes.ReasonCode
 	Missing []string
+	Errors  map[string]string
 }
 
 type StatFunc func(string) (fs.FileInfo, error)
 
+type LiveProbe func(ctx context.Context) error
+
+type LiveProbes struct {
+	DB               LiveProbe
+	Filters          LiveProbe
+	Clock            LiveProbe
+	WS               LiveProbe
+	InitialReconcile LiveProbe
+}
+
+// LiveChecker runs the probes off the loop goroutine and publishes the latest
+// result; the loop only reads it, so a slow probe never stalls a cycle.
+type LiveChecker struct {
+	cfg        config.Config
+	probes     LiveProbes
+	stat       StatFunc
+	sleep      func(context.Context, time.Duration) error
+	reconciled bool
+
+	mu     sync.Mutex
+	latest LiveChecklistResult
+	seq    int64
+}
+
 func ValidateLiveChecklist(cfg config.Config, status LiveRuntimeStatus, stat StatFunc) LiveChecklistResult {
 	missing := make([]string, 0, 6)
 	if cfg.Mode != "LIVE" {
@@ -62,3 +100,147 @@ func ValidateLiveChecklist(cfg config.Config, status LiveRuntimeStatus, stat Sta
 		Missing: missing,
 	}
 }
+
+func NewLiveChecker(cfg config.Config, probes LiveProbes, stat StatFunc) (*LiveChecker, error) {
+	if probes.DB == nil || probes.Filters == nil || probes.Clock == nil || probes.WS == nil || probes.InitialReconcile == nil {
+		return nil, fmt.Errorf("live checklist probes missing")
+	}
+	if stat == nil {
+		stat = os.Stat
+	}
+	return &LiveChecker{cfg: cfg, probes: probes, stat: stat, sleep: sleepCtx}, nil
+}
+
+// Run checks every live_checklist_interval_ms until ctx is done.
+func (c *LiveChecker) Run(ctx context.Context) {
+	interval := time.Duration(c.cfg.LiveChecklistIntervalMs) * time.Millisecond
+	for ctx.Err() == nil {
+		c.checkAndPublish(ctx)
+		if err := c.sleep(ctx, interval); err != nil {
+			return
+		}
+	}
+}
+
+// Latest returns the last published result and its sequence number; seq is 0
+// until the first check completes.
+func (c *LiveChecker) Latest() (LiveChecklistResult, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest, c.seq
+}
+
+func (c *LiveChecker) checkAndPublish(ctx context.Context) {
+	result := c.Check(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	c.mu.Lock()
+	c.latest = result
+	c.seq++
+	c.mu.Unlock()
+}
+
+func (c *LiveChecker) Check(ctx context.Context) LiveChecklistResult {
+	errs := map[string]string{}
+	run := func(name string, probe LiveProbe) bool {
+		probeCtx, cancel := context.WithTimeout(ctx, liveProbeTimeout)
+		defer cancel()
+		if err := probe(probeCtx); err != nil {
+			errs[name] = probeError(err)
+			return false
+		}
+		return true
+	}
+	status := LiveRuntimeStatus{
+		DBOK:          run("db_ok", c.probes.DB),
+		FiltersLoaded: run("filters_loaded", c.probes.Filters),
+		ClockOK:       run("clock_ok", c.probes.Clock),
+		WSOK:          run("ws_ok", c.probes.WS),
+	}
+	status.InitialReconcileOK = c.reconciled || run("initial_reconcile_ok", c.probes.InitialReconcile)
+	c.reconciled = status.InitialReconcileOK
+	result := ValidateLiveChecklist(c.cfg, status, c.stat)
+	if len(errs) > 0 {
+		result.Errors = errs
+	}
+	return result
+}
+
+func probeError(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err.Error()
+	}
+	return err.Error()
+}
+
+func (l *Loop) EnableLiveChecklist(checker *LiveChecker) {
+	l.liveChecker = checker
+}
+
+func (l *Loop) liveChecklistFailed() bool {
+	return l.liveChecker != nil && !l.liveResult.OK
+}
+
+// refreshLiveChecklist picks up the checker's latest published result. Until
+// the first check completes liveResult is not OK, so the loop stays paused.
+func (l *Loop) refreshLiveChecklist(runID string, cycleID string) error {
+	if l.liveChecker == nil {
+		return nil
+	}
+	result, seq := l.liveChecker.Latest()
+	if seq == l.liveSeq {
+		return nil
+	}
+	first := l.liveSeq == 0
+	l.liveSeq = seq
+	changed := first || result.OK != l.liveResult.OK || !slices.Equal(result.Missing, l.liveResult.Missing)
+	l.liveResult = result
+	if !changed {
+		return nil
+	}
+	return l.emitLiveChecklist(runID, cycleID, result)
+}
+
+func (l *Loop) emitLiveChecklist(runID string, cycleID string, result LiveChecklistResult) error {
+	now := l.now()
+	reasons := []reasoncodes.ReasonCode{}
+	if !result.OK {
+		reasons = append([]reasoncodes.ReasonCode{reasoncodes.LIVE_CHECKLIST_FAILED}, result.Reasons...)
+	}
+	missing := result.Missing
+	if missing == nil {
+		missing = []string{}
+	}
+	errs := result.Errors
+	if errs == nil {
+		errs = map[string]string{}
+	}
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            l.cfg.Mode,
+			Stage:           observability.DOCTOR_CHECKS,
+			EventType:       auditdomain.LIVE_CHECKLIST,
+			Reasons:         reasons,
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"ok":          result.OK,
+			"missing":     missing,
+			"errors":      errs,
+			"interval_ms": l.cfg.LiveChecklistIntervalMs,
+		},
+	}
+	if err := l.writeAudit(record); err != nil {
+		return fmt.Errorf("audit live checklist write: %w", err)
+	}
+	return nil
+}
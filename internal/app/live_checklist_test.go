@@ -1,11 +1,26 @@
 package app
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
 )
 
 func TestValidateLiveChecklistOK(t *testing.T) {
@@ -58,3 +73,223 @@ func testConfig() config.Config {
 	cfg.AiDec = 2
 	return cfg
 }
+
+func TestLiveChecklistGatesAndRechecks(t *testing.T) {
+	cfg := testConfig()
+	cfg.DiskFreeDegradeBytes = -1
+	cfg.DiskFreePauseBytes = -1
+	cfg.LiveChecklistIntervalMs = 1000
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	now := time.Now()
+	loop, err := NewLoop(cfg, writer, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	var wsErr, dbErr error
+	wsErr = errors.New("dial refused")
+	reconciles := 0
+	ok := func(context.Context) error { return nil }
+	checker, err := NewLiveChecker(cfg, LiveProbes{
+		DB:      func(context.Context) error { return dbErr },
+		Filters: ok,
+		Clock:   ok,
+		WS:      func(context.Context) error { return wsErr },
+		InitialReconcile: func(context.Context) error {
+			reconciles++
+			return nil
+		},
+	}, os.Stat)
+	if err != nil {
+		t.Fatalf("checker: %v", err)
+	}
+	loop.EnableLiveChecklist(checker)
+	refresh := func(check bool) {
+		t.Helper()
+		if check {
+			checker.checkAndPublish(context.Background())
+		}
+		now = now.Add(500 * time.Millisecond)
+		loop.UpdateWSLastMsg(now)
+		loop.UpdateRESTLastSuccess(now)
+		loop.lastProgressMs = now.UnixMilli()
+		if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+			t.Fatalf("refresh: %v", err)
+		}
+	}
+
+	refresh(false)
+	if loop.sysMode != health.SysModePause || len(loop.liveResult.Missing) != 0 {
+		t.Fatalf("expected pause before the first check completes, got %s %+v", loop.sysMode, loop.liveResult)
+	}
+	refresh(true)
+	if loop.sysMode != health.SysModePause || !slices.Contains(loop.sysModeReasons, reasoncodes.LIVE_CHECKLIST_FAILED) {
+		t.Fatalf("expected pause on failed checklist, got %s %v", loop.sysMode, loop.sysModeReasons)
+	}
+	if !slices.Equal(loop.liveResult.Missing, []string{"ws_ok"}) || loop.liveResult.Errors["ws_ok"] != "dial refused" {
+		t.Fatalf("unexpected checklist result: %+v", loop.liveResult)
+	}
+	wsErr = nil
+	refresh(false)
+	if loop.sysMode != health.SysModePause {
+		t.Fatalf("expected pause until the next check, got %s", loop.sysMode)
+	}
+	refresh(true)
+	if loop.sysMode != health.SysModeNormal {
+		t.Fatalf("expected normal after checklist passed, got %s %v", loop.sysMode, loop.sysModeReasons)
+	}
+	dbErr = errors.New("disk I/O error")
+	refresh(true)
+	if loop.sysMode != health.SysModePause || !slices.Equal(loop.liveResult.Missing, []string{"db_ok"}) {
+		t.Fatalf("expected pause after losing the db, got %s %+v", loop.sysMode, loop.liveResult)
+	}
+	if reconciles != 1 {
+		t.Fatalf("expected initial reconcile once, got %d", reconciles)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("writer close: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	var events int
+	if err := db.QueryRow("SELECT COUNT(*) FROM audit_events WHERE event_type='LIVE_CHECKLIST'").Scan(&events); err != nil {
+		t.Fatalf("count checklist events: %v", err)
+	}
+	if events != 3 {
+		t.Fatalf("expected 3 checklist events on changes, got %d", events)
+	}
+	var alertData string
+	if err := db.QueryRow("SELECT data_json FROM audit_events WHERE event_type='ALERT_RAISED' ORDER BY event_id DESC LIMIT 1").Scan(&alertData); err != nil {
+		t.Fatalf("alert: %v", err)
+	}
+	if !strings.Contains(alertData, `"live_checklist_missing":["db_ok"]`) {
+		t.Fatalf("expected missing items in alert, got %s", alertData)
+	}
+}
+
+func TestLiveChecklistProbesRunOffTheLoop(t *testing.T) {
+	cfg := testConfig()
+	cfg.DiskFreeDegradeBytes = -1
+	cfg.DiskFreePauseBytes = -1
+	cfg.LiveChecklistIntervalMs = 10
+	tmp := t.TempDir()
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: filepath.Join(tmp, "data", "audit.sqlite"), JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	defer writer.Close()
+	loop, err := NewLoop(cfg, writer, nil, time.Now)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	release := make(chan struct{})
+	ok := func(context.Context) error { return nil }
+	checker, err := NewLiveChecker(cfg, LiveProbes{
+		DB:      ok,
+		Filters: ok,
+		Clock:   ok,
+		WS: func(ctx context.Context) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		InitialReconcile: ok,
+	}, os.Stat)
+	if err != nil {
+		t.Fatalf("checker: %v", err)
+	}
+	loop.EnableLiveChecklist(checker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go checker.Run(ctx)
+
+	started := time.Now()
+	loop.UpdateWSLastMsg(started)
+	loop.UpdateRESTLastSuccess(started)
+	loop.lastProgressMs = started.UnixMilli()
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("refresh waited on a probe for %s", elapsed)
+	}
+	if loop.sysMode != health.SysModePause {
+		t.Fatalf("expected pause while the first check is running, got %s", loop.sysMode)
+	}
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, seq := checker.Latest(); seq > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if result, seq := checker.Latest(); seq == 0 || !result.OK {
+		t.Fatalf("expected a published result, got %d %+v", seq, result)
+	}
+}
+
+func TestBinanceLiveProbesAgainstStub(t *testing.T) {
+	cfg := testConfig()
+	serverSkewMs := int64(0)
+	symbolsJSON := `[{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT","filters":[{"filterType":"PRICE_FILTER","tickSize":"0.01"},{"filterType":"LOT_SIZE","stepSize":"0.00001"}]}]`
+	var wsLastMsgMs atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/time", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli()+serverSkewMs)
+	})
+	mux.HandleFunc("/api/v3/exchangeInfo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"timezone":"UTC","rateLimits":[],"symbols":%s}`, symbolsJSON)
+	})
+	mux.HandleFunc("/api/v3/account", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"balances":[{"asset":"USDT","free":"100","locked":"0"}]}`)
+	})
+	mux.HandleFunc("/api/v3/openOrders", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "audit.sqlite"), cfg)
+	if err != nil {
+		t.Fatalf("db open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, time.Now()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	rest, err := binance.NewClient(cfg, binance.Options{BaseURL: server.URL, APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	checker, err := NewLiveChecker(cfg, NewBinanceLiveProbes(cfg, db, rest, wsLastMsgMs.Load, []string{"BTCUSDT"}, time.Now), os.Stat)
+	if err != nil {
+		t.Fatalf("checker: %v", err)
+	}
+	if result := checker.Check(context.Background()); result.OK || !slices.Equal(result.Missing, []string{"ws_ok"}) {
+		t.Fatalf("expected ws_ok to fail before the feed delivers a message, got %+v", result)
+	}
+	wsLastMsgMs.Store(time.Now().UnixMilli())
+	if result := checker.Check(context.Background()); !result.OK {
+		t.Fatalf("expected checklist to pass, got %+v", result)
+	}
+
+	serverSkewMs = int64(cfg.ClockDriftMaxMsLive) * 3
+	symbolsJSON = `[{"symbol":"BTCUSDT","status":"BREAK","filters":[]}]`
+	wsLastMsgMs.Store(time.Now().UnixMilli() - int64(cfg.WsStaleMsPause))
+	result := checker.Check(context.Background())
+	if result.OK || !slices.Equal(result.Missing, []string{"filters_loaded", "clock_ok", "ws_ok"}) {
+		t.Fatalf("expected filters, clock and a stale ws to fail, got %+v", result)
+	}
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+)
+
+// NewBinanceLiveProbes builds the live checklist probes. wsLastMsgMs reports when the running
+// market feed last delivered a message; the WS probe checks its age instead of opening a stream.
+func NewBinanceLiveProbes(cfg config.Config, db *sql.DB, rest *binance.Client, wsLastMsgMs func() int64, symbols []string, now func() time.Time) LiveProbes {
+	if now == nil {
+		now = time.Now
+	}
+	return LiveProbes{
+		DB: func(ctx context.Context) error {
+			return probeDB(ctx, db)
+		},
+		Filters: func(ctx context.Context) error {
+			return probeFilters(ctx, rest, symbols)
+		},
+		Clock: func(ctx context.Context) error {
+			return probeClock(ctx, cfg, rest, now)
+		},
+		WS: func(ctx context.Context) error {
+			return probeWS(cfg, wsLastMsgMs, now)
+		},
+		InitialReconcile: func(ctx context.Context) error {
+			return probeInitialReconcile(ctx, rest)
+		},
+	}
+}
+
+func probeDB(ctx context.Context, db *sql.DB) error {
+	if db == nil {
+		return fmt.Errorf("db missing")
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("db ping: %w", err)
+	}
+	var journal string
+	if err := db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journal); err != nil {
+		return fmt.Errorf("db journal mode: %w", err)
+	}
+	if journal != "wal" {
+		return fmt.Errorf("db journal mode %s, want wal", journal)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.ExecContext(ctx, "UPDATE schema_migrations SET name = name WHERE 0"); err != nil {
+		return fmt.Errorf("db not writable: %w", err)
+	}
+	return nil
+}
+
+func probeFilters(ctx context.Context, rest *binance.Client, symbols []string) error {
+	if len(symbols) == 0 {
+		return fmt.Errorf("filters symbols missing")
+	}
+	info, err := rest.ExchangeInfo(ctx)
+	if err != nil {
+		return err
+	}
+	bySymbol := make(map[string]binance.SymbolInfo, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		bySymbol[symbol.Symbol] = symbol
+	}
+	for _, name := range symbols {
+		symbol, ok := bySymbol[name]
+		if !ok {
+			return fmt.Errorf("filters missing for %s", name)
+		}
+		if symbol.Status != "TRADING" {
+			return fmt.Errorf("%s status %s", name, symbol.Status)
+		}
+		var tickSize, stepSize string
+		for _, filter := range symbol.Filters {
+			switch filter.FilterType {
+			case "PRICE_FILTER":
+				tickSize = filter.TickSize
+			case "LOT_SIZE":
+				stepSize = filter.StepSize
+			}
+		}
+		if tickSize == "" || stepSize == "" {
+			return fmt.Errorf("%s price or lot size filter missing", name)
+		}
+	}
+	return nil
+}
+
+func probeClock(ctx context.Context, cfg config.Config, rest *binance.Client, now func() time.Time) error {
+	sent := now().UnixMilli()
+	resp, err := rest.Time(ctx)
+	if err != nil {
+		return err
+	}
+	received := now().UnixMilli()
+	drift := resp.ServerTime - (sent+received)/2
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift > int64(cfg.ClockDriftMaxMsLive) {
+		return fmt.Errorf("clock drift %dms exceeds %dms", drift, cfg.ClockDriftMaxMsLive)
+	}
+	return nil
+}
+
+func probeWS(cfg config.Config, wsLastMsgMs func() int64, now func() time.Time) error {
+	if wsLastMsgMs == nil {
+		return fmt.Errorf("ws feed missing")
+	}
+	last := wsLastMsgMs()
+	if last == 0 {
+		return fmt.Errorf("ws no message received yet")
+	}
+	age := now().UnixMilli() - last
+	if age >= int64(cfg.WsStaleMsPause) {
+		return fmt.Errorf("ws last message %dms ago exceeds %dms", age, cfg.WsStaleMsPause)
+	}
+	return nil
+}
+
+func probeInitialReconcile(ctx context.Context, rest *binance.Client) error {
+	account, err := rest.Account(ctx)
+	if err != nil {
+		return fmt.Errorf("account: %w", err)
+	}
+	var balances struct {
+		Balances []json.RawMessage `json:"balances"`
+	}
+	if err := json.Unmarshal(account.Body, &balances); err != nil {
+		return fmt.Errorf("account decode: %w", err)
+	}
+	open, err := rest.OpenOrders(ctx, url.Values{})
+	if err != nil {
+		return fmt.Errorf("open orders: %w", err)
+	}
+	var orders []json.RawMessage
+	if err := json.Unmarshal(open.Body, &orders); err != nil {
+		return fmt.Errorf("open orders decode: %w", err)
+	}
+	return nil
+}
@@ -2,15 +2,24 @@ package app
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
 	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
 	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/reports"
 	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability/alerts"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
 )
 
 type Loop struct {
@@ -25,11 +34,49 @@ type Loop struct {
 	sysModeReasons []reasoncodes.ReasonCode
 
 	lastProgressMs    int64
-	wsLastMsgMs       int64
-	restLastSuccessMs int64
+	wsLastMsgMs       atomic.Int64
+	restLastSuccessMs atomic.Int64
 	diskFreeBytes     int64
 	auditWriterLagMs  int
 	forceExit         bool
+	controls          *operatorControls
+	metrics           *engineMetrics
+	notifier          *alerts.Notifier
+	reports           *reports.Generator
+	lastReportDay     string
+	backpressure      audit.BackpressureAction
+	writerDrops       int64
+	retention         *ops.Retainer
+	lastRetentionDay  string
+	backup            *ops.Backuper
+	lastBackupDay     string
+	hooks             Hooks
+	sleep             func(ctx context.Context, d time.Duration) error
+	sysModeChanges    int64
+	liveChecker       *LiveChecker
+	liveResult        LiveChecklistResult
+	liveSeq           int64
+	quarantine        *QuarantineService
+	aiPolicy          *aigate.Policy
+	aiOutcomesDB      *sql.DB
+	proposals         []proposedDecision
+	gated             []contracts.Decision
+	lastOutcomesDay   string
+	rateLimits        RateLimitSource
+	ledger            *executor.LedgerService
+	orders            *OrderClient
+}
+
+type CycleInfo struct {
+	RunID          string
+	CycleID        string
+	SysMode        health.SysMode
+	SysModeChanges int64
+}
+
+type Hooks struct {
+	AfterCycle func(CycleInfo)
+	Sleep      func(ctx context.Context, d time.Duration) error
 }
 
 func NewLoop(cfg config.Config, writer *audit.Writer, reporter observability.StageReporter, now func() time.Time) (*Loop, error) {
@@ -47,9 +94,19 @@ func NewLoop(cfg config.Config, writer *audit.Writer, reporter observability.Sta
 		sysEval:      health.NewEvaluator(cfg),
 		sysMode:      health.SysModeNormal,
 		sysModeSince: now(),
+		controls:     newOperatorControls(),
+		backpressure: audit.BackpressureNone,
+		sleep:        sleepCtx,
 	}, nil
 }
 
+func (l *Loop) EnableHooks(hooks Hooks) {
+	l.hooks = hooks
+	if hooks.Sleep != nil {
+		l.sleep = hooks.Sleep
+	}
+}
+
 func (l *Loop) RunDryRun() error {
 	runID, err := observability.NewRunID(l.now())
 	if err != nil {
@@ -73,6 +130,9 @@ func (l *Loop) Run(ctx context.Context) error {
 		return err
 	}
 	l.lastProgressMs = l.now().UnixMilli()
+	if l.liveChecker != nil {
+		go l.liveChecker.Run(ctx)
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -90,7 +150,12 @@ func (l *Loop) Run(ctx context.Context) error {
 }
 
 func (l *Loop) runCycle(ctx context.Context, runID string, cycleID string) error {
+	cycleStart := l.now()
+	if l.quarantine != nil {
+		l.quarantine.BeginCycle(runID, cycleID)
+	}
 	for _, stage := range l.stageSequence() {
+		stageStart := l.now()
 		if err := l.refreshSysMode(ctx, runID, cycleID); err != nil {
 			return err
 		}
@@ -98,7 +163,7 @@ func (l *Loop) runCycle(ctx context.Context, runID string, cycleID string) error
 			if err := l.emitStage(runID, cycleID, observability.PAUSE, "", "paused"); err != nil {
 				return err
 			}
-			if err := sleepCtx(ctx, 500*time.Millisecond); err != nil {
+			if err := l.sleep(ctx, 500*time.Millisecond); err != nil {
 				return err
 			}
 			continue
@@ -107,9 +172,65 @@ func (l *Loop) runCycle(ctx context.Context, runID string, cycleID string) error
 		if l.sysMode == health.SysModeDegrade {
 			summary = "degraded: entries blocked"
 		}
+		if stage == observability.POSITION_MANAGE {
+			if err := l.emitFlattenPending(runID, cycleID); err != nil {
+				return err
+			}
+		}
+		if stage == observability.UNIVERSE_SCAN {
+			if notes := l.runQuarantineSweep(); notes != "" {
+				summary = notes
+			}
+		}
+		if stage == observability.AIGATE_CALL {
+			if notes := l.runAIGate(ctx, runID, cycleID); notes != "" {
+				summary = notes
+			}
+		}
+		if stage == observability.RECONCILE_REST {
+			if notes := l.runIntentResolution(ctx); notes != "" {
+				summary = notes
+			}
+		}
+		if stage == observability.REPORT_DAILY_SUMMARY {
+			if notes := l.runDailyJobs(ctx, runID, cycleID); notes != "" {
+				summary = notes
+			}
+		}
+		if stage == observability.EXECUTE_INTENT {
+			// Nothing executes before the audit trail behind it is committed; under
+			// backpressure the cycle skips execution rather than ending Run.
+			if err := l.writer.Flush(); err != nil {
+				if !errors.Is(err, audit.ErrQueueFull) {
+					return fmt.Errorf("audit flush before execute: %w", err)
+				}
+				summary = "skipped: audit queue full"
+			}
+		}
 		if err := l.emitStage(runID, cycleID, stage, "", summary); err != nil {
 			return err
 		}
+		l.metrics.observeStage(stage, l.now().Sub(stageStart))
+	}
+	l.proposals = nil
+	l.gated = nil
+	l.metrics.observeCycle(l.now().Sub(cycleStart))
+	if l.hooks.AfterCycle != nil {
+		l.hooks.AfterCycle(CycleInfo{RunID: runID, CycleID: cycleID, SysMode: l.sysMode, SysModeChanges: l.sysModeChanges})
+	}
+	return nil
+}
+
+// emitFlattenPending records each operator flatten request and acknowledges it. The loop does
+// not own an executor, so flatten is advisory: the request is audited with OPERATOR_FLATTEN
+// and holds DEGRADE for the cycle it was seen in; closing the position is left to the operator.
+func (l *Loop) emitFlattenPending(runID string, cycleID string) error {
+	for _, symbol := range l.PendingFlatten() {
+		summary := "flatten requested by " + l.FlattenOperator(symbol) + ": advisory, close manually"
+		if err := l.emitStageWithReasons(runID, cycleID, observability.POSITION_MANAGE, symbol, summary, []reasoncodes.ReasonCode{reasoncodes.OPERATOR_FLATTEN}); err != nil {
+			return err
+		}
+		l.AckFlatten(symbol)
 	}
 	return nil
 }
@@ -136,11 +257,16 @@ func (l *Loop) stageSequence() []observability.StageName {
 }
 
 func (l *Loop) UpdateWSLastMsg(ts time.Time) {
-	l.wsLastMsgMs = ts.UnixMilli()
+	l.wsLastMsgMs.Store(ts.UnixMilli())
+}
+
+// WSLastMsgMs returns when the market feed last delivered a message, 0 before the first one.
+func (l *Loop) WSLastMsgMs() int64 {
+	return l.wsLastMsgMs.Load()
 }
 
 func (l *Loop) UpdateRESTLastSuccess(ts time.Time) {
-	l.restLastSuccessMs = ts.UnixMilli()
+	l.restLastSuccessMs.Store(ts.UnixMilli())
 }
 
 func (l *Loop) UpdateDiskFreeBytes(bytes int64) {
@@ -181,7 +307,7 @@ func (l *Loop) emitStage(runID string, cycleID string, stage observability.Stage
 			"summary": summary,
 		},
 	}
-	if err := l.writer.Write(record); err != nil {
+	if err := l.writeAudit(record); err != nil {
 		return fmt.Errorf("audit stage write: %w", err)
 	}
 	return nil
@@ -191,36 +317,69 @@ func (l *Loop) refreshSysMode(ctx context.Context, runID string, cycleID string)
 	if err := l.sampleDiskFree(); err != nil {
 		return err
 	}
-	queueLen, queueCap := l.writer.QueueStats()
-	queuePct := 0
-	if queueCap > 0 {
-		queuePct = int(float64(queueLen) / float64(queueCap) * 100)
+	if err := l.refreshLiveChecklist(runID, cycleID); err != nil {
+		return err
 	}
+	stats := l.writer.Stats()
+	if err := l.emitBackpressure(runID, cycleID, stats); err != nil {
+		return err
+	}
+	lagMs := int(stats.LagMs)
+	if l.auditWriterLagMs > lagMs {
+		lagMs = l.auditWriterLagMs
+	}
+	operatorPause, operatorExit, flattenPending := l.operatorSignals()
 	signals := health.Signals{
-		NowMs:              l.now().UnixMilli(),
-		LastProgressMs:     l.lastProgressMs,
-		WsLastMsgMs:        l.wsLastMsgMs,
-		RestLastSuccessMs:  l.restLastSuccessMs,
-		DiskFreeBytes:      l.diskFreeBytes,
-		AuditQueuePct:      queuePct,
-		AuditWriterLagMs:   l.auditWriterLagMs,
-		ForceExitRequested: l.forceExit,
+		NowMs:               l.now().UnixMilli(),
+		LastProgressMs:      l.lastProgressMs,
+		WsLastMsgMs:         l.wsLastMsgMs.Load(),
+		RestLastSuccessMs:   l.restLastSuccessMs.Load(),
+		DiskFreeBytes:       l.diskFreeBytes,
+		AuditQueuePct:       stats.QueuePct,
+		AuditWriterLagMs:    lagMs,
+		ForceExitRequested:  l.forceExit || operatorExit,
+		OperatorPause:       operatorPause,
+		FlattenPending:      flattenPending,
+		LiveChecklistFailed: l.liveChecklistFailed(),
+		AIGatePause:         l.aiGatePauseRequested(),
 	}
+	l.metrics.observeSignals(signals)
+	l.sampleRateLimit()
 	result := l.sysEval.Evaluate(l.sysMode, signals)
 	if result.Mode == l.sysMode {
 		return nil
 	}
 	l.sysMode = result.Mode
+	l.sysModeChanges++
+	l.metrics.setSysMode(l.sysMode)
+	if l.sysMode == health.SysModeNormal && l.notifier != nil {
+		l.notifier.Resolve("sys_mode back to NORMAL")
+	}
 	l.sysModeSince = l.now()
 	l.sysModeReasons = result.Reasons
+	pauseBy, exitBy, resumeBy := l.operatorActors()
+	operator := ""
+	if operatorPause {
+		operator = pauseBy
+	}
+	if operatorExit {
+		operator = exitBy
+	}
 	if l.sysMode == health.SysModeDegrade {
-		return l.emitSysModeChange(runID, cycleID, observability.DEGRADE, reasoncodes.ENTER_DEGRADE, result.Reasons, signals)
+		return l.emitSysModeChange(runID, cycleID, observability.DEGRADE, reasoncodes.ENTER_DEGRADE, result.Reasons, signals, operator)
 	}
 	if l.sysMode == health.SysModePause {
-		return l.emitSysModeChange(runID, cycleID, observability.PAUSE, reasoncodes.ENTER_PAUSE, result.Reasons, signals)
+		return l.emitSysModeChange(runID, cycleID, observability.PAUSE, reasoncodes.ENTER_PAUSE, result.Reasons, signals, operator)
+	}
+	if l.sysMode == health.SysModeNormal {
+		summary := "sys_mode change"
+		if resumeBy != "" {
+			summary = "sys_mode change: resumed by " + resumeBy
+		}
+		return l.emitStageWithReasons(runID, cycleID, observability.STATE_UPDATE, "", summary, []reasoncodes.ReasonCode{reasoncodes.ENTER_NORMAL})
 	}
 	if l.sysMode == health.SysModeExit {
-		if err := l.emitSysModeChange(runID, cycleID, observability.SHUTDOWN, reasoncodes.ENTER_EXIT, result.Reasons, signals); err != nil {
+		if err := l.emitSysModeChange(runID, cycleID, observability.SHUTDOWN, reasoncodes.ENTER_EXIT, result.Reasons, signals, operator); err != nil {
 			return err
 		}
 		return fmt.Errorf("exit requested")
@@ -228,7 +387,49 @@ func (l *Loop) refreshSysMode(ctx context.Context, runID string, cycleID string)
 	return nil
 }
 
-func (l *Loop) emitSysModeChange(runID string, cycleID string, stage observability.StageName, enterReason reasoncodes.ReasonCode, reasons []reasoncodes.ReasonCode, signals health.Signals) error {
+func (l *Loop) emitBackpressure(runID string, cycleID string, stats audit.WriterStats) error {
+	pressure := audit.EvaluateBackpressure(l.cfg, stats, l.writerDrops)
+	l.writerDrops = stats.DropsTotal
+	if pressure.Action == l.backpressure && pressure.DropsDelta == 0 {
+		return nil
+	}
+	l.backpressure = pressure.Action
+	now := l.now()
+	record := pressure.Record(auditdomain.AuditEvent{
+		TsMs:            now.UnixMilli(),
+		RunID:           runID,
+		CycleID:         cycleID,
+		Mode:            l.cfg.Mode,
+		Stage:           observability.STATE_UPDATE,
+		SnapshotID:      "",
+		DecisionID:      "",
+		OrderIntentID:   "",
+		ExchangeTimeMs:  0,
+		LocalReceivedMs: now.UnixMilli(),
+	})
+	if err := l.writeAudit(record); err != nil {
+		return fmt.Errorf("audit backpressure write: %w", err)
+	}
+	return nil
+}
+
+// writeAudit drops only plain STAGE_CHANGED telemetry (no reasons) on a full queue; the
+// drop is counted by the writer and in livespot_audit_stage_drops_total, and the next
+// refresh reports it through emitBackpressure. Every other record (alerts, sys mode
+// changes, live checklist, backpressure) goes through WriteSync and waits for room.
+func (l *Loop) writeAudit(record audit.Record) error {
+	if record.Event.EventType != auditdomain.STAGE_CHANGED || len(record.Event.Reasons) > 0 {
+		return l.writer.WriteSync(record)
+	}
+	err := l.writer.Write(record)
+	if errors.Is(err, audit.ErrQueueFull) {
+		l.metrics.countStageDrop()
+		return nil
+	}
+	return err
+}
+
+func (l *Loop) emitSysModeChange(runID string, cycleID string, stage observability.StageName, enterReason reasoncodes.ReasonCode, reasons []reasoncodes.ReasonCode, signals health.Signals, operator string) error {
 	alertReasons := append([]reasoncodes.ReasonCode{reasoncodes.ALERT_RAISED, enterReason}, reasons...)
 	alertData := map[string]any{
 		"last_progress_ts_ms":     signals.LastProgressMs,
@@ -238,6 +439,12 @@ func (l *Loop) emitSysModeChange(runID string, cycleID string, stage observabili
 		"audit_queue_pct":         signals.AuditQueuePct,
 		"audit_writer_lag_ms":     signals.AuditWriterLagMs,
 	}
+	if signals.LiveChecklistFailed {
+		alertData["live_checklist_missing"] = l.liveResult.Missing
+	}
+	if operator != "" {
+		alertData["operator"] = operator
+	}
 	if err := l.emitAlert(runID, cycleID, stage, alertReasons, alertData); err != nil {
 		return err
 	}
@@ -267,13 +474,29 @@ func (l *Loop) emitAlert(runID string, cycleID string, stage observability.Stage
 		},
 		Data: data,
 	}
-	if err := l.writer.Write(record); err != nil {
+	if err := l.writeAudit(record); err != nil {
 		return fmt.Errorf("audit alert write: %w", err)
 	}
 	fmt.Printf("ALERT stage=%s reasons=%v\n", stage, reasons)
+	if l.notifier != nil {
+		l.notifier.Raise(alerts.Alert{
+			TsMs:    now.UnixMilli(),
+			RunID:   runID,
+			CycleID: cycleID,
+			Mode:    l.cfg.Mode,
+			Stage:   stage,
+			Reasons: reasons,
+			Summary: "sys_mode " + string(l.sysMode),
+			Data:    data,
+		})
+	}
 	return nil
 }
 
+func (l *Loop) EnableNotifier(notifier *alerts.Notifier) {
+	l.notifier = notifier
+}
+
 func (l *Loop) emitStageWithReasons(runID string, cycleID string, stage observability.StageName, symbol string, summary string, reasons []reasoncodes.ReasonCode) error {
 	now := l.now()
 	l.lastProgressMs = now.UnixMilli()
@@ -300,7 +523,7 @@ func (l *Loop) emitStageWithReasons(runID string, cycleID string, stage observab
 			"summary": summary,
 		},
 	}
-	if err := l.writer.Write(record); err != nil {
+	if err := l.writeAudit(record); err != nil {
 		return fmt.Errorf("audit stage write: %w", err)
 	}
 	return nil
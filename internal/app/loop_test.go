@@ -1,13 +1,17 @@
 package app
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
 	"github.com/RodrigoBeloyanis/livespot/internal/observability"
 
 	_ "modernc.org/sqlite"
@@ -46,3 +50,104 @@ func TestRunDryRunEmitsStageEvents(t *testing.T) {
 		t.Fatalf("expected stage events")
 	}
 }
+
+func TestOperatorControlsDriveSysMode(t *testing.T) {
+	cfg := config.Default()
+	cfg.DiskFreeDegradeBytes = -1
+	cfg.DiskFreePauseBytes = -1
+	tmp := t.TempDir()
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: filepath.Join(tmp, "data", "audit.sqlite"), JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	defer writer.Close()
+	now := time.Now()
+	loop, err := NewLoop(cfg, writer, nil, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	registry := observability.NewRegistry()
+	loop.EnableMetrics(registry)
+	loop.UpdateWSLastMsg(now)
+	loop.UpdateRESTLastSuccess(now)
+	loop.lastProgressMs = now.UnixMilli()
+
+	if err := loop.OperatorFlatten("alice", "BTCUSDT"); err != nil {
+		t.Fatalf("flatten: %v", err)
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModeDegrade {
+		t.Fatalf("expected degrade while flatten pending, got %s", loop.sysMode)
+	}
+	if err := loop.emitFlattenPending("run", "cycle"); err != nil {
+		t.Fatalf("flatten pending: %v", err)
+	}
+	if len(loop.PendingFlatten()) != 0 {
+		t.Fatalf("expected advisory flatten acknowledged, got %v", loop.PendingFlatten())
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModeNormal {
+		t.Fatalf("expected normal after flatten ack, got %s", loop.sysMode)
+	}
+	if err := loop.OperatorPause("alice"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModePause {
+		t.Fatalf("expected pause, got %s", loop.sysMode)
+	}
+	var metrics bytes.Buffer
+	if err := registry.WriteText(&metrics); err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	if !strings.Contains(metrics.String(), `livespot_sys_mode{mode="PAUSE"} 1`) || !strings.Contains(metrics.String(), `livespot_sys_mode{mode="DEGRADE"} 0`) {
+		t.Fatalf("unexpected sys mode metrics:\n%s", metrics.String())
+	}
+	if err := loop.OperatorResume("alice"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if loop.sysMode != health.SysModeNormal || len(loop.PendingFlatten()) != 0 {
+		t.Fatalf("expected normal with no pending flatten, got %s %v", loop.sysMode, loop.PendingFlatten())
+	}
+
+	if err := loop.OperatorQuarantine("alice", "ETHUSDT", now.UnixMilli()+1000); err == nil {
+		t.Fatalf("expected quarantine without the service to fail")
+	}
+	if err := loop.OperatorRelease("alice", "ETHUSDT"); err == nil {
+		t.Fatalf("expected release without the service to fail")
+	}
+
+	if err := loop.OperatorExit("alice"); err != nil {
+		t.Fatalf("exit: %v", err)
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err == nil {
+		t.Fatalf("expected exit error")
+	}
+	if loop.sysMode != health.SysModeExit {
+		t.Fatalf("expected exit, got %s", loop.sysMode)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("writer close: %v", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(tmp, "data", "audit.sqlite"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	var operators int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_events WHERE event_type='ALERT_RAISED' AND data_json LIKE '%"operator":"alice"%'`).Scan(&operators); err != nil {
+		t.Fatalf("count alerts: %v", err)
+	}
+	if operators != 2 {
+		t.Fatalf("expected pause and exit alerts to name the operator, got %d", operators)
+	}
+}
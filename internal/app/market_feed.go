@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+)
+
+const (
+	marketFeedBackoffMin = 500 * time.Millisecond
+	marketFeedBackoffMax = 30 * time.Second
+)
+
+// MarketFeed keeps a bookTicker stream open for the live symbols and feeds the loop: the last
+// message time, per-symbol WS lag and reconnects. A dropped stream is re-dialed with backoff.
+type MarketFeed struct {
+	ws      *binance.WSClient
+	symbols []string
+	loop    *Loop
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func NewMarketFeed(ws *binance.WSClient, symbols []string, loop *Loop, now func() time.Time) *MarketFeed {
+	if now == nil {
+		now = time.Now
+	}
+	return &MarketFeed{ws: ws, symbols: symbols, loop: loop, now: now, sleep: sleepCtx}
+}
+
+// Run blocks until ctx is done.
+func (f *MarketFeed) Run(ctx context.Context) {
+	backoff := marketFeedBackoffMin
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			f.loop.RecordWSReconnect()
+		}
+		received := false
+		_ = f.ws.Run(ctx, f.symbols, func(event binance.BookTickerEvent) {
+			received = true
+			now := f.now()
+			f.loop.UpdateWSLastMsg(now)
+			f.loop.UpdateWSSymbolLag(event.Symbol, event.EventTime, now)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			backoff = marketFeedBackoffMin
+		}
+		if f.sleep(ctx, backoff) != nil {
+			return
+		}
+		backoff *= 2
+		if backoff > marketFeedBackoffMax {
+			backoff = marketFeedBackoffMax
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+	"github.com/gorilla/websocket"
+)
+
+type fixedRateLimits struct{}
+
+func (fixedRateLimits) RateLimitUsage() (int, int) { return 300, 6000 }
+
+func TestMarketFeedFeedsLoopMetrics(t *testing.T) {
+	cfg := testConfig()
+	cfg.DiskFreeDegradeBytes = -1
+	cfg.DiskFreePauseBytes = -1
+	tmp := t.TempDir()
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: filepath.Join(tmp, "data", "audit.sqlite"), JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	defer writer.Close()
+	loop, err := NewLoop(cfg, writer, nil, time.Now)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	registry := observability.NewRegistry()
+	registry.SetWatchlist([]string{"BTCUSDT"})
+	loop.EnableMetrics(registry)
+	loop.EnableRateLimitMetrics(fixedRateLimits{})
+
+	var dials atomic.Int64
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dials.Add(1)
+		event := fmt.Sprintf(`{"stream":"btcusdt@bookTicker","data":{"E":%d,"u":1,"s":"BTCUSDT"}}`, time.Now().UnixMilli()-250)
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(event))
+	}))
+	defer server.Close()
+
+	ws := binance.NewWSClient(binance.WSOptions{BaseURL: "ws" + strings.TrimPrefix(server.URL, "http")})
+	feed := NewMarketFeed(ws, []string{"BTCUSDT"}, loop, time.Now)
+	feed.sleep = func(ctx context.Context, d time.Duration) error { return sleepCtx(ctx, time.Millisecond) }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		feed.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for dials.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if dials.Load() < 3 {
+		t.Fatalf("expected the feed to re-dial, got %d dials", dials.Load())
+	}
+	if loop.wsLastMsgMs.Load() == 0 {
+		t.Fatalf("expected ws last message time fed")
+	}
+	if err := loop.refreshSysMode(context.Background(), "run", "cycle"); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	var out bytes.Buffer
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{`livespot_ws_lag_ms{symbol="BTCUSDT"}`, "livespot_rest_weight_used_1m 300", "livespot_rest_weight_limit_1m 6000"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %s in metrics:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "livespot_ws_reconnects_total 0") {
+		t.Fatalf("expected reconnects counted:\n%s", text)
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+var (
+	stageDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	cycleDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	sysModes             = []health.SysMode{health.SysModeNormal, health.SysModeDegrade, health.SysModePause, health.SysModeExit}
+)
+
+type engineMetrics struct {
+	stageDuration   *observability.Histogram
+	cycleDuration   *observability.Histogram
+	sysMode         *observability.Gauge
+	auditQueuePct   *observability.Gauge
+	auditLagMs      *observability.Gauge
+	diskFreeBytes   *observability.Gauge
+	wsMessageAge    *observability.Gauge
+	wsSymbolLagMs   *observability.Gauge
+	wsReconnects    *observability.Counter
+	auditStageDrops *observability.Counter
+	restWeightUsed  *observability.Gauge
+	restWeightLimit *observability.Gauge
+}
+
+func (l *Loop) EnableMetrics(reg *observability.Registry) {
+	l.metrics = &engineMetrics{
+		stageDuration:   reg.Histogram("livespot_stage_duration_seconds", "Time spent in each loop stage.", stageDurationBuckets, "stage"),
+		cycleDuration:   reg.Histogram("livespot_cycle_duration_seconds", "Time spent in one full loop cycle.", cycleDurationBuckets),
+		sysMode:         reg.Gauge("livespot_sys_mode", "Current SysMode (1 for the active mode).", "mode"),
+		auditQueuePct:   reg.Gauge("livespot_audit_queue_pct", "Audit writer queue fill in percent."),
+		auditLagMs:      reg.Gauge("livespot_audit_writer_lag_ms", "Audit writer lag in milliseconds."),
+		diskFreeBytes:   reg.Gauge("livespot_disk_free_bytes", "Free bytes on the audit database volume."),
+		wsMessageAge:    reg.Gauge("livespot_ws_last_message_age_seconds", "Seconds since the last WS market message."),
+		wsSymbolLagMs:   reg.Gauge("livespot_ws_lag_ms", "Local receive time minus exchange event time per watchlist symbol.", observability.SymbolLabel),
+		wsReconnects:    reg.Counter("livespot_ws_reconnects_total", "WS reconnects since start."),
+		auditStageDrops: reg.Counter("livespot_audit_stage_drops_total", "STAGE_CHANGED telemetry records dropped on a full audit queue."),
+		restWeightUsed:  reg.Gauge("livespot_rest_weight_used_1m", "REST request weight used in the current minute."),
+		restWeightLimit: reg.Gauge("livespot_rest_weight_limit_1m", "REST request weight limit per minute."),
+	}
+	l.metrics.setSysMode(l.sysMode)
+}
+
+func (m *engineMetrics) setSysMode(current health.SysMode) {
+	if m == nil {
+		return
+	}
+	for _, mode := range sysModes {
+		value := 0.0
+		if mode == current {
+			value = 1
+		}
+		m.sysMode.Set(value, string(mode))
+	}
+}
+
+func (m *engineMetrics) observeSignals(signals health.Signals) {
+	if m == nil {
+		return
+	}
+	m.auditQueuePct.Set(float64(signals.AuditQueuePct))
+	m.auditLagMs.Set(float64(signals.AuditWriterLagMs))
+	m.diskFreeBytes.Set(float64(signals.DiskFreeBytes))
+	if signals.WsLastMsgMs > 0 {
+		m.wsMessageAge.Set(float64(signals.NowMs-signals.WsLastMsgMs) / 1000)
+	}
+}
+
+func (m *engineMetrics) countStageDrop() {
+	if m == nil {
+		return
+	}
+	m.auditStageDrops.Add(1)
+}
+
+func (m *engineMetrics) observeStage(stage observability.StageName, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.stageDuration.Observe(d.Seconds(), string(stage))
+}
+
+func (m *engineMetrics) observeCycle(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.cycleDuration.Observe(d.Seconds())
+}
+
+func (l *Loop) UpdateWSSymbolLag(symbol string, exchangeTimeMs int64, received time.Time) {
+	if l.metrics == nil || exchangeTimeMs <= 0 {
+		return
+	}
+	l.metrics.wsSymbolLagMs.Set(float64(received.UnixMilli()-exchangeTimeMs), symbol)
+}
+
+func (l *Loop) RecordWSReconnect() {
+	if l.metrics == nil {
+		return
+	}
+	l.metrics.wsReconnects.Add(1)
+}
+
+// RateLimitSource reports REST request weight usage; binance.Client implements it.
+type RateLimitSource interface {
+	RateLimitUsage() (usedWeight1m int, weightLimit1m int)
+}
+
+// EnableRateLimitMetrics makes every sys mode refresh sample source into the REST weight gauges.
+func (l *Loop) EnableRateLimitMetrics(source RateLimitSource) {
+	l.rateLimits = source
+}
+
+func (l *Loop) sampleRateLimit() {
+	if l.rateLimits == nil {
+		return
+	}
+	l.UpdateRateLimitUsage(l.rateLimits.RateLimitUsage())
+}
+
+func (l *Loop) UpdateRateLimitUsage(usedWeight1m int, weightLimit1m int) {
+	if l.metrics == nil {
+		return
+	}
+	l.metrics.restWeightUsed.Set(float64(usedWeight1m))
+	l.metrics.restWeightLimit.Set(float64(weightLimit1m))
+}
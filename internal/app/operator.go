@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const FlattenAll = "*"
+
+type operatorControls struct {
+	mu       sync.Mutex
+	pause    bool
+	exit     bool
+	pauseBy  string
+	exitBy   string
+	resumeBy string
+	flatten  map[string]string
+	aiPause  bool
+}
+
+func newOperatorControls() *operatorControls {
+	return &operatorControls{
+		flatten: map[string]string{},
+	}
+}
+
+func (l *Loop) OperatorPause(operator string) error {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	l.controls.pause = true
+	l.controls.pauseBy = operator
+	return nil
+}
+
+func (l *Loop) OperatorResume(operator string) error {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	if l.controls.exit {
+		return fmt.Errorf("exit already requested")
+	}
+	l.controls.pause = false
+	l.controls.pauseBy = ""
+	l.controls.aiPause = false
+	l.controls.resumeBy = operator
+	l.controls.flatten = map[string]string{}
+	return nil
+}
+
+func (l *Loop) OperatorExit(operator string) error {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	l.controls.exit = true
+	l.controls.exitBy = operator
+	return nil
+}
+
+func (l *Loop) OperatorFlatten(operator string, symbol string) error {
+	if symbol == "" {
+		return fmt.Errorf("flatten symbol missing")
+	}
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	l.controls.flatten[symbol] = operator
+	return nil
+}
+
+func (l *Loop) OperatorQuarantine(operator string, symbol string, untilMs int64) error {
+	if symbol == "" {
+		return fmt.Errorf("quarantine symbol missing")
+	}
+	if l.quarantine == nil {
+		return fmt.Errorf("quarantine service not enabled")
+	}
+	return l.quarantine.Quarantine(operator, symbol, untilMs)
+}
+
+func (l *Loop) OperatorRelease(operator string, symbol string) error {
+	if l.quarantine == nil {
+		return fmt.Errorf("quarantine service not enabled")
+	}
+	return l.quarantine.Release(operator, symbol)
+}
+
+func (l *Loop) PendingFlatten() []string {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	out := make([]string, 0, len(l.controls.flatten))
+	for symbol := range l.controls.flatten {
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FlattenOperator returns who requested the pending flatten of symbol.
+func (l *Loop) FlattenOperator(symbol string) string {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	return l.controls.flatten[symbol]
+}
+
+func (l *Loop) AckFlatten(symbol string) {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	delete(l.controls.flatten, symbol)
+}
+
+// operatorActors returns who requested the active pause and exit, and who resumed last; the
+// resume actor is consumed so it is reported with one transition only.
+func (l *Loop) operatorActors() (pauseBy string, exitBy string, resumeBy string) {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	resumeBy = l.controls.resumeBy
+	l.controls.resumeBy = ""
+	return l.controls.pauseBy, l.controls.exitBy, resumeBy
+}
+
+func (l *Loop) operatorSignals() (pause bool, exit bool, flattenPending bool) {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	return l.controls.pause, l.controls.exit, len(l.controls.flatten) > 0
+}
+
+// requestAIGatePause latches the PAUSE asked for by a mandatory (ai_dec=2) gate failure; like an
+// operator pause it holds until OperatorResume.
+func (l *Loop) requestAIGatePause() {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	l.controls.aiPause = true
+}
+
+func (l *Loop) aiGatePauseRequested() bool {
+	l.controls.mu.Lock()
+	defer l.controls.mu.Unlock()
+	return l.controls.aiPause
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+)
+
+// OrderSender is the order surface shared by binance.Client and binance.OrderTransport.
+type OrderSender interface {
+	NewOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error)
+	CancelOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error)
+	CancelReplaceOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error)
+	QueryOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error)
+}
+
+// OrderClient maps executor requests onto Binance order parameters, so the intent ledger
+// can drive either transport. It implements executor.OrderRestClient and executor.RestClient.
+type OrderClient struct {
+	orders OrderSender
+}
+
+type binanceOrder struct {
+	OrderID       int64         `json:"orderId"`
+	ClientOrderID string        `json:"clientOrderId"`
+	Status        string        `json:"status"`
+	Side          string        `json:"side"`
+	TransactTime  int64         `json:"transactTime"`
+	Fills         []binanceFill `json:"fills"`
+}
+
+type binanceFill struct {
+	TradeID         int64  `json:"tradeId"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+}
+
+func NewOrderClient(orders OrderSender) *OrderClient {
+	return &OrderClient{orders: orders}
+}
+
+func (c *OrderClient) SubmitOrder(ctx context.Context, req executor.OrderRequest) (executor.OrderResponse, error) {
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("side", string(req.Side))
+	params.Set("type", string(req.Type))
+	setIf(params, "timeInForce", string(req.TimeInForce))
+	setIf(params, "price", req.Price)
+	params.Set("quantity", req.Qty)
+	setIf(params, "stopPrice", req.StopPrice)
+	if req.TrailingDeltaBips > 0 {
+		params.Set("trailingDelta", strconv.Itoa(req.TrailingDeltaBips))
+	}
+	params.Set("newClientOrderId", req.ClientOrderID)
+	params.Set("newOrderRespType", "FULL")
+	resp, err := c.orders.NewOrder(ctx, params)
+	return orderResponse(resp.Body, err)
+}
+
+func (c *OrderClient) CancelOrder(ctx context.Context, req executor.CancelRequest) (executor.OrderResponse, error) {
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("origClientOrderId", req.ClientOrderID)
+	resp, err := c.orders.CancelOrder(ctx, params)
+	return orderResponse(resp.Body, err)
+}
+
+func (c *OrderClient) CancelReplaceOrder(ctx context.Context, req executor.CancelReplaceRequest) (executor.OrderResponse, error) {
+	params := url.Values{}
+	params.Set("symbol", req.Symbol)
+	params.Set("side", string(req.Side))
+	params.Set("type", string(req.Type))
+	setIf(params, "timeInForce", string(req.TimeInForce))
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE")
+	params.Set("cancelOrigClientOrderId", req.ClientOrderID)
+	params.Set("newClientOrderId", req.NewClientID)
+	setIf(params, "price", req.NewPrice)
+	params.Set("quantity", req.NewQty)
+	params.Set("newOrderRespType", "FULL")
+	resp, err := c.orders.CancelReplaceOrder(ctx, params)
+	if err != nil {
+		return orderResponse(nil, err)
+	}
+	var body struct {
+		NewOrderResponse json.RawMessage `json:"newOrderResponse"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		return executor.OrderResponse{}, fmt.Errorf("cancel replace response: %w", err)
+	}
+	return orderResponse(body.NewOrderResponse, nil)
+}
+
+// GetOrderByClientID reports Found=false only when the exchange answers -2013 (order does not exist).
+func (c *OrderClient) GetOrderByClientID(ctx context.Context, symbol string, clientOrderID string) (executor.OrderResponse, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("origClientOrderId", clientOrderID)
+	resp, err := c.orders.QueryOrder(ctx, params)
+	var bErr binance.BinanceError
+	if errors.As(err, &bErr) && bErr.Code == -2013 {
+		return executor.OrderResponse{Found: false}, nil
+	}
+	out, err := orderResponse(resp.Body, err)
+	out.Found = err == nil
+	return out, err
+}
+
+// orderResponse maps every network failure, not only timeouts, to executor.ErrTimeout: a reset
+// or EOF after the request went out leaves the order SENT_UNKNOWN, never FAILED.
+func orderResponse(body []byte, err error) (executor.OrderResponse, error) {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return executor.OrderResponse{}, fmt.Errorf("%v: %w", err, executor.ErrTimeout)
+	}
+	var bErr binance.BinanceError
+	if errors.As(err, &bErr) && bErr.Code == -2010 {
+		return executor.OrderResponse{Rejected: true, ErrorCode: strconv.Itoa(bErr.Code)}, nil
+	}
+	if err != nil {
+		return executor.OrderResponse{}, err
+	}
+	var order binanceOrder
+	if err := json.Unmarshal(body, &order); err != nil {
+		return executor.OrderResponse{}, fmt.Errorf("order response: %w", err)
+	}
+	fills := make([]executor.Fill, 0, len(order.Fills))
+	for _, fill := range order.Fills {
+		fills = append(fills, executor.Fill{
+			TradeID:         fill.TradeID,
+			Price:           fill.Price,
+			Qty:             fill.Qty,
+			Commission:      fill.Commission,
+			CommissionAsset: fill.CommissionAsset,
+		})
+	}
+	return executor.OrderResponse{
+		OrderID:       strconv.FormatInt(order.OrderID, 10),
+		ClientOrderID: order.ClientOrderID,
+		Status:        order.Status,
+		Side:          contracts.Side(order.Side),
+		TransactTime:  order.TransactTime,
+		Fills:         fills,
+	}, nil
+}
+
+func setIf(params url.Values, key string, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
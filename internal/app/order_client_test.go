@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+)
+
+type failingSender struct {
+	err error
+}
+
+func (s failingSender) NewOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error) {
+	return binance.JSONResponse{}, s.err
+}
+
+func (s failingSender) CancelOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error) {
+	return binance.JSONResponse{}, s.err
+}
+
+func (s failingSender) CancelReplaceOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error) {
+	return binance.JSONResponse{}, s.err
+}
+
+func (s failingSender) QueryOrder(ctx context.Context, params url.Values) (binance.JSONResponse, error) {
+	return binance.JSONResponse{}, s.err
+}
+
+func TestOrderClientMapsTransportFailuresToSentUnknown(t *testing.T) {
+	req := executor.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", Type: executor.OrderTypeLimit, Price: "100", Qty: "1", ClientOrderID: "LS_1"}
+	reset := &url.Error{Op: "Post", URL: "https://api.binance.com/api/v3/order", Err: io.ErrUnexpectedEOF}
+	if _, err := NewOrderClient(failingSender{err: reset}).SubmitOrder(context.Background(), req); !errors.Is(err, executor.ErrTimeout) {
+		t.Fatalf("expected a connection failure to map to SENT_UNKNOWN, got %v", err)
+	}
+	rejected := binance.BinanceError{Status: 400, Code: -2010, Msg: "insufficient balance"}
+	resp, err := NewOrderClient(failingSender{err: rejected}).SubmitOrder(context.Background(), req)
+	if err != nil || !resp.Rejected || resp.ErrorCode != "-2010" {
+		t.Fatalf("expected -2010 reject, got %+v (%v)", resp, err)
+	}
+	throttled := binance.BinanceError{Status: 429, Code: -1003, Msg: "too many requests"}
+	if _, err := NewOrderClient(failingSender{err: throttled}).SubmitOrder(context.Background(), req); errors.Is(err, executor.ErrTimeout) || err == nil {
+		t.Fatalf("expected a 429 to fail without SENT_UNKNOWN, got %v", err)
+	}
+}
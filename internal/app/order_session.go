@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+)
+
+const (
+	orderSessionPingInterval = 30 * time.Second
+	orderSessionBackoffMin   = 500 * time.Millisecond
+	orderSessionBackoffMax   = 30 * time.Second
+)
+
+// OrderSession keeps the WS API order session up: it dials (and logs on) with backoff, pings
+// on an interval and drops a session whose ping fails so the next pass re-dials. While the
+// session is down binance.OrderTransport sends over REST.
+type OrderSession struct {
+	ws       *binance.WSAPIClient
+	interval time.Duration
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewOrderSession(ws *binance.WSAPIClient) *OrderSession {
+	return &OrderSession{ws: ws, interval: orderSessionPingInterval, sleep: sleepCtx}
+}
+
+// Run blocks until ctx is done and closes the session on the way out.
+func (s *OrderSession) Run(ctx context.Context) {
+	defer func() {
+		_ = s.ws.Close()
+	}()
+	backoff := orderSessionBackoffMin
+	for ctx.Err() == nil {
+		if !s.ws.Connected() {
+			if err := s.ws.Connect(ctx); err != nil {
+				if s.sleep(ctx, backoff) != nil {
+					return
+				}
+				backoff *= 2
+				if backoff > orderSessionBackoffMax {
+					backoff = orderSessionBackoffMax
+				}
+				continue
+			}
+			backoff = orderSessionBackoffMin
+		}
+		if s.sleep(ctx, s.interval) != nil {
+			return
+		}
+		if s.ws.Connected() {
+			if err := s.ws.Ping(ctx); err != nil {
+				_ = s.ws.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/gorilla/websocket"
+)
+
+func TestOrderSessionRedialsWhenPingGoesUnanswered(t *testing.T) {
+	cfg := testConfig()
+	cfg.BinanceWSAPIRequestTimeoutMs = 100
+	var dials, pongs atomic.Int64
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		first := dials.Add(1) == 1
+		for {
+			var req struct {
+				ID     string `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			if first || req.Method != "ping" {
+				continue
+			}
+			pongs.Add(1)
+			_ = conn.WriteJSON(map[string]any{"id": req.ID, "status": 200, "result": map[string]any{}})
+		}
+	}))
+	defer server.Close()
+
+	signer, err := binance.NewHMACSigner("secret")
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	ws := binance.NewWSAPIClient(cfg, binance.WSAPIOptions{URL: "ws" + strings.TrimPrefix(server.URL, "http"), APIKey: "key", Signer: signer})
+	session := NewOrderSession(ws)
+	session.interval = 5 * time.Millisecond
+	session.sleep = func(ctx context.Context, d time.Duration) error { return sleepCtx(ctx, 5*time.Millisecond) }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		session.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for pongs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ws.Connected() {
+		t.Fatalf("expected the session to be up after re-dialing")
+	}
+	cancel()
+	<-done
+	if dials.Load() < 2 || pongs.Load() < 2 {
+		t.Fatalf("expected a re-dial after the unanswered ping, got %d dials %d pongs", dials.Load(), pongs.Load())
+	}
+	if ws.Connected() {
+		t.Fatalf("expected the session closed on shutdown")
+	}
+}
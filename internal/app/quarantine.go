@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/persist"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/risk"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+const (
+	QuarantineActionQuarantined = "QUARANTINED"
+	QuarantineActionReleased    = "RELEASED"
+)
+
+// QuarantineService owns the symbol quarantine: it feeds risk.SymbolQuarantine from order outcomes
+// and WS disconnects, persists every change to symbol_health and audits each transition as
+// SYMBOL_QUARANTINE. It implements executor.OrderOutcomeObserver and universe.HealthSource.
+type QuarantineService struct {
+	cfg    config.Config
+	store  *persist.QuarantineStore
+	writer *audit.Writer
+	now    func() time.Time
+
+	mu      sync.Mutex
+	tracker *risk.SymbolQuarantine
+	runID   string
+	cycleID string
+	err     error
+}
+
+func NewQuarantineService(ctx context.Context, cfg config.Config, db *sql.DB, writer *audit.Writer, now func() time.Time) (*QuarantineService, error) {
+	if db == nil {
+		return nil, fmt.Errorf("quarantine db missing")
+	}
+	if writer == nil {
+		return nil, fmt.Errorf("quarantine audit writer missing")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	store := persist.NewQuarantineStore(db)
+	states, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QuarantineService{
+		cfg:     cfg,
+		store:   store,
+		writer:  writer,
+		now:     now,
+		tracker: risk.NewSymbolQuarantine(cfg, states),
+		runID:   "unknown",
+		cycleID: "unknown",
+	}, nil
+}
+
+// BeginCycle sets the run and cycle ids used by audit records of asynchronous triggers.
+func (s *QuarantineService) BeginCycle(runID string, cycleID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runID = runID
+	s.cycleID = cycleID
+}
+
+func (s *QuarantineService) OrderAccepted(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h, changed := s.tracker.RecordSuccess(symbol, s.now().UnixMilli()); changed {
+		s.keep(s.save(h))
+	}
+}
+
+func (s *QuarantineService) OrderRejected(symbol string, errCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, tripped := s.tracker.RecordReject(symbol, errCode, s.now().UnixMilli())
+	s.keep(s.record(h, tripped))
+}
+
+func (s *QuarantineService) OrderTimedOut(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, tripped := s.tracker.RecordTimeout(symbol, s.now().UnixMilli())
+	s.keep(s.record(h, tripped))
+}
+
+// RecordWSDisconnect counts one disconnect for every symbol carried by the dropped stream.
+func (s *QuarantineService) RecordWSDisconnect(symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nowMs := s.now().UnixMilli()
+	for _, symbol := range symbols {
+		h, tripped := s.tracker.RecordWSDisconnect(symbol, nowMs)
+		s.keep(s.record(h, tripped))
+	}
+}
+
+// Quarantine places symbol in quarantine on operator request; untilMs 0 means until released.
+func (s *QuarantineService) Quarantine(operator string, symbol string, untilMs int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.tracker.Quarantine(symbol, untilMs, risk.QuarantineTriggerOperator, s.now().UnixMilli())
+	if err := s.save(h); err != nil {
+		return err
+	}
+	return s.emit(h, QuarantineActionQuarantined, operator, observability.STATE_UPDATE)
+}
+
+func (s *QuarantineService) Release(operator string, symbol string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prior, ok := s.tracker.Release(symbol, s.now().UnixMilli())
+	if !ok {
+		return fmt.Errorf("symbol not quarantined: %s", symbol)
+	}
+	if err := s.save(s.tracker.State(symbol)); err != nil {
+		return err
+	}
+	return s.emit(prior, QuarantineActionReleased, operator, observability.STATE_UPDATE)
+}
+
+// Sweep auto-releases expired quarantines and returns the released symbols. It also reports
+// the first persistence or audit failure seen by the observer callbacks since the last sweep.
+func (s *QuarantineService) Sweep() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.err
+	s.err = nil
+	if err != nil {
+		return nil, err
+	}
+	var released []string
+	for _, prior := range s.tracker.Expire(s.now().UnixMilli()) {
+		if err := s.save(s.tracker.State(prior.Symbol)); err != nil {
+			return released, err
+		}
+		if err := s.emit(prior, QuarantineActionReleased, "", observability.UNIVERSE_SCAN); err != nil {
+			return released, err
+		}
+		released = append(released, prior.Symbol)
+	}
+	return released, nil
+}
+
+func (s *QuarantineService) HealthFlags(symbol string, nowMs int64) (int64, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.HealthFlags(symbol, nowMs)
+}
+
+func (s *QuarantineService) States() []risk.SymbolHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracker.States()
+}
+
+func (s *QuarantineService) record(h risk.SymbolHealth, tripped bool) error {
+	if err := s.save(h); err != nil {
+		return err
+	}
+	if !tripped {
+		return nil
+	}
+	return s.emit(h, QuarantineActionQuarantined, "", observability.STATE_UPDATE)
+}
+
+func (s *QuarantineService) save(h risk.SymbolHealth) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.store.Save(ctx, h)
+}
+
+func (s *QuarantineService) keep(err error) {
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *QuarantineService) emit(h risk.SymbolHealth, action string, operator string, stage observability.StageName) error {
+	now := s.now()
+	reasons := []reasoncodes.ReasonCode{}
+	if action == QuarantineActionQuarantined {
+		reasons = append(reasons, reasoncodes.SYMBOL_QUARANTINED)
+		if h.Trigger == risk.QuarantineTriggerOperator {
+			reasons = append(reasons, reasoncodes.OPERATOR_QUARANTINE)
+		}
+	}
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           s.runID,
+			CycleID:         s.cycleID,
+			Mode:            s.cfg.Mode,
+			Stage:           stage,
+			EventType:       auditdomain.SYMBOL_QUARANTINE,
+			Reasons:         reasons,
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"symbol":               h.Symbol,
+			"action":               action,
+			"trigger":              string(h.Trigger),
+			"until_ms":             h.QuarantinedUntilMs,
+			"operator":             operator,
+			"recent_rejects":       len(h.RejectTsMs),
+			"ws_disconnects":       len(h.WSDisconnectTsMs),
+			"consecutive_timeouts": h.ConsecutiveTimeouts,
+			"last_error_code":      h.LastErrorCode,
+			"auto_release":         s.cfg.RiskQuarantineAutoRelease,
+		},
+	}
+	if err := s.writer.Write(record); err != nil {
+		return fmt.Errorf("audit quarantine write: %w", err)
+	}
+	return nil
+}
+
+func (l *Loop) EnableQuarantine(service *QuarantineService) {
+	l.quarantine = service
+}
+
+func (l *Loop) runQuarantineSweep() string {
+	if l.quarantine == nil {
+		return ""
+	}
+	released, err := l.quarantine.Sweep()
+	if err != nil {
+		return "quarantine failed: " + err.Error()
+	}
+	if len(released) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("quarantine released %d symbols", len(released))
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/universe"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func TestQuarantineServicePersistsAndAutoReleases(t *testing.T) {
+	cfg := testConfig()
+	cfg.RiskQuarantineTTLSeconds = 60
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer create: %v", err)
+	}
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	now := time.UnixMilli(1_700_000_000_000)
+	clock := func() time.Time { return now }
+	svc, err := NewQuarantineService(context.Background(), cfg, db, writer, clock)
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	svc.BeginCycle("run", "cycle")
+	for i := 0; i < cfg.RiskQuarantineMaxRejectsPerHour; i++ {
+		svc.OrderRejected("BTCUSDT", "REJECTED")
+	}
+	if err := svc.Quarantine("alice", "ETHUSDT", 0); err != nil {
+		t.Fatalf("operator quarantine: %v", err)
+	}
+
+	restarted, err := NewQuarantineService(context.Background(), cfg, db, writer, clock)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	var snapshots []contracts.Snapshot
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+		snapshot := contracts.Snapshot{Symbol: symbol}
+		snapshot.HealthFlags.SymbolStatus = "TRADING"
+		snapshots = append(snapshots, snapshot)
+	}
+	snapshots = universe.ApplyHealth(snapshots, restarted, now.UnixMilli())
+	results, err := universe.Scan(cfg, snapshots)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if snapshots[0].HealthFlags.QuarantinedUntilMs != now.UnixMilli()+60_000 || snapshots[0].HealthFlags.RecentRejectsWindowCount != cfg.RiskQuarantineMaxRejectsPerHour {
+		t.Fatalf("expected restored reject quarantine, got %+v", snapshots[0].HealthFlags)
+	}
+	for i, want := range []bool{true, true, false} {
+		if slices.Contains(results[i].Reasons, reasoncodes.SYMBOL_QUARANTINED) != want {
+			t.Fatalf("unexpected scan result for %s: %+v", results[i].Symbol, results[i])
+		}
+	}
+
+	now = now.Add(61 * time.Second)
+	released, err := restarted.Sweep()
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	if !slices.Equal(released, []string{"BTCUSDT"}) {
+		t.Fatalf("expected BTCUSDT released, got %v", released)
+	}
+	if err := restarted.Release("alice", "ETHUSDT"); err != nil {
+		t.Fatalf("operator release: %v", err)
+	}
+	if err := restarted.Release("alice", "ETHUSDT"); err == nil {
+		t.Fatalf("expected release of free symbol to fail")
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("writer close: %v", err)
+	}
+
+	var untilMs int64
+	if err := db.QueryRow("SELECT quarantined_until_ms FROM symbol_health WHERE symbol='BTCUSDT'").Scan(&untilMs); err != nil || untilMs != 0 {
+		t.Fatalf("expected persisted release, got %d %v", untilMs, err)
+	}
+	rows, err := db.Query("SELECT data_json FROM audit_events WHERE event_type='SYMBOL_QUARANTINE' ORDER BY event_id")
+	if err != nil {
+		t.Fatalf("query events: %v", err)
+	}
+	defer rows.Close()
+	var events []string
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		events = append(events, data)
+	}
+	want := []string{`"trigger":"REJECTS"`, `"operator":"alice"`, `"action":"RELEASED"`, `"symbol":"ETHUSDT"`}
+	if len(events) != 4 {
+		t.Fatalf("expected 4 quarantine events, got %d: %v", len(events), events)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(events[i], fragment) {
+			t.Fatalf("event %d missing %s: %s", i, fragment, events[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/reports"
+)
+
+func (l *Loop) EnableDailyReport(generator *reports.Generator) {
+	l.reports = generator
+}
+
+func (l *Loop) runDailyJobs(ctx context.Context, runID string, cycleID string) string {
+	var notes []string
+	for _, job := range []func(context.Context, string, string) string{l.runDailyReport, l.runAIGateOutcomes, l.runRetention, l.runBackup} {
+		if note := job(ctx, runID, cycleID); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	return strings.Join(notes, "; ")
+}
+
+func (l *Loop) runDailyReport(ctx context.Context, runID string, cycleID string) string {
+	if l.reports == nil {
+		return ""
+	}
+	day := reports.DayStart(l.now()).AddDate(0, 0, -1)
+	key := day.Format(reports.DayLayout)
+	if l.lastReportDay == key {
+		return ""
+	}
+	l.lastReportDay = key
+	if l.reports.Exists(day) {
+		return ""
+	}
+	if _, err := l.reports.Generate(ctx, day, runID, cycleID); err != nil {
+		return "daily summary failed: " + err.Error()
+	}
+	return "daily summary written for " + key
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/ops"
+)
+
+func (l *Loop) EnableRetention(retainer *ops.Retainer) {
+	l.retention = retainer
+}
+
+func (l *Loop) runRetention(ctx context.Context, runID string, cycleID string) string {
+	if l.retention == nil {
+		return ""
+	}
+	key := l.now().UTC().Format("2006-01-02")
+	if l.lastRetentionDay == key {
+		return ""
+	}
+	if l.sysMode != health.SysModeNormal || len(l.PendingFlatten()) > 0 {
+		return ""
+	}
+	result, err := l.retention.Run(ctx, runID, cycleID)
+	if err != nil {
+		l.lastRetentionDay = key
+		return "retention failed: " + err.Error()
+	}
+	if result.Skipped != "" {
+		return "retention skipped: " + result.Skipped
+	}
+	l.lastRetentionDay = key
+	var rows int64
+	for _, n := range result.RowsDeleted {
+		rows += n
+	}
+	return fmt.Sprintf("retention archived %d rows in %d files", rows, len(result.Archives))
+}
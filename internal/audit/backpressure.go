@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+type BackpressureAction string
+
+const (
+	BackpressureNone    BackpressureAction = "NONE"
+	BackpressureDegrade BackpressureAction = "DEGRADE"
+	BackpressurePause   BackpressureAction = "PAUSE"
+)
+
+type Backpressure struct {
+	QueuePct    int
+	QueueLen    int
+	QueueCap    int
+	LagMs       int64
+	Action      BackpressureAction
+	DropsTotal  int64
+	DropsDelta  int64
+	StallsTotal int64
+}
+
+func EvaluateBackpressure(cfg config.Config, stats WriterStats, prevDropsTotal int64) Backpressure {
+	action := BackpressureNone
+	if stats.QueuePct >= cfg.AuditWriterQueueFull {
+		action = BackpressurePause
+	} else if stats.QueuePct >= cfg.AuditWriterQueueHiWatermark || stats.LagMs >= int64(cfg.AuditWriterMaxLagMs) || stats.DropsTotal > prevDropsTotal {
+		action = BackpressureDegrade
+	}
+	return Backpressure{
+		QueuePct:    stats.QueuePct,
+		QueueLen:    stats.QueueLen,
+		QueueCap:    stats.QueueCap,
+		LagMs:       stats.LagMs,
+		Action:      action,
+		DropsTotal:  stats.DropsTotal,
+		DropsDelta:  stats.DropsTotal - prevDropsTotal,
+		StallsTotal: stats.StallsTotal,
+	}
+}
+
+func (b Backpressure) Reasons() []reasoncodes.ReasonCode {
+	reasons := []reasoncodes.ReasonCode{reasoncodes.DB_WRITER_BACKPRESSURE}
+	switch b.Action {
+	case BackpressurePause:
+		reasons = append(reasons, reasoncodes.DB_WRITER_QUEUE_FULL)
+	case BackpressureDegrade:
+		reasons = append(reasons, reasoncodes.DB_WRITER_QUEUE_HIGH)
+	}
+	return reasons
+}
+
+func (b Backpressure) Record(event auditdomain.AuditEvent) Record {
+	event.EventType = auditdomain.DB_WRITER_BACKPRESSURE
+	event.Reasons = b.Reasons()
+	if event.Stage == "" {
+		event.Stage = observability.STATE_UPDATE
+	}
+	return Record{
+		Event: event,
+		Data: map[string]any{
+			"queue_pct":    b.QueuePct,
+			"queue_len":    b.QueueLen,
+			"queue_cap":    b.QueueCap,
+			"lag_ms":       b.LagMs,
+			"action":       string(b.Action),
+			"drops_total":  b.DropsTotal,
+			"drops_delta":  b.DropsDelta,
+			"stalls_total": b.StallsTotal,
+		},
+	}
+}
@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/hash"
+)
+
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+func ChainHash(line map[string]any, prevHash string) (string, error) {
+	body := make(map[string]any, len(line)+1)
+	for key, value := range line {
+		if key == "record_hash" {
+			continue
+		}
+		body[key] = value
+	}
+	body["prev_hash"] = prevHash
+	return hash.CanonicalHash(body)
+}
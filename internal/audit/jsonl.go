@@ -3,7 +3,6 @@ package audit
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 )
 
@@ -11,13 +10,17 @@ type jsonlWriter struct {
 	dir        string
 	currentDay string
 	file       *os.File
+	keyPath    string
+	keyAlg     string
+	key        *ManifestKey
+	now        func() time.Time
 }
 
-func newJSONLWriter(dir string) (*jsonlWriter, error) {
+func newJSONLWriter(dir string, keyPath string, keyAlg string, now func() time.Time) (*jsonlWriter, error) {
 	if err := ensureDir(dir); err != nil {
 		return nil, err
 	}
-	return &jsonlWriter{dir: dir}, nil
+	return &jsonlWriter{dir: dir, keyPath: keyPath, keyAlg: keyAlg, now: now}, nil
 }
 
 func (w *jsonlWriter) Write(ts time.Time, line []byte) error {
@@ -40,7 +43,7 @@ func (w *jsonlWriter) Close() error {
 }
 
 func (w *jsonlWriter) rotateIfNeeded(ts time.Time) error {
-	day := ts.UTC().Format("2006-01-02")
+	day := ts.UTC().Format(jsonlDayLayout)
 	if day == w.currentDay && w.file != nil {
 		return nil
 	}
@@ -48,9 +51,12 @@ func (w *jsonlWriter) rotateIfNeeded(ts time.Time) error {
 		if err := w.file.Close(); err != nil {
 			return fmt.Errorf("jsonl close: %w", err)
 		}
+		w.file = nil
 	}
-	path := filepath.Join(w.dir, fmt.Sprintf("audit-%s.jsonl", day))
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err := w.sealBefore(day); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(JSONLPath(w.dir, day), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
 	if err != nil {
 		return fmt.Errorf("jsonl open: %w", err)
 	}
@@ -59,6 +65,42 @@ func (w *jsonlWriter) rotateIfNeeded(ts time.Time) error {
 	return nil
 }
 
+func (w *jsonlWriter) sealBefore(day string) error {
+	if w.currentDay != "" && w.currentDay != day {
+		return w.seal(w.currentDay)
+	}
+	days, err := ListJSONLDays(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, pending := range days {
+		if pending >= day {
+			continue
+		}
+		if _, err := os.Stat(ManifestPath(w.dir, pending)); err == nil {
+			continue
+		}
+		if err := w.seal(pending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *jsonlWriter) seal(day string) error {
+	if w.key == nil {
+		key, err := LoadManifestKey(w.keyPath, w.keyAlg, true)
+		if err != nil {
+			return fmt.Errorf("jsonl seal: %w", err)
+		}
+		w.key = key
+	}
+	if _, err := SealDay(w.dir, day, w.key, w.now()); err != nil {
+		return fmt.Errorf("jsonl seal %s: %w", day, err)
+	}
+	return nil
+}
+
 func ensureDir(path string) error {
 	if fi, err := os.Stat(path); err == nil {
 		if fi.IsDir() {
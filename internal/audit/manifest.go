@@ -0,0 +1,306 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/hash"
+)
+
+const (
+	ManifestAlgHMACSHA256 = "hmac-sha256"
+	ManifestAlgEd25519    = "ed25519"
+	manifestVersion       = 1
+	manifestKeyBytes      = 32
+	jsonlDayLayout        = "2006-01-02"
+)
+
+var jsonlFilePattern = regexp.MustCompile(`^audit-(\d{4}-\d{2}-\d{2})\.jsonl$`)
+
+type ManifestRun struct {
+	Count     int    `json:"count"`
+	FirstHash string `json:"first_hash"`
+	LastHash  string `json:"last_hash"`
+}
+
+type Manifest struct {
+	Version     int                    `json:"version"`
+	Day         string                 `json:"day"`
+	File        string                 `json:"file"`
+	Bytes       int64                  `json:"bytes"`
+	Lines       int                    `json:"lines"`
+	SHA256      string                 `json:"sha256"`
+	Runs        map[string]ManifestRun `json:"runs"`
+	CreatedAtMs int64                  `json:"created_at_ms"`
+	Alg         string                 `json:"alg"`
+	KeyID       string                 `json:"key_id"`
+	Signature   string                 `json:"signature,omitempty"`
+}
+
+type ManifestKey struct {
+	alg     string
+	secret  []byte
+	private ed25519.PrivateKey
+	public  ed25519.PublicKey
+}
+
+// ManifestPublicKeyPath is where the ed25519 public key is kept next to the
+// seed, so verification never needs the signing secret.
+func ManifestPublicKeyPath(keyPath string) string {
+	return keyPath + ".pub"
+}
+
+func LoadManifestKey(path string, alg string, create bool) (*ManifestKey, error) {
+	if alg != ManifestAlgHMACSHA256 && alg != ManifestAlgEd25519 {
+		return nil, fmt.Errorf("manifest key alg unsupported: %s", alg)
+	}
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		buf, err = createManifestKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("manifest key read: %w", err)
+	}
+	secret, err := hex.DecodeString(strings.TrimSpace(string(buf)))
+	if err != nil || len(secret) != manifestKeyBytes {
+		return nil, fmt.Errorf("manifest key invalid: %s", path)
+	}
+	key := &ManifestKey{alg: alg, secret: secret}
+	if alg == ManifestAlgEd25519 {
+		key.private = ed25519.NewKeyFromSeed(secret)
+		key.public = key.private.Public().(ed25519.PublicKey)
+		key.secret = nil
+		if err := ensureManifestPublicKey(ManifestPublicKeyPath(path), key.public); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// LoadManifestVerifyKey loads the key used to check manifests. For hmac-sha256
+// that is the shared secret; for ed25519 path is the public key file and the
+// seed is never read.
+func LoadManifestVerifyKey(path string, alg string) (*ManifestKey, error) {
+	if alg != ManifestAlgEd25519 {
+		return LoadManifestKey(path, alg, false)
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("manifest public key read: %w", err)
+	}
+	public, err := hex.DecodeString(strings.TrimSpace(string(buf)))
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("manifest public key invalid: %s", path)
+	}
+	return &ManifestKey{alg: alg, public: ed25519.PublicKey(public)}, nil
+}
+
+func ensureManifestPublicKey(path string, public ed25519.PublicKey) error {
+	want := hex.EncodeToString(public)
+	if buf, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(buf)) == want {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(want+"\n"), 0o644); err != nil {
+		return fmt.Errorf("manifest public key write: %w", err)
+	}
+	return nil
+}
+
+func createManifestKey(path string) ([]byte, error) {
+	secret := make([]byte, manifestKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	buf := []byte(hex.EncodeToString(secret) + "\n")
+	if err := os.WriteFile(path, buf, 0o600); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (k *ManifestKey) Alg() string {
+	return k.alg
+}
+
+func (k *ManifestKey) KeyID() string {
+	material := k.secret
+	if k.alg == ManifestAlgEd25519 {
+		material = k.public
+	}
+	return hash.HashSHA256Hex(material)[:16]
+}
+
+func (k *ManifestKey) Sign(m *Manifest) error {
+	if k.alg == ManifestAlgEd25519 && k.private == nil {
+		return errors.New("manifest key is verify-only")
+	}
+	m.Alg = k.alg
+	m.KeyID = k.KeyID()
+	payload, err := manifestPayload(*m)
+	if err != nil {
+		return err
+	}
+	m.Signature = hex.EncodeToString(k.signature(payload))
+	return nil
+}
+
+func (k *ManifestKey) Verify(m Manifest) error {
+	if m.Alg != k.alg {
+		return fmt.Errorf("manifest alg mismatch: %s", m.Alg)
+	}
+	if m.KeyID != k.KeyID() {
+		return fmt.Errorf("manifest key_id mismatch: %s", m.KeyID)
+	}
+	signature, err := hex.DecodeString(m.Signature)
+	if err != nil || len(signature) == 0 {
+		return errors.New("manifest signature missing")
+	}
+	payload, err := manifestPayload(m)
+	if err != nil {
+		return err
+	}
+	if k.alg == ManifestAlgEd25519 {
+		if !ed25519.Verify(k.public, payload, signature) {
+			return errors.New("manifest signature invalid")
+		}
+		return nil
+	}
+	if !hmac.Equal(signature, k.signature(payload)) {
+		return errors.New("manifest signature invalid")
+	}
+	return nil
+}
+
+func (k *ManifestKey) signature(payload []byte) []byte {
+	if k.alg == ManifestAlgEd25519 {
+		return ed25519.Sign(k.private, payload)
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func manifestPayload(m Manifest) ([]byte, error) {
+	m.Signature = ""
+	buf, err := hash.CanonicalJSON(m)
+	if err != nil {
+		return nil, fmt.Errorf("manifest canonical json: %w", err)
+	}
+	return buf, nil
+}
+
+func JSONLPath(dir string, day string) string {
+	return filepath.Join(dir, fmt.Sprintf("audit-%s.jsonl", day))
+}
+
+func ManifestPath(dir string, day string) string {
+	return filepath.Join(dir, fmt.Sprintf("audit-%s.manifest.json", day))
+}
+
+func ListJSONLDays(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("jsonl list: %w", err)
+	}
+	days := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		match := jsonlFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		days = append(days, match[1])
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func BuildManifest(dir string, day string, now time.Time) (Manifest, error) {
+	buf, err := os.ReadFile(JSONLPath(dir, day))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("manifest read jsonl: %w", err)
+	}
+	manifest := Manifest{
+		Version:     manifestVersion,
+		Day:         day,
+		File:        filepath.Base(JSONLPath(dir, day)),
+		Bytes:       int64(len(buf)),
+		SHA256:      hash.HashSHA256Hex(buf),
+		Runs:        map[string]ManifestRun{},
+		CreatedAtMs: now.UnixMilli(),
+	}
+	for _, raw := range bytes.Split(buf, []byte{'\n'}) {
+		if len(raw) == 0 {
+			continue
+		}
+		manifest.Lines++
+		var head struct {
+			RunID      string `json:"run_id"`
+			RecordHash string `json:"record_hash"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return Manifest{}, fmt.Errorf("manifest parse line %d: %w", manifest.Lines, err)
+		}
+		manifest.Runs[head.RunID] = addManifestRun(manifest.Runs[head.RunID], head.RecordHash)
+	}
+	return manifest, nil
+}
+
+func addManifestRun(run ManifestRun, recordHash string) ManifestRun {
+	if run.Count == 0 {
+		run.FirstHash = recordHash
+	}
+	run.Count++
+	run.LastHash = recordHash
+	return run
+}
+
+func ReadManifest(path string) (Manifest, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("manifest decode: %w", err)
+	}
+	return manifest, nil
+}
+
+func SealDay(dir string, day string, key *ManifestKey, now time.Time) (Manifest, error) {
+	manifest, err := BuildManifest(dir, day, now)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := key.Sign(&manifest); err != nil {
+		return Manifest{}, err
+	}
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("manifest encode: %w", err)
+	}
+	path := ManifestPath(dir, day)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(buf, '\n'), 0o640); err != nil {
+		return Manifest{}, fmt.Errorf("manifest write: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return Manifest{}, fmt.Errorf("manifest rename: %w", err)
+	}
+	return manifest, nil
+}
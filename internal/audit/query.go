@@ -0,0 +1,501 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	QueryTableEvents  = "audit_events"
+	QueryTableIntents = "order_intents"
+	QueryTableGate    = "ai_gate_events"
+
+	QuerySourceSQLite = "sqlite"
+	QuerySourceJSONL  = "jsonl"
+	QuerySourceBoth   = "sqlite+jsonl"
+
+	QueryFormatTable = "table"
+	QueryFormatJSONL = "jsonl"
+	QueryFormatCSV   = "csv"
+)
+
+type QueryFilter struct {
+	RunID         string
+	CycleID       string
+	DecisionID    string
+	OrderIntentID string
+	Symbol        string
+	EventType     string
+	Reason        string
+	FromMs        int64
+	ToMs          int64
+	Limit         int
+}
+
+type QueryRow struct {
+	TsMs          int64          `json:"ts_ms"`
+	Table         string         `json:"table"`
+	Source        string         `json:"source"`
+	RunID         string         `json:"run_id"`
+	CycleID       string         `json:"cycle_id"`
+	Stage         string         `json:"stage"`
+	EventType     string         `json:"event_type"`
+	Symbol        string         `json:"symbol"`
+	SnapshotID    string         `json:"snapshot_id"`
+	DecisionID    string         `json:"decision_id"`
+	OrderIntentID string         `json:"order_intent_id"`
+	Reasons       []string       `json:"reasons"`
+	Detail        map[string]any `json:"detail"`
+	recordHash    string
+}
+
+type Querier struct {
+	DB       *sql.DB
+	JSONLDir string
+}
+
+func (f QueryFilter) Match(row QueryRow) bool {
+	if f.RunID != "" && row.RunID != f.RunID {
+		return false
+	}
+	if f.CycleID != "" && row.CycleID != f.CycleID {
+		return false
+	}
+	if f.DecisionID != "" && row.DecisionID != f.DecisionID {
+		return false
+	}
+	if f.OrderIntentID != "" && row.OrderIntentID != f.OrderIntentID {
+		return false
+	}
+	if f.Symbol != "" && !strings.EqualFold(row.Symbol, f.Symbol) {
+		return false
+	}
+	if f.EventType != "" && row.EventType != f.EventType {
+		return false
+	}
+	if f.FromMs > 0 && row.TsMs < f.FromMs {
+		return false
+	}
+	if f.ToMs > 0 && row.TsMs >= f.ToMs {
+		return false
+	}
+	if f.Reason != "" {
+		for _, reason := range row.Reasons {
+			if reason == f.Reason {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (q Querier) Events(ctx context.Context, filter QueryFilter, sources []string) ([]QueryRow, error) {
+	var rows []QueryRow
+	for _, source := range sources {
+		switch source {
+		case QuerySourceSQLite:
+			sqliteRows, err := q.sqliteEvents(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			rows = mergeEvents(rows, sqliteRows)
+		case QuerySourceJSONL:
+			jsonlRows, err := q.jsonlEvents(filter)
+			if err != nil {
+				return nil, err
+			}
+			rows = mergeEvents(rows, jsonlRows)
+		default:
+			return nil, fmt.Errorf("query source unsupported: %s", source)
+		}
+	}
+	return finishRows(rows, filter.Limit), nil
+}
+
+func (q Querier) Intents(ctx context.Context, filter QueryFilter) ([]QueryRow, error) {
+	query, args := sqlWhere(`SELECT order_intent_id, run_id, cycle_id, decision_id, symbol, action, client_order_id, state,
+COALESCE(exchange_order_id, ''), COALESCE(last_error_code, ''), created_at_ms, updated_at_ms FROM order_intents`, filter, "", "created_at_ms", true)
+	result, err := q.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query intents: %w", err)
+	}
+	defer result.Close()
+	var rows []QueryRow
+	for result.Next() {
+		row := QueryRow{Table: QueryTableIntents, Source: QuerySourceSQLite, Stage: "EXECUTE_INTENT", Reasons: []string{}}
+		var clientOrderID, state, exchangeOrderID, lastErrorCode string
+		var updatedAtMs int64
+		if err := result.Scan(&row.OrderIntentID, &row.RunID, &row.CycleID, &row.DecisionID, &row.Symbol, &row.EventType, &clientOrderID, &state,
+			&exchangeOrderID, &lastErrorCode, &row.TsMs, &updatedAtMs); err != nil {
+			return nil, fmt.Errorf("query intents scan: %w", err)
+		}
+		if lastErrorCode != "" {
+			row.Reasons = []string{lastErrorCode}
+		}
+		row.Detail = map[string]any{
+			"state":             state,
+			"client_order_id":   clientOrderID,
+			"exchange_order_id": exchangeOrderID,
+			"updated_at_ms":     updatedAtMs,
+		}
+		if filter.Match(row) {
+			rows = append(rows, row)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("query intents rows: %w", err)
+	}
+	return finishRows(rows, filter.Limit), nil
+}
+
+func (q Querier) Gate(ctx context.Context, filter QueryFilter) ([]QueryRow, error) {
+	query, args := sqlWhere(`SELECT g.run_id, g.cycle_id, g.stage, g.event_type, g.snapshot_id, g.decision_id, g.verdict, g.reasons_json,
+COALESCE(g.model, ''), COALESCE(g.latency_ms, 0), COALESCE(g.error_code, ''), g.modify_applied, g.created_at_ms,
+COALESCE((SELECT s.symbol FROM snapshots s WHERE s.snapshot_id = g.snapshot_id), '')
+FROM ai_gate_events g`, filter, "g.", "created_at_ms", false)
+	result, err := q.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query gate: %w", err)
+	}
+	defer result.Close()
+	var rows []QueryRow
+	for result.Next() {
+		row := QueryRow{Table: QueryTableGate, Source: QuerySourceSQLite}
+		var verdict, reasonsJSON, model, errorCode string
+		var latencyMs int64
+		var modifyApplied int
+		if err := result.Scan(&row.RunID, &row.CycleID, &row.Stage, &row.EventType, &row.SnapshotID, &row.DecisionID, &verdict, &reasonsJSON,
+			&model, &latencyMs, &errorCode, &modifyApplied, &row.TsMs, &row.Symbol); err != nil {
+			return nil, fmt.Errorf("query gate scan: %w", err)
+		}
+		row.Reasons = decodeReasons(reasonsJSON)
+		row.Detail = map[string]any{
+			"verdict":        verdict,
+			"model":          model,
+			"latency_ms":     latencyMs,
+			"error_code":     errorCode,
+			"modify_applied": modifyApplied == 1,
+		}
+		if filter.Match(row) {
+			rows = append(rows, row)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("query gate rows: %w", err)
+	}
+	return finishRows(rows, filter.Limit), nil
+}
+
+func (q Querier) Trace(ctx context.Context, id string, filter QueryFilter, sources []string) ([]QueryRow, error) {
+	limit := filter.Limit
+	filter.Limit = 0
+	events, err := q.Events(ctx, filter, sources)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := q.Intents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	gates, err := q.Gate(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	all := append(append(events, intents...), gates...)
+	decisions := map[string]bool{}
+	for _, row := range all {
+		if rowMentions(row, id) && row.DecisionID != "" {
+			decisions[row.DecisionID] = true
+		}
+	}
+	var rows []QueryRow
+	for _, row := range all {
+		if rowMentions(row, id) || decisions[row.DecisionID] {
+			rows = append(rows, row)
+		}
+	}
+	return finishRows(rows, limit), nil
+}
+
+func rowMentions(row QueryRow, id string) bool {
+	if id == row.RunID || id == row.CycleID || id == row.DecisionID || id == row.OrderIntentID || id == row.SnapshotID {
+		return true
+	}
+	clientOrderID, _ := row.Detail["client_order_id"].(string)
+	return clientOrderID != "" && clientOrderID == id
+}
+
+func (q Querier) sqliteEvents(ctx context.Context, filter QueryFilter) ([]QueryRow, error) {
+	query, args := sqlWhere(`SELECT ts_ms, run_id, cycle_id, stage, event_type, reasons_json, snapshot_id, decision_id, order_intent_id,
+data_json, record_hash FROM audit_events`, filter, "", "ts_ms", true)
+	result, err := q.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer result.Close()
+	var rows []QueryRow
+	for result.Next() {
+		row := QueryRow{Table: QueryTableEvents, Source: QuerySourceSQLite}
+		var reasonsJSON, dataJSON string
+		if err := result.Scan(&row.TsMs, &row.RunID, &row.CycleID, &row.Stage, &row.EventType, &reasonsJSON, &row.SnapshotID, &row.DecisionID,
+			&row.OrderIntentID, &dataJSON, &row.recordHash); err != nil {
+			return nil, fmt.Errorf("query events scan: %w", err)
+		}
+		row.Reasons = decodeReasons(reasonsJSON)
+		row.Detail, _ = decodeJSONObject([]byte(dataJSON))
+		row.Symbol, _ = row.Detail["symbol"].(string)
+		if filter.Match(row) {
+			rows = append(rows, row)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("query events rows: %w", err)
+	}
+	return rows, nil
+}
+
+func (q Querier) jsonlEvents(filter QueryFilter) ([]QueryRow, error) {
+	days, err := ListJSONLDays(q.JSONLDir)
+	if err != nil {
+		if _, statErr := os.Stat(q.JSONLDir); os.IsNotExist(statErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rows []QueryRow
+	for _, day := range days {
+		if !dayInRange(day, filter) {
+			continue
+		}
+		buf, err := os.ReadFile(JSONLPath(q.JSONLDir, day))
+		if err != nil {
+			return nil, fmt.Errorf("query jsonl read: %w", err)
+		}
+		for _, raw := range bytes.Split(buf, []byte{'\n'}) {
+			if len(bytes.TrimSpace(raw)) == 0 {
+				continue
+			}
+			line, err := decodeJSONObject(raw)
+			if err != nil {
+				continue
+			}
+			row := jsonlRow(line)
+			if filter.Match(row) {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func jsonlRow(line map[string]any) QueryRow {
+	row := QueryRow{Table: QueryTableEvents, Source: QuerySourceJSONL, Reasons: []string{}, Detail: map[string]any{}}
+	if ts, ok := line["ts_ms"].(json.Number); ok {
+		row.TsMs, _ = ts.Int64()
+	}
+	str := func(key string) string {
+		value, _ := line[key].(string)
+		return value
+	}
+	row.RunID = str("run_id")
+	row.CycleID = str("cycle_id")
+	row.Stage = str("stage")
+	row.EventType = str("event_type")
+	row.SnapshotID = str("snapshot_id")
+	row.DecisionID = str("decision_id")
+	row.OrderIntentID = str("order_intent_id")
+	row.Symbol = str("symbol")
+	row.recordHash = str("record_hash")
+	if reasons, ok := line["reasons"].([]any); ok {
+		for _, reason := range reasons {
+			if value, ok := reason.(string); ok {
+				row.Reasons = append(row.Reasons, value)
+			}
+		}
+	}
+	for key, value := range line {
+		if _, reserved := reservedKeys[key]; !reserved {
+			row.Detail[key] = value
+		}
+	}
+	return row
+}
+
+func mergeEvents(rows []QueryRow, more []QueryRow) []QueryRow {
+	index := make(map[string]int, len(rows))
+	for i, row := range rows {
+		index[eventKey(row)] = i
+	}
+	for _, row := range more {
+		if i, ok := index[eventKey(row)]; ok {
+			if rows[i].Source != row.Source {
+				rows[i].Source = QuerySourceBoth
+			}
+			continue
+		}
+		index[eventKey(row)] = len(rows)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func eventKey(row QueryRow) string {
+	if row.recordHash != "" {
+		return row.recordHash
+	}
+	return strings.Join([]string{strconv.FormatInt(row.TsMs, 10), row.RunID, row.CycleID, row.Stage, row.EventType, strings.Join(row.Reasons, ",")}, "|")
+}
+
+func finishRows(rows []QueryRow, limit int) []QueryRow {
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].TsMs < rows[j].TsMs })
+	if limit > 0 && len(rows) > limit {
+		rows = rows[len(rows)-limit:]
+	}
+	if rows == nil {
+		rows = []QueryRow{}
+	}
+	return rows
+}
+
+func sqlWhere(base string, filter QueryFilter, prefix string, tsColumn string, hasIntentID bool) (string, []any) {
+	var clauses []string
+	var args []any
+	add := func(column string, value any) {
+		clauses = append(clauses, column)
+		args = append(args, value)
+	}
+	if filter.RunID != "" {
+		add(prefix+"run_id = ?", filter.RunID)
+	}
+	if filter.CycleID != "" {
+		add(prefix+"cycle_id = ?", filter.CycleID)
+	}
+	if filter.DecisionID != "" {
+		add(prefix+"decision_id = ?", filter.DecisionID)
+	}
+	if filter.OrderIntentID != "" && hasIntentID {
+		add(prefix+"order_intent_id = ?", filter.OrderIntentID)
+	}
+	if filter.FromMs > 0 {
+		add(prefix+tsColumn+" >= ?", filter.FromMs)
+	}
+	if filter.ToMs > 0 {
+		add(prefix+tsColumn+" < ?", filter.ToMs)
+	}
+	if len(clauses) == 0 {
+		return base, nil
+	}
+	return base + " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func decodeReasons(reasonsJSON string) []string {
+	var reasons []string
+	if err := json.Unmarshal([]byte(reasonsJSON), &reasons); err != nil || reasons == nil {
+		return []string{}
+	}
+	return reasons
+}
+
+func dayInRange(day string, filter QueryFilter) bool {
+	start, err := time.Parse(jsonlDayLayout, day)
+	if err != nil {
+		return false
+	}
+	end := start.AddDate(0, 0, 1).UnixMilli()
+	if filter.FromMs > 0 && end <= filter.FromMs {
+		return false
+	}
+	if filter.ToMs > 0 && start.UnixMilli() >= filter.ToMs {
+		return false
+	}
+	return true
+}
+
+func ParseQueryTime(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ms, nil
+	}
+	if day, err := time.Parse(jsonlDayLayout, value); err == nil {
+		return day.UnixMilli(), nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("time must be unix ms, YYYY-MM-DD or RFC3339: %s", value)
+	}
+	return ts.UnixMilli(), nil
+}
+
+var queryColumns = []string{"time", "table", "source", "run_id", "cycle_id", "stage", "event_type", "symbol", "decision_id", "order_intent_id", "reasons", "detail"}
+
+func (r QueryRow) columns() []string {
+	detail := ""
+	if len(r.Detail) > 0 {
+		buf, err := json.Marshal(r.Detail)
+		if err == nil {
+			detail = string(buf)
+		}
+	}
+	return []string{
+		time.UnixMilli(r.TsMs).UTC().Format("2006-01-02T15:04:05.000Z"),
+		r.Table,
+		r.Source,
+		r.RunID,
+		r.CycleID,
+		r.Stage,
+		r.EventType,
+		r.Symbol,
+		r.DecisionID,
+		r.OrderIntentID,
+		strings.Join(r.Reasons, ","),
+		detail,
+	}
+}
+
+func WriteQueryRows(out io.Writer, format string, rows []QueryRow) error {
+	switch format {
+	case QueryFormatTable:
+		tw := tabwriter.NewWriter(out, 0, 2, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(queryColumns, "\t")))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row.columns(), "\t"))
+		}
+		return tw.Flush()
+	case QueryFormatJSONL:
+		encoder := json.NewEncoder(out)
+		for _, row := range rows {
+			if err := encoder.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	case QueryFormatCSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write(queryColumns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writer.Write(row.columns()); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("query format unsupported: %s", format)
+	}
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+func TestQueryMergesStoresAndTraces(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := testConfig()
+	cfg.AuditManifestKeyPath = filepath.Join(tmp, "secrets", "manifest.key")
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	jsonlDir := filepath.Join(tmp, "logs")
+	day1 := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	writer, err := NewWriter(cfg, WriterOptions{DBPath: dbPath, JSONLDir: jsonlDir, Now: func() time.Time { return day2 }})
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	write := func(at time.Time, stage observability.StageName, decisionID string, reasons []reasoncodes.ReasonCode, symbol string) {
+		t.Helper()
+		err := writer.Write(Record{
+			Event: auditdomain.AuditEvent{
+				TsMs:            at.UnixMilli(),
+				RunID:           "run_q",
+				CycleID:         "cyc_q",
+				Mode:            "LIVE",
+				Stage:           stage,
+				EventType:       auditdomain.STAGE_CHANGED,
+				Reasons:         reasons,
+				DecisionID:      decisionID,
+				LocalReceivedMs: at.UnixMilli(),
+			},
+			Data: map[string]any{"symbol": symbol, "summary": string(stage)},
+		})
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	write(day1, observability.BOOT, "", []reasoncodes.ReasonCode{}, "")
+	write(day1.Add(time.Minute), observability.RISK_VERDICT, "dec_1", []reasoncodes.ReasonCode{reasoncodes.RISK_EXPOSURE_LIMIT}, "BTCUSDT")
+	write(day2, observability.STRATEGY_PROPOSE, "dec_2", []reasoncodes.ReasonCode{}, "ETHUSDT")
+	write(day2.Add(time.Minute), observability.EXECUTE_INTENT, "dec_2", []reasoncodes.ReasonCode{}, "ETHUSDT")
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`DELETE FROM audit_events WHERE ts_ms < ?`, day2.UnixMilli()); err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if err := os.Remove(JSONLPath(jsonlDir, "2026-02-02")); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	err = sqlite.InsertOrderIntent(context.Background(), db, sqlite.OrderIntentRecord{
+		OrderIntentID: "oi_2", RunID: "run_q", CycleID: "cyc_q", Mode: "LIVE", DecisionID: "dec_2", Symbol: "ETHUSDT",
+		Action: "NEW_ORDER", ClientOrderID: "LS_oi_2", IntentPayloadJSON: "{}", State: "CONFIRMED",
+		CreatedAtMs: day2.Add(2 * time.Minute).UnixMilli(), UpdatedAtMs: day2.Add(2 * time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("intent: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES ('snap_2', 'ETHUSDT', 'hash', 0, 0, '{}', 0)`); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO ai_gate_events (run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash, enabled, verdict,
+reasons_json, model, latency_ms, modify_applied, local_received_ms, created_at_ms)
+VALUES ('run_q', 'cyc_q', 'LIVE', 'AIGATE_CALL', 'AIGATE_CALL', 'snap_2', 'hash', 'dec_2', 'input', 1, 'ALLOW', '[]', 'stub', 40, 0, ?, ?)`,
+		day2.Add(30*time.Second).UnixMilli(), day2.Add(30*time.Second).UnixMilli()); err != nil {
+		t.Fatalf("gate: %v", err)
+	}
+
+	querier := Querier{DB: db, JSONLDir: jsonlDir}
+	ctx := context.Background()
+	all := []string{QuerySourceSQLite, QuerySourceJSONL}
+	events, err := querier.Events(ctx, QueryFilter{}, all)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if len(events) != 4 || events[0].Source != QuerySourceJSONL || events[2].Source != QuerySourceSQLite {
+		t.Fatalf("expected both stores to be merged: %+v", events)
+	}
+	blocked, err := querier.Events(ctx, QueryFilter{Reason: "RISK_EXPOSURE_LIMIT", Symbol: "btcusdt"}, all)
+	if err != nil || len(blocked) != 1 || blocked[0].DecisionID != "dec_1" {
+		t.Fatalf("unexpected reason filter result: %+v (%v)", blocked, err)
+	}
+	ranged, err := querier.Events(ctx, QueryFilter{FromMs: day2.UnixMilli(), Limit: 1}, all)
+	if err != nil || len(ranged) != 1 || ranged[0].Stage != string(observability.EXECUTE_INTENT) {
+		t.Fatalf("unexpected range result: %+v (%v)", ranged, err)
+	}
+	trace, err := querier.Trace(ctx, "LS_oi_2", QueryFilter{}, all)
+	if err != nil {
+		t.Fatalf("trace: %v", err)
+	}
+	var tables []string
+	for _, row := range trace {
+		tables = append(tables, row.Table)
+	}
+	if strings.Join(tables, ",") != "audit_events,ai_gate_events,audit_events,order_intents" || trace[1].Symbol != "ETHUSDT" {
+		t.Fatalf("unexpected trace: %v %+v", tables, trace)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteQueryRows(&buf, QueryFormatCSV, trace); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 5 || records[0][0] != "time" || records[4][1] != QueryTableIntents {
+		t.Fatalf("unexpected csv: %v %v", records, err)
+	}
+	buf.Reset()
+	if err := WriteQueryRows(&buf, QueryFormatJSONL, trace[:1]); err != nil {
+		t.Fatalf("jsonl: %v", err)
+	}
+	var decoded QueryRow
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.DecisionID != "dec_2" {
+		t.Fatalf("unexpected jsonl: %s %v", buf.String(), err)
+	}
+	buf.Reset()
+	if err := WriteQueryRows(&buf, QueryFormatTable, trace); err != nil || !strings.HasPrefix(buf.String(), "TIME") {
+		t.Fatalf("unexpected table: %s %v", buf.String(), err)
+	}
+}
+
+func TestParseQueryTime(t *testing.T) {
+	day := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	for value, want := range map[string]int64{"": 0, "2026-02-01": day, "2026-02-01T00:00:00Z": day, "1769904000000": 1769904000000} {
+		got, err := ParseQueryTime(value)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %d (%v), want %d", value, got, err, want)
+		}
+	}
+	if _, err := ParseQueryTime("yesterday"); err == nil {
+		t.Fatalf("expected invalid time to be rejected")
+	}
+}
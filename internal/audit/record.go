@@ -26,6 +26,8 @@ var reservedKeys = map[string]struct{}{
 	"order_intent_id":   {},
 	"exchange_time_ms":  {},
 	"local_received_ms": {},
+	"prev_hash":         {},
+	"record_hash":       {},
 }
 
 func (r Record) Validate() error {
@@ -41,6 +43,10 @@ func (r Record) Validate() error {
 }
 
 func (r Record) JSONLine() ([]byte, error) {
+	return json.Marshal(r.lineMap())
+}
+
+func (r Record) lineMap() map[string]any {
 	line := map[string]any{
 		"ts_ms":             r.Event.TsMs,
 		"run_id":            r.Event.RunID,
@@ -58,7 +64,7 @@ func (r Record) JSONLine() ([]byte, error) {
 	for key, value := range r.Data {
 		line[key] = value
 	}
-	return json.Marshal(line)
+	return line
 }
 
 func (r Record) DataJSON() (string, error) {
@@ -4,10 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/domain/hash"
 )
 
+const secretValueMinLen = 8
+
+var secretValues struct {
+	mu     sync.RWMutex
+	values []string
+}
+
 type RedactionPolicy struct {
 	RemoveKeys            []string
 	VolatileKeys          []string
@@ -43,14 +51,34 @@ func DefaultRedactionPolicy() RedactionPolicy {
 			"api_key",
 			"password",
 		},
-		RejectValueSubstrings: []string{
+		RejectValueSubstrings: append([]string{
 			"BINANCE_API_KEY",
 			"BINANCE_API_SECRET",
 			"OPENAI_API_KEY",
-		},
+		}, registeredSecretValues()...),
 	}
 }
 
+func RegisterSecretValue(value string) {
+	if len(value) < secretValueMinLen {
+		return
+	}
+	secretValues.mu.Lock()
+	defer secretValues.mu.Unlock()
+	for _, existing := range secretValues.values {
+		if existing == value {
+			return
+		}
+	}
+	secretValues.values = append(secretValues.values, value)
+}
+
+func registeredSecretValues() []string {
+	secretValues.mu.RLock()
+	defer secretValues.mu.RUnlock()
+	return append([]string(nil), secretValues.values...)
+}
+
 func RedactAndTruncateJSON(raw []byte, maxBytes int, policy RedactionPolicy) (string, error) {
 	if maxBytes <= 0 {
 		return "", fmt.Errorf("audit_redacted_json_max_bytes must be > 0")
@@ -0,0 +1,370 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/hash"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+const (
+	FindingError  = "ERROR"
+	FindingNotice = "NOTICE"
+)
+
+type Finding struct {
+	Severity string `json:"severity"`
+	Store    string `json:"store"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+type VerifyReport struct {
+	SQLiteRecords     int       `json:"sqlite_records"`
+	SQLiteUnchained   int       `json:"sqlite_unchained"`
+	JSONLRecords      int       `json:"jsonl_records"`
+	JSONLUnchained    int       `json:"jsonl_unchained"`
+	JSONLDays         int       `json:"jsonl_days"`
+	ManifestsVerified int       `json:"manifests_verified"`
+	Findings          []Finding `json:"findings"`
+}
+
+func (r VerifyReport) OK() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == FindingError {
+			return false
+		}
+	}
+	return true
+}
+
+type VerifyOptions struct {
+	DB       *sql.DB
+	JSONLDir string
+	Key      *ManifestKey
+}
+
+type chainRef struct {
+	day      string
+	location string
+}
+
+type chainChecker struct {
+	store  string
+	last   map[string]string
+	anchor func(prevHash string) bool
+	report *VerifyReport
+}
+
+func Verify(ctx context.Context, opts VerifyOptions) (VerifyReport, error) {
+	report := VerifyReport{Findings: []Finding{}}
+	anchors, archivedBefore, err := loadRetention(ctx, opts.DB)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	if archivedBefore != "" {
+		report.Findings = append(report.Findings, Finding{Severity: FindingNotice, Store: "sqlite", Location: "retention", Message: "records before " + archivedBefore + " archived"})
+	}
+	sqliteHashes, err := verifySQLite(ctx, opts.DB, anchors, &report)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	jsonlHashes, days, err := verifyJSONL(opts.JSONLDir, opts.Key, sqliteHashes, anchors, &report)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	covered := make(map[string]bool, len(days))
+	for _, day := range days {
+		covered[day] = true
+	}
+	var missing []Finding
+	for recordHash, ref := range sqliteHashes {
+		if _, ok := jsonlHashes[recordHash]; !ok && covered[ref.day] {
+			missing = append(missing, Finding{Severity: FindingError, Store: "cross", Location: ref.location, Message: "record missing from jsonl"})
+		}
+	}
+	for recordHash, ref := range jsonlHashes {
+		if _, ok := sqliteHashes[recordHash]; !ok && ref.day >= archivedBefore {
+			missing = append(missing, Finding{Severity: FindingError, Store: "cross", Location: ref.location, Message: "record missing from sqlite"})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Location < missing[j].Location })
+	report.Findings = append(report.Findings, missing...)
+	return report, nil
+}
+
+func loadRetention(ctx context.Context, db *sql.DB) (map[string]bool, string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT record_hash FROM audit_chain_anchors`)
+	if err != nil {
+		return nil, "", fmt.Errorf("verify chain anchors: %w", err)
+	}
+	defer rows.Close()
+	anchors := make(map[string]bool)
+	for rows.Next() {
+		var recordHash string
+		if err := rows.Scan(&recordHash); err != nil {
+			return nil, "", fmt.Errorf("verify chain anchors scan: %w", err)
+		}
+		anchors[recordHash] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("verify chain anchors rows: %w", err)
+	}
+	var cutoffMs sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(cutoff_ms) FROM retention_archives WHERE table_name = 'audit_events'`).Scan(&cutoffMs); err != nil {
+		return nil, "", fmt.Errorf("verify retention cutoff: %w", err)
+	}
+	if !cutoffMs.Valid {
+		return anchors, "", nil
+	}
+	return anchors, time.UnixMilli(cutoffMs.Int64).UTC().Format(jsonlDayLayout), nil
+}
+
+func verifySQLite(ctx context.Context, db *sql.DB, anchors map[string]bool, report *VerifyReport) (map[string]chainRef, error) {
+	rows, err := db.QueryContext(ctx, `SELECT event_id, ts_ms, run_id, cycle_id, mode, stage, event_type, reasons_json, snapshot_id,
+decision_id, order_intent_id, exchange_time_ms, local_received_ms, data_json, prev_hash, record_hash
+FROM audit_events ORDER BY event_id`)
+	if err != nil {
+		return nil, fmt.Errorf("verify sqlite query: %w", err)
+	}
+	defer rows.Close()
+	anchor := func(prevHash string) bool {
+		return anchors[prevHash]
+	}
+	checker := &chainChecker{store: "sqlite", last: map[string]string{}, anchor: anchor, report: report}
+	hashes := make(map[string]chainRef)
+	for rows.Next() {
+		var (
+			eventID               int64
+			event                 auditdomain.AuditEvent
+			stage, eventType      string
+			reasonsJSON, dataJSON string
+			prevHash, recordHash  string
+		)
+		if err := rows.Scan(&eventID, &event.TsMs, &event.RunID, &event.CycleID, &event.Mode, &stage, &eventType, &reasonsJSON,
+			&event.SnapshotID, &event.DecisionID, &event.OrderIntentID, &event.ExchangeTimeMs, &event.LocalReceivedMs, &dataJSON,
+			&prevHash, &recordHash); err != nil {
+			return nil, fmt.Errorf("verify sqlite scan: %w", err)
+		}
+		report.SQLiteRecords++
+		location := fmt.Sprintf("event_id=%d", eventID)
+		if recordHash == "" {
+			report.SQLiteUnchained++
+			checker.unchained(location, event.RunID)
+			continue
+		}
+		event.Stage = observability.StageName(stage)
+		event.EventType = auditdomain.AuditEventType(eventType)
+		var reasons []string
+		if err := json.Unmarshal([]byte(reasonsJSON), &reasons); err != nil {
+			checker.add(FindingError, location, "reasons_json invalid")
+			continue
+		}
+		for _, reason := range reasons {
+			event.Reasons = append(event.Reasons, reasoncodes.ReasonCode(reason))
+		}
+		data, err := decodeJSONObject([]byte(dataJSON))
+		if err != nil {
+			checker.add(FindingError, location, "data_json invalid")
+			continue
+		}
+		line := Record{Event: event, Data: data}.lineMap()
+		checker.check(location, event.RunID, prevHash, recordHash, line)
+		hashes[recordHash] = chainRef{day: time.UnixMilli(event.TsMs).UTC().Format(jsonlDayLayout), location: location}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("verify sqlite rows: %w", err)
+	}
+	return hashes, nil
+}
+
+func verifyJSONL(dir string, key *ManifestKey, sqliteHashes map[string]chainRef, anchors map[string]bool, report *VerifyReport) (map[string]chainRef, []string, error) {
+	days, err := ListJSONLDays(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.JSONLDays = len(days)
+	anchor := func(prevHash string) bool {
+		if anchors[prevHash] {
+			return true
+		}
+		ref, ok := sqliteHashes[prevHash]
+		return ok && len(days) > 0 && ref.day < days[0]
+	}
+	checker := &chainChecker{store: "jsonl", last: map[string]string{}, anchor: anchor, report: report}
+	hashes := make(map[string]chainRef)
+	for idx, day := range days {
+		buf, err := os.ReadFile(JSONLPath(dir, day))
+		if err != nil {
+			return nil, nil, fmt.Errorf("verify jsonl read: %w", err)
+		}
+		manifest, manifestErr := ReadManifest(ManifestPath(dir, day))
+		sealed := manifestErr == nil
+		seen := Manifest{Runs: map[string]ManifestRun{}}
+		var offset int64
+		lineNo := 0
+		for len(buf[offset:]) > 0 {
+			rest := buf[offset:]
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				checker.add(FindingError, fmt.Sprintf("%s:%d", day, lineNo+1), "line not terminated")
+				break
+			}
+			raw := rest[:end]
+			offset += int64(end) + 1
+			lineNo++
+			location := fmt.Sprintf("%s:%d", day, lineNo)
+			runID, recordHash := checker.checkLine(location, day, raw)
+			if sealed && offset <= manifest.Bytes {
+				seen.Lines++
+				seen.Runs[runID] = addManifestRun(seen.Runs[runID], recordHash)
+			}
+			if recordHash == "" {
+				continue
+			}
+			report.JSONLRecords++
+			if prior, ok := hashes[recordHash]; ok {
+				checker.add(FindingError, location, "duplicate record of "+prior.location)
+				continue
+			}
+			hashes[recordHash] = chainRef{day: day, location: location}
+		}
+		if !sealed {
+			if !errors.Is(manifestErr, os.ErrNotExist) {
+				checker.addStore("manifest", FindingError, day, manifestErr.Error())
+			} else if idx < len(days)-1 {
+				checker.addStore("manifest", FindingError, day, "manifest missing")
+			} else {
+				checker.addStore("manifest", FindingNotice, day, "current day not sealed yet")
+			}
+			continue
+		}
+		verifyManifest(checker, day, manifest, seen, buf, key)
+	}
+	return hashes, days, nil
+}
+
+func verifyManifest(checker *chainChecker, day string, manifest Manifest, seen Manifest, buf []byte, key *ManifestKey) {
+	if key == nil {
+		checker.addStore("manifest", FindingNotice, day, "signature not checked")
+	} else if err := key.Verify(manifest); err != nil {
+		checker.addStore("manifest", FindingError, day, err.Error())
+		return
+	}
+	if manifest.Day != day || manifest.File != fmt.Sprintf("audit-%s.jsonl", day) {
+		checker.addStore("manifest", FindingError, day, "manifest names another file")
+		return
+	}
+	size := int64(len(buf))
+	if manifest.Bytes > size {
+		checker.addStore("manifest", FindingError, day, fmt.Sprintf("file truncated: %d of %d sealed bytes", size, manifest.Bytes))
+		return
+	}
+	if hash.HashSHA256Hex(buf[:manifest.Bytes]) != manifest.SHA256 {
+		checker.addStore("manifest", FindingError, day, "sealed content differs from manifest")
+		return
+	}
+	if seen.Lines != manifest.Lines || !sameRuns(seen.Runs, manifest.Runs) {
+		checker.addStore("manifest", FindingError, day, "sealed lines or run heads differ from manifest")
+		return
+	}
+	checker.report.ManifestsVerified++
+	if size > manifest.Bytes {
+		checker.addStore("manifest", FindingNotice, day, fmt.Sprintf("unsealed tail of %d bytes", size-manifest.Bytes))
+	}
+}
+
+func sameRuns(a map[string]ManifestRun, b map[string]ManifestRun) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for runID, run := range a {
+		if b[runID] != run {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *chainChecker) checkLine(location string, day string, raw []byte) (string, string) {
+	line, err := decodeJSONObject(raw)
+	if err != nil {
+		c.add(FindingError, location, "line is not a json object")
+		return "", ""
+	}
+	runID, _ := line["run_id"].(string)
+	recordHash, _ := line["record_hash"].(string)
+	prevHash, _ := line["prev_hash"].(string)
+	if recordHash == "" {
+		c.report.JSONLUnchained++
+		c.unchained(location, runID)
+		return runID, ""
+	}
+	if ts, ok := line["ts_ms"].(json.Number); ok {
+		if tsMs, err := ts.Int64(); err != nil || time.UnixMilli(tsMs).UTC().Format(jsonlDayLayout) != day {
+			c.add(FindingError, location, "ts_ms outside file day")
+		}
+	}
+	c.check(location, runID, prevHash, recordHash, line)
+	return runID, recordHash
+}
+
+func (c *chainChecker) check(location string, runID string, prevHash string, recordHash string, line map[string]any) {
+	computed, err := ChainHash(line, prevHash)
+	if err != nil || computed != recordHash {
+		c.add(FindingError, location, "record_hash mismatch (record edited)")
+	}
+	last, seen := c.last[runID]
+	switch {
+	case seen && prevHash != last:
+		c.add(FindingError, location, "chain break in run "+runID+" (gap or reorder)")
+	case !seen && prevHash != GenesisHash && (c.anchor == nil || !c.anchor(prevHash)):
+		c.add(FindingError, location, "chain start missing for run "+runID)
+	}
+	c.last[runID] = recordHash
+}
+
+func (c *chainChecker) unchained(location string, runID string) {
+	if _, chained := c.last[runID]; chained {
+		c.add(FindingError, location, "unchained record inside chained run "+runID)
+	}
+}
+
+func (c *chainChecker) add(severity string, location string, message string) {
+	c.addStore(c.store, severity, location, message)
+}
+
+func (c *chainChecker) addStore(store string, severity string, location string, message string) {
+	c.report.Findings = append(c.report.Findings, Finding{Severity: severity, Store: store, Location: location, Message: message})
+}
+
+func decodeJSONObject(raw []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var out map[string]any
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, errors.New("json object expected")
+	}
+	if decoder.More() {
+		return nil, errors.New("trailing json content")
+	}
+	return out, nil
+}
+
+func (f Finding) String() string {
+	return strings.Join([]string{f.Severity, f.Store, f.Location, f.Message}, " ")
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+func TestVerifyHashChainAndManifests(t *testing.T) {
+	for _, alg := range []string{ManifestAlgHMACSHA256, ManifestAlgEd25519} {
+		t.Run(alg, func(t *testing.T) {
+			env := newChainEnv(t, alg)
+			report := env.verify(t)
+			if !report.OK() || report.SQLiteRecords != 4 || report.JSONLRecords != 4 || report.JSONLDays != 2 || report.ManifestsVerified != 1 {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+			if len(report.Findings) != 1 || report.Findings[0].Message != "current day not sealed yet" {
+				t.Fatalf("unexpected findings: %+v", report.Findings)
+			}
+		})
+	}
+}
+
+func TestVerifyDetectsSQLiteEdit(t *testing.T) {
+	env := newChainEnv(t, ManifestAlgHMACSHA256)
+	if _, err := env.db.Exec(`UPDATE audit_events SET data_json = '{"n":99}' WHERE event_id = 2`); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	expectFinding(t, env.verify(t), "sqlite", "event_id=2", "record_hash mismatch")
+}
+
+func TestVerifyDetectsSQLiteDeletion(t *testing.T) {
+	env := newChainEnv(t, ManifestAlgHMACSHA256)
+	if _, err := env.db.Exec(`DELETE FROM audit_events WHERE event_id = 2`); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	report := env.verify(t)
+	expectFinding(t, report, "sqlite", "event_id=4", "chain break")
+	expectFinding(t, report, "cross", "2026-02-01:2", "record missing from sqlite")
+}
+
+func TestVerifyDetectsJSONLReorder(t *testing.T) {
+	env := newChainEnv(t, ManifestAlgHMACSHA256)
+	path := JSONLPath(env.jsonlDir, "2026-02-01")
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := strings.SplitAfter(string(buf), "\n")
+	lines[0], lines[1] = lines[1], lines[0]
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o640); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	report := env.verify(t)
+	expectFinding(t, report, "jsonl", "2026-02-01:1", "chain start missing")
+	expectFinding(t, report, "manifest", "2026-02-01", "sealed content differs")
+}
+
+func TestVerifyDetectsManifestForgery(t *testing.T) {
+	env := newChainEnv(t, ManifestAlgEd25519)
+	path := ManifestPath(env.jsonlDir, "2026-02-01")
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	forged := strings.Replace(string(buf), `"lines": 3`, `"lines": 2`, 1)
+	if err := os.WriteFile(path, []byte(forged), 0o640); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	expectFinding(t, env.verify(t), "manifest", "2026-02-01", "manifest signature invalid")
+}
+
+type chainEnv struct {
+	db       *sql.DB
+	jsonlDir string
+	key      *ManifestKey
+}
+
+func newChainEnv(t *testing.T, alg string) chainEnv {
+	t.Helper()
+	tmp := t.TempDir()
+	cfg := testConfig()
+	cfg.AuditManifestAlg = alg
+	cfg.AuditManifestKeyPath = filepath.Join(tmp, "secrets", "manifest.key")
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	jsonlDir := filepath.Join(tmp, "logs")
+	day := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		runID string
+		at    time.Time
+	}{
+		{"run_a", day},
+		{"run_a", day.Add(time.Minute)},
+		{"run_b", day.Add(2 * time.Minute)},
+		{"run_a", day.Add(24 * time.Hour)},
+	}
+	for i, step := range steps {
+		writer, err := NewWriter(cfg, WriterOptions{DBPath: dbPath, JSONLDir: jsonlDir, Now: func() time.Time { return step.at }})
+		if err != nil {
+			t.Fatalf("writer: %v", err)
+		}
+		record := Record{
+			Event: auditdomain.AuditEvent{
+				TsMs:            step.at.UnixMilli(),
+				RunID:           step.runID,
+				CycleID:         "cyc_1",
+				Mode:            "LIVE",
+				Stage:           observability.BOOT,
+				EventType:       auditdomain.STAGE_CHANGED,
+				Reasons:         []reasoncodes.ReasonCode{},
+				LocalReceivedMs: step.at.UnixMilli(),
+			},
+			Data: map[string]any{"n": i, "note": "<step>"},
+		}
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	}
+	keyPath := cfg.AuditManifestKeyPath
+	if alg == ManifestAlgEd25519 {
+		keyPath = ManifestPublicKeyPath(keyPath)
+		if err := os.Remove(cfg.AuditManifestKeyPath); err != nil {
+			t.Fatalf("remove seed: %v", err)
+		}
+	}
+	key, err := LoadManifestVerifyKey(keyPath, alg)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return chainEnv{db: db, jsonlDir: jsonlDir, key: key}
+}
+
+func (e chainEnv) verify(t *testing.T) VerifyReport {
+	t.Helper()
+	report, err := Verify(context.Background(), VerifyOptions{DB: e.db, JSONLDir: e.jsonlDir, Key: e.key})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	return report
+}
+
+func expectFinding(t *testing.T, report VerifyReport, store string, location string, message string) {
+	t.Helper()
+	if report.OK() {
+		t.Fatalf("expected verification failure, got %+v", report)
+	}
+	for _, finding := range report.Findings {
+		if finding.Store == store && finding.Location == location && strings.Contains(finding.Message, message) {
+			return
+		}
+	}
+	t.Fatalf("missing %s finding at %s (%s): %+v", store, location, message, report.Findings)
+}
@@ -6,24 +6,65 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
 )
 
+var (
+	ErrQueueFull    = errors.New("audit writer queue full")
+	ErrWriterClosed = errors.New("audit writer closed")
+)
+
 type Writer struct {
-	queue         chan writeRequest
-	queueCapacity int
-	jsonl         *jsonlWriter
-	db            *sql.DB
-	closed        chan struct{}
-	wg            sync.WaitGroup
+	queue          chan writeRequest
+	queueCapacity  int
+	batchMaxEvents int
+	batchMaxDelay  time.Duration
+	enqueueWait    time.Duration
+	jsonl          *jsonlWriter
+	db             *sql.DB
+	chain          map[string]string
+	now            func() time.Time
+	closed         chan struct{}
+	sendMu         sync.RWMutex
+	wg             sync.WaitGroup
+
+	mu             sync.Mutex
+	err            error
+	drops          atomic.Int64
+	stalls         atomic.Int64
+	batches        atomic.Int64
+	lagMs          atomic.Int64
+	pendingSinceMs atomic.Int64
+	lastCommitMs   atomic.Int64
+}
+
+type WriterStats struct {
+	QueueLen     int
+	QueueCap     int
+	QueuePct     int
+	LagMs        int64
+	DropsTotal   int64
+	StallsTotal  int64
+	Batches      int64
+	LastCommitMs int64
 }
 
 type writeRequest struct {
-	record Record
-	result chan error
+	record     *pendingRecord
+	enqueuedAt time.Time
+	result     chan error
+}
+
+type pendingRecord struct {
+	event       auditdomain.AuditEvent
+	reasonsJSON string
+	dataJSON    string
+	line        map[string]any
 }
 
 type WriterOptions struct {
@@ -45,7 +86,10 @@ func NewWriter(cfg config.Config, opts WriterOptions) (*Writer, error) {
 	if cfg.AuditWriterQueueCapacity <= 0 {
 		return nil, fmt.Errorf("audit writer queue capacity invalid")
 	}
-	jsonl, err := newJSONLWriter(opts.JSONLDir)
+	if cfg.AuditWriterBatchMaxEvents <= 0 || cfg.AuditWriterBatchMaxDelayMs <= 0 {
+		return nil, fmt.Errorf("audit writer batch limits invalid")
+	}
+	jsonl, err := newJSONLWriter(opts.JSONLDir, cfg.AuditManifestKeyPath, cfg.AuditManifestAlg, opts.Now)
 	if err != nil {
 		return nil, err
 	}
@@ -60,39 +104,79 @@ func NewWriter(cfg config.Config, opts WriterOptions) (*Writer, error) {
 		return nil, err
 	}
 	writer := &Writer{
-		queue:         make(chan writeRequest, cfg.AuditWriterQueueCapacity),
-		queueCapacity: cfg.AuditWriterQueueCapacity,
-		jsonl:         jsonl,
-		db:            db,
-		closed:        make(chan struct{}),
+		queue:          make(chan writeRequest, cfg.AuditWriterQueueCapacity),
+		queueCapacity:  cfg.AuditWriterQueueCapacity,
+		batchMaxEvents: cfg.AuditWriterBatchMaxEvents,
+		batchMaxDelay:  time.Duration(cfg.AuditWriterBatchMaxDelayMs) * time.Millisecond,
+		enqueueWait:    time.Duration(cfg.AuditWriterMaxLagMs) * time.Millisecond,
+		jsonl:          jsonl,
+		db:             db,
+		chain:          make(map[string]string),
+		now:            opts.Now,
+		closed:         make(chan struct{}),
 	}
 	writer.wg.Add(1)
-	go writer.run(opts.Now)
+	go writer.run()
 	return writer, nil
 }
 
 func (w *Writer) Write(record Record) error {
-	if err := record.Validate(); err != nil {
-		return err
-	}
+	return w.enqueue(record, false)
+}
+
+// WriteSync waits for room in the queue instead of failing with ErrQueueFull, then waits
+// for the commit. Critical events use it so a full queue delays them but never drops them.
+func (w *Writer) WriteSync(record Record) error {
+	return w.enqueue(record, true)
+}
+
+func (w *Writer) Flush() error {
 	select {
 	case <-w.closed:
-		return errors.New("audit writer closed")
+		return w.Err()
 	default:
 	}
-	req := writeRequest{record: record, result: make(chan error, 1)}
-	select {
-	case w.queue <- req:
-		return <-req.result
-	default:
-		return errors.New("audit writer queue full")
+	req := writeRequest{enqueuedAt: w.now(), result: make(chan error, 1)}
+	if err := w.send(req, false); err != nil {
+		if errors.Is(err, ErrWriterClosed) {
+			return w.Err()
+		}
+		return err
 	}
+	return <-req.result
+}
+
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
 }
 
 func (w *Writer) QueueStats() (int, int) {
 	return len(w.queue), w.queueCapacity
 }
 
+func (w *Writer) Stats() WriterStats {
+	stats := WriterStats{
+		QueueLen:     len(w.queue),
+		QueueCap:     w.queueCapacity,
+		LagMs:        w.lagMs.Load(),
+		DropsTotal:   w.drops.Load(),
+		StallsTotal:  w.stalls.Load(),
+		Batches:      w.batches.Load(),
+		LastCommitMs: w.lastCommitMs.Load(),
+	}
+	if stats.QueueCap > 0 {
+		stats.QueuePct = stats.QueueLen * 100 / stats.QueueCap
+	}
+	if since := w.pendingSinceMs.Load(); since > 0 {
+		if pending := w.now().UnixMilli() - since; pending > stats.LagMs {
+			stats.LagMs = pending
+		}
+	}
+	return stats
+}
+
 func (w *Writer) Close() error {
 	select {
 	case <-w.closed:
@@ -100,11 +184,13 @@ func (w *Writer) Close() error {
 	default:
 		close(w.closed)
 	}
+	w.sendMu.Lock()
 	close(w.queue)
+	w.sendMu.Unlock()
 	w.wg.Wait()
-	var err error
+	err := w.Err()
 	if w.jsonl != nil {
-		if closeErr := w.jsonl.Close(); closeErr != nil {
+		if closeErr := w.jsonl.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 	}
@@ -116,35 +202,166 @@ func (w *Writer) Close() error {
 	return err
 }
 
-func (w *Writer) run(now func() time.Time) {
-	defer w.wg.Done()
-	for req := range w.queue {
-		req.result <- w.writeOnce(req.record)
-		close(req.result)
+func (w *Writer) enqueue(record Record, wait bool) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	pending, err := newPendingRecord(record)
+	if err != nil {
+		return err
+	}
+	if err := w.Err(); err != nil {
+		return err
+	}
+	req := writeRequest{record: pending, enqueuedAt: w.now()}
+	if wait {
+		req.result = make(chan error, 1)
+	}
+	if err := w.send(req, wait); err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			w.drops.Add(1)
+		}
+		return err
+	}
+	if !wait {
+		return nil
 	}
+	return <-req.result
 }
 
-func (w *Writer) writeOnce(record Record) error {
+// send queues req, waiting at most enqueueWait for room unless block is set, in which
+// case it waits until there is room or the writer closes. The read lock keeps Close from
+// closing the queue while a send is in flight.
+func (w *Writer) send(req writeRequest, block bool) error {
+	w.sendMu.RLock()
+	defer w.sendMu.RUnlock()
+	select {
+	case <-w.closed:
+		return ErrWriterClosed
+	default:
+	}
+	select {
+	case w.queue <- req:
+		return nil
+	default:
+	}
+	w.stalls.Add(1)
+	if block {
+		select {
+		case w.queue <- req:
+			return nil
+		case <-w.closed:
+			return ErrWriterClosed
+		}
+	}
+	timer := time.NewTimer(w.enqueueWait)
+	defer timer.Stop()
+	select {
+	case w.queue <- req:
+		return nil
+	case <-w.closed:
+		return ErrWriterClosed
+	case <-timer.C:
+		return ErrQueueFull
+	}
+}
+
+func newPendingRecord(record Record) (*pendingRecord, error) {
 	dataJSON, err := record.DataJSON()
 	if err != nil {
-		return fmt.Errorf("audit data json: %w", err)
+		return nil, fmt.Errorf("audit data json: %w", err)
 	}
 	reasonsJSON, err := recordReasonsJSON(record)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := w.writeSQLite(record, reasonsJSON, dataJSON); err != nil {
-		return err
+	buf, err := json.Marshal(record.lineMap())
+	if err != nil {
+		return nil, fmt.Errorf("audit jsonl: %w", err)
 	}
-	line, err := record.JSONLine()
+	line, err := decodeJSONObject(buf)
 	if err != nil {
-		return fmt.Errorf("audit jsonl: %w", err)
+		return nil, fmt.Errorf("audit jsonl: %w", err)
 	}
-	ts := time.UnixMilli(record.Event.TsMs)
-	if err := w.jsonl.Write(ts, line); err != nil {
-		return err
+	return &pendingRecord{event: record.Event, reasonsJSON: reasonsJSON, dataJSON: dataJSON, line: line}, nil
+}
+
+func (w *Writer) run() {
+	defer w.wg.Done()
+	for first := range w.queue {
+		batch := w.collect(first)
+		err := w.commit(batch)
+		for _, req := range batch {
+			if req.result != nil {
+				req.result <- err
+				close(req.result)
+			}
+		}
 	}
-	return nil
+}
+
+func (w *Writer) collect(first writeRequest) []writeRequest {
+	batch := []writeRequest{first}
+	w.pendingSinceMs.Store(first.enqueuedAt.UnixMilli())
+	if first.result != nil {
+		return batch
+	}
+	timer := time.NewTimer(w.batchMaxDelay)
+	defer timer.Stop()
+	for len(batch) < w.batchMaxEvents {
+		select {
+		case req, ok := <-w.queue:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, req)
+			if req.result != nil {
+				return batch
+			}
+		case <-timer.C:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (w *Writer) commit(batch []writeRequest) error {
+	defer w.pendingSinceMs.Store(0)
+	records := make([]*pendingRecord, 0, len(batch))
+	for _, req := range batch {
+		if req.record != nil {
+			records = append(records, req.record)
+		}
+	}
+	if len(records) == 0 {
+		return w.Err()
+	}
+	lines, heads, err := w.writeSQLite(records)
+	if err != nil {
+		return w.fail(err)
+	}
+	for runID, head := range heads {
+		w.chain[runID] = head
+	}
+	for i, record := range records {
+		if err := w.jsonl.Write(time.UnixMilli(record.event.TsMs), lines[i]); err != nil {
+			return w.fail(err)
+		}
+	}
+	now := w.now()
+	w.lagMs.Store(now.Sub(batch[0].enqueuedAt).Milliseconds())
+	w.lastCommitMs.Store(now.UnixMilli())
+	w.batches.Add(1)
+	return w.Err()
+}
+
+func (w *Writer) fail(err error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+	return err
 }
 
 func recordReasonsJSON(record Record) (string, error) {
@@ -156,28 +373,90 @@ func recordReasonsJSON(record Record) (string, error) {
 	return string(buf), nil
 }
 
-func (w *Writer) writeSQLite(record Record, reasonsJSON string, dataJSON string) error {
-	_, err := w.db.Exec(`INSERT INTO audit_events (
+func (w *Writer) writeSQLite(records []*pendingRecord) ([][]byte, map[string]string, error) {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("audit sqlite begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	stmt, err := tx.Prepare(`INSERT INTO audit_events (
   ts_ms, run_id, cycle_id, mode, stage, event_type, reasons_json, snapshot_id,
-  decision_id, order_intent_id, exchange_time_ms, local_received_ms, data_json, created_at_ms
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		record.Event.TsMs,
-		record.Event.RunID,
-		record.Event.CycleID,
-		record.Event.Mode,
-		record.Event.Stage,
-		record.Event.EventType,
-		reasonsJSON,
-		record.Event.SnapshotID,
-		record.Event.DecisionID,
-		record.Event.OrderIntentID,
-		record.Event.ExchangeTimeMs,
-		record.Event.LocalReceivedMs,
-		dataJSON,
-		record.Event.TsMs,
-	)
+  decision_id, order_intent_id, exchange_time_ms, local_received_ms, data_json, created_at_ms,
+  prev_hash, record_hash
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("audit sqlite prepare: %w", err)
+	}
+	defer stmt.Close()
+	heads := make(map[string]string)
+	lines := make([][]byte, 0, len(records))
+	for _, record := range records {
+		event := record.event
+		prevHash, ok := heads[event.RunID]
+		if !ok {
+			prevHash, err = w.prevHash(tx, event.RunID)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		recordHash, err := ChainHash(record.line, prevHash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("audit chain hash: %w", err)
+		}
+		record.line["prev_hash"] = prevHash
+		record.line["record_hash"] = recordHash
+		line, err := json.Marshal(record.line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("audit jsonl: %w", err)
+		}
+		_, err = stmt.Exec(
+			event.TsMs,
+			event.RunID,
+			event.CycleID,
+			event.Mode,
+			event.Stage,
+			event.EventType,
+			record.reasonsJSON,
+			event.SnapshotID,
+			event.DecisionID,
+			event.OrderIntentID,
+			event.ExchangeTimeMs,
+			event.LocalReceivedMs,
+			record.dataJSON,
+			event.TsMs,
+			prevHash,
+			recordHash,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("audit sqlite insert: %w", err)
+		}
+		heads[event.RunID] = recordHash
+		lines = append(lines, line)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("audit sqlite commit: %w", err)
+	}
+	return lines, heads, nil
+}
+
+func (w *Writer) prevHash(tx *sql.Tx, runID string) (string, error) {
+	if last, ok := w.chain[runID]; ok {
+		return last, nil
+	}
+	var last string
+	err := tx.QueryRow(`SELECT record_hash FROM audit_events
+WHERE run_id = ? AND record_hash != ''
+ORDER BY event_id DESC LIMIT 1`, runID).Scan(&last)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow(`SELECT record_hash FROM audit_chain_anchors WHERE run_id = ?`, runID).Scan(&last)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return GenesisHash, nil
+	}
 	if err != nil {
-		return fmt.Errorf("audit sqlite insert: %w", err)
+		return "", fmt.Errorf("audit chain head: %w", err)
 	}
-	return nil
+	return last, nil
 }
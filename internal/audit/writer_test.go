@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -106,6 +107,131 @@ func TestWriterRejectsJSONLDirFile(t *testing.T) {
 	}
 }
 
+func TestWriterBatchesAndFlushes(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := testConfig()
+	cfg.AuditWriterQueueCapacity = 64
+	cfg.AuditWriterBatchMaxEvents = 16
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	writer, err := NewWriter(cfg, WriterOptions{DBPath: dbPath, JSONLDir: filepath.Join(tmp, "logs"), Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("unexpected writer error: %v", err)
+	}
+	defer writer.Close()
+	event := auditdomain.AuditEvent{
+		TsMs:            now.UnixMilli(),
+		RunID:           "run_batch",
+		CycleID:         "cyc_batch",
+		Mode:            "LIVE",
+		Stage:           observability.STATE_UPDATE,
+		EventType:       auditdomain.STAGE_CHANGED,
+		Reasons:         []reasoncodes.ReasonCode{},
+		LocalReceivedMs: now.UnixMilli(),
+	}
+	for i := 0; i < 40; i++ {
+		if err := writer.Write(Record{Event: event, Data: map[string]any{"n": i}}); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM audit_events WHERE run_id = 'run_batch'").Scan(&count); err != nil || count != 40 {
+		t.Fatalf("expected 40 rows after flush, got %d (%v)", count, err)
+	}
+	stats := writer.Stats()
+	if stats.Batches == 0 || stats.Batches >= 40 || stats.DropsTotal != 0 || stats.LastCommitMs != now.UnixMilli() {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if err := writer.WriteSync(Record{Event: event, Data: map[string]any{"n": 40}}); err != nil {
+		t.Fatalf("write sync: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM audit_events WHERE run_id = 'run_batch'").Scan(&count); err != nil || count != 41 {
+		t.Fatalf("expected 41 rows after sync write, got %d (%v)", count, err)
+	}
+}
+
+func TestEvaluateBackpressure(t *testing.T) {
+	cfg := testConfig()
+	cases := []struct {
+		stats     WriterStats
+		prevDrops int64
+		action    BackpressureAction
+	}{
+		{WriterStats{QueuePct: 10, LagMs: 20}, 0, BackpressureNone},
+		{WriterStats{QueuePct: cfg.AuditWriterQueueHiWatermark}, 0, BackpressureDegrade},
+		{WriterStats{QueuePct: 10, LagMs: int64(cfg.AuditWriterMaxLagMs)}, 0, BackpressureDegrade},
+		{WriterStats{QueuePct: 10, DropsTotal: 3}, 1, BackpressureDegrade},
+		{WriterStats{QueuePct: cfg.AuditWriterQueueFull}, 0, BackpressurePause},
+	}
+	for _, tc := range cases {
+		got := EvaluateBackpressure(cfg, tc.stats, tc.prevDrops)
+		if got.Action != tc.action || got.DropsDelta != tc.stats.DropsTotal-tc.prevDrops {
+			t.Fatalf("stats %+v: got %+v, want %s", tc.stats, got, tc.action)
+		}
+	}
+	record := EvaluateBackpressure(cfg, WriterStats{QueuePct: cfg.AuditWriterQueueFull}, 0).Record(auditdomain.AuditEvent{
+		TsMs: 1, RunID: "run", CycleID: "cyc", Mode: "LIVE", LocalReceivedMs: 1,
+	})
+	if err := record.Validate(); err != nil || record.Event.EventType != auditdomain.DB_WRITER_BACKPRESSURE || record.Data["action"] != "PAUSE" {
+		t.Fatalf("unexpected backpressure record: %+v (%v)", record, err)
+	}
+}
+
+func TestWriterFlushIsBoundedSyncBlocksAndCloseIsSafe(t *testing.T) {
+	// No run goroutine drains this queue, so it stays full.
+	w := &Writer{
+		queue:         make(chan writeRequest, 1),
+		queueCapacity: 1,
+		enqueueWait:   10 * time.Millisecond,
+		now:           time.Now,
+		closed:        make(chan struct{}),
+	}
+	w.queue <- writeRequest{}
+	if err := w.Flush(); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected bounded flush on a full queue, got %v", err)
+	}
+	if w.drops.Load() != 0 || w.stalls.Load() != 1 {
+		t.Fatalf("flush must stall without counting a dropped record: drops=%d stalls=%d", w.drops.Load(), w.stalls.Load())
+	}
+	blocked := make(chan error, 1)
+	go func() { blocked <- w.send(writeRequest{}, true) }()
+	select {
+	case err := <-blocked:
+		t.Fatalf("blocking send must wait past enqueueWait for room, got %v", err)
+	case <-time.After(5 * w.enqueueWait):
+	}
+	<-w.queue
+	if err := <-blocked; err != nil {
+		t.Fatalf("blocking send after room freed: %v", err)
+	}
+	w.enqueueWait = time.Minute
+	done := make(chan error, 1)
+	go func() { done <- w.Flush() }()
+	time.Sleep(10 * time.Millisecond)
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected flush on a closed writer to return its error state, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("flush did not return after close")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush after close: %v", err)
+	}
+}
+
 func testConfig() config.Config {
 	cfg := config.Default()
 	cfg.AuditWriterQueueCapacity = 4
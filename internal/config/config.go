@@ -28,6 +28,10 @@ type Config struct {
 	WebuiStreamSnapshotIntervalMs          int
 	WebuiIntentsRecentLimit                int
 	WebuiReconcileDiffsRecentLimit         int
+	WebuiMarketSymbolsLimit                int
+	WebuiControlEnabled                    bool
+	WebuiControlTokenPath                  string
+	WebuiMetricsEnabled                    bool
 	TimeSyncRecvWindowMs                   int
 	TimeSyncIntervalMs                     int
 	ClockDriftMaxMsLive                    int
@@ -37,6 +41,20 @@ type Config struct {
 	AIGateTimeoutMs                        int
 	AIGateModel                            string
 	OpenAIBaseURL                          string
+	AIGateProvider                         string
+	AIGateStubTablePath                    string
+	AIGateCacheMode                        string
+	AIGateMaxCallsPerCycle                 int
+	AIGateMaxCallsPerDay                   int
+	AIGateCircuitTimeouts                  int
+	AIGateCircuitOpenMs                    int
+	AIGateErrorPolicy                      string
+	AIGateErrorTightenQtyPct               int
+	AIGatePromptVersion                    string
+	AIGatePromptChallengerVersion          string
+	AIGatePromptChallengerPct              int
+	AIGatePromptAllowlist                  map[string]string
+	AIGateCounterfactualHorizonMs          int
 	IntentMaxRestQueries                   int
 	IntentRestQueryTimeoutMs               int
 	TopNSize                               int
@@ -123,42 +141,101 @@ type Config struct {
 	RiskQuarantineMaxTimeoutsConsecutive   int
 	RiskQuarantineTTLSeconds               int
 	RiskQuarantineAutoRelease              bool
+	AlertNotifyEnabled                     bool
+	AlertDedupeWindowMs                    int
+	AlertRateLimitPerMin                   int
+	AlertQueueCapacity                     int
+	AlertSendTimeoutMs                     int
+	AlertSendMaxAttempts                   int
+	AlertSeverityByReason                  map[string]string
+	AlertWebhookURL                        string
+	AlertWebhookMinSeverity                string
+	AlertSMTPAddr                          string
+	AlertSMTPFrom                          string
+	AlertSMTPTo                            []string
+	AlertSMTPUsername                      string
+	AlertSMTPMinSeverity                   string
+	AlertTelegramBaseURL                   string
+	AlertTelegramChatID                    string
+	AlertTelegramMinSeverity               string
+	ReportDailyEnabled                     bool
+	ReportDir                              string
+	AuditManifestAlg                       string
+	AuditManifestKeyPath                   string
+	AuditWriterBatchMaxEvents              int
+	AuditWriterBatchMaxDelayMs             int
+	RetentionEnabled                       bool
+	RetentionAuditKeepDays                 int
+	RetentionDataKeepDays                  int
+	RetentionArchiveDir                    string
+	RetentionBatchRows                     int
+	RetentionVacuumMaxPages                int
+	BackupEnabled                          bool
+	BackupDir                              string
+	BackupKeepDays                         int
+	RunLockPath                            string
+	LiveChecklistIntervalMs                int
+	SecretsDotEnvPath                      string
+	SecretsFilePath                        string
+	SecretsIdentityPath                    string
+	BinancePrivateKeyPath                  string
+	BinanceWSAPIRequestTimeoutMs           int
 }
 
 func Default() Config {
 	return Config{
-		Mode:                                   "LIVE",
-		AiDec:                                  2,
-		LiveRequireOKFile:                      false,
-		LiveOKFilePath:                         "var/LIVE.ok",
-		LoopStuckMsDegrade:                     5000,
-		LoopStuckMsPause:                       15000,
-		WsStaleMsDegrade:                       2000,
-		WsStaleMsPause:                         10000,
-		RestStaleMsDegrade:                     10000,
-		RestStaleMsPause:                       60000,
-		DiskFreeDegradeBytes:                   1073741824,
-		DiskFreePauseBytes:                     536870912,
-		AuditWriterQueueHiWatermark:            80,
-		AuditWriterQueueFull:                   95,
-		AuditWriterQueueCapacity:               1024,
-		AuditWriterMaxLagMs:                    5000,
-		ReconcileRestIntervalMs:                5000,
-		ReconcileDriftDegradeX10000:            20000,
-		ReconcileDriftPauseX10000:              50000,
-		WebuiPort:                              8787,
-		WebuiStreamSnapshotIntervalMs:          1000,
-		WebuiIntentsRecentLimit:                50,
-		WebuiReconcileDiffsRecentLimit:         50,
-		TimeSyncRecvWindowMs:                   5000,
-		TimeSyncIntervalMs:                     300000,
-		ClockDriftMaxMsLive:                    500,
-		ClockDriftMaxMsPaper:                   2000,
-		DiskHealthSampleIntervalMs:             5000,
-		AuditRedactedJSONMaxBytes:              4096,
-		AIGateTimeoutMs:                        8000,
-		AIGateModel:                            "gpt-4o-mini",
-		OpenAIBaseURL:                          "https://api.openai.com/v1",
+		Mode:                           "LIVE",
+		AiDec:                          2,
+		LiveRequireOKFile:              false,
+		LiveOKFilePath:                 "var/LIVE.ok",
+		LoopStuckMsDegrade:             5000,
+		LoopStuckMsPause:               15000,
+		WsStaleMsDegrade:               2000,
+		WsStaleMsPause:                 10000,
+		RestStaleMsDegrade:             10000,
+		RestStaleMsPause:               60000,
+		DiskFreeDegradeBytes:           1073741824,
+		DiskFreePauseBytes:             536870912,
+		AuditWriterQueueHiWatermark:    80,
+		AuditWriterQueueFull:           95,
+		AuditWriterQueueCapacity:       1024,
+		AuditWriterMaxLagMs:            5000,
+		ReconcileRestIntervalMs:        5000,
+		ReconcileDriftDegradeX10000:    20000,
+		ReconcileDriftPauseX10000:      50000,
+		WebuiPort:                      8787,
+		WebuiStreamSnapshotIntervalMs:  1000,
+		WebuiIntentsRecentLimit:        50,
+		WebuiReconcileDiffsRecentLimit: 50,
+		WebuiMarketSymbolsLimit:        50,
+		WebuiControlEnabled:            false,
+		WebuiControlTokenPath:          "var/secrets/webui_operators",
+		WebuiMetricsEnabled:            true,
+		TimeSyncRecvWindowMs:           5000,
+		TimeSyncIntervalMs:             300000,
+		ClockDriftMaxMsLive:            500,
+		ClockDriftMaxMsPaper:           2000,
+		DiskHealthSampleIntervalMs:     5000,
+		AuditRedactedJSONMaxBytes:      4096,
+		AIGateTimeoutMs:                8000,
+		AIGateModel:                    "gpt-4o-mini",
+		OpenAIBaseURL:                  "https://api.openai.com/v1",
+		AIGateProvider:                 "openai",
+		AIGateStubTablePath:            "",
+		AIGateCacheMode:                "cache",
+		AIGateMaxCallsPerCycle:         3,
+		AIGateMaxCallsPerDay:           500,
+		AIGateCircuitTimeouts:          3,
+		AIGateCircuitOpenMs:            300000,
+		AIGateErrorPolicy:              "block",
+		AIGateErrorTightenQtyPct:       50,
+		AIGatePromptVersion:            "v1",
+		AIGatePromptChallengerVersion:  "",
+		AIGatePromptChallengerPct:      0,
+		AIGatePromptAllowlist: map[string]string{
+			"v1": "d89475d2830ae4610203a3c8341c32610e9d2b8df2cab9f188c724a5d5fa1b96",
+		},
+		AIGateCounterfactualHorizonMs:          14400000,
 		IntentMaxRestQueries:                   3,
 		IntentRestQueryTimeoutMs:               5000,
 		TopNSize:                               20,
@@ -245,6 +322,50 @@ func Default() Config {
 		RiskQuarantineMaxTimeoutsConsecutive:   3,
 		RiskQuarantineTTLSeconds:               3600,
 		RiskQuarantineAutoRelease:              true,
+		AlertNotifyEnabled:                     false,
+		AlertDedupeWindowMs:                    600000,
+		AlertRateLimitPerMin:                   10,
+		AlertQueueCapacity:                     64,
+		AlertSendTimeoutMs:                     5000,
+		AlertSendMaxAttempts:                   3,
+		AlertWebhookURL:                        "",
+		AlertWebhookMinSeverity:                "WARN",
+		AlertSMTPAddr:                          "",
+		AlertSMTPFrom:                          "",
+		AlertSMTPTo:                            []string{},
+		AlertSMTPUsername:                      "",
+		AlertSMTPMinSeverity:                   "CRITICAL",
+		AlertTelegramBaseURL:                   "https://api.telegram.org",
+		AlertTelegramChatID:                    "",
+		AlertTelegramMinSeverity:               "CRITICAL",
+		AlertSeverityByReason: map[string]string{
+			"ENTER_EXIT":           "CRITICAL",
+			"ENTER_PAUSE":          "CRITICAL",
+			"ENTER_DEGRADE":        "WARN",
+			"DRIFT_LIMIT_EXCEEDED": "CRITICAL",
+		},
+		ReportDailyEnabled:           true,
+		ReportDir:                    "var/reports",
+		AuditManifestAlg:             "hmac-sha256",
+		AuditManifestKeyPath:         "var/secrets/audit_manifest.key",
+		AuditWriterBatchMaxEvents:    256,
+		AuditWriterBatchMaxDelayMs:   50,
+		RetentionEnabled:             true,
+		RetentionAuditKeepDays:       90,
+		RetentionDataKeepDays:        14,
+		RetentionArchiveDir:          "var/archive",
+		RetentionBatchRows:           2000,
+		RetentionVacuumMaxPages:      4096,
+		BackupEnabled:                true,
+		BackupDir:                    "var/backups",
+		BackupKeepDays:               7,
+		RunLockPath:                  "var/livespot.lock",
+		LiveChecklistIntervalMs:      60000,
+		SecretsDotEnvPath:            ".env",
+		SecretsFilePath:              "var/secrets/secrets.enc",
+		SecretsIdentityPath:          "var/secrets/secrets.key",
+		BinancePrivateKeyPath:        "",
+		BinanceWSAPIRequestTimeoutMs: 2000,
 	}
 }
 
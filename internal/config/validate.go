@@ -78,6 +78,15 @@ func Validate(cfg Config, stat StatFunc) error {
 	if err := requirePositiveInt("audit_writer_max_lag_ms", cfg.AuditWriterMaxLagMs); err != nil {
 		return err
 	}
+	if err := requireRangeInt("audit_writer_batch_max_events", cfg.AuditWriterBatchMaxEvents, 1, 4096); err != nil {
+		return err
+	}
+	if err := requireRangeInt("audit_writer_batch_max_delay_ms", cfg.AuditWriterBatchMaxDelayMs, 1, 1000); err != nil {
+		return err
+	}
+	if cfg.AuditWriterBatchMaxDelayMs >= cfg.AuditWriterMaxLagMs {
+		return ValidationError{Field: "audit_writer_batch_max_delay_ms", Message: "must be below audit_writer_max_lag_ms"}
+	}
 	if err := requirePositiveInt("reconcile_rest_interval_ms", cfg.ReconcileRestIntervalMs); err != nil {
 		return err
 	}
@@ -99,12 +108,77 @@ func Validate(cfg Config, stat StatFunc) error {
 	if err := requireRangeInt("webui_reconcile_diffs_recent_limit", cfg.WebuiReconcileDiffsRecentLimit, 10, 200); err != nil {
 		return err
 	}
+	if err := requireRangeInt("webui_market_symbols_limit", cfg.WebuiMarketSymbolsLimit, 10, 200); err != nil {
+		return err
+	}
+	if cfg.WebuiControlEnabled {
+		if err := requireNonEmpty("webui_control_token_path", cfg.WebuiControlTokenPath); err != nil {
+			return err
+		}
+	}
+	if err := validateAlerts(cfg); err != nil {
+		return err
+	}
+	if cfg.ReportDailyEnabled {
+		if err := requireNonEmpty("report_dir", cfg.ReportDir); err != nil {
+			return err
+		}
+	}
+	if cfg.AuditManifestAlg != "hmac-sha256" && cfg.AuditManifestAlg != "ed25519" {
+		return ValidationError{Field: "audit_manifest_alg", Message: "must be hmac-sha256 or ed25519"}
+	}
+	if err := requireNonEmpty("audit_manifest_key_path", cfg.AuditManifestKeyPath); err != nil {
+		return err
+	}
+	if cfg.RetentionEnabled {
+		if err := requireRangeInt("retention_audit_keep_days", cfg.RetentionAuditKeepDays, 1, 3650); err != nil {
+			return err
+		}
+		if err := requireRangeInt("retention_data_keep_days", cfg.RetentionDataKeepDays, 1, 3650); err != nil {
+			return err
+		}
+		if err := requireNonEmpty("retention_archive_dir", cfg.RetentionArchiveDir); err != nil {
+			return err
+		}
+		if err := requireRangeInt("retention_batch_rows", cfg.RetentionBatchRows, 100, 100000); err != nil {
+			return err
+		}
+		if err := requireRangeInt("retention_vacuum_max_pages", cfg.RetentionVacuumMaxPages, 0, 1000000); err != nil {
+			return err
+		}
+	}
+	if cfg.BackupEnabled {
+		if err := requireNonEmpty("backup_dir", cfg.BackupDir); err != nil {
+			return err
+		}
+		if err := requireRangeInt("backup_keep_days", cfg.BackupKeepDays, 1, 365); err != nil {
+			return err
+		}
+	}
+	if err := requireNonEmpty("run_lock_path", cfg.RunLockPath); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("live_checklist_interval_ms", cfg.LiveChecklistIntervalMs); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("secrets_dotenv_path", cfg.SecretsDotEnvPath); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("secrets_file_path", cfg.SecretsFilePath); err != nil {
+		return err
+	}
+	if err := requireNonEmpty("secrets_identity_path", cfg.SecretsIdentityPath); err != nil {
+		return err
+	}
 	if err := requirePositiveInt("time_sync_recv_window_ms", cfg.TimeSyncRecvWindowMs); err != nil {
 		return err
 	}
 	if err := requirePositiveInt("time_sync_interval_ms", cfg.TimeSyncIntervalMs); err != nil {
 		return err
 	}
+	if err := requirePositiveInt("binance_ws_api_request_timeout_ms", cfg.BinanceWSAPIRequestTimeoutMs); err != nil {
+		return err
+	}
 	if err := requirePositiveInt("clock_drift_max_ms_live", cfg.ClockDriftMaxMsLive); err != nil {
 		return err
 	}
@@ -126,6 +200,81 @@ func Validate(cfg Config, stat StatFunc) error {
 	if err := requireNonEmpty("openai_base_url", cfg.OpenAIBaseURL); err != nil {
 		return err
 	}
+	switch cfg.AIGateProvider {
+	case "openai", "openai_compatible":
+	case "stub":
+		// The stub answers ALLOW by default, so in LIVE it would silently bypass the gate.
+		if cfg.Mode == "LIVE" {
+			return ValidationError{Field: "ai_gate_provider", Message: "stub is not allowed in LIVE"}
+		}
+	default:
+		return ValidationError{Field: "ai_gate_provider", Message: "must be openai, openai_compatible or stub"}
+	}
+	switch cfg.AIGateCacheMode {
+	case "off", "cache", "replay":
+	default:
+		return ValidationError{Field: "ai_gate_cache_mode", Message: "must be off, cache or replay"}
+	}
+	if err := requirePositiveInt("ai_gate_max_calls_per_cycle", cfg.AIGateMaxCallsPerCycle); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("ai_gate_max_calls_per_day", cfg.AIGateMaxCallsPerDay); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("ai_gate_circuit_timeouts", cfg.AIGateCircuitTimeouts); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("ai_gate_circuit_open_ms", cfg.AIGateCircuitOpenMs); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("ai_gate_counterfactual_horizon_ms", cfg.AIGateCounterfactualHorizonMs); err != nil {
+		return err
+	}
+	switch cfg.AIGateErrorPolicy {
+	case "block":
+	case "tighten":
+		if cfg.AiDec == 2 {
+			return ValidationError{Field: "ai_gate_error_policy", Message: "tighten is not allowed with ai_dec=2"}
+		}
+	default:
+		return ValidationError{Field: "ai_gate_error_policy", Message: "must be block or tighten"}
+	}
+	if err := requireRangeInt("ai_gate_error_tighten_qty_pct", cfg.AIGateErrorTightenQtyPct, 1, 99); err != nil {
+		return err
+	}
+	if err := requirePromptVersion("ai_gate_prompt_version", cfg.AIGatePromptVersion); err != nil {
+		return err
+	}
+	if cfg.AIGatePromptChallengerVersion != "" {
+		if err := requirePromptVersion("ai_gate_prompt_challenger_version", cfg.AIGatePromptChallengerVersion); err != nil {
+			return err
+		}
+		if cfg.AIGatePromptChallengerVersion == cfg.AIGatePromptVersion {
+			return ValidationError{Field: "ai_gate_prompt_challenger_version", Message: "must differ from ai_gate_prompt_version"}
+		}
+		if _, ok := cfg.AIGatePromptAllowlist[cfg.AIGatePromptChallengerVersion]; !ok {
+			return ValidationError{Field: "ai_gate_prompt_allowlist", Message: "missing challenger version"}
+		}
+	}
+	if err := requireRangeInt("ai_gate_prompt_challenger_pct", cfg.AIGatePromptChallengerPct, 0, 100); err != nil {
+		return err
+	}
+	if _, ok := cfg.AIGatePromptAllowlist[cfg.AIGatePromptVersion]; !ok {
+		return ValidationError{Field: "ai_gate_prompt_allowlist", Message: "missing primary version"}
+	}
+	for version, contentHash := range cfg.AIGatePromptAllowlist {
+		if !isLowerHex64(contentHash) {
+			return ValidationError{Field: "ai_gate_prompt_allowlist", Message: "invalid hash for " + version}
+		}
+	}
+	if cfg.AIGateStubTablePath != "" {
+		if stat == nil {
+			return ValidationError{Field: "ai_gate_stub_table_path", Message: "stat function missing"}
+		}
+		if _, err := stat(cfg.AIGateStubTablePath); err != nil {
+			return ValidationError{Field: "ai_gate_stub_table_path", Message: "file not found"}
+		}
+	}
 	if err := requirePositiveInt("intent_max_rest_queries", cfg.IntentMaxRestQueries); err != nil {
 		return err
 	}
@@ -502,6 +651,34 @@ func requireDecimalNonPositive(field string, v string) error {
 	return nil
 }
 
+func requirePromptVersion(field string, v string) error {
+	if v == "" {
+		return ValidationError{Field: field, Message: "missing"}
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_' || c == '-' {
+			continue
+		}
+		return ValidationError{Field: field, Message: "must match [a-z0-9_-]+"}
+	}
+	return nil
+}
+
+func isLowerHex64(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
 func requireNonEmpty(field string, v string) error {
 	if v == "" {
 		return ValidationError{Field: field, Message: "missing"}
@@ -516,3 +693,61 @@ func parseDecimal(value string) (*big.Rat, bool) {
 	}
 	return r, true
 }
+
+func validateAlerts(cfg Config) error {
+	if err := requirePositiveInt("alert_dedupe_window_ms", cfg.AlertDedupeWindowMs); err != nil {
+		return err
+	}
+	if err := requireRangeInt("alert_rate_limit_per_min", cfg.AlertRateLimitPerMin, 1, 120); err != nil {
+		return err
+	}
+	if err := requireRangeInt("alert_queue_capacity", cfg.AlertQueueCapacity, 1, 4096); err != nil {
+		return err
+	}
+	if err := requirePositiveInt("alert_send_timeout_ms", cfg.AlertSendTimeoutMs); err != nil {
+		return err
+	}
+	if err := requireRangeInt("alert_send_max_attempts", cfg.AlertSendMaxAttempts, 1, 10); err != nil {
+		return err
+	}
+	for reason, severity := range cfg.AlertSeverityByReason {
+		if err := requireAlertSeverity("alert_severity_by_reason."+reason, severity); err != nil {
+			return err
+		}
+	}
+	for field, severity := range map[string]string{
+		"alert_webhook_min_severity":  cfg.AlertWebhookMinSeverity,
+		"alert_smtp_min_severity":     cfg.AlertSMTPMinSeverity,
+		"alert_telegram_min_severity": cfg.AlertTelegramMinSeverity,
+	} {
+		if err := requireAlertSeverity(field, severity); err != nil {
+			return err
+		}
+	}
+	if cfg.AlertSMTPAddr != "" {
+		if err := requireNonEmpty("alert_smtp_from", cfg.AlertSMTPFrom); err != nil {
+			return err
+		}
+		if len(cfg.AlertSMTPTo) == 0 {
+			return ValidationError{Field: "alert_smtp_to", Message: "missing"}
+		}
+	}
+	if cfg.AlertTelegramChatID != "" {
+		if err := requireNonEmpty("alert_telegram_base_url", cfg.AlertTelegramBaseURL); err != nil {
+			return err
+		}
+	}
+	if cfg.AlertNotifyEnabled && cfg.AlertWebhookURL == "" && cfg.AlertSMTPAddr == "" && cfg.AlertTelegramChatID == "" {
+		return ValidationError{Field: "alert_notify_enabled", Message: "no alert sink configured"}
+	}
+	return nil
+}
+
+func requireAlertSeverity(field string, v string) error {
+	switch v {
+	case "INFO", "WARN", "CRITICAL":
+		return nil
+	default:
+		return ValidationError{Field: field, Message: "must be INFO, WARN or CRITICAL"}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -80,3 +81,15 @@ func TestValidateLiveOKFileMissing(t *testing.T) {
 		t.Fatalf("expected error for missing live ok file")
 	}
 }
+
+func TestValidateAIGateProvider(t *testing.T) {
+	cfg := Default()
+	cfg.AIGateProvider = "anthropic"
+	if err := Validate(cfg, os.Stat); err == nil {
+		t.Fatalf("expected error for ai_gate_provider")
+	}
+	cfg.AIGateProvider = "stub"
+	if err := Validate(cfg, os.Stat); err == nil || !strings.Contains(err.Error(), "not allowed in LIVE") {
+		t.Fatalf("expected stub provider rejected in LIVE, got %v", err)
+	}
+}
@@ -1,27 +1,65 @@
 package doctor
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
+	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
 	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
 )
 
+const (
+	QuoteAsset      = "USDT"
+	exchangeTimeout = 10 * time.Second
+)
+
 type CheckResult struct {
-	Name    string
-	OK      bool
-	Details string
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Details string `json:"details"`
+}
+
+type Report struct {
+	OK            bool          `json:"ok"`
+	GeneratedAtMs int64         `json:"generated_at_ms"`
+	Checks        []CheckResult `json:"checks"`
+}
+
+type Exchange interface {
+	Time(ctx context.Context) (binance.TimeResponse, error)
+	ExchangeInfo(ctx context.Context) (binance.ExchangeInfo, error)
+	Account(ctx context.Context) (binance.JSONResponse, error)
+	APIRestrictions(ctx context.Context) (binance.APIRestrictions, error)
 }
 
 type Runner struct {
-	Cfg  config.Config
-	Now  func() time.Time
-	Stat func(string) (fs.FileInfo, error)
+	Cfg       config.Config
+	Now       func() time.Time
+	Stat      func(string) (fs.FileInfo, error)
+	Exchange  Exchange
+	Getenv    func(string) string
+	FreeBytes func(string) (int64, error)
+}
+
+func NewReport(results []CheckResult, now time.Time) Report {
+	ok := true
+	for _, result := range results {
+		if !result.OK {
+			ok = false
+		}
+	}
+	return Report{OK: ok, GeneratedAtMs: now.UnixMilli(), Checks: results}
 }
 
 func RunAll(r Runner) []CheckResult {
@@ -31,13 +69,24 @@ func RunAll(r Runner) []CheckResult {
 	if r.Stat == nil {
 		r.Stat = os.Stat
 	}
+	if r.Getenv == nil {
+		r.Getenv = os.Getenv
+	}
+	if r.FreeBytes == nil {
+		r.FreeBytes = health.FreeBytes
+	}
 	results := []CheckResult{
 		checkMode(r.Cfg),
 		checkAiDec(r.Cfg),
 		checkLiveOKFile(r.Cfg, r.Stat),
 		checkSQLite(r.Cfg, r.Now),
 		checkJSONLWritable(r.Now),
+		checkDiskFree(r.Cfg, r.FreeBytes),
+		checkPrompts(r.Cfg),
+		checkOpenAIKey(r.Cfg, r.Getenv),
+		checkBinanceCredentials(r.Cfg, r.Getenv),
 	}
+	results = append(results, checkExchange(r.Cfg, r.Exchange, r.Now)...)
 	return results
 }
 
@@ -100,3 +149,180 @@ func checkJSONLWritable(now func() time.Time) CheckResult {
 	}
 	return CheckResult{Name: "audit_jsonl", OK: true, Details: audit.DefaultJSONLDir}
 }
+
+func checkDiskFree(cfg config.Config, freeBytes func(string) (int64, error)) CheckResult {
+	free, err := freeBytes(audit.DefaultSQLitePath)
+	if err != nil {
+		return CheckResult{Name: "disk_free", OK: false, Details: err.Error()}
+	}
+	if free < cfg.DiskFreeDegradeBytes {
+		return CheckResult{Name: "disk_free", OK: false, Details: fmt.Sprintf("%d bytes free below %d", free, cfg.DiskFreeDegradeBytes)}
+	}
+	return CheckResult{Name: "disk_free", OK: true, Details: fmt.Sprintf("%d bytes free", free)}
+}
+
+func checkPrompts(cfg config.Config) CheckResult {
+	versions := []string{cfg.AIGatePromptVersion}
+	if cfg.AIGatePromptChallengerVersion != "" {
+		versions = append(versions, cfg.AIGatePromptChallengerVersion)
+	}
+	for _, version := range versions {
+		set, err := aigate.LoadPromptSet(version)
+		if err != nil {
+			return CheckResult{Name: "ai_prompts", OK: false, Details: err.Error()}
+		}
+		if err := aigate.VerifyPromptSet(set, cfg.AIGatePromptAllowlist); err != nil {
+			return CheckResult{Name: "ai_prompts", OK: false, Details: err.Error()}
+		}
+	}
+	return CheckResult{Name: "ai_prompts", OK: true, Details: strings.Join(versions, ",")}
+}
+
+func checkOpenAIKey(cfg config.Config, getenv func(string) string) CheckResult {
+	if cfg.AIGateProvider == "stub" {
+		return CheckResult{Name: "openai_key", OK: false, Details: "ai_gate_provider stub is not allowed in LIVE"}
+	}
+	key := getenv("OPENAI_API_KEY")
+	if key == "" {
+		return CheckResult{Name: "openai_key", OK: false, Details: "OPENAI_API_KEY missing"}
+	}
+	if strings.ContainsAny(key, " \t\r\n") {
+		return CheckResult{Name: "openai_key", OK: false, Details: "OPENAI_API_KEY contains whitespace"}
+	}
+	if cfg.AIGateProvider == "openai" && (!strings.HasPrefix(key, "sk-") || len(key) < 20) {
+		return CheckResult{Name: "openai_key", OK: false, Details: "OPENAI_API_KEY format invalid"}
+	}
+	return CheckResult{Name: "openai_key", OK: true, Details: "format ok"}
+}
+
+func checkBinanceCredentials(cfg config.Config, getenv func(string) string) CheckResult {
+	names := []string{"BINANCE_API_KEY", "BINANCE_API_SECRET"}
+	keyPath := cfg.BinancePrivateKeyPath
+	if keyPath != "" {
+		names = names[:1]
+	}
+	for _, name := range names {
+		value := getenv(name)
+		if value == "" {
+			return CheckResult{Name: "binance_credentials", OK: false, Details: name + " missing"}
+		}
+		if strings.ContainsAny(value, " \t\r\n") {
+			return CheckResult{Name: "binance_credentials", OK: false, Details: name + " contains whitespace"}
+		}
+	}
+	if keyPath != "" {
+		signer, err := binance.LoadPrivateKeySigner(keyPath)
+		if err != nil {
+			return CheckResult{Name: "binance_credentials", OK: false, Details: err.Error()}
+		}
+		return CheckResult{Name: "binance_credentials", OK: true, Details: "present, " + signer.Name() + " key"}
+	}
+	return CheckResult{Name: "binance_credentials", OK: true, Details: "present, HMAC_SHA256 secret"}
+}
+
+func checkExchange(cfg config.Config, exchange Exchange, now func() time.Time) []CheckResult {
+	names := []string{"exchange_clock", "exchange_info", "api_restrictions", "quote_balance"}
+	if exchange == nil {
+		results := make([]CheckResult, 0, len(names))
+		for _, name := range names {
+			results = append(results, CheckResult{Name: name, OK: false, Details: "exchange client missing"})
+		}
+		return results
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exchangeTimeout)
+	defer cancel()
+	return []CheckResult{
+		checkClockDrift(ctx, cfg, exchange, now),
+		checkExchangeInfo(ctx, exchange),
+		checkAPIRestrictions(ctx, exchange),
+		checkQuoteBalance(ctx, cfg, exchange),
+	}
+}
+
+func checkClockDrift(ctx context.Context, cfg config.Config, exchange Exchange, now func() time.Time) CheckResult {
+	sent := now().UnixMilli()
+	resp, err := exchange.Time(ctx)
+	if err != nil {
+		return CheckResult{Name: "exchange_clock", OK: false, Details: err.Error()}
+	}
+	received := now().UnixMilli()
+	drift := resp.ServerTime - (sent+received)/2
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift > int64(cfg.ClockDriftMaxMsLive) {
+		return CheckResult{Name: "exchange_clock", OK: false, Details: fmt.Sprintf("drift %dms exceeds %dms", drift, cfg.ClockDriftMaxMsLive)}
+	}
+	return CheckResult{Name: "exchange_clock", OK: true, Details: fmt.Sprintf("drift %dms", drift)}
+}
+
+func checkExchangeInfo(ctx context.Context, exchange Exchange) CheckResult {
+	info, err := exchange.ExchangeInfo(ctx)
+	if err != nil {
+		return CheckResult{Name: "exchange_info", OK: false, Details: err.Error()}
+	}
+	trading := 0
+	for _, symbol := range info.Symbols {
+		if symbol.QuoteAsset == QuoteAsset && symbol.Status == "TRADING" {
+			trading++
+		}
+	}
+	if trading == 0 {
+		return CheckResult{Name: "exchange_info", OK: false, Details: fmt.Sprintf("no %s symbols trading", QuoteAsset)}
+	}
+	return CheckResult{Name: "exchange_info", OK: true, Details: fmt.Sprintf("%d %s symbols trading", trading, QuoteAsset)}
+}
+
+func checkAPIRestrictions(ctx context.Context, exchange Exchange) CheckResult {
+	restrictions, err := exchange.APIRestrictions(ctx)
+	if err != nil {
+		return CheckResult{Name: "api_restrictions", OK: false, Details: err.Error()}
+	}
+	var problems []string
+	if !restrictions.EnableSpotAndMarginTrading {
+		problems = append(problems, "spot trading disabled")
+	}
+	if restrictions.EnableWithdrawals {
+		problems = append(problems, "withdrawals enabled")
+	}
+	if !restrictions.IPRestrict {
+		problems = append(problems, "ip restriction missing")
+	}
+	if len(problems) > 0 {
+		return CheckResult{Name: "api_restrictions", OK: false, Details: strings.Join(problems, ", ")}
+	}
+	return CheckResult{Name: "api_restrictions", OK: true, Details: "spot trading only, ip restricted"}
+}
+
+func checkQuoteBalance(ctx context.Context, cfg config.Config, exchange Exchange) CheckResult {
+	resp, err := exchange.Account(ctx)
+	if err != nil {
+		return CheckResult{Name: "quote_balance", OK: false, Details: err.Error()}
+	}
+	var account struct {
+		Balances []struct {
+			Asset string `json:"asset"`
+			Free  string `json:"free"`
+		} `json:"balances"`
+	}
+	if err := json.Unmarshal(resp.Body, &account); err != nil {
+		return CheckResult{Name: "quote_balance", OK: false, Details: fmt.Sprintf("account decode: %s", err)}
+	}
+	required, ok := new(big.Rat).SetString(cfg.RiskPerTradeMinUSDT)
+	if !ok {
+		return CheckResult{Name: "quote_balance", OK: false, Details: "risk_per_trade_min_usdt invalid"}
+	}
+	free := new(big.Rat)
+	for _, balance := range account.Balances {
+		if balance.Asset != QuoteAsset {
+			continue
+		}
+		if _, ok := free.SetString(balance.Free); !ok {
+			return CheckResult{Name: "quote_balance", OK: false, Details: fmt.Sprintf("%s free invalid: %s", QuoteAsset, balance.Free)}
+		}
+	}
+	if free.Cmp(required) < 0 {
+		return CheckResult{Name: "quote_balance", OK: false, Details: fmt.Sprintf("%s free %s below %s", QuoteAsset, free.FloatString(2), cfg.RiskPerTradeMinUSDT)}
+	}
+	return CheckResult{Name: "quote_balance", OK: true, Details: fmt.Sprintf("%s free %s", QuoteAsset, free.FloatString(2))}
+}
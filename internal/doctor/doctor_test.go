@@ -1,13 +1,19 @@
 package doctor
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
 )
 
 func TestRunAllReturnsResults(t *testing.T) {
@@ -28,3 +34,110 @@ func TestRunAllReturnsResults(t *testing.T) {
 		t.Fatalf("expected results")
 	}
 }
+
+func TestRunAllExchangeChecksAgainstStub(t *testing.T) {
+	cfg := config.Default()
+	cfg.AuditWriterQueueCapacity = 4
+	cfg.LiveRequireOKFile = false
+
+	tmp := t.TempDir()
+	cwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(cwd) }()
+	_ = os.Chdir(tmp)
+	_ = os.MkdirAll(filepath.Join(tmp, filepath.Dir(audit.DefaultSQLitePath)), 0o750)
+
+	restrictions := `{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":false}`
+	balances := `{"balances":[{"asset":"BTC","free":"1.0","locked":"0"},{"asset":"USDT","free":"25.50","locked":"0"}]}`
+	skewMs := int64(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/time", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"serverTime":%d}`, time.Now().UnixMilli()+skewMs)
+	})
+	mux.HandleFunc("/api/v3/exchangeInfo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"timezone":"UTC","rateLimits":[],"symbols":[{"symbol":"BTCUSDT","status":"TRADING","baseAsset":"BTC","quoteAsset":"USDT"}]}`)
+	})
+	mux.HandleFunc("/api/v3/account", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") == "" || r.URL.Query().Get("signature") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, balances)
+	})
+	mux.HandleFunc("/sapi/v1/account/apiRestrictions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MBX-APIKEY") == "" || r.URL.Query().Get("signature") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, restrictions)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	env := map[string]string{
+		"OPENAI_API_KEY":     "sk-test-0123456789abcdef",
+		"BINANCE_API_KEY":    "key",
+		"BINANCE_API_SECRET": "secret",
+	}
+	run := func() map[string]CheckResult {
+		client, err := binance.NewClient(cfg, binance.Options{BaseURL: server.URL, APIKey: env["BINANCE_API_KEY"], APISecret: env["BINANCE_API_SECRET"]})
+		if err != nil {
+			t.Fatalf("client: %v", err)
+		}
+		results := RunAll(Runner{
+			Cfg:       cfg,
+			Exchange:  client,
+			Getenv:    func(name string) string { return env[name] },
+			FreeBytes: func(string) (int64, error) { return cfg.DiskFreeDegradeBytes * 2, nil },
+		})
+		byName := map[string]CheckResult{}
+		for _, result := range results {
+			byName[result.Name] = result
+		}
+		return byName
+	}
+
+	results := run()
+	for _, name := range []string{"disk_free", "ai_prompts", "openai_key", "binance_credentials", "exchange_clock", "exchange_info", "api_restrictions", "quote_balance"} {
+		if result, ok := results[name]; !ok || !result.OK {
+			t.Fatalf("expected %s to pass, got %+v", name, result)
+		}
+	}
+
+	restrictions = `{"ipRestrict":false,"enableSpotAndMarginTrading":true,"enableWithdrawals":true}`
+	balances = `{"balances":[{"asset":"USDT","free":"9.99","locked":"0"}]}`
+	skewMs = int64(cfg.ClockDriftMaxMsLive) * 4
+	env["OPENAI_API_KEY"] = "not-a-key"
+	results = run()
+	for _, name := range []string{"openai_key", "exchange_clock", "api_restrictions", "quote_balance"} {
+		if results[name].OK {
+			t.Fatalf("expected %s to fail", name)
+		}
+	}
+	env["OPENAI_API_KEY"] = "sk-test-0123456789abcdef"
+	cfg.AIGateProvider = "stub"
+	if results := run(); results["openai_key"].OK {
+		t.Fatalf("expected the stub provider to fail doctor, got %+v", results["openai_key"])
+	}
+	if !strings.Contains(results["api_restrictions"].Details, "withdrawals enabled") || !strings.Contains(results["api_restrictions"].Details, "ip restriction missing") {
+		t.Fatalf("unexpected api_restrictions details: %s", results["api_restrictions"].Details)
+	}
+
+	report := NewReport([]CheckResult{results["exchange_info"], results["quote_balance"]}, time.UnixMilli(1700000000000))
+	buf, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded struct {
+		OK     bool `json:"ok"`
+		Checks []struct {
+			Name string `json:"name"`
+			OK   bool   `json:"ok"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.OK || len(decoded.Checks) != 2 || decoded.Checks[1].Name != "quote_balance" {
+		t.Fatalf("unexpected report: %s", string(buf))
+	}
+}
@@ -19,6 +19,13 @@ const (
 	ORDER_SUBMIT           AuditEventType = "ORDER_SUBMIT"
 	ORDER_CANCEL           AuditEventType = "ORDER_CANCEL"
 	ORDER_CANCEL_REPLACE   AuditEventType = "ORDER_CANCEL_REPLACE"
+	OPERATOR_ACTION        AuditEventType = "OPERATOR_ACTION"
+	ALERT_NOTIFY           AuditEventType = "ALERT_NOTIFY"
+	DAILY_SUMMARY          AuditEventType = "DAILY_SUMMARY"
+	DB_RETENTION           AuditEventType = "DB_RETENTION"
+	DB_BACKUP              AuditEventType = "DB_BACKUP"
+	LIVE_CHECKLIST         AuditEventType = "LIVE_CHECKLIST"
+	SYMBOL_QUARANTINE      AuditEventType = "SYMBOL_QUARANTINE"
 )
 
 var eventTypes = map[AuditEventType]struct{}{
@@ -38,6 +45,13 @@ var eventTypes = map[AuditEventType]struct{}{
 	ORDER_SUBMIT:           {},
 	ORDER_CANCEL:           {},
 	ORDER_CANCEL_REPLACE:   {},
+	OPERATOR_ACTION:        {},
+	ALERT_NOTIFY:           {},
+	DAILY_SUMMARY:          {},
+	DB_RETENTION:           {},
+	DB_BACKUP:              {},
+	LIVE_CHECKLIST:         {},
+	SYMBOL_QUARANTINE:      {},
 }
 
 func IsValidEventType(eventType AuditEventType) bool {
@@ -16,9 +16,14 @@ const (
 	DRIFT_LIMIT_EXCEEDED          ReasonCode = "DRIFT_LIMIT_EXCEEDED"
 	ENTER_DEGRADE                 ReasonCode = "ENTER_DEGRADE"
 	ENTER_EXIT                    ReasonCode = "ENTER_EXIT"
+	ENTER_NORMAL                  ReasonCode = "ENTER_NORMAL"
 	ENTER_PAUSE                   ReasonCode = "ENTER_PAUSE"
+	LIVE_CHECKLIST_FAILED         ReasonCode = "LIVE_CHECKLIST_FAILED"
 	LOOP_STUCK_DEGRADE            ReasonCode = "LOOP_STUCK_DEGRADE"
 	LOOP_STUCK_PAUSE              ReasonCode = "LOOP_STUCK_PAUSE"
+	OPERATOR_FLATTEN              ReasonCode = "OPERATOR_FLATTEN"
+	OPERATOR_PAUSE                ReasonCode = "OPERATOR_PAUSE"
+	OPERATOR_QUARANTINE           ReasonCode = "OPERATOR_QUARANTINE"
 	PAUSE_NEEDS_MANUAL_PROTECTION ReasonCode = "PAUSE_NEEDS_MANUAL_PROTECTION"
 	PAUSE_NEEDS_MANUAL            ReasonCode = "PAUSE_NEEDS_MANUAL"
 	REST_STALE_DEGRADE            ReasonCode = "REST_STALE_DEGRADE"
@@ -26,11 +31,17 @@ const (
 	WS_STALE_DEGRADE              ReasonCode = "WS_STALE_DEGRADE"
 	WS_STALE_PAUSE                ReasonCode = "WS_STALE_PAUSE"
 
-	AIGATE_MODIFY_INVALID ReasonCode = "AIGATE_MODIFY_INVALID"
-	AIGATE_PARSE_FAIL     ReasonCode = "AIGATE_PARSE_FAIL"
-	AIGATE_REASON_UNKNOWN ReasonCode = "AIGATE_REASON_UNKNOWN"
-	AIGATE_SCHEMA_INVALID ReasonCode = "AIGATE_SCHEMA_INVALID"
-	AIGATE_TIMEOUT        ReasonCode = "AIGATE_TIMEOUT"
+	AIGATE_BUDGET_EXHAUSTED ReasonCode = "AIGATE_BUDGET_EXHAUSTED"
+	AIGATE_CIRCUIT_OPEN     ReasonCode = "AIGATE_CIRCUIT_OPEN"
+	AIGATE_ERROR_TIGHTENED  ReasonCode = "AIGATE_ERROR_TIGHTENED"
+	AIGATE_MODIFY_INVALID   ReasonCode = "AIGATE_MODIFY_INVALID"
+	AIGATE_PARSE_FAIL       ReasonCode = "AIGATE_PARSE_FAIL"
+	AIGATE_PAUSE_REQUESTED  ReasonCode = "AIGATE_PAUSE_REQUESTED"
+	AIGATE_REASON_UNKNOWN   ReasonCode = "AIGATE_REASON_UNKNOWN"
+	AIGATE_REPLAY_MISS      ReasonCode = "AIGATE_REPLAY_MISS"
+	AIGATE_SCHEMA_INVALID   ReasonCode = "AIGATE_SCHEMA_INVALID"
+	AIGATE_SKIPPED          ReasonCode = "AIGATE_SKIPPED"
+	AIGATE_TIMEOUT          ReasonCode = "AIGATE_TIMEOUT"
 
 	BINANCE_TIMESTAMP_REJECTED      ReasonCode = "BINANCE_TIMESTAMP_REJECTED"
 	CLIENT_ORDER_ID_INVALID         ReasonCode = "CLIENT_ORDER_ID_INVALID"
@@ -128,9 +139,14 @@ var allCodes = map[ReasonCode]struct{}{
 	DRIFT_LIMIT_EXCEEDED:          {},
 	ENTER_DEGRADE:                 {},
 	ENTER_EXIT:                    {},
+	ENTER_NORMAL:                  {},
 	ENTER_PAUSE:                   {},
+	LIVE_CHECKLIST_FAILED:         {},
 	LOOP_STUCK_DEGRADE:            {},
 	LOOP_STUCK_PAUSE:              {},
+	OPERATOR_FLATTEN:              {},
+	OPERATOR_PAUSE:                {},
+	OPERATOR_QUARANTINE:           {},
 	PAUSE_NEEDS_MANUAL_PROTECTION: {},
 	PAUSE_NEEDS_MANUAL:            {},
 	REST_STALE_DEGRADE:            {},
@@ -138,11 +154,17 @@ var allCodes = map[ReasonCode]struct{}{
 	WS_STALE_DEGRADE:              {},
 	WS_STALE_PAUSE:                {},
 
-	AIGATE_MODIFY_INVALID: {},
-	AIGATE_PARSE_FAIL:     {},
-	AIGATE_REASON_UNKNOWN: {},
-	AIGATE_SCHEMA_INVALID: {},
-	AIGATE_TIMEOUT:        {},
+	AIGATE_BUDGET_EXHAUSTED: {},
+	AIGATE_CIRCUIT_OPEN:     {},
+	AIGATE_ERROR_TIGHTENED:  {},
+	AIGATE_MODIFY_INVALID:   {},
+	AIGATE_PARSE_FAIL:       {},
+	AIGATE_PAUSE_REQUESTED:  {},
+	AIGATE_REASON_UNKNOWN:   {},
+	AIGATE_REPLAY_MISS:      {},
+	AIGATE_SCHEMA_INVALID:   {},
+	AIGATE_SKIPPED:          {},
+	AIGATE_TIMEOUT:          {},
 
 	BINANCE_TIMESTAMP_REJECTED:      {},
 	CLIENT_ORDER_ID_INVALID:         {},
@@ -0,0 +1,536 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/app"
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+type SoakSLO struct {
+	MaxCycleP99Ms            int64
+	MaxHeapGrowthBytes       int64
+	MaxAuditQueuePct         int
+	MaxAuditDrops            int64
+	MaxSysModeChangesPerHour float64
+	MaxPausePct              float64
+	MaxGoroutineLeak         int
+}
+
+func DefaultSoakSLO(cfg config.Config) SoakSLO {
+	return SoakSLO{
+		MaxCycleP99Ms:            250,
+		MaxHeapGrowthBytes:       64 << 20,
+		MaxAuditQueuePct:         cfg.AuditWriterQueueHiWatermark,
+		MaxAuditDrops:            0,
+		MaxSysModeChangesPerHour: 12,
+		MaxPausePct:              5,
+		MaxGoroutineLeak:         2,
+	}
+}
+
+type LoopSoakOptions struct {
+	Config     config.Config
+	DBPath     string
+	JSONLDir   string
+	Symbols    []string
+	Start      time.Time
+	Duration   time.Duration
+	Step       time.Duration
+	Throttle   time.Duration
+	Chaos      bool
+	ChaosEvery time.Duration
+	Seed       int64
+	Reporter   observability.StageReporter
+}
+
+type LoopSoakResult struct {
+	StartTsMs          int64
+	EndTsMs            int64
+	WallMs             int64
+	Cycles             int
+	CycleP50Ms         float64
+	CycleP95Ms         float64
+	CycleP99Ms         float64
+	CycleMaxMs         float64
+	HeapStartBytes     int64
+	HeapEndBytes       int64
+	HeapMaxBytes       int64
+	AuditQueueMaxPct   int
+	AuditLagMaxMs      int64
+	AuditDrops         int64
+	AuditStalls        int64
+	SysModeChanges     int64
+	CyclesByMode       map[string]int
+	GoroutinesStart    int
+	GoroutinesEnd      int
+	WSEvents           int64
+	WSReconnects       int64
+	WSGaps             int64
+	RESTOk             int64
+	RESTErrors         int64
+	FaultsInjected     []string
+	DiskSignalDisabled bool
+	LoopErr            string
+}
+
+type LoopSoakRunner struct {
+	Options LoopSoakOptions
+}
+
+func (r *LoopSoakRunner) Run(ctx context.Context) (LoopSoakResult, error) {
+	opts := r.Options
+	if opts.Duration <= 0 {
+		return LoopSoakResult{}, fmt.Errorf("duration invalid")
+	}
+	if opts.DBPath == "" || opts.JSONLDir == "" {
+		return LoopSoakResult{}, fmt.Errorf("audit paths missing")
+	}
+	if opts.Step <= 0 {
+		opts.Step = 500 * time.Millisecond
+	}
+	if opts.ChaosEvery <= 0 {
+		opts.ChaosEvery = 30 * time.Minute
+	}
+	if len(opts.Symbols) == 0 {
+		opts.Symbols = []string{"BTCUSDT", "ETHUSDT"}
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Now().UTC()
+	}
+	cfg := opts.Config
+	result := LoopSoakResult{GoroutinesStart: runtime.NumGoroutine(), CyclesByMode: map[string]int{}}
+	if _, err := health.FreeBytes(opts.DBPath); err != nil {
+		cfg.DiskFreeDegradeBytes = -1
+		cfg.DiskFreePauseBytes = -1
+		result.DiskSignalDisabled = true
+	}
+
+	clock := &simClock{}
+	clock.Set(opts.Start)
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: opts.DBPath, JSONLDir: opts.JSONLDir, Now: time.Now})
+	if err != nil {
+		return LoopSoakResult{}, err
+	}
+	exchange := NewMockExchange(time.Now)
+	market := newSyntheticMarket(opts.Symbols, opts.Seed)
+	market.publish(exchange)
+	server := NewMockServer(exchange, MockServerOptions{APIKey: "soak", APISecret: "soak", WSInterval: time.Millisecond})
+	transport := &http.Transport{}
+	client, err := binance.NewClient(cfg, binance.Options{
+		BaseURL:    server.URL,
+		APIKey:     "soak",
+		APISecret:  "soak",
+		HTTPClient: &http.Client{Timeout: time.Second, Transport: transport},
+	})
+	if err != nil {
+		server.Close()
+		_ = writer.Close()
+		return LoopSoakResult{}, err
+	}
+	loop, err := app.NewLoop(cfg, writer, opts.Reporter, clock.Now)
+	if err != nil {
+		server.Close()
+		_ = writer.Close()
+		return LoopSoakResult{}, err
+	}
+
+	feeds := &soakFeeds{}
+	feedCtx, stopFeeds := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		feeds.runWS(feedCtx, binance.NewWSClient(binance.WSOptions{BaseURL: server.WSURL()}), opts.Symbols)
+	}()
+	go func() {
+		defer wg.Done()
+		feeds.runREST(feedCtx, client)
+	}()
+	teardown := func() {
+		stopFeeds()
+		wg.Wait()
+		server.Close()
+		transport.CloseIdleConnections()
+	}
+	if err := feeds.waitReady(ctx, 5*time.Second); err != nil {
+		teardown()
+		_ = writer.Close()
+		return LoopSoakResult{}, err
+	}
+	feeds.pump(loop, clock.Now())
+
+	meter := newSoakMeter()
+	chaos := soakChaos{every: opts.ChaosEvery, next: opts.Start.Add(opts.ChaosEvery)}
+	end := opts.Start.Add(opts.Duration)
+	loopCtx, stopLoop := context.WithCancel(ctx)
+	defer stopLoop()
+	wallStart := time.Now()
+	cycleStart := wallStart
+	loop.EnableHooks(app.Hooks{
+		AfterCycle: func(info app.CycleInfo) {
+			meter.observeCycle(time.Since(cycleStart), info, writer.Stats())
+			clock.Advance(opts.Step)
+			market.step()
+			market.publish(exchange)
+			if opts.Chaos {
+				if name := chaos.maybeInject(clock.Now(), exchange, server); name != "" {
+					result.FaultsInjected = append(result.FaultsInjected, name)
+				}
+			}
+			feeds.pump(loop, clock.Now())
+			if !clock.Now().Before(end) {
+				stopLoop()
+			}
+			if opts.Throttle > 0 {
+				time.Sleep(opts.Throttle)
+			}
+			cycleStart = time.Now()
+		},
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			clock.Advance(d)
+			feeds.pump(loop, clock.Now())
+			return waitCtx(ctx, time.Millisecond)
+		},
+	})
+	loopErr := loop.Run(loopCtx)
+	if loopErr != nil && loopCtx.Err() == nil {
+		result.LoopErr = loopErr.Error()
+	}
+	result.WallMs = time.Since(wallStart).Milliseconds()
+	stats := writer.Stats()
+	teardown()
+	if err := writer.Close(); err != nil && result.LoopErr == "" {
+		result.LoopErr = fmt.Sprintf("audit writer close: %v", err)
+	}
+
+	meter.finish(&result, stats)
+	result.StartTsMs = opts.Start.UnixMilli()
+	result.EndTsMs = clock.Now().UnixMilli()
+	result.WSEvents = feeds.wsEvents.Load()
+	result.WSReconnects = feeds.wsReconnects.Load()
+	result.WSGaps = feeds.wsGaps.Load()
+	result.RESTOk = feeds.restOK.Load()
+	result.RESTErrors = feeds.restErrors.Load()
+	result.GoroutinesEnd = settleGoroutines(result.GoroutinesStart, 2*time.Second)
+	return result, nil
+}
+
+func BuildLoopReadinessReport(runID string, result LoopSoakResult, slo SoakSLO, configOK bool) ReadinessReport {
+	simHours := float64(result.EndTsMs-result.StartTsMs) / float64(time.Hour.Milliseconds())
+	changesPerHour := 0.0
+	if simHours > 0 {
+		changesPerHour = float64(result.SysModeChanges) / simHours
+	}
+	pausePct := 0.0
+	if result.Cycles > 0 {
+		pausePct = float64(result.CyclesByMode[string(health.SysModePause)]) * 100 / float64(result.Cycles)
+	}
+	heapGrowth := result.HeapEndBytes - result.HeapStartBytes
+	leak := result.GoroutinesEnd - result.GoroutinesStart
+	checks := []ReadinessCheck{
+		{Name: "config_validated", Pass: configOK},
+		{Name: "loop_completed", Pass: result.LoopErr == "" && result.Cycles > 0, Detail: fmt.Sprintf("cycles=%d err=%s", result.Cycles, result.LoopErr)},
+		{Name: "cycle_latency_p99", Pass: result.CycleP99Ms <= float64(slo.MaxCycleP99Ms), Detail: fmt.Sprintf("p50=%.2fms p95=%.2fms p99=%.2fms max=%.2fms limit=%dms", result.CycleP50Ms, result.CycleP95Ms, result.CycleP99Ms, result.CycleMaxMs, slo.MaxCycleP99Ms)},
+		{Name: "heap_growth", Pass: heapGrowth <= slo.MaxHeapGrowthBytes, Detail: fmt.Sprintf("growth=%d max_heap=%d limit=%d", heapGrowth, result.HeapMaxBytes, slo.MaxHeapGrowthBytes)},
+		{Name: "audit_queue_saturation", Pass: result.AuditQueueMaxPct < slo.MaxAuditQueuePct, Detail: fmt.Sprintf("max_queue_pct=%d max_lag_ms=%d limit=%d", result.AuditQueueMaxPct, result.AuditLagMaxMs, slo.MaxAuditQueuePct)},
+		{Name: "audit_drops", Pass: result.AuditDrops <= slo.MaxAuditDrops, Detail: fmt.Sprintf("drops=%d stalls=%d limit=%d", result.AuditDrops, result.AuditStalls, slo.MaxAuditDrops)},
+		{Name: "sysmode_transitions", Pass: changesPerHour <= slo.MaxSysModeChangesPerHour, Detail: fmt.Sprintf("changes=%d per_hour=%.2f limit=%.2f", result.SysModeChanges, changesPerHour, slo.MaxSysModeChangesPerHour)},
+		{Name: "pause_ratio", Pass: pausePct <= slo.MaxPausePct, Detail: fmt.Sprintf("pause_pct=%.2f limit=%.2f", pausePct, slo.MaxPausePct)},
+		{Name: "goroutine_leak", Pass: leak <= slo.MaxGoroutineLeak, Detail: fmt.Sprintf("start=%d end=%d limit=%d", result.GoroutinesStart, result.GoroutinesEnd, slo.MaxGoroutineLeak)},
+	}
+	ready := true
+	for _, check := range checks {
+		if !check.Pass {
+			ready = false
+			break
+		}
+	}
+	measurements := result
+	return ReadinessReport{
+		RunID:        runID,
+		StartTsMs:    result.StartTsMs,
+		EndTsMs:      result.EndTsMs,
+		DurationMs:   result.EndTsMs - result.StartTsMs,
+		Checks:       checks,
+		Ready:        ready,
+		Measurements: &measurements,
+	}
+}
+
+type simClock struct {
+	nanos atomic.Int64
+}
+
+func (c *simClock) Set(t time.Time) {
+	c.nanos.Store(t.UnixNano())
+}
+
+func (c *simClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load()).UTC()
+}
+
+func (c *simClock) Advance(d time.Duration) {
+	c.nanos.Add(int64(d))
+}
+
+type soakFeeds struct {
+	wsEvents     atomic.Int64
+	wsReconnects atomic.Int64
+	wsGaps       atomic.Int64
+	restOK       atomic.Int64
+	restErrors   atomic.Int64
+	seenWS       int64
+	seenREST     int64
+}
+
+func (f *soakFeeds) runWS(ctx context.Context, ws *binance.WSClient, symbols []string) {
+	for {
+		var lastID int64
+		_ = ws.Run(ctx, symbols, func(ev binance.BookTickerEvent) {
+			if lastID > 0 && ev.UpdateID > lastID+1 {
+				f.wsGaps.Add(1)
+			}
+			lastID = ev.UpdateID
+			f.wsEvents.Add(1)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		f.wsReconnects.Add(1)
+		if waitCtx(ctx, 20*time.Millisecond) != nil {
+			return
+		}
+	}
+}
+
+func (f *soakFeeds) runREST(ctx context.Context, client *binance.Client) {
+	for {
+		_, err := client.Account(ctx)
+		if err == nil {
+			_, err = client.OpenOrders(ctx, nil)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		delay := 20 * time.Millisecond
+		if err != nil {
+			f.restErrors.Add(1)
+			delay = 100 * time.Millisecond
+		} else {
+			f.restOK.Add(1)
+		}
+		if waitCtx(ctx, delay) != nil {
+			return
+		}
+	}
+}
+
+func (f *soakFeeds) waitReady(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for f.wsEvents.Load() == 0 || f.restOK.Load() == 0 {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("soak feeds not ready: ws_events=%d rest_ok=%d rest_errors=%d", f.wsEvents.Load(), f.restOK.Load(), f.restErrors.Load())
+		}
+		if err := waitCtx(ctx, 5*time.Millisecond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *soakFeeds) pump(loop *app.Loop, now time.Time) {
+	if n := f.wsEvents.Load(); n != f.seenWS {
+		f.seenWS = n
+		loop.UpdateWSLastMsg(now)
+	}
+	if n := f.restOK.Load(); n != f.seenREST {
+		f.seenREST = n
+		loop.UpdateRESTLastSuccess(now)
+	}
+}
+
+type syntheticMarket struct {
+	symbols []string
+	prices  []float64
+	rng     *rand.Rand
+}
+
+func newSyntheticMarket(symbols []string, seed int64) *syntheticMarket {
+	prices := make([]float64, len(symbols))
+	for i := range prices {
+		prices[i] = 100 * float64(i+1)
+	}
+	return &syntheticMarket{symbols: symbols, prices: prices, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (m *syntheticMarket) step() {
+	for i := range m.prices {
+		m.prices[i] *= 1 + m.rng.NormFloat64()*0.0005
+		if m.prices[i] < 0.01 {
+			m.prices[i] = 0.01
+		}
+	}
+}
+
+func (m *syntheticMarket) publish(exchange *MockExchange) {
+	for i, symbol := range m.symbols {
+		price := m.prices[i]
+		bid := strconv.FormatFloat(price*0.9999, 'f', 4, 64)
+		ask := strconv.FormatFloat(price*1.0001, 'f', 4, 64)
+		payload, _ := json.Marshal(map[string]any{
+			"lastUpdateId": 1,
+			"bids":         [][]string{{bid, "1.0"}},
+			"asks":         [][]string{{ask, "1.0"}},
+		})
+		exchange.SetDepth(symbol, payload)
+	}
+}
+
+type soakChaos struct {
+	every time.Duration
+	next  time.Time
+	round int
+}
+
+func (c *soakChaos) maybeInject(now time.Time, exchange *MockExchange, server *MockServer) string {
+	if now.Before(c.next) {
+		return ""
+	}
+	c.next = now.Add(c.every)
+	c.round++
+	switch c.round % 6 {
+	case 1:
+		server.DisconnectStreams()
+		exchange.Inject(Fault{Kind: FaultWSGap, AfterMsgs: 5, Skip: 10})
+		return "ws_disconnect_gap"
+	case 2:
+		exchange.Inject(Fault{Op: OpAccount, Kind: FaultRateLimited, Count: 3, RetryAfter: time.Second})
+		return "rest_429"
+	case 3:
+		exchange.Inject(Fault{Op: OpAccount, Kind: FaultLatency, Count: 5, Latency: 300 * time.Millisecond})
+		return "rest_latency"
+	case 4:
+		exchange.Inject(Fault{Op: OpOpenOrders, Kind: FaultTimestampSkew})
+		return "rest_1021"
+	case 5:
+		exchange.Inject(Fault{Kind: FaultIPBanned, Count: 2, RetryAfter: time.Minute})
+		return "rest_418"
+	default:
+		exchange.Inject(Fault{Kind: FaultWSDisconnect, AfterMsgs: 0, Count: 10})
+		server.DisconnectStreams()
+		return "ws_outage"
+	}
+}
+
+type soakMeter struct {
+	durations []time.Duration
+	byMode    map[string]int
+	changes   int64
+	queueMax  int
+	lagMax    int64
+	heapStart int64
+	heapMax   int64
+}
+
+const soakWarmupCycles = 20
+
+func newSoakMeter() *soakMeter {
+	return &soakMeter{byMode: map[string]int{}}
+}
+
+func (m *soakMeter) observeCycle(d time.Duration, info app.CycleInfo, stats audit.WriterStats) {
+	m.durations = append(m.durations, d)
+	m.byMode[string(info.SysMode)]++
+	m.changes = info.SysModeChanges
+	if stats.QueuePct > m.queueMax {
+		m.queueMax = stats.QueuePct
+	}
+	if stats.LagMs > m.lagMax {
+		m.lagMax = stats.LagMs
+	}
+	n := len(m.durations)
+	if n == soakWarmupCycles {
+		m.heapStart = heapAfterGC()
+	}
+	if n%1000 == 0 {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		if int64(ms.HeapAlloc) > m.heapMax {
+			m.heapMax = int64(ms.HeapAlloc)
+		}
+	}
+}
+
+func (m *soakMeter) finish(result *LoopSoakResult, stats audit.WriterStats) {
+	result.Cycles = len(m.durations)
+	result.CyclesByMode = m.byMode
+	result.SysModeChanges = m.changes
+	result.AuditQueueMaxPct = m.queueMax
+	result.AuditLagMaxMs = m.lagMax
+	result.AuditDrops = stats.DropsTotal
+	result.AuditStalls = stats.StallsTotal
+	result.HeapEndBytes = heapAfterGC()
+	result.HeapStartBytes = m.heapStart
+	if result.HeapStartBytes == 0 {
+		result.HeapStartBytes = result.HeapEndBytes
+	}
+	result.HeapMaxBytes = max(m.heapMax, result.HeapEndBytes)
+	sorted := append([]time.Duration(nil), m.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result.CycleP50Ms = percentileMs(sorted, 50)
+	result.CycleP95Ms = percentileMs(sorted, 95)
+	result.CycleP99Ms = percentileMs(sorted, 99)
+	result.CycleMaxMs = percentileMs(sorted, 100)
+}
+
+func percentileMs(sorted []time.Duration, pct int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (len(sorted)*pct+99)/100 - 1
+	idx = min(max(idx, 0), len(sorted)-1)
+	return float64(sorted[idx].Microseconds()) / 1000
+}
+
+func heapAfterGC() int64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return int64(ms.HeapAlloc)
+}
+
+func settleGoroutines(target int, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	n := runtime.NumGoroutine()
+	for n > target && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		n = runtime.NumGoroutine()
+	}
+	return n
+}
+
+func waitCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package e2e
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+func TestLoopSoakRunsRealLoopUnderChaos(t *testing.T) {
+	cfg := config.Default()
+	tmp := t.TempDir()
+	runner := LoopSoakRunner{Options: LoopSoakOptions{
+		Config:     cfg,
+		DBPath:     filepath.Join(tmp, "data", "audit.sqlite"),
+		JSONLDir:   filepath.Join(tmp, "logs"),
+		Start:      time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Duration:   10 * time.Minute,
+		Step:       500 * time.Millisecond,
+		Throttle:   time.Millisecond,
+		Chaos:      true,
+		ChaosEvery: 90 * time.Second,
+		Seed:       7,
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	result, err := runner.Run(ctx)
+	if err != nil {
+		t.Fatalf("soak: %v", err)
+	}
+	if result.Cycles == 0 || result.CyclesByMode["NORMAL"] == 0 || result.EndTsMs-result.StartTsMs < (10*time.Minute).Milliseconds() {
+		t.Fatalf("expected 10 simulated minutes of cycles: %+v", result)
+	}
+	if len(result.FaultsInjected) != 6 || result.WSReconnects == 0 || result.RESTErrors == 0 {
+		t.Fatalf("expected injected faults to reach the feeds: %+v", result)
+	}
+	if result.CycleP50Ms <= 0 || result.CycleP99Ms < result.CycleP50Ms || result.HeapEndBytes == 0 {
+		t.Fatalf("expected latency and heap measurements: %+v", result)
+	}
+
+	report := BuildLoopReadinessReport("run_soak", result, DefaultSoakSLO(cfg), true)
+	checks := map[string]ReadinessCheck{}
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	for _, name := range []string{"loop_completed", "audit_drops", "goroutine_leak"} {
+		if !checks[name].Pass {
+			t.Fatalf("expected %s to pass: %+v", name, checks[name])
+		}
+	}
+	if report.Measurements == nil || len(report.Checks) != 9 {
+		t.Fatalf("expected measurements and SLO checks: %+v", report)
+	}
+
+	strict := DefaultSoakSLO(cfg)
+	strict.MaxCycleP99Ms = -1
+	if BuildLoopReadinessReport("run_soak", result, strict, true).Ready {
+		t.Fatalf("expected a failing SLO to block readiness")
+	}
+}
@@ -1,16 +1,80 @@
 package e2e
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 )
 
+const (
+	OpTime          = "Time"
+	OpExchangeInfo  = "ExchangeInfo"
+	OpDepth         = "Depth"
+	OpNewOrder      = "NewOrder"
+	OpQueryOrder    = "QueryOrder"
+	OpCancelOrder   = "CancelOrder"
+	OpCancelReplace = "CancelReplace"
+	OpOpenOrders    = "OpenOrders"
+	OpAllOrders     = "AllOrders"
+	OpAccount       = "Account"
+	OpWSStream      = "WSStream"
+)
+
+type FaultKind string
+
+const (
+	FaultLatency            FaultKind = "LATENCY"
+	FaultTimeoutAfterAccept FaultKind = "TIMEOUT_AFTER_ACCEPT"
+	FaultRateLimited        FaultKind = "RATE_LIMITED"
+	FaultIPBanned           FaultKind = "IP_BANNED"
+	FaultRejected           FaultKind = "REJECTED"
+	FaultTimestampSkew      FaultKind = "TIMESTAMP_SKEW"
+	FaultPartialFill        FaultKind = "PARTIAL_FILL"
+	FaultSilentCancel       FaultKind = "SILENT_CANCEL"
+	FaultWSDisconnect       FaultKind = "WS_DISCONNECT"
+	FaultWSGap              FaultKind = "WS_GAP"
+)
+
+type Fault struct {
+	Op         string
+	Kind       FaultKind
+	Count      int
+	Latency    time.Duration
+	RetryAfter time.Duration
+	FillQty    string
+	AfterMsgs  int
+	Skip       int
+}
+
+type MockError struct {
+	Status     int
+	Code       int
+	Msg        string
+	RetryAfter time.Duration
+}
+
+func (e MockError) Error() string {
+	return fmt.Sprintf("mock exchange %d (%d): %s", e.Status, e.Code, e.Msg)
+}
+
+var ErrMockTimeout = fmt.Errorf("mock exchange timeout after accept: %w", context.DeadlineExceeded)
+
 type MockExchange struct {
 	Now           func() time.Time
 	FiltersJSON   []byte
 	DepthBySymbol map[string][]byte
 	BalancesJSON  []byte
-	orders        map[string]OrderResponse
+
+	mu          sync.Mutex
+	clockSkew   time.Duration
+	orders      map[string]OrderResponse
+	nextOrderID int64
+	faults      []Fault
+	calls       map[string]int
 }
 
 func NewMockExchange(now func() time.Time) *MockExchange {
@@ -21,18 +85,96 @@ func NewMockExchange(now func() time.Time) *MockExchange {
 		Now:           now,
 		DepthBySymbol: map[string][]byte{},
 		orders:        map[string]OrderResponse{},
+		calls:         map[string]int{},
+	}
+}
+
+func (m *MockExchange) Inject(faults ...Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, fault := range faults {
+		if fault.Count == 0 {
+			fault.Count = 1
+		}
+		m.faults = append(m.faults, fault)
+	}
+}
+
+func (m *MockExchange) ClearFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = nil
+}
+
+func (m *MockExchange) Calls(op string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[op]
+}
+
+func (m *MockExchange) Fill(clientOrderID string, qty string) (OrderResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp, ok := m.orders[clientOrderID]
+	if !ok {
+		return OrderResponse{}, errors.New("order not found")
 	}
+	resp = applyFill(resp, qty, m.Now().UnixMilli())
+	m.orders[clientOrderID] = resp
+	return resp, nil
+}
+
+func (m *MockExchange) SilentCancel(clientOrderID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp, ok := m.orders[clientOrderID]
+	if !ok {
+		return errors.New("order not found")
+	}
+	resp.Status = "CANCELED"
+	resp.UpdatedTsMs = m.Now().UnixMilli()
+	m.orders[clientOrderID] = resp
+	return nil
+}
+
+func (m *MockExchange) SetDepth(symbol string, payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DepthBySymbol[symbol] = payload
+}
+
+func (m *MockExchange) SetClockSkew(skew time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clockSkew = skew
+}
+
+func (m *MockExchange) ServerTime() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Now().Add(m.clockSkew)
 }
 
 func (m *MockExchange) Time(ctx Context) (int64, error) {
-	return m.Now().UnixMilli(), nil
+	if _, err := m.enter(ctx, OpTime); err != nil {
+		return 0, err
+	}
+	return m.ServerTime().UnixMilli(), nil
 }
 
 func (m *MockExchange) ExchangeInfo(ctx Context) ([]byte, error) {
+	if _, err := m.enter(ctx, OpExchangeInfo); err != nil {
+		return nil, err
+	}
 	return m.FiltersJSON, nil
 }
 
 func (m *MockExchange) Depth(ctx Context, symbol string, limit int) ([]byte, error) {
+	if _, err := m.enter(ctx, OpDepth); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if payload, ok := m.DepthBySymbol[symbol]; ok {
 		return payload, nil
 	}
@@ -40,43 +182,54 @@ func (m *MockExchange) Depth(ctx Context, symbol string, limit int) ([]byte, err
 }
 
 func (m *MockExchange) NewOrder(ctx Context, req OrderRequest) (OrderResponse, error) {
-	now := m.Now().UnixMilli()
-	resp := OrderResponse{
-		Symbol:        req.Symbol,
-		ClientOrderID: req.ClientOrderID,
-		Status:        "NEW",
-		Price:         req.Price,
-		Qty:           req.Qty,
-		Side:          req.Side,
-		CreatedTsMs:   now,
-		UpdatedTsMs:   now,
+	fault, err := m.enter(ctx, OpNewOrder)
+	if err != nil {
+		return OrderResponse{}, err
 	}
-	m.orders[req.ClientOrderID] = resp
-	return resp, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.newOrderLocked(req, fault)
 }
 
 func (m *MockExchange) QueryOrder(ctx Context, symbol string, clientOrderID string) (OrderResponse, error) {
+	if _, err := m.enter(ctx, OpQueryOrder); err != nil {
+		return OrderResponse{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	resp, ok := m.orders[clientOrderID]
 	if !ok {
-		return OrderResponse{}, errors.New("order not found")
+		return OrderResponse{}, orderNotFound()
 	}
 	return resp, nil
 }
 
 func (m *MockExchange) CancelOrder(ctx Context, symbol string, clientOrderID string) (OrderResponse, error) {
-	resp, ok := m.orders[clientOrderID]
-	if !ok {
-		return OrderResponse{}, errors.New("order not found")
+	fault, err := m.enter(ctx, OpCancelOrder)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp, err := m.cancelLocked(clientOrderID)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	if fault.Kind == FaultTimeoutAfterAccept {
+		return OrderResponse{}, ErrMockTimeout
 	}
-	resp.Status = "CANCELED"
-	resp.UpdatedTsMs = m.Now().UnixMilli()
-	m.orders[clientOrderID] = resp
 	return resp, nil
 }
 
 func (m *MockExchange) CancelReplace(ctx Context, req CancelReplaceRequest) (OrderResponse, error) {
+	fault, err := m.enter(ctx, OpCancelReplace)
+	if err != nil {
+		return OrderResponse{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if req.CancelClientOrderID != "" {
-		if _, err := m.CancelOrder(ctx, req.Symbol, req.CancelClientOrderID); err != nil {
+		if _, err := m.cancelLocked(req.CancelClientOrderID); err != nil {
 			return OrderResponse{}, err
 		}
 	}
@@ -87,35 +240,201 @@ func (m *MockExchange) CancelReplace(ctx Context, req CancelReplaceRequest) (Ord
 	if newID == "" {
 		return OrderResponse{}, errors.New("new client order id missing")
 	}
-	return m.NewOrder(ctx, OrderRequest{
+	return m.newOrderLocked(OrderRequest{
 		Symbol:        req.Symbol,
 		Side:          req.Side,
 		Price:         req.Price,
 		Qty:           req.Qty,
 		ClientOrderID: newID,
-	})
+	}, fault)
 }
 
 func (m *MockExchange) OpenOrders(ctx Context, symbol string) ([]OrderResponse, error) {
+	if _, err := m.enter(ctx, OpOpenOrders); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	out := make([]OrderResponse, 0, len(m.orders))
 	for _, order := range m.orders {
-		if order.Symbol == symbol && order.Status == "NEW" {
+		if (symbol == "" || order.Symbol == symbol) && (order.Status == "NEW" || order.Status == "PARTIALLY_FILLED") {
 			out = append(out, order)
 		}
 	}
+	sortOrders(out)
 	return out, nil
 }
 
 func (m *MockExchange) AllOrders(ctx Context, symbol string, limit int) ([]OrderResponse, error) {
+	if _, err := m.enter(ctx, OpAllOrders); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	out := make([]OrderResponse, 0, len(m.orders))
 	for _, order := range m.orders {
 		if symbol == "" || order.Symbol == symbol {
 			out = append(out, order)
 		}
 	}
+	sortOrders(out)
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
 	return out, nil
 }
 
 func (m *MockExchange) Account(ctx Context) ([]byte, error) {
+	if _, err := m.enter(ctx, OpAccount); err != nil {
+		return nil, err
+	}
 	return m.BalancesJSON, nil
 }
+
+func (m *MockExchange) enter(ctx Context, op string) (Fault, error) {
+	m.mu.Lock()
+	m.calls[op]++
+	var latency time.Duration
+	var fault Fault
+	for i := 0; i < len(m.faults); i++ {
+		f := m.faults[i]
+		if f.Op != "" && f.Op != op {
+			continue
+		}
+		if f.Kind == FaultWSDisconnect || f.Kind == FaultWSGap {
+			continue
+		}
+		if f.Kind == FaultLatency {
+			latency += f.Latency
+		} else if fault.Kind == "" {
+			fault = f
+		} else {
+			continue
+		}
+		if f.Count > 0 {
+			m.faults[i].Count--
+			if m.faults[i].Count == 0 {
+				m.faults = append(m.faults[:i], m.faults[i+1:]...)
+				i--
+			}
+		}
+	}
+	m.mu.Unlock()
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Fault{}, ErrMockTimeout
+		case <-timer.C:
+		}
+	}
+	switch fault.Kind {
+	case FaultRateLimited:
+		return fault, MockError{Status: 429, Code: -1003, Msg: "Too many requests; please use the websocket for live updates.", RetryAfter: fault.RetryAfter}
+	case FaultIPBanned:
+		return fault, MockError{Status: 418, Code: -1003, Msg: "Way too many requests; IP banned.", RetryAfter: fault.RetryAfter}
+	case FaultRejected:
+		return fault, MockError{Status: 400, Code: -2010, Msg: "Account has insufficient balance for requested action."}
+	case FaultTimestampSkew:
+		return fault, timestampOutside()
+	}
+	return fault, nil
+}
+
+func (m *MockExchange) takeWSFault(kind FaultKind) (Fault, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, f := range m.faults {
+		if f.Kind != kind || (f.Op != "" && f.Op != OpWSStream) {
+			continue
+		}
+		if f.Count > 0 {
+			m.faults[i].Count--
+			if m.faults[i].Count == 0 {
+				m.faults = append(m.faults[:i], m.faults[i+1:]...)
+			}
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+func (m *MockExchange) newOrderLocked(req OrderRequest, fault Fault) (OrderResponse, error) {
+	if existing, ok := m.orders[req.ClientOrderID]; ok && existing.Status != "CANCELED" {
+		return OrderResponse{}, MockError{Status: 400, Code: -2010, Msg: "Duplicate order sent."}
+	}
+	now := m.Now().UnixMilli()
+	m.nextOrderID++
+	resp := OrderResponse{
+		OrderID:       m.nextOrderID,
+		Symbol:        req.Symbol,
+		ClientOrderID: req.ClientOrderID,
+		Status:        "NEW",
+		Price:         req.Price,
+		Qty:           req.Qty,
+		ExecutedQty:   "0",
+		Side:          req.Side,
+		CreatedTsMs:   now,
+		UpdatedTsMs:   now,
+	}
+	switch fault.Kind {
+	case FaultPartialFill:
+		resp = applyFill(resp, fault.FillQty, now)
+	case FaultSilentCancel:
+		stored := resp
+		stored.Status = "CANCELED"
+		m.orders[req.ClientOrderID] = stored
+		return resp, nil
+	}
+	m.orders[req.ClientOrderID] = resp
+	if fault.Kind == FaultTimeoutAfterAccept {
+		return OrderResponse{}, ErrMockTimeout
+	}
+	return resp, nil
+}
+
+func (m *MockExchange) cancelLocked(clientOrderID string) (OrderResponse, error) {
+	resp, ok := m.orders[clientOrderID]
+	if !ok {
+		return OrderResponse{}, MockError{Status: 400, Code: -2011, Msg: "Unknown order sent."}
+	}
+	if resp.Status != "NEW" && resp.Status != "PARTIALLY_FILLED" {
+		return OrderResponse{}, MockError{Status: 400, Code: -2011, Msg: "Unknown order sent."}
+	}
+	resp.Status = "CANCELED"
+	resp.UpdatedTsMs = m.Now().UnixMilli()
+	m.orders[clientOrderID] = resp
+	return resp, nil
+}
+
+func applyFill(resp OrderResponse, qty string, nowMs int64) OrderResponse {
+	executed, _ := strconv.ParseFloat(resp.ExecutedQty, 64)
+	orig, _ := strconv.ParseFloat(resp.Qty, 64)
+	add, err := strconv.ParseFloat(qty, 64)
+	if err != nil || add <= 0 {
+		add = orig / 2
+	}
+	executed += add
+	if orig > 0 && executed >= orig {
+		executed = orig
+		resp.Status = "FILLED"
+	} else {
+		resp.Status = "PARTIALLY_FILLED"
+	}
+	resp.ExecutedQty = strconv.FormatFloat(executed, 'f', -1, 64)
+	resp.UpdatedTsMs = nowMs
+	return resp
+}
+
+func sortOrders(orders []OrderResponse) {
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID < orders[j].OrderID })
+}
+
+func orderNotFound() error {
+	return MockError{Status: 400, Code: -2013, Msg: "Order does not exist."}
+}
+
+func timestampOutside() error {
+	return MockError{Status: 400, Code: -1021, Msg: "Timestamp for this request is outside of the recvWindow."}
+}
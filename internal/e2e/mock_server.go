@@ -0,0 +1,589 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/gorilla/websocket"
+)
+
+type MockServerOptions struct {
+	APIKey     string
+	APISecret  string
+	HangMax    time.Duration
+	WSInterval time.Duration
+}
+
+type MockServer struct {
+	*httptest.Server
+	Exchange *MockExchange
+
+	opts     MockServerOptions
+	upgrader websocket.Upgrader
+	done     chan struct{}
+	once     sync.Once
+
+	mu           sync.Mutex
+	weightMinute int64
+	weightUsed   int
+	wsUpdateID   int64
+	streams      map[*websocket.Conn]struct{}
+}
+
+func NewMockServer(exchange *MockExchange, opts MockServerOptions) *MockServer {
+	if opts.HangMax <= 0 {
+		opts.HangMax = 5 * time.Second
+	}
+	if opts.WSInterval <= 0 {
+		opts.WSInterval = 10 * time.Millisecond
+	}
+	s := &MockServer{
+		Exchange: exchange,
+		opts:     opts,
+		done:     make(chan struct{}),
+		streams:  map[*websocket.Conn]struct{}{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/time", s.handleTime)
+	mux.HandleFunc("/api/v3/exchangeInfo", s.handleExchangeInfo)
+	mux.HandleFunc("/api/v3/depth", s.handleDepth)
+	mux.HandleFunc("/api/v3/order", s.signed(s.handleOrder))
+	mux.HandleFunc("/api/v3/order/cancelReplace", s.signed(s.handleCancelReplace))
+	mux.HandleFunc("/api/v3/openOrders", s.signed(s.handleOpenOrders))
+	mux.HandleFunc("/api/v3/allOrders", s.signed(s.handleAllOrders))
+	mux.HandleFunc("/api/v3/account", s.signed(s.handleAccount))
+	mux.HandleFunc("/stream", s.handleStream)
+	mux.HandleFunc("/ws-api/v3", s.handleWSAPI)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *MockServer) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *MockServer) WSAPIURL() string {
+	return s.WSURL() + "/ws-api/v3"
+}
+
+func (s *MockServer) DisconnectStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.streams {
+		_ = conn.UnderlyingConn().Close()
+	}
+}
+
+func (s *MockServer) Close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	s.Server.Close()
+}
+
+func (s *MockServer) handleTime(w http.ResponseWriter, r *http.Request) {
+	ts, err := s.Exchange.Time(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, map[string]int64{"serverTime": ts})
+}
+
+func (s *MockServer) handleExchangeInfo(w http.ResponseWriter, r *http.Request) {
+	payload, err := s.Exchange.ExchangeInfo(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(payload) == 0 {
+		s.writeJSON(w, map[string]any{"timezone": "UTC", "serverTime": s.Exchange.ServerTime().UnixMilli(), "rateLimits": []any{}})
+		return
+	}
+	s.writeRaw(w, payload)
+}
+
+func (s *MockServer) handleDepth(w http.ResponseWriter, r *http.Request) {
+	payload, err := s.Exchange.Depth(r.Context(), r.URL.Query().Get("symbol"), 0)
+	if err != nil {
+		s.writeError(w, r, MockError{Status: 400, Code: -1121, Msg: "Invalid symbol."})
+		return
+	}
+	s.writeRaw(w, payload)
+}
+
+func (s *MockServer) handleOrder(w http.ResponseWriter, r *http.Request, params url.Values) {
+	symbol := params.Get("symbol")
+	switch r.Method {
+	case http.MethodPost:
+		clientID := params.Get("newClientOrderId")
+		if clientID == "" {
+			clientID = fmt.Sprintf("mock_%d", time.Now().UnixNano())
+		}
+		resp, err := s.Exchange.NewOrder(r.Context(), OrderRequest{
+			Symbol:        symbol,
+			Side:          params.Get("side"),
+			Price:         params.Get("price"),
+			Qty:           params.Get("quantity"),
+			ClientOrderID: clientID,
+		})
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, orderJSON(resp))
+	case http.MethodGet:
+		resp, err := s.Exchange.QueryOrder(r.Context(), symbol, s.clientOrderID(params, "origClientOrderId"))
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, orderJSON(resp))
+	case http.MethodDelete:
+		resp, err := s.Exchange.CancelOrder(r.Context(), symbol, s.clientOrderID(params, "origClientOrderId"))
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, orderJSON(resp))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *MockServer) handleCancelReplace(w http.ResponseWriter, r *http.Request, params url.Values) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	cancelID := s.clientOrderID(params, "cancelOrigClientOrderId")
+	resp, err := s.Exchange.CancelReplace(r.Context(), CancelReplaceRequest{
+		Symbol:              params.Get("symbol"),
+		Side:                params.Get("side"),
+		Price:               params.Get("price"),
+		Qty:                 params.Get("quantity"),
+		CancelClientOrderID: cancelID,
+		NewClientOrderID:    params.Get("newClientOrderId"),
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, map[string]any{
+		"cancelResult":     "SUCCESS",
+		"newOrderResult":   "SUCCESS",
+		"cancelResponse":   map[string]any{"symbol": resp.Symbol, "origClientOrderId": cancelID, "status": "CANCELED"},
+		"newOrderResponse": orderJSON(resp),
+	})
+}
+
+func (s *MockServer) handleOpenOrders(w http.ResponseWriter, r *http.Request, params url.Values) {
+	orders, err := s.Exchange.OpenOrders(r.Context(), params.Get("symbol"))
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, ordersJSON(orders))
+}
+
+func (s *MockServer) handleAllOrders(w http.ResponseWriter, r *http.Request, params url.Values) {
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	orders, err := s.Exchange.AllOrders(r.Context(), params.Get("symbol"), limit)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	s.writeJSON(w, ordersJSON(orders))
+}
+
+func (s *MockServer) handleAccount(w http.ResponseWriter, r *http.Request, params url.Values) {
+	payload, err := s.Exchange.Account(r.Context())
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(payload) == 0 {
+		s.writeJSON(w, map[string]any{"balances": []any{}})
+		return
+	}
+	s.writeRaw(w, payload)
+}
+
+func (s *MockServer) signed(next func(http.ResponseWriter, *http.Request, url.Values)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		if s.opts.APIKey != "" && r.Header.Get("X-MBX-APIKEY") != s.opts.APIKey {
+			s.writeError(w, r, MockError{Status: 401, Code: -2014, Msg: "API-key format invalid."})
+			return
+		}
+		if s.opts.APISecret != "" && !validSignature(params, s.opts.APISecret) {
+			s.writeError(w, r, MockError{Status: 400, Code: -1022, Msg: "Signature for this request is not valid."})
+			return
+		}
+		timestamp, err := strconv.ParseInt(params.Get("timestamp"), 10, 64)
+		if err != nil {
+			s.writeError(w, r, MockError{Status: 400, Code: -1102, Msg: "Mandatory parameter 'timestamp' was not sent."})
+			return
+		}
+		recvWindow, err := strconv.ParseInt(params.Get("recvWindow"), 10, 64)
+		if err != nil || recvWindow <= 0 {
+			recvWindow = 5000
+		}
+		serverMs := s.Exchange.ServerTime().UnixMilli()
+		if timestamp > serverMs+1000 || serverMs-timestamp > recvWindow {
+			s.writeError(w, r, timestampOutside())
+			return
+		}
+		next(w, r, params)
+	}
+}
+
+func (s *MockServer) clientOrderID(params url.Values, key string) string {
+	if id := params.Get(key); id != "" {
+		return id
+	}
+	orderID, err := strconv.ParseInt(params.Get("orderId"), 10, 64)
+	if err != nil {
+		return ""
+	}
+	s.Exchange.mu.Lock()
+	defer s.Exchange.mu.Unlock()
+	for id, order := range s.Exchange.orders {
+		if order.OrderID == orderID {
+			return id
+		}
+	}
+	return ""
+}
+
+func (s *MockServer) writeJSON(w http.ResponseWriter, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.writeRaw(w, payload)
+}
+
+func (s *MockServer) writeRaw(w http.ResponseWriter, payload []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-MBX-USED-WEIGHT-1M", strconv.Itoa(s.useWeight()))
+	_, _ = w.Write(payload)
+}
+
+func (s *MockServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrMockTimeout) {
+		timer := time.NewTimer(s.opts.HangMax)
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		case <-timer.C:
+		}
+		timer.Stop()
+		panic(http.ErrAbortHandler)
+	}
+	var mockErr MockError
+	if !errors.As(err, &mockErr) {
+		mockErr = MockError{Status: 400, Code: -1100, Msg: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-MBX-USED-WEIGHT-1M", strconv.Itoa(s.useWeight()))
+	if mockErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((mockErr.RetryAfter+time.Second-1)/time.Second)))
+	}
+	w.WriteHeader(mockErr.Status)
+	payload, _ := json.Marshal(map[string]any{"code": mockErr.Code, "msg": mockErr.Msg})
+	_, _ = w.Write(payload)
+}
+
+func (s *MockServer) useWeight() int {
+	minute := s.Exchange.Now().Unix() / 60
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if minute != s.weightMinute {
+		s.weightMinute = minute
+		s.weightUsed = 0
+	}
+	s.weightUsed++
+	return s.weightUsed
+}
+
+func (s *MockServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	var symbols []string
+	for _, stream := range strings.Split(r.URL.Query().Get("streams"), "/") {
+		name, _, _ := strings.Cut(stream, "@")
+		if name != "" {
+			symbols = append(symbols, strings.ToUpper(name))
+		}
+	}
+	if len(symbols) == 0 {
+		http.Error(w, "streams missing", http.StatusBadRequest)
+		return
+	}
+	s.Exchange.mu.Lock()
+	s.Exchange.calls[OpWSStream]++
+	s.Exchange.mu.Unlock()
+	disconnect, hasDisconnect := s.Exchange.takeWSFault(FaultWSDisconnect)
+	gap, hasGap := s.Exchange.takeWSFault(FaultWSGap)
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	s.mu.Lock()
+	s.streams[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, conn)
+		s.mu.Unlock()
+	}()
+	ticker := time.NewTicker(s.opts.WSInterval)
+	defer ticker.Stop()
+	sent := 0
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		for _, symbol := range symbols {
+			if hasDisconnect && sent >= disconnect.AfterMsgs {
+				_ = conn.UnderlyingConn().Close()
+				return
+			}
+			step := int64(1)
+			if hasGap && sent == gap.AfterMsgs {
+				step += int64(max(gap.Skip, 1))
+			}
+			updateID := s.nextUpdateID(step)
+			bid, ask := s.bestQuotes(symbol)
+			payload, err := json.Marshal(map[string]any{
+				"stream": strings.ToLower(symbol) + "@bookTicker",
+				"data": map[string]any{
+					"u": updateID,
+					"E": s.Exchange.ServerTime().UnixMilli(),
+					"s": symbol,
+					"b": bid,
+					"B": "1",
+					"a": ask,
+					"A": "1",
+				},
+			})
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+			sent++
+		}
+	}
+}
+
+// handleWSAPI serves order.place, order.cancel and order.cancelReplace from the same exchange state as
+// REST. A timeout fault leaves the request unanswered, like a response lost on the wire.
+func (s *MockServer) handleWSAPI(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	s.mu.Lock()
+	s.streams[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, conn)
+		s.mu.Unlock()
+	}()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	var writeMu sync.Mutex
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req struct {
+			ID     string         `json:"id"`
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		if err := decoder.Decode(&req); err != nil {
+			continue
+		}
+		go func() {
+			result, err := s.wsAPICall(ctx, req.Method, req.Params)
+			reply := map[string]any{"id": req.ID, "status": 200, "result": result}
+			if err != nil {
+				if errors.Is(err, ErrMockTimeout) {
+					return
+				}
+				var mockErr MockError
+				if !errors.As(err, &mockErr) {
+					mockErr = MockError{Status: 400, Code: -1100, Msg: err.Error()}
+				}
+				reply = map[string]any{"id": req.ID, "status": mockErr.Status, "error": map[string]any{"code": mockErr.Code, "msg": mockErr.Msg}}
+			}
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_ = conn.WriteJSON(reply)
+		}()
+	}
+}
+
+func (s *MockServer) wsAPICall(ctx context.Context, method string, raw map[string]any) (any, error) {
+	if method == "ping" {
+		return map[string]any{}, nil
+	}
+	params := url.Values{}
+	for key, value := range raw {
+		params.Set(key, fmt.Sprint(value))
+	}
+	if s.opts.APIKey != "" && params.Get("apiKey") != s.opts.APIKey {
+		return nil, MockError{Status: 401, Code: -2014, Msg: "API-key format invalid."}
+	}
+	if s.opts.APISecret != "" && !validWSAPISignature(raw, s.opts.APISecret) {
+		return nil, MockError{Status: 400, Code: -1022, Msg: "Signature for this request is not valid."}
+	}
+	symbol := params.Get("symbol")
+	switch method {
+	case "order.place":
+		resp, err := s.Exchange.NewOrder(ctx, OrderRequest{
+			Symbol:        symbol,
+			Side:          params.Get("side"),
+			Price:         params.Get("price"),
+			Qty:           params.Get("quantity"),
+			ClientOrderID: params.Get("newClientOrderId"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return orderJSON(resp), nil
+	case "order.cancel":
+		resp, err := s.Exchange.CancelOrder(ctx, symbol, s.clientOrderID(params, "origClientOrderId"))
+		if err != nil {
+			return nil, err
+		}
+		return orderJSON(resp), nil
+	case "order.cancelReplace":
+		cancelID := s.clientOrderID(params, "cancelOrigClientOrderId")
+		resp, err := s.Exchange.CancelReplace(ctx, CancelReplaceRequest{
+			Symbol:              symbol,
+			Side:                params.Get("side"),
+			Price:               params.Get("price"),
+			Qty:                 params.Get("quantity"),
+			CancelClientOrderID: cancelID,
+			NewClientOrderID:    params.Get("newClientOrderId"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"cancelResult":     "SUCCESS",
+			"newOrderResult":   "SUCCESS",
+			"cancelResponse":   map[string]any{"symbol": resp.Symbol, "origClientOrderId": cancelID, "status": "CANCELED"},
+			"newOrderResponse": orderJSON(resp),
+		}, nil
+	default:
+		return nil, MockError{Status: 400, Code: -1100, Msg: "Unsupported method " + method}
+	}
+}
+
+func (s *MockServer) nextUpdateID(step int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wsUpdateID += step
+	return s.wsUpdateID
+}
+
+func (s *MockServer) bestQuotes(symbol string) (string, string) {
+	s.Exchange.mu.Lock()
+	payload := s.Exchange.DepthBySymbol[symbol]
+	s.Exchange.mu.Unlock()
+	var depth struct {
+		Bids [][]string `json:"bids"`
+		Asks [][]string `json:"asks"`
+	}
+	bid, ask := "0", "0"
+	if err := json.Unmarshal(payload, &depth); err == nil {
+		if len(depth.Bids) > 0 && len(depth.Bids[0]) > 0 {
+			bid = depth.Bids[0][0]
+		}
+		if len(depth.Asks) > 0 && len(depth.Asks[0]) > 0 {
+			ask = depth.Asks[0][0]
+		}
+	}
+	return bid, ask
+}
+
+func validSignature(params url.Values, secret string) bool {
+	signature := params.Get("signature")
+	if signature == "" {
+		return false
+	}
+	unsigned := url.Values{}
+	for key, values := range params {
+		if key != "signature" {
+			unsigned[key] = values
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(unsigned.Encode()))
+	return hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature))
+}
+
+func validWSAPISignature(params map[string]any, secret string) bool {
+	signature, _ := params["signature"].(string)
+	if signature == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(binance.WSAPISignaturePayload(params)))
+	return hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature))
+}
+
+func orderJSON(resp OrderResponse) map[string]any {
+	return map[string]any{
+		"symbol":        resp.Symbol,
+		"orderId":       resp.OrderID,
+		"orderListId":   -1,
+		"clientOrderId": resp.ClientOrderID,
+		"price":         resp.Price,
+		"origQty":       resp.Qty,
+		"executedQty":   resp.ExecutedQty,
+		"status":        resp.Status,
+		"type":          "LIMIT",
+		"side":          resp.Side,
+		"transactTime":  resp.CreatedTsMs,
+		"time":          resp.CreatedTsMs,
+		"updateTime":    resp.UpdatedTsMs,
+	}
+}
+
+func ordersJSON(orders []OrderResponse) []map[string]any {
+	out := make([]map[string]any, 0, len(orders))
+	for _, order := range orders {
+		out = append(out, orderJSON(order))
+	}
+	return out
+}
@@ -0,0 +1,400 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/app"
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/risk"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/state"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/universe"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/binance"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+type binanceOrder struct {
+	OrderID       int64  `json:"orderId"`
+	ClientOrderID string `json:"clientOrderId"`
+	Status        string `json:"status"`
+	ExecutedQty   string `json:"executedQty"`
+}
+
+func newMockServerClient(t *testing.T, exchange *MockExchange) (*MockServer, *binance.Client) {
+	t.Helper()
+	server := NewMockServer(exchange, MockServerOptions{APIKey: "key", APISecret: "secret"})
+	t.Cleanup(server.Close)
+	client, err := binance.NewClient(config.Default(), binance.Options{
+		BaseURL:    server.URL,
+		APIKey:     "key",
+		APISecret:  "secret",
+		HTTPClient: &http.Client{Timeout: 300 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return server, client
+}
+
+func newOrderParams(clientOrderID string) url.Values {
+	params := url.Values{}
+	params.Set("symbol", "BTCUSDT")
+	params.Set("side", "BUY")
+	params.Set("type", "LIMIT")
+	params.Set("price", "100")
+	params.Set("quantity", "1")
+	params.Set("newClientOrderId", clientOrderID)
+	return params
+}
+
+func decodeOrder(t *testing.T, resp binance.JSONResponse) binanceOrder {
+	t.Helper()
+	var order binanceOrder
+	if err := json.Unmarshal(resp.Body, &order); err != nil {
+		t.Fatalf("decode order: %v", err)
+	}
+	return order
+}
+
+func TestMockServerInjectsRESTFaults(t *testing.T) {
+	exchange := NewMockExchange(time.Now)
+	_, client := newMockServerClient(t, exchange)
+	ctx := context.Background()
+
+	resp, err := client.NewOrder(ctx, newOrderParams("LS_ok"))
+	if err != nil || decodeOrder(t, resp).Status != "NEW" {
+		t.Fatalf("expected accepted order: %v", err)
+	}
+
+	exchange.SetClockSkew(30 * time.Second)
+	if _, err := client.NewOrder(ctx, newOrderParams("LS_skew")); err != nil {
+		t.Fatalf("expected -1021 resync and retry to succeed: %v", err)
+	}
+	if calls := exchange.Calls(OpTime); calls != 2 {
+		t.Fatalf("expected a time resync after -1021, got %d time calls", calls)
+	}
+
+	exchange.Inject(Fault{Op: OpAccount, Kind: FaultRateLimited, RetryAfter: 2 * time.Second})
+	_, err = client.Account(ctx)
+	var bErr binance.BinanceError
+	if !errors.As(err, &bErr) || bErr.Status != 429 || bErr.Code != -1003 {
+		t.Fatalf("expected 429, got %v", err)
+	}
+	exchange.Inject(Fault{Kind: FaultIPBanned, RetryAfter: time.Minute})
+	_, err = client.OpenOrders(ctx, url.Values{})
+	if !errors.As(err, &bErr) || bErr.Status != 418 {
+		t.Fatalf("expected 418, got %v", err)
+	}
+	if _, err := client.Account(ctx); err != nil {
+		t.Fatalf("faults should be consumed: %v", err)
+	}
+
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultRejected})
+	_, err = client.NewOrder(ctx, newOrderParams("LS_reject"))
+	if !errors.As(err, &bErr) || bErr.Code != -2010 {
+		t.Fatalf("expected -2010, got %v", err)
+	}
+
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultPartialFill, FillQty: "0.4"})
+	resp, err = client.NewOrder(ctx, newOrderParams("LS_partial"))
+	if err != nil {
+		t.Fatalf("partial: %v", err)
+	}
+	if order := decodeOrder(t, resp); order.Status != "PARTIALLY_FILLED" || order.ExecutedQty != "0.4" {
+		t.Fatalf("unexpected partial fill: %+v", order)
+	}
+
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultSilentCancel})
+	resp, err = client.NewOrder(ctx, newOrderParams("LS_silent"))
+	if err != nil || decodeOrder(t, resp).Status != "NEW" {
+		t.Fatalf("silent cancel should acknowledge as NEW: %v", err)
+	}
+	query := url.Values{}
+	query.Set("symbol", "BTCUSDT")
+	query.Set("origClientOrderId", "LS_silent")
+	resp, err = client.QueryOrder(ctx, query)
+	if err != nil || decodeOrder(t, resp).Status != "CANCELED" {
+		t.Fatalf("expected silently canceled order: %v", err)
+	}
+	resp, err = client.OpenOrders(ctx, url.Values{"symbol": {"BTCUSDT"}})
+	if err != nil {
+		t.Fatalf("open orders: %v", err)
+	}
+	var open []binanceOrder
+	if err := json.Unmarshal(resp.Body, &open); err != nil || len(open) != 3 {
+		t.Fatalf("expected 3 open orders, got %d (%v)", len(open), err)
+	}
+}
+
+func TestMockServerSentUnknownResolvesThroughExecutor(t *testing.T) {
+	exchange := NewMockExchange(time.Now)
+	_, client := newMockServerClient(t, exchange)
+	rest := app.NewOrderClient(client)
+	cfg := config.Default()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "audit.sqlite"), cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, time.Now()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ledger := executor.NewLedger(db, time.Now)
+	ctx := context.Background()
+	intent := func(id string) sqlite.OrderIntentRecord {
+		return sqlite.OrderIntentRecord{
+			OrderIntentID: id, RunID: "run_m", CycleID: "cyc_m", Mode: "LIVE", DecisionID: "dec_" + id,
+			Symbol: "BTCUSDT", Action: string(executor.IntentActionNewOrder), ClientOrderID: "LS_" + id, IntentPayloadJSON: "{}",
+		}
+	}
+	order := func(id string) executor.OrderRequest {
+		return executor.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", Type: executor.OrderTypeLimit, Price: "100", Qty: "1", ClientOrderID: "LS_" + id}
+	}
+
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultTimeoutAfterAccept})
+	if _, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("accepted"), order("accepted")); !errors.Is(err, executor.ErrSentUnknown) {
+		t.Fatalf("expected SENT_UNKNOWN, got %v", err)
+	}
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultLatency, Latency: time.Second})
+	if _, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("lost"), order("lost")); !errors.Is(err, executor.ErrSentUnknown) {
+		t.Fatalf("expected SENT_UNKNOWN, got %v", err)
+	}
+	pending, err := ledger.PendingSentUnknown(ctx, 10)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("expected 2 SENT_UNKNOWN intents, got %d (%v)", len(pending), err)
+	}
+	for _, rec := range pending {
+		if err := executor.ResolveSentUnknown(ctx, cfg, ledger, rest, rec); err != nil {
+			t.Fatalf("resolve %s: %v", rec.OrderIntentID, err)
+		}
+	}
+	for id, want := range map[string]executor.IntentState{"accepted": executor.IntentConfirmed, "lost": executor.IntentNotFound} {
+		rec, err := sqlite.GetOrderIntent(ctx, db, id)
+		if err != nil || rec.State != string(want) {
+			t.Fatalf("intent %s: expected %s, got %s (%v)", id, want, rec.State, err)
+		}
+	}
+
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultRejected})
+	resp, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("rejected"), order("rejected"))
+	if err != nil || !resp.Rejected {
+		t.Fatalf("expected rejected order, got %+v (%v)", resp, err)
+	}
+	if rec, err := sqlite.GetOrderIntent(ctx, db, "rejected"); err != nil || rec.State != string(executor.IntentFailed) {
+		t.Fatalf("expected FAILED_TERMINAL, got %s (%v)", rec.State, err)
+	}
+}
+
+func TestMockServerWSAPIOrdersMapToIntentLedger(t *testing.T) {
+	exchange := NewMockExchange(time.Now)
+	server, client := newMockServerClient(t, exchange)
+	cfg := config.Default()
+	cfg.BinanceWSAPIRequestTimeoutMs = 300
+	signer, err := binance.NewHMACSigner("secret")
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	ws := binance.NewWSAPIClient(cfg, binance.WSAPIOptions{URL: server.WSAPIURL(), APIKey: "key", Signer: signer})
+	defer ws.Close()
+	ctx := context.Background()
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	rest := app.NewOrderClient(binance.NewOrderTransport(client, ws))
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "audit.sqlite"), cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, time.Now()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	ledger := executor.NewLedger(db, time.Now)
+	intent := func(id string) sqlite.OrderIntentRecord {
+		return sqlite.OrderIntentRecord{
+			OrderIntentID: id, RunID: "run_w", CycleID: "cyc_w", Mode: "LIVE", DecisionID: "dec_" + id,
+			Symbol: "BTCUSDT", Action: string(executor.IntentActionNewOrder), ClientOrderID: "LS_" + id, IntentPayloadJSON: "{}",
+		}
+	}
+	order := func(id string) executor.OrderRequest {
+		return executor.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", Type: executor.OrderTypeLimit, Price: "100", Qty: "1", ClientOrderID: "LS_" + id}
+	}
+
+	if _, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("ws_ok"), order("ws_ok")); err != nil {
+		t.Fatalf("submit over ws: %v", err)
+	}
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultRejected})
+	if resp, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("ws_rejected"), order("ws_rejected")); err != nil || !resp.Rejected {
+		t.Fatalf("expected rejected order, got %+v (%v)", resp, err)
+	}
+	exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultTimeoutAfterAccept})
+	if _, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("ws_unknown"), order("ws_unknown")); !errors.Is(err, executor.ErrSentUnknown) {
+		t.Fatalf("expected SENT_UNKNOWN, got %v", err)
+	}
+	if exchange.Calls(OpNewOrder) != 3 {
+		t.Fatalf("timeout must not fall back to REST, got %d orders", exchange.Calls(OpNewOrder))
+	}
+	pending, err := ledger.PendingSentUnknown(ctx, 10)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected 1 SENT_UNKNOWN intent, got %d (%v)", len(pending), err)
+	}
+	if err := executor.ResolveSentUnknown(ctx, cfg, ledger, rest, pending[0]); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	server.DisconnectStreams()
+	for ws.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := executor.SubmitWithIntent(ctx, ledger, rest, intent("rest_fallback"), order("rest_fallback")); err != nil {
+		t.Fatalf("submit after session down: %v", err)
+	}
+	for id, want := range map[string]executor.IntentState{
+		"ws_ok":         executor.IntentConfirmed,
+		"ws_rejected":   executor.IntentFailed,
+		"ws_unknown":    executor.IntentConfirmed,
+		"rest_fallback": executor.IntentConfirmed,
+	} {
+		rec, err := sqlite.GetOrderIntent(ctx, db, id)
+		if err != nil || rec.State != string(want) {
+			t.Fatalf("intent %s: expected %s, got %s (%v)", id, want, rec.State, err)
+		}
+	}
+}
+
+func TestMockServerWSDisconnectAndGap(t *testing.T) {
+	exchange := NewMockExchange(time.Now)
+	exchange.DepthBySymbol["BTCUSDT"] = []byte(`{"bids":[["100.0","1"]],"asks":[["100.1","1"]]}`)
+	server := NewMockServer(exchange, MockServerOptions{WSInterval: time.Millisecond})
+	defer server.Close()
+	exchange.Inject(Fault{Kind: FaultWSDisconnect, AfterMsgs: 6}, Fault{Kind: FaultWSGap, AfterMsgs: 2, Skip: 3})
+
+	ws := binance.NewWSClient(binance.WSOptions{BaseURL: server.WSURL()})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var ids []int64
+	err := ws.Run(ctx, []string{"BTCUSDT"}, func(ev binance.BookTickerEvent) {
+		ids = append(ids, ev.UpdateID)
+	})
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected abrupt disconnect, got %v", err)
+	}
+	if len(ids) != 6 {
+		t.Fatalf("expected 6 events before disconnect, got %d", len(ids))
+	}
+	gaps := 0
+	for i := 1; i < len(ids); i++ {
+		if ids[i] != ids[i-1]+1 {
+			gaps++
+		}
+	}
+	if gaps != 1 || ids[2]-ids[1] != 4 {
+		t.Fatalf("expected one gap of 3 missing updates, got %v", ids)
+	}
+
+	received := 0
+	reconnectCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	_ = ws.Run(reconnectCtx, []string{"BTCUSDT"}, func(ev binance.BookTickerEvent) {
+		received++
+		if received == 3 {
+			stop()
+		}
+	})
+	if received < 3 || exchange.Calls(OpWSStream) != 2 {
+		t.Fatalf("expected reconnect to stream again: received=%d streams=%d", received, exchange.Calls(OpWSStream))
+	}
+}
+
+func TestMockServerQuarantineFromExecutorAndStreamDrops(t *testing.T) {
+	exchange := NewMockExchange(time.Now)
+	server, client := newMockServerClient(t, exchange)
+	cfg := config.Default()
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "audit.sqlite")
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, time.Now()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: filepath.Join(tmp, "logs"), Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	defer writer.Close()
+	ctx := context.Background()
+	quarantine, err := app.NewQuarantineService(ctx, cfg, db, writer, time.Now)
+	if err != nil {
+		t.Fatalf("quarantine: %v", err)
+	}
+	ledger := executor.NewLedger(db, time.Now)
+	ledger.Outcomes = quarantine
+	rest := app.NewOrderClient(client)
+
+	for i := 0; i < cfg.RiskQuarantineMaxRejectsPerHour; i++ {
+		id := "q" + strconv.Itoa(i)
+		exchange.Inject(Fault{Op: OpNewOrder, Kind: FaultRejected})
+		intent := sqlite.OrderIntentRecord{
+			OrderIntentID: id, RunID: "run_q", CycleID: "cyc_q", Mode: "LIVE", DecisionID: "dec_" + id,
+			Symbol: "BTCUSDT", Action: string(executor.IntentActionNewOrder), ClientOrderID: "LS_" + id, IntentPayloadJSON: "{}",
+		}
+		order := executor.OrderRequest{Symbol: "BTCUSDT", Side: "BUY", Type: executor.OrderTypeLimit, Price: "100", Qty: "1", ClientOrderID: "LS_" + id}
+		if resp, err := executor.SubmitWithIntent(ctx, ledger, rest, intent, order); err != nil || !resp.Rejected {
+			t.Fatalf("expected rejected order, got %+v (%v)", resp, err)
+		}
+	}
+
+	exchange.Inject(Fault{Kind: FaultWSDisconnect, AfterMsgs: 2})
+	ws := binance.NewWSClient(binance.WSOptions{BaseURL: server.WSURL(), OnDisconnect: quarantine.RecordWSDisconnect})
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := ws.Run(streamCtx, []string{"ethusdt"}, nil); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected abrupt disconnect, got %v", err)
+	}
+
+	health := map[string]risk.SymbolHealth{}
+	for _, h := range quarantine.States() {
+		health[h.Symbol] = h
+	}
+	if h := health["BTCUSDT"]; !h.Quarantined() || h.LastErrorCode != "-2010" {
+		t.Fatalf("expected BTCUSDT quarantined on -2010, got %+v", h)
+	}
+	if h := health["ETHUSDT"]; len(h.WSDisconnectTsMs) != 1 || h.Quarantined() {
+		t.Fatalf("expected one ETHUSDT disconnect, got %+v", h)
+	}
+
+	now := time.Now()
+	sample, err := SampleSnapshot(now)
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	snapshot, err := state.BuildSnapshot(cfg, sample, quarantine, now.UnixMilli())
+	if err != nil {
+		t.Fatalf("build snapshot: %v", err)
+	}
+	if snapshot.HealthFlags.QuarantinedUntilMs == 0 || snapshot.HealthFlags.RecentRejectsWindowCount != cfg.RiskQuarantineMaxRejectsPerHour {
+		t.Fatalf("expected quarantine copied into the snapshot, got %+v", snapshot.HealthFlags)
+	}
+	results, err := universe.Scan(cfg, []contracts.Snapshot{snapshot})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if results[0].Eligible || !slices.Contains(results[0].Reasons, reasoncodes.SYMBOL_QUARANTINED) {
+		t.Fatalf("expected scan to drop the quarantined symbol, got %+v", results[0])
+	}
+}
@@ -57,6 +57,9 @@ func TestPipelineRunOnceWritesStages(t *testing.T) {
 	if err != nil {
 		t.Fatalf("run once: %v", err)
 	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
 
 	db, err := sql.Open("sqlite", filepath.Join(tmp, "data", "audit.sqlite"))
 	if err != nil {
@@ -43,11 +43,13 @@ type CancelReplaceRequest struct {
 }
 
 type OrderResponse struct {
+	OrderID       int64
 	Symbol        string
 	ClientOrderID string
 	Status        string
 	Price         string
 	Qty           string
+	ExecutedQty   string
 	Side          string
 	CreatedTsMs   int64
 	UpdatedTsMs   int64
@@ -105,10 +107,11 @@ type ReadinessCheck struct {
 }
 
 type ReadinessReport struct {
-	RunID      string
-	StartTsMs  int64
-	EndTsMs    int64
-	DurationMs int64
-	Checks     []ReadinessCheck
-	Ready      bool
+	RunID        string
+	StartTsMs    int64
+	EndTsMs      int64
+	DurationMs   int64
+	Checks       []ReadinessCheck
+	Ready        bool
+	Measurements *LoopSoakResult
 }
@@ -0,0 +1,92 @@
+package aigate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	CacheModeOff    = "off"
+	CacheModeCache  = "cache"
+	CacheModeReplay = "replay"
+)
+
+var ErrReplayMiss = errors.New("ai gate replay miss")
+
+type CachedProvider struct {
+	next   Provider
+	db     *sql.DB
+	replay bool
+}
+
+func NewCachedProvider(db *sql.DB, next Provider, replay bool) *CachedProvider {
+	return &CachedProvider{next: next, db: db, replay: replay}
+}
+
+func WithCache(mode string, db *sql.DB, next Provider) (Provider, error) {
+	switch mode {
+	case CacheModeOff, "":
+		return next, nil
+	case CacheModeCache:
+		if db == nil {
+			return nil, fmt.Errorf("ai gate cache db missing")
+		}
+		return NewCachedProvider(db, next, false), nil
+	case CacheModeReplay:
+		if db == nil {
+			return nil, fmt.Errorf("ai gate cache db missing")
+		}
+		return NewCachedProvider(db, nil, true), nil
+	default:
+		return nil, fmt.Errorf("ai gate cache mode unsupported: %s", mode)
+	}
+}
+
+func (p *CachedProvider) Complete(ctx context.Context, req ProviderRequest) (ProviderResponse, error) {
+	cached, ok, err := LookupRecordedResponse(ctx, p.db, req.InputHash, req.Model)
+	if err != nil && p.replay {
+		return ProviderResponse{Model: req.Model}, err
+	}
+	if ok {
+		return cached, nil
+	}
+	if p.replay || p.next == nil {
+		return ProviderResponse{Model: req.Model}, ErrReplayMiss
+	}
+	return p.next.Complete(ctx, req)
+}
+
+// LookupRecordedResponse returns the latest parseable response recorded for inputHash and
+// model. The input hash covers the payload and prompt but not the model, so a verdict recorded
+// for another ai_gate_model is never served; the gate records the configured model.
+func LookupRecordedResponse(ctx context.Context, db *sql.DB, inputHash string, model string) (ProviderResponse, bool, error) {
+	if db == nil {
+		return ProviderResponse{}, false, fmt.Errorf("ai gate cache db missing")
+	}
+	if inputHash == "" {
+		return ProviderResponse{}, false, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT response_json_redacted FROM ai_gate_events
+WHERE input_hash = ? AND model = ? AND error_code IS NULL AND response_json_redacted IS NOT NULL
+ORDER BY created_at_ms DESC`, inputHash, model)
+	if err != nil {
+		return ProviderResponse{}, false, fmt.Errorf("ai gate cache query: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return ProviderResponse{}, false, fmt.Errorf("ai gate cache scan: %w", err)
+		}
+		if _, _, _, err := ParseModelResponse(content); err != nil {
+			continue
+		}
+		return ProviderResponse{Content: content, Model: model, CacheHit: true}, true, nil
+	}
+	if err := rows.Err(); err != nil {
+		return ProviderResponse{}, false, fmt.Errorf("ai gate cache rows: %w", err)
+	}
+	return ProviderResponse{}, false, nil
+}
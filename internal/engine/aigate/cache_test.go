@@ -0,0 +1,135 @@
+package aigate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+)
+
+type countingProvider struct {
+	calls   int
+	content string
+}
+
+func (p *countingProvider) Complete(ctx context.Context, req ProviderRequest) (ProviderResponse, error) {
+	p.calls++
+	return ProviderResponse{Content: p.content, Model: req.Model}, nil
+}
+
+func TestCachedProviderServesRecordedVerdict(t *testing.T) {
+	db := openTestDB(t)
+	decision := baseDecision()
+	snapshot := stubSnapshot()
+	inner := &countingProvider{content: `{"verdict":"BLOCK","reasons":["REGIME_WEAK"]}`}
+	provider, err := WithCache(CacheModeCache, db, inner)
+	if err != nil {
+		t.Fatalf("with cache: %v", err)
+	}
+	gate := newTestGate(t, db, provider)
+	first, _, err := gate.Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, decision, snapshot)
+	if err != nil {
+		t.Fatalf("first evaluate: %v", err)
+	}
+	second, _, err := gate.Evaluate(context.Background(), CallContext{RunID: "run_2", CycleID: "cyc_1"}, decision, snapshot)
+	if err != nil {
+		t.Fatalf("second evaluate: %v", err)
+	}
+	if inner.calls != 1 {
+		t.Fatalf("expected provider called once, got %d", inner.calls)
+	}
+	if first.Verdict != contracts.AIGateBlock || second.Verdict != contracts.AIGateBlock {
+		t.Fatalf("expected BLOCK from both calls")
+	}
+	var hit int
+	if err := db.QueryRow(`SELECT cache_hit FROM ai_gate_events WHERE run_id = ?`, "run_2").Scan(&hit); err != nil {
+		t.Fatalf("query cache_hit: %v", err)
+	}
+	if hit != 1 {
+		t.Fatalf("expected cache hit recorded")
+	}
+}
+
+func TestReplayMissFailsClosed(t *testing.T) {
+	db := openTestDB(t)
+	provider, err := WithCache(CacheModeReplay, db, &countingProvider{})
+	if err != nil {
+		t.Fatalf("with cache: %v", err)
+	}
+	gate := newTestGate(t, db, provider)
+	result, _, err := gate.Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if err == nil {
+		t.Fatalf("expected replay miss error")
+	}
+	if result.Verdict != contracts.AIGateError || result.Reasons[0] != reasoncodes.AIGATE_REPLAY_MISS {
+		t.Fatalf("expected AIGATE_REPLAY_MISS, got %s %v", result.Verdict, result.Reasons)
+	}
+}
+
+func TestReplayServesStubRun(t *testing.T) {
+	db := openTestDB(t)
+	decision := baseDecision()
+	snapshot := stubSnapshot()
+	cfg := config.Default()
+	cfg.AIGateProvider = "stub"
+	recordProvider, err := NewProvider(cfg, func(string) string { return "" }, db)
+	if err != nil {
+		t.Fatalf("record provider: %v", err)
+	}
+	recorded, _, err := newTestGate(t, db, recordProvider).Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, decision, snapshot)
+	if err != nil {
+		t.Fatalf("record evaluate: %v", err)
+	}
+	cfg.AIGateCacheMode = CacheModeReplay
+	replayProvider, err := NewProvider(cfg, func(string) string { return "" }, db)
+	if err != nil {
+		t.Fatalf("replay provider: %v", err)
+	}
+	replayed, _, err := newTestGate(t, db, replayProvider).Evaluate(context.Background(), CallContext{RunID: "run_2", CycleID: "cyc_1"}, decision, snapshot)
+	if err != nil {
+		t.Fatalf("replay evaluate: %v", err)
+	}
+	if replayed.Verdict != recorded.Verdict || replayed.Model != StubModel {
+		t.Fatalf("expected replayed stub verdict %s, got %s model=%s", recorded.Verdict, replayed.Verdict, replayed.Model)
+	}
+}
+
+func TestCacheIsKeyedOnConfiguredModel(t *testing.T) {
+	db := openTestDB(t)
+	now := func() time.Time { return time.UnixMilli(1706700000000) }
+	gateFor := func(model string, provider Provider) *Gate {
+		cfg := config.Default()
+		cfg.AIGateModel = model
+		gate, err := NewGate(cfg, provider, NewRecorder(cfg, db, nil, now), now)
+		if err != nil {
+			t.Fatalf("new gate: %v", err)
+		}
+		return gate
+	}
+	inner := &countingProvider{content: `{"verdict":"BLOCK","reasons":["REGIME_WEAK"]}`}
+	recording, err := WithCache(CacheModeCache, db, inner)
+	if err != nil {
+		t.Fatalf("with cache: %v", err)
+	}
+	if _, _, err := gateFor("gpt-4o-mini", recording).Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot()); err != nil {
+		t.Fatalf("record evaluate: %v", err)
+	}
+	if _, _, err := gateFor("gpt-4o", recording).Evaluate(context.Background(), CallContext{RunID: "run_2", CycleID: "cyc_1"}, baseDecision(), stubSnapshot()); err != nil {
+		t.Fatalf("evaluate after model change: %v", err)
+	}
+	if inner.calls != 2 {
+		t.Fatalf("expected a model change to miss the cache, got %d provider calls", inner.calls)
+	}
+	replay, err := WithCache(CacheModeReplay, db, nil)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	result, _, err := gateFor("gpt-4.1", replay).Evaluate(context.Background(), CallContext{RunID: "run_3", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if !errors.Is(err, ErrReplayMiss) || result.Model != "gpt-4.1" {
+		t.Fatalf("expected replay miss recorded for gpt-4.1, got %v model=%s", err, result.Model)
+	}
+}
@@ -2,9 +2,8 @@ package aigate
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -16,13 +15,12 @@ import (
 )
 
 type Gate struct {
-	cfg          config.Config
-	client       *openai.Client
-	recorder     *Recorder
-	systemPrompt string
-	userTemplate string
-	schema       openai.JSONSchema
-	now          func() time.Time
+	cfg        config.Config
+	provider   Provider
+	recorder   *Recorder
+	primary    PromptSet
+	challenger *PromptSet
+	now        func() time.Time
 }
 
 type CallContext struct {
@@ -31,92 +29,100 @@ type CallContext struct {
 	ExchangeTimeMs int64
 }
 
-func NewGate(cfg config.Config, client *openai.Client, recorder *Recorder, now func() time.Time) (*Gate, error) {
+type preparedCall struct {
+	prompt       PromptSet
+	snapshotHash string
+	inputHash    string
+	payloadJSON  []byte
+}
+
+func NewGate(cfg config.Config, provider Provider, recorder *Recorder, now func() time.Time) (*Gate, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ai gate provider missing")
+	}
 	if now == nil {
 		now = time.Now
 	}
-	systemPrompt, err := loadText("prompts/ai_gate_system.txt")
+	primary, err := loadAllowedPromptSet(cfg, cfg.AIGatePromptVersion)
 	if err != nil {
 		return nil, err
 	}
-	userTemplate, err := loadText("prompts/ai_gate_user_template.txt")
-	if err != nil {
-		return nil, err
+	gate := &Gate{
+		cfg:      cfg,
+		provider: provider,
+		recorder: recorder,
+		primary:  primary,
+		now:      now,
 	}
-	schemaBytes, err := os.ReadFile("prompts/schemas/ai_gate_result.schema.json")
-	if err != nil {
-		return nil, fmt.Errorf("read ai_gate schema: %w", err)
-	}
-	return &Gate{
-		cfg:          cfg,
-		client:       client,
-		recorder:     recorder,
-		systemPrompt: systemPrompt,
-		userTemplate: userTemplate,
-		schema: openai.JSONSchema{
-			Name:   "ai_gate_result",
-			Schema: json.RawMessage(schemaBytes),
-			Strict: true,
-		},
-		now: now,
-	}, nil
+	if cfg.AIGatePromptChallengerVersion != "" {
+		challenger, err := loadAllowedPromptSet(cfg, cfg.AIGatePromptChallengerVersion)
+		if err != nil {
+			return nil, err
+		}
+		gate.challenger = &challenger
+	}
+	return gate, nil
 }
 
-func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contracts.Decision, snapshot contracts.Snapshot) (contracts.AIGateResult, *contracts.Decision, error) {
-	start := g.now()
-	snapshotHash, err := snapshot.Hash()
-	if err != nil {
-		return g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, "snapshot_hash"), nil, err
+func (g *Gate) PromptFor(decisionID string) PromptSet {
+	if g.challenger == nil {
+		return g.primary
 	}
-	payload, err := BuildPayload(decision, snapshot, snapshotHash)
-	if err != nil {
-		return g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, "payload"), nil, err
+	version := AssignPromptVersion(decisionID, g.primary.Version, g.challenger.Version, g.cfg.AIGatePromptChallengerPct)
+	if version == g.challenger.Version {
+		return *g.challenger
 	}
-	inputHash, err := InputHash(payload)
-	if err != nil {
-		return g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, "input_hash"), nil, err
-	}
-	payload.InputHash = inputHash
-	payloadJSON, err := hash.CanonicalJSON(payload)
+	return g.primary
+}
+
+func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contracts.Decision, snapshot contracts.Snapshot) (contracts.AIGateResult, *contracts.Decision, error) {
+	start := g.now()
+	call, detail, err := g.prepare(decision, snapshot)
 	if err != nil {
-		return g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, "payload_json"), nil, err
+		return g.fail(callCtx, decision, call.snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, detail), nil, err
 	}
-	userPrompt := strings.ReplaceAll(g.userTemplate, "{{payload_json}}", string(payloadJSON))
+	inputHash := call.inputHash
+	snapshotHash := call.snapshotHash
+	userPrompt := strings.ReplaceAll(call.prompt.UserTemplate, "{{payload_json}}", string(call.payloadJSON))
 
-	req := openai.ChatCompletionRequest{
-		Model: g.cfg.AIGateModel,
-		Messages: []openai.Message{
-			{Role: "system", Content: g.systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		ResponseFormat: &openai.ResponseFormat{
-			Type:       "json_schema",
-			JSONSchema: &g.schema,
+	req := ProviderRequest{
+		Model:        g.model(),
+		SystemPrompt: call.prompt.System,
+		UserPrompt:   userPrompt,
+		Schema: openai.JSONSchema{
+			Name:   "ai_gate_result",
+			Schema: call.prompt.Schema,
+			Strict: true,
 		},
+		InputHash: inputHash,
 	}
-	temp := 0.0
-	req.Temperature = &temp
 
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(g.cfg.AIGateTimeoutMs)*time.Millisecond)
 	defer cancel()
 
-	resp, _, err := g.client.ChatCompletion(ctx, req)
+	resp, err := g.provider.Complete(ctx, req)
 	latency := int(g.now().Sub(start).Milliseconds())
 	if err != nil {
 		reason := reasoncodes.AIGATE_PARSE_FAIL
-		if ctx.Err() == context.DeadlineExceeded {
+		detail := "request_failed"
+		if ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded) {
 			reason = reasoncodes.AIGATE_TIMEOUT
 		}
-		result := g.fail(callCtx, decision, snapshotHash, reason, "request_failed")
+		if errors.Is(err, ErrEmptyResponse) {
+			detail = "empty_choices"
+		}
+		if errors.Is(err, ErrReplayMiss) {
+			reason = reasoncodes.AIGATE_REPLAY_MISS
+			detail = "replay_miss"
+		}
+		result := g.fail(callCtx, decision, snapshotHash, reason, detail)
 		result.InputHash = inputHash
 		result.LatencyMs = latency
-		g.record(callCtx, decision, result, payloadJSON, nil, nil, false, reason, "request_failed")
+		g.record(callCtx, call, decision, result, nil, nil, false, false, reason, detail)
 		return result, nil, err
 	}
-	if len(resp.Choices) == 0 {
-		return g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_PARSE_FAIL, "empty_choices"), nil, fmt.Errorf("ai gate empty response")
-	}
-	rawContent := resp.Choices[0].Message.Content
+	model := g.model()
+	rawContent := resp.Content
 	rawHash := hash.HashSHA256Hex([]byte(rawContent))
 
 	verdict, reasons, modifiedDecision, parseErr := ParseModelResponse(rawContent)
@@ -129,10 +135,11 @@ func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contr
 			reason = reasoncodes.AIGATE_REASON_UNKNOWN
 		}
 		result := g.fail(callCtx, decision, snapshotHash, reason, "parse_failed")
+		result.Model = model
 		result.InputHash = inputHash
 		result.RawHash = rawHash
 		result.LatencyMs = latency
-		g.record(callCtx, decision, result, payloadJSON, []byte(rawContent), nil, false, reason, "parse_failed")
+		g.record(callCtx, call, decision, result, []byte(rawContent), nil, false, resp.CacheHit, reason, "parse_failed")
 		return result, nil, parseErr
 	}
 
@@ -140,7 +147,7 @@ func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contr
 		Enabled:      true,
 		Verdict:      verdict,
 		Reasons:      reasons,
-		Model:        g.cfg.AIGateModel,
+		Model:        model,
 		LatencyMs:    latency,
 		RawHash:      rawHash,
 		InputHash:    inputHash,
@@ -152,10 +159,18 @@ func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contr
 	if verdict == contracts.AIGateModify {
 		if modifiedDecision == nil {
 			result = g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, "modified_missing")
+			result.Model = model
+			result.InputHash = inputHash
+			result.RawHash = rawHash
+			result.LatencyMs = latency
 		} else {
 			mod, err := ApplyModify(decision, *modifiedDecision)
 			if err != nil {
 				result = g.fail(callCtx, decision, snapshotHash, reasoncodes.AIGATE_MODIFY_INVALID, "modify_invalid")
+				result.Model = model
+				result.InputHash = inputHash
+				result.RawHash = rawHash
+				result.LatencyMs = latency
 			} else {
 				result.ModifiedDecision = &mod
 				applied = &mod
@@ -164,16 +179,53 @@ func (g *Gate) Evaluate(ctx context.Context, callCtx CallContext, decision contr
 		}
 	}
 
-	g.record(callCtx, decision, result, payloadJSON, []byte(rawContent), decisionPatch(decision, applied), modifyApplied, "", "")
+	g.record(callCtx, call, decision, result, []byte(rawContent), decisionPatch(decision, applied), modifyApplied, resp.CacheHit, "", "")
 	return result, applied, nil
 }
 
+func (g *Gate) Reject(callCtx CallContext, decision contracts.Decision, snapshot contracts.Snapshot, reason reasoncodes.ReasonCode, detail string) (contracts.AIGateResult, error) {
+	call, failDetail, err := g.prepare(decision, snapshot)
+	if err != nil {
+		return g.fail(callCtx, decision, call.snapshotHash, reasoncodes.AIGATE_SCHEMA_INVALID, failDetail), err
+	}
+	result := g.fail(callCtx, decision, call.snapshotHash, reason, detail)
+	result.InputHash = call.inputHash
+	g.record(callCtx, call, decision, result, nil, nil, false, false, reason, detail)
+	return result, nil
+}
+
+func (g *Gate) prepare(decision contracts.Decision, snapshot contracts.Snapshot) (preparedCall, string, error) {
+	call := preparedCall{prompt: g.PromptFor(decision.DecisionID)}
+	snapshotHash, err := snapshot.Hash()
+	if err != nil {
+		return call, "snapshot_hash", err
+	}
+	call.snapshotHash = snapshotHash
+	payload, err := BuildPayload(decision, snapshot, snapshotHash)
+	if err != nil {
+		return call, "payload", err
+	}
+	payload.PromptHash = call.prompt.Hash
+	inputHash, err := InputHash(payload)
+	if err != nil {
+		return call, "input_hash", err
+	}
+	payload.InputHash = inputHash
+	payloadJSON, err := hash.CanonicalJSON(payload)
+	if err != nil {
+		return call, "payload_json", err
+	}
+	call.inputHash = inputHash
+	call.payloadJSON = payloadJSON
+	return call, "", nil
+}
+
 func (g *Gate) fail(callCtx CallContext, decision contracts.Decision, snapshotHash string, reason reasoncodes.ReasonCode, detail string) contracts.AIGateResult {
 	return contracts.AIGateResult{
 		Enabled:          true,
 		Verdict:          contracts.AIGateError,
 		Reasons:          []reasoncodes.ReasonCode{reason},
-		Model:            g.cfg.AIGateModel,
+		Model:            g.model(),
 		LatencyMs:        0,
 		RawHash:          hash.HashSHA256Hex([]byte{}),
 		InputHash:        "",
@@ -182,7 +234,7 @@ func (g *Gate) fail(callCtx CallContext, decision contracts.Decision, snapshotHa
 	}
 }
 
-func (g *Gate) record(callCtx CallContext, decision contracts.Decision, result contracts.AIGateResult, requestJSON []byte, responseJSON []byte, patch map[string]any, modifyApplied bool, errCode reasoncodes.ReasonCode, errDetail string) {
+func (g *Gate) record(callCtx CallContext, call preparedCall, decision contracts.Decision, result contracts.AIGateResult, responseJSON []byte, patch map[string]any, modifyApplied bool, cacheHit bool, errCode reasoncodes.ReasonCode, errDetail string) {
 	if g.recorder == nil {
 		return
 	}
@@ -207,10 +259,13 @@ func (g *Gate) record(callCtx CallContext, decision contracts.Decision, result c
 		Model:                 result.Model,
 		LatencyMs:             result.LatencyMs,
 		RawHash:               result.RawHash,
-		RequestJSON:           requestJSON,
+		PromptVersion:         call.prompt.Version,
+		PromptHash:            call.prompt.Hash,
+		RequestJSON:           call.payloadJSON,
 		ResponseJSON:          responseJSON,
 		ModifiedDecisionPatch: patch,
 		ModifyApplied:         modifyApplied,
+		CacheHit:              cacheHit,
 		ErrorCode:             errorCode,
 		ErrorDetailRedacted:   errDetail,
 		ExchangeTimeMs:        callCtx.ExchangeTimeMs,
@@ -226,10 +281,22 @@ func decisionPatch(original contracts.Decision, modified *contracts.Decision) ma
 	return BuildDecisionPatch(original, *modified)
 }
 
-func loadText(path string) (string, error) {
-	buf, err := os.ReadFile(path)
+func loadAllowedPromptSet(cfg config.Config, version string) (PromptSet, error) {
+	set, err := LoadPromptSet(version)
 	if err != nil {
-		return "", fmt.Errorf("read %s: %w", path, err)
+		return PromptSet{}, err
+	}
+	if err := VerifyPromptSet(set, cfg.AIGatePromptAllowlist); err != nil {
+		return PromptSet{}, err
+	}
+	return set, nil
+}
+
+// model is the configured model: ai_gate_model, or StubModel for the stub provider. Results
+// record it rather than the model a provider reports, so cache lookups key on what was asked for.
+func (g *Gate) model() string {
+	if g.cfg.AIGateProvider == "stub" {
+		return StubModel
 	}
-	return string(buf), nil
+	return g.cfg.AIGateModel
 }
@@ -0,0 +1,516 @@
+package aigate
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+)
+
+const (
+	OutcomeSourceRealized = "REALIZED"
+	OutcomeSourceBacktest = "BACKTEST"
+)
+
+const quoteAsset = "USDT"
+
+type Outcome struct {
+	DecisionID            string
+	Source                string
+	RealizedPnLUSDT       string
+	CounterfactualPnLUSDT string
+	CreatedAtMs           int64
+}
+
+type EvaluationReport struct {
+	Groups []EvaluationGroup `json:"groups"`
+}
+
+type EvaluationGroup struct {
+	Model               string         `json:"model"`
+	PromptVersion       string         `json:"prompt_version"`
+	Calls               int            `json:"calls"`
+	Errors              int            `json:"errors"`
+	Allow               int            `json:"allow"`
+	Block               int            `json:"block"`
+	Modify              int            `json:"modify"`
+	WithOutcome         int            `json:"with_outcome"`
+	BlockTruePositive   int            `json:"block_true_positive"`
+	BlockFalsePositive  int            `json:"block_false_positive"`
+	MissedLosers        int            `json:"missed_losers"`
+	BlockPrecisionX1000 int            `json:"block_precision_x1000"`
+	BlockRecallX1000    int            `json:"block_recall_x1000"`
+	BlockedPnLUSDT      string         `json:"blocked_counterfactual_pnl_usdt"`
+	ModifyPnLDeltaUSDT  string         `json:"modify_pnl_delta_usdt"`
+	LatencyP50Ms        int            `json:"latency_p50_ms"`
+	LatencyP90Ms        int            `json:"latency_p90_ms"`
+	LatencyP99Ms        int            `json:"latency_p99_ms"`
+	ReasonCounts        map[string]int `json:"reason_counts"`
+}
+
+func RecordOutcome(ctx context.Context, db *sql.DB, outcome Outcome) error {
+	if db == nil {
+		return fmt.Errorf("ai gate db missing")
+	}
+	if outcome.DecisionID == "" {
+		return fmt.Errorf("outcome decision_id missing")
+	}
+	if outcome.Source != OutcomeSourceRealized && outcome.Source != OutcomeSourceBacktest {
+		return fmt.Errorf("outcome source invalid: %s", outcome.Source)
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO ai_gate_outcomes (
+  decision_id, source, realized_pnl_usdt, counterfactual_pnl_usdt, created_at_ms
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(decision_id) DO UPDATE SET
+  source = CASE WHEN excluded.realized_pnl_usdt IS NULL AND ai_gate_outcomes.realized_pnl_usdt IS NOT NULL
+    THEN ai_gate_outcomes.source ELSE excluded.source END,
+  realized_pnl_usdt = COALESCE(excluded.realized_pnl_usdt, ai_gate_outcomes.realized_pnl_usdt),
+  counterfactual_pnl_usdt = COALESCE(excluded.counterfactual_pnl_usdt, ai_gate_outcomes.counterfactual_pnl_usdt),
+  created_at_ms = excluded.created_at_ms`,
+		outcome.DecisionID,
+		outcome.Source,
+		nullIfEmpty(outcome.RealizedPnLUSDT),
+		nullIfEmpty(outcome.CounterfactualPnLUSDT),
+		outcome.CreatedAtMs,
+	)
+	if err != nil {
+		return fmt.Errorf("ai gate outcome insert: %w", err)
+	}
+	return nil
+}
+
+// RecordRealizedOutcomes derives realized PnL per entry decision from order_fills and upserts it
+// as a REALIZED outcome. Sell fills are matched FIFO per symbol to the buy fills of ENTRY intents;
+// PnL is net of commissions, valued in USDT when charged in the quote or the base asset. An entry
+// is written once all of its fills are closed; one with a commission in any other asset is left
+// out. It returns the number of outcomes written.
+func RecordRealizedOutcomes(ctx context.Context, db *sql.DB, nowMs int64) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("ai gate db missing")
+	}
+	rows, err := db.QueryContext(ctx, `SELECT f.symbol, f.side, f.price, f.qty, f.commission, f.commission_asset,
+  i.decision_id, i.intent_payload_json
+FROM order_fills f
+JOIN order_intents i ON i.order_intent_id = f.order_intent_id
+ORDER BY f.filled_at_ms, f.order_intent_id, f.trade_id`)
+	if err != nil {
+		return 0, fmt.Errorf("ai gate outcome query: %w", err)
+	}
+	type entry struct {
+		pnl      *big.Rat
+		open     int
+		unpriced bool
+	}
+	type lot struct {
+		entry *entry
+		held  *big.Rat
+		open  *big.Rat
+		cost  *big.Rat
+	}
+	entries := map[string]*entry{}
+	var order []string
+	lots := map[string][]*lot{}
+	for rows.Next() {
+		var symbol, side, priceText, qtyText, commissionText, commissionAsset, decisionID, payloadJSON string
+		if err := rows.Scan(&symbol, &side, &priceText, &qtyText, &commissionText, &commissionAsset, &decisionID, &payloadJSON); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ai gate outcome scan: %w", err)
+		}
+		price, okPrice := new(big.Rat).SetString(priceText)
+		qty, okQty := new(big.Rat).SetString(qtyText)
+		commission, okCommission := new(big.Rat).SetString(commissionText)
+		if !okPrice || !okQty || !okCommission || qty.Sign() <= 0 {
+			continue
+		}
+		base := strings.TrimSuffix(symbol, quoteAsset)
+		fee, feePriced := new(big.Rat), true
+		switch {
+		case commission.Sign() == 0:
+		case commissionAsset == quoteAsset:
+			fee.Set(commission)
+		case commissionAsset == base:
+			fee.Mul(commission, price)
+		default:
+			feePriced = false
+		}
+		if contracts.Side(side) == contracts.SideBuy {
+			var payload executor.OrderIntentHashPayload
+			if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil || payload.Intent != contracts.IntentEntry {
+				continue
+			}
+			e, ok := entries[decisionID]
+			if !ok {
+				e = &entry{pnl: new(big.Rat)}
+				entries[decisionID] = e
+				order = append(order, decisionID)
+			}
+			held := new(big.Rat).Set(qty)
+			cost := new(big.Rat).Mul(qty, price)
+			if commissionAsset == base {
+				held.Sub(held, commission)
+			} else {
+				cost.Add(cost, fee)
+			}
+			e.unpriced = e.unpriced || !feePriced
+			if held.Sign() <= 0 {
+				continue
+			}
+			e.open++
+			lots[symbol] = append(lots[symbol], &lot{entry: e, held: held, open: new(big.Rat).Set(held), cost: cost})
+			continue
+		}
+		remaining := new(big.Rat).Set(qty)
+		for remaining.Sign() > 0 && len(lots[symbol]) > 0 {
+			head := lots[symbol][0]
+			sold := new(big.Rat).Set(remaining)
+			if sold.Cmp(head.open) > 0 {
+				sold.Set(head.open)
+			}
+			share := new(big.Rat).Quo(sold, qty)
+			proceeds := new(big.Rat).Mul(sold, price)
+			proceeds.Sub(proceeds, new(big.Rat).Mul(fee, share))
+			basis := new(big.Rat).Mul(head.cost, new(big.Rat).Quo(sold, head.held))
+			head.entry.pnl.Add(head.entry.pnl, proceeds.Sub(proceeds, basis))
+			head.entry.unpriced = head.entry.unpriced || !feePriced
+			head.open.Sub(head.open, sold)
+			remaining.Sub(remaining, sold)
+			if head.open.Sign() == 0 {
+				head.entry.open--
+				lots[symbol] = lots[symbol][1:]
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("ai gate outcome rows: %w", err)
+	}
+	rows.Close()
+	written := 0
+	for _, decisionID := range order {
+		e := entries[decisionID]
+		if e.open > 0 || e.unpriced {
+			continue
+		}
+		err := RecordOutcome(ctx, db, Outcome{
+			DecisionID:      decisionID,
+			Source:          OutcomeSourceRealized,
+			RealizedPnLUSDT: e.pnl.FloatString(8),
+			CreatedAtMs:     nowMs,
+		})
+		if err != nil {
+			return 0, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// RecordCounterfactualOutcomes replays every BLOCKed or MODIFYed BUY entry older than
+// ai_gate_counterfactual_horizon_ms over the stored snapshots of its symbol and upserts the
+// result as a BACKTEST counterfactual outcome. The fill model enters at the best ask of the
+// gated snapshot, exits at the stop or take profit on the first later snapshot whose best bid
+// reaches it, otherwise at the last best bid inside the horizon, and pays the taker fee on both
+// legs. The quantity is sized as the strategy sizes it: risk_per_trade_usdt over the stop
+// distance. Entries without a readable payload, snapshot or later snapshot are left out.
+func RecordCounterfactualOutcomes(ctx context.Context, db *sql.DB, cfg config.Config, nowMs int64) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("ai gate db missing")
+	}
+	risk, ok := new(big.Rat).SetString(cfg.RiskPerTradeUSDT)
+	if !ok || risk.Sign() <= 0 {
+		return 0, fmt.Errorf("risk_per_trade_usdt invalid: %s", cfg.RiskPerTradeUSDT)
+	}
+	horizonMs := int64(cfg.AIGateCounterfactualHorizonMs)
+	rows, err := db.QueryContext(ctx, `SELECT e.decision_id, e.snapshot_id, e.request_json_redacted, e.created_at_ms
+FROM ai_gate_events e
+LEFT JOIN ai_gate_outcomes o ON o.decision_id = e.decision_id
+WHERE e.enabled = 1 AND e.verdict IN (?, ?) AND e.created_at_ms <= ? AND o.counterfactual_pnl_usdt IS NULL
+ORDER BY e.created_at_ms`, string(contracts.AIGateBlock), string(contracts.AIGateModify), nowMs-horizonMs)
+	if err != nil {
+		return 0, fmt.Errorf("ai gate counterfactual query: %w", err)
+	}
+	type gated struct {
+		decisionID  string
+		snapshotID  string
+		requestJSON sql.NullString
+		createdAtMs int64
+	}
+	var pending []gated
+	for rows.Next() {
+		var g gated
+		if err := rows.Scan(&g.decisionID, &g.snapshotID, &g.requestJSON, &g.createdAtMs); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ai gate counterfactual scan: %w", err)
+		}
+		pending = append(pending, g)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("ai gate counterfactual rows: %w", err)
+	}
+	rows.Close()
+	written := 0
+	for _, g := range pending {
+		var payload Payload
+		if !g.requestJSON.Valid || json.Unmarshal([]byte(g.requestJSON.String), &payload) != nil {
+			continue
+		}
+		if payload.Side != contracts.SideBuy || payload.Intent != contracts.IntentEntry {
+			continue
+		}
+		pnl, ok, err := replayEntry(ctx, db, payload, g.snapshotID, g.createdAtMs, g.createdAtMs+horizonMs, risk)
+		if err != nil {
+			return written, err
+		}
+		if !ok {
+			continue
+		}
+		err = RecordOutcome(ctx, db, Outcome{
+			DecisionID:            g.decisionID,
+			Source:                OutcomeSourceBacktest,
+			CounterfactualPnLUSDT: pnl.FloatString(8),
+			CreatedAtMs:           nowMs,
+		})
+		if err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+func replayEntry(ctx context.Context, db *sql.DB, payload Payload, snapshotID string, fromMs int64, toMs int64, risk *big.Rat) (*big.Rat, bool, error) {
+	var snapshotJSON string
+	err := db.QueryRowContext(ctx, `SELECT snapshot_json FROM snapshots WHERE snapshot_id = ?`, snapshotID).Scan(&snapshotJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("ai gate counterfactual snapshot: %w", err)
+	}
+	var snapshot contracts.Snapshot
+	if err := json.Unmarshal([]byte(snapshotJSON), &snapshot); err != nil {
+		return nil, false, nil
+	}
+	entry, okEntry := new(big.Rat).SetString(snapshot.Prices.BestAsk)
+	stop, okStop := new(big.Rat).SetString(payload.ExitPlan.SLPrice)
+	target, okTarget := new(big.Rat).SetString(payload.ExitPlan.TPPrice)
+	if !okEntry || !okStop || !okTarget || entry.Cmp(stop) <= 0 {
+		return nil, false, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT snapshot_json FROM snapshots
+WHERE symbol = ? AND created_at_ms > ? AND created_at_ms <= ?
+ORDER BY created_at_ms, snapshot_id`, payload.Symbol, fromMs, toMs)
+	if err != nil {
+		return nil, false, fmt.Errorf("ai gate counterfactual path: %w", err)
+	}
+	defer rows.Close()
+	var exit *big.Rat
+	for rows.Next() {
+		var pathJSON string
+		if err := rows.Scan(&pathJSON); err != nil {
+			return nil, false, fmt.Errorf("ai gate counterfactual path scan: %w", err)
+		}
+		var later contracts.Snapshot
+		if err := json.Unmarshal([]byte(pathJSON), &later); err != nil {
+			continue
+		}
+		bid, ok := new(big.Rat).SetString(later.Prices.BestBid)
+		if !ok {
+			continue
+		}
+		exit = bid
+		if bid.Cmp(stop) <= 0 {
+			exit = stop
+			break
+		}
+		if bid.Cmp(target) >= 0 {
+			exit = target
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("ai gate counterfactual path rows: %w", err)
+	}
+	if exit == nil {
+		return nil, false, nil
+	}
+	qty := new(big.Rat).Quo(risk, new(big.Rat).Sub(entry, stop))
+	pnl := new(big.Rat).Mul(qty, new(big.Rat).Sub(exit, entry))
+	turnover := new(big.Rat).Mul(qty, new(big.Rat).Add(entry, exit))
+	fees := new(big.Rat).Mul(turnover, big.NewRat(int64(payload.Costs.TakerFeeBps), 10000))
+	return pnl.Sub(pnl, fees), true, nil
+}
+
+func BuildEvaluationReport(ctx context.Context, db *sql.DB, sinceMs int64) (EvaluationReport, error) {
+	if db == nil {
+		return EvaluationReport{}, fmt.Errorf("ai gate db missing")
+	}
+	rows, err := db.QueryContext(ctx, `SELECT e.model, e.prompt_version, e.verdict, e.reasons_json, e.latency_ms,
+  o.realized_pnl_usdt, o.counterfactual_pnl_usdt
+FROM ai_gate_events e
+LEFT JOIN ai_gate_outcomes o ON o.decision_id = e.decision_id
+WHERE e.enabled = 1 AND e.created_at_ms >= ?
+ORDER BY e.created_at_ms`, sinceMs)
+	if err != nil {
+		return EvaluationReport{}, fmt.Errorf("ai gate evaluation query: %w", err)
+	}
+	defer rows.Close()
+	accs := map[string]*evaluationAcc{}
+	for rows.Next() {
+		var model, promptVersion, verdict, reasonsJSON sql.NullString
+		var latency sql.NullInt64
+		var realized, counterfactual sql.NullString
+		if err := rows.Scan(&model, &promptVersion, &verdict, &reasonsJSON, &latency, &realized, &counterfactual); err != nil {
+			return EvaluationReport{}, fmt.Errorf("ai gate evaluation scan: %w", err)
+		}
+		key := model.String + "|" + promptVersion.String
+		acc, ok := accs[key]
+		if !ok {
+			acc = newEvaluationAcc(model.String, promptVersion.String)
+			accs[key] = acc
+		}
+		if err := acc.add(contracts.AIGateVerdict(verdict.String), reasonsJSON.String, int(latency.Int64), realized, counterfactual); err != nil {
+			return EvaluationReport{}, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return EvaluationReport{}, fmt.Errorf("ai gate evaluation rows: %w", err)
+	}
+	keys := make([]string, 0, len(accs))
+	for key := range accs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	report := EvaluationReport{Groups: make([]EvaluationGroup, 0, len(keys))}
+	for _, key := range keys {
+		report.Groups = append(report.Groups, accs[key].group())
+	}
+	return report, nil
+}
+
+type evaluationAcc struct {
+	out         EvaluationGroup
+	latencies   []int
+	blockedPnL  *big.Rat
+	modifyDelta *big.Rat
+}
+
+func newEvaluationAcc(model string, promptVersion string) *evaluationAcc {
+	return &evaluationAcc{
+		out:         EvaluationGroup{Model: model, PromptVersion: promptVersion, ReasonCounts: map[string]int{}},
+		blockedPnL:  new(big.Rat),
+		modifyDelta: new(big.Rat),
+	}
+}
+
+func (a *evaluationAcc) add(verdict contracts.AIGateVerdict, reasonsJSON string, latencyMs int, realized sql.NullString, counterfactual sql.NullString) error {
+	a.out.Calls++
+	if latencyMs > 0 {
+		a.latencies = append(a.latencies, latencyMs)
+	}
+	var reasons []string
+	if reasonsJSON != "" {
+		if err := json.Unmarshal([]byte(reasonsJSON), &reasons); err != nil {
+			return fmt.Errorf("ai gate evaluation reasons: %w", err)
+		}
+	}
+	for _, reason := range reasons {
+		a.out.ReasonCounts[reason]++
+	}
+	realizedPnL, err := optionalDecimal(realized)
+	if err != nil {
+		return err
+	}
+	counterfactualPnL, err := optionalDecimal(counterfactual)
+	if err != nil {
+		return err
+	}
+	switch verdict {
+	case contracts.AIGateError:
+		a.out.Errors++
+	case contracts.AIGateAllow:
+		a.out.Allow++
+		if realizedPnL != nil {
+			a.out.WithOutcome++
+			if realizedPnL.Sign() < 0 {
+				a.out.MissedLosers++
+			}
+		}
+	case contracts.AIGateBlock:
+		a.out.Block++
+		if counterfactualPnL != nil {
+			a.out.WithOutcome++
+			a.blockedPnL.Add(a.blockedPnL, counterfactualPnL)
+			if counterfactualPnL.Sign() < 0 {
+				a.out.BlockTruePositive++
+			} else {
+				a.out.BlockFalsePositive++
+			}
+		}
+	case contracts.AIGateModify:
+		a.out.Modify++
+		if realizedPnL != nil {
+			a.out.WithOutcome++
+			if realizedPnL.Sign() < 0 {
+				a.out.MissedLosers++
+			}
+			if counterfactualPnL != nil {
+				a.modifyDelta.Add(a.modifyDelta, new(big.Rat).Sub(realizedPnL, counterfactualPnL))
+			}
+		}
+	}
+	return nil
+}
+
+func (a *evaluationAcc) group() EvaluationGroup {
+	out := a.out
+	flagged := out.BlockTruePositive + out.BlockFalsePositive
+	if flagged > 0 {
+		out.BlockPrecisionX1000 = out.BlockTruePositive * 1000 / flagged
+	}
+	losers := out.BlockTruePositive + out.MissedLosers
+	if losers > 0 {
+		out.BlockRecallX1000 = out.BlockTruePositive * 1000 / losers
+	}
+	out.BlockedPnLUSDT = a.blockedPnL.FloatString(2)
+	out.ModifyPnLDeltaUSDT = a.modifyDelta.FloatString(2)
+	sort.Ints(a.latencies)
+	out.LatencyP50Ms = percentile(a.latencies, 50)
+	out.LatencyP90Ms = percentile(a.latencies, 90)
+	out.LatencyP99Ms = percentile(a.latencies, 99)
+	return out
+}
+
+func optionalDecimal(value sql.NullString) (*big.Rat, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	r := new(big.Rat)
+	if _, ok := r.SetString(value.String); !ok {
+		return nil, fmt.Errorf("ai gate outcome pnl invalid: %s", value.String)
+	}
+	return r, nil
+}
+
+func percentile(sorted []int, pct int) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := (len(sorted)*pct+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
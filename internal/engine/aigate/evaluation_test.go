@@ -0,0 +1,207 @@
+package aigate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+)
+
+func TestBuildEvaluationReport(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	recorder := NewRecorder(config.Default(), db, nil, nil)
+	events := []struct {
+		decisionID string
+		verdict    contracts.AIGateVerdict
+		latency    int
+		outcome    Outcome
+	}{
+		{"dec_1", contracts.AIGateBlock, 100, Outcome{Source: OutcomeSourceBacktest, CounterfactualPnLUSDT: "-12.50"}},
+		{"dec_2", contracts.AIGateBlock, 200, Outcome{Source: OutcomeSourceBacktest, CounterfactualPnLUSDT: "4.00"}},
+		{"dec_3", contracts.AIGateAllow, 300, Outcome{Source: OutcomeSourceRealized, RealizedPnLUSDT: "-3.00"}},
+		{"dec_4", contracts.AIGateModify, 400, Outcome{Source: OutcomeSourceRealized, RealizedPnLUSDT: "-1.00", CounterfactualPnLUSDT: "-5.00"}},
+	}
+	for _, evt := range events {
+		err := recorder.Record(ctx, Event{
+			RunID:           "run_1",
+			CycleID:         "cyc_1",
+			Mode:            "LIVE",
+			SnapshotID:      "snap_1",
+			SnapshotHash:    "h",
+			DecisionID:      evt.decisionID,
+			InputHash:       "i_" + evt.decisionID,
+			Enabled:         true,
+			Verdict:         string(evt.verdict),
+			Reasons:         []reasoncodes.ReasonCode{reasoncodes.REGIME_WEAK},
+			Model:           "gpt-4o-mini",
+			LatencyMs:       evt.latency,
+			LocalReceivedMs: 1706700000000,
+		})
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		evt.outcome.DecisionID = evt.decisionID
+		if err := RecordOutcome(ctx, db, evt.outcome); err != nil {
+			t.Fatalf("record outcome: %v", err)
+		}
+	}
+	report, err := BuildEvaluationReport(ctx, db, 0)
+	if err != nil {
+		t.Fatalf("build report: %v", err)
+	}
+	if len(report.Groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(report.Groups))
+	}
+	group := report.Groups[0]
+	if group.BlockPrecisionX1000 != 500 {
+		t.Fatalf("expected precision 500, got %d", group.BlockPrecisionX1000)
+	}
+	if group.BlockRecallX1000 != 333 {
+		t.Fatalf("expected recall 333, got %d", group.BlockRecallX1000)
+	}
+	if group.ModifyPnLDeltaUSDT != "4.00" {
+		t.Fatalf("expected modify delta 4.00, got %s", group.ModifyPnLDeltaUSDT)
+	}
+	if group.LatencyP50Ms != 200 || group.LatencyP99Ms != 400 {
+		t.Fatalf("unexpected latency percentiles: p50=%d p99=%d", group.LatencyP50Ms, group.LatencyP99Ms)
+	}
+	if group.ReasonCounts["REGIME_WEAK"] != 4 {
+		t.Fatalf("expected reason count 4, got %d", group.ReasonCounts["REGIME_WEAK"])
+	}
+}
+
+func TestRecordRealizedOutcomesFromFills(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	insert := func(id string, decisionID string, side contracts.Side, intent contracts.Intent, state executor.IntentState, ts int64, price string, qty string, commission string, asset string) {
+		t.Helper()
+		payload, err := json.Marshal(executor.OrderIntentHashPayload{Symbol: "BTCUSDT", Side: side, Intent: intent, EntryPlanQty: qty, EntryPlanPrice: "1"})
+		if err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		_, err = db.Exec(`INSERT INTO order_intents (order_intent_id, run_id, cycle_id, mode, decision_id, symbol, action, client_order_id,
+  intent_payload_json, state, created_at_ms, updated_at_ms) VALUES (?, 'run_1', 'cyc_1', 'LIVE', ?, 'BTCUSDT', ?, ?, ?, ?, ?, ?)`,
+			id, decisionID, string(executor.IntentActionNewOrder), "c_"+id, string(payload), string(state), ts, ts)
+		if err != nil {
+			t.Fatalf("insert intent: %v", err)
+		}
+		_, err = db.Exec(`INSERT INTO order_fills (order_intent_id, trade_id, symbol, side, price, qty, commission, commission_asset, filled_at_ms)
+VALUES (?, ?, 'BTCUSDT', ?, ?, ?, ?, ?, ?)`, id, ts, string(side), price, qty, commission, asset, ts)
+		if err != nil {
+			t.Fatalf("insert fill: %v", err)
+		}
+	}
+	insert("oi_1", "dec_win", contracts.SideBuy, contracts.IntentEntry, executor.IntentConfirmed, 1, "100", "1", "0.001", "BTC")
+	insert("oi_2", "dec_loss", contracts.SideBuy, contracts.IntentEntry, executor.IntentConfirmed, 2, "110", "2", "0.22", "USDT")
+	insert("oi_3", "dec_exit_1", contracts.SideSell, contracts.IntentExit, executor.IntentConfirmed, 3, "105", "1.5", "0.1575", "USDT")
+	insert("oi_4", "dec_open", contracts.SideBuy, contracts.IntentEntry, executor.IntentConfirmed, 4, "90", "1", "0", "USDT")
+	insert("oi_5", "dec_exit_2", contracts.SideSell, contracts.IntentExit, executor.IntentConfirmed, 5, "100", "1.5", "0.15", "USDT")
+
+	written, err := RecordRealizedOutcomes(ctx, db, 1706700000000)
+	if err != nil {
+		t.Fatalf("record outcomes: %v", err)
+	}
+	if written != 2 {
+		t.Fatalf("expected 2 closed entries, got %d", written)
+	}
+	want := map[string]string{"dec_win": "4.79010500", "dec_loss": "-17.91750500"}
+	for decisionID, pnl := range want {
+		var got string
+		if err := db.QueryRow(`SELECT realized_pnl_usdt FROM ai_gate_outcomes WHERE decision_id = ?`, decisionID).Scan(&got); err != nil {
+			t.Fatalf("outcome %s: %v", decisionID, err)
+		}
+		if got != pnl {
+			t.Fatalf("expected %s pnl %s, got %s", decisionID, pnl, got)
+		}
+	}
+}
+
+func TestRecordCounterfactualOutcomesReplaysBlockedEntry(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	cfg := config.Default()
+	cfg.RiskPerTradeUSDT = "10"
+	const t0 = int64(1706700000000)
+	insertSnapshot := func(id string, ts int64, bid string, ask string) {
+		t.Helper()
+		buf, err := json.Marshal(contracts.Snapshot{Symbol: "BTCUSDT", Prices: contracts.PricesSnapshot{BestBid: bid, BestAsk: ask}})
+		if err != nil {
+			t.Fatalf("snapshot json: %v", err)
+		}
+		_, err = db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES (?, 'BTCUSDT', 'h', ?, ?, ?, ?)`, id, ts, ts, string(buf), ts)
+		if err != nil {
+			t.Fatalf("insert snapshot: %v", err)
+		}
+	}
+	insertSnapshot("snap_gate", t0, "99.9", "100")
+	insertSnapshot("snap_2", t0+1000, "97", "97.1")
+	insertSnapshot("snap_3", t0+2000, "94", "94.1")
+	insertSnapshot("snap_late", t0+int64(cfg.AIGateCounterfactualHorizonMs)+1, "120", "120.1")
+
+	request, err := json.Marshal(Payload{
+		DecisionID: "dec_block",
+		SnapshotID: "snap_gate",
+		Symbol:     "BTCUSDT",
+		Side:       contracts.SideBuy,
+		Intent:     contracts.IntentEntry,
+		Costs:      contracts.CostInputs{TakerFeeBps: 10},
+		ExitPlan:   contracts.ExitPlan{TPPrice: "110", SLPrice: "95"},
+	})
+	if err != nil {
+		t.Fatalf("request json: %v", err)
+	}
+	recorder := NewRecorder(cfg, db, nil, nil)
+	err = recorder.Record(ctx, Event{
+		RunID:           "run_1",
+		CycleID:         "cyc_1",
+		Mode:            "LIVE",
+		SnapshotID:      "snap_gate",
+		SnapshotHash:    "h",
+		DecisionID:      "dec_block",
+		InputHash:       "i_dec_block",
+		Enabled:         true,
+		Verdict:         string(contracts.AIGateBlock),
+		Reasons:         []reasoncodes.ReasonCode{reasoncodes.REGIME_WEAK},
+		Model:           "gpt-4o-mini",
+		LatencyMs:       100,
+		RequestJSON:     request,
+		LocalReceivedMs: t0,
+	})
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	nowMs := t0 + int64(cfg.AIGateCounterfactualHorizonMs)
+	written, err := RecordCounterfactualOutcomes(ctx, db, cfg, nowMs-1)
+	if err != nil || written != 0 {
+		t.Fatalf("expected nothing before the horizon, got %d %v", written, err)
+	}
+	written, err = RecordCounterfactualOutcomes(ctx, db, cfg, nowMs)
+	if err != nil || written != 1 {
+		t.Fatalf("expected 1 counterfactual outcome, got %d %v", written, err)
+	}
+	var source, pnl string
+	if err := db.QueryRow(`SELECT source, counterfactual_pnl_usdt FROM ai_gate_outcomes WHERE decision_id = 'dec_block'`).Scan(&source, &pnl); err != nil {
+		t.Fatalf("outcome: %v", err)
+	}
+	if source != string(OutcomeSourceBacktest) || pnl != "-10.39000000" {
+		t.Fatalf("expected BACKTEST -10.39000000, got %s %s", source, pnl)
+	}
+	written, err = RecordCounterfactualOutcomes(ctx, db, cfg, nowMs)
+	if err != nil || written != 0 {
+		t.Fatalf("expected the outcome written once, got %d %v", written, err)
+	}
+	report, err := BuildEvaluationReport(ctx, db, 0)
+	if err != nil {
+		t.Fatalf("build report: %v", err)
+	}
+	if len(report.Groups) != 1 || report.Groups[0].BlockPrecisionX1000 != 1000 {
+		t.Fatalf("expected block precision 1000, got %+v", report.Groups)
+	}
+}
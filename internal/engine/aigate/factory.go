@@ -1,14 +1,75 @@
 package aigate
 
 import (
-	"os"
+	"database/sql"
+	"fmt"
 	"time"
 
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	"github.com/RodrigoBeloyanis/livespot/internal/infra/openai"
+	"github.com/RodrigoBeloyanis/livespot/internal/secrets"
 )
 
-func NewOpenAIClient(cfg config.Config) (*openai.Client, error) {
-	key := os.Getenv("OPENAI_API_KEY")
+func NewOpenAIClient(cfg config.Config, getenv func(string) string) (*openai.Client, error) {
+	key := getenv(secrets.OpenAIAPIKey)
 	return openai.NewClient(cfg.OpenAIBaseURL, key, time.Duration(cfg.AIGateTimeoutMs)*time.Millisecond)
 }
+
+// NewProvider builds the configured provider wrapped by ai_gate_cache_mode; db backs the
+// cache and replay lookups and may be nil only when the mode is off.
+func NewProvider(cfg config.Config, getenv func(string) string, db *sql.DB) (Provider, error) {
+	p, err := newBaseProvider(cfg, getenv)
+	if err != nil {
+		return nil, err
+	}
+	return WithCache(cfg.AIGateCacheMode, db, p)
+}
+
+// NewGatePolicy is the gate construction path: provider (with cache), recorded gate and the
+// AI_DEC policy that applies the call budgets and the timeout circuit breaker. With ai_dec=0
+// no provider is built and the policy skips every call.
+func NewGatePolicy(cfg config.Config, getenv func(string) string, db *sql.DB, writer *audit.Writer, now func() time.Time) (*Policy, error) {
+	if cfg.AiDec == 0 {
+		return NewPolicy(cfg, nil, now)
+	}
+	provider, err := NewProvider(cfg, getenv, db)
+	if err != nil {
+		return nil, err
+	}
+	gate, err := NewGate(cfg, provider, NewRecorder(cfg, db, writer, now), now)
+	if err != nil {
+		return nil, err
+	}
+	return NewPolicy(cfg, gate, now)
+}
+
+func newBaseProvider(cfg config.Config, getenv func(string) string) (Provider, error) {
+	switch cfg.AIGateProvider {
+	case "openai":
+		client, err := NewOpenAIClient(cfg, getenv)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenAIProvider(client), nil
+	case "openai_compatible":
+		key := getenv(secrets.OpenAIAPIKey)
+		client, err := openai.NewCompatibleClient(cfg.OpenAIBaseURL, key, time.Duration(cfg.AIGateTimeoutMs)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpenAIProvider(client), nil
+	case "stub":
+		table := StubTable{}
+		if cfg.AIGateStubTablePath != "" {
+			loaded, err := LoadStubTable(cfg.AIGateStubTablePath)
+			if err != nil {
+				return nil, err
+			}
+			table = loaded
+		}
+		return NewStubProvider(table), nil
+	default:
+		return nil, fmt.Errorf("ai gate provider unsupported: %s", cfg.AIGateProvider)
+	}
+}
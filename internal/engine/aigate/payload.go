@@ -13,6 +13,7 @@ type Payload struct {
 	SnapshotID     string                       `json:"snapshot_id"`
 	SnapshotHash   string                       `json:"snapshot_hash"`
 	InputHash      string                       `json:"input_hash"`
+	PromptHash     string                       `json:"prompt_hash"`
 	Mode           string                       `json:"mode"`
 	Symbol         string                       `json:"symbol"`
 	Side           contracts.Side               `json:"side"`
@@ -0,0 +1,208 @@
+package aigate
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+)
+
+type PolicyAction string
+
+const (
+	PolicyProceed          PolicyAction = "PROCEED"
+	PolicyProceedTightened PolicyAction = "PROCEED_TIGHTENED"
+	PolicyBlock            PolicyAction = "BLOCK"
+)
+
+const (
+	ErrorPolicyBlock   = "block"
+	ErrorPolicyTighten = "tighten"
+)
+
+type PolicyOutcome struct {
+	Action         PolicyAction
+	Decision       contracts.Decision
+	Result         contracts.AIGateResult
+	Called         bool
+	PauseRequested bool
+}
+
+type Policy struct {
+	cfg  config.Config
+	gate *Gate
+	now  func() time.Time
+
+	mu                  sync.Mutex
+	cycleID             string
+	cycleCalls          int
+	dayKey              string
+	dayCalls            int
+	consecutiveTimeouts int
+	circuitOpenUntilMs  int64
+}
+
+func NewPolicy(cfg config.Config, gate *Gate, now func() time.Time) (*Policy, error) {
+	if cfg.AiDec != 0 && gate == nil {
+		return nil, fmt.Errorf("ai gate missing for ai_dec=%d", cfg.AiDec)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Policy{cfg: cfg, gate: gate, now: now}, nil
+}
+
+func (p *Policy) Apply(ctx context.Context, callCtx CallContext, decision contracts.Decision, snapshot contracts.Snapshot) (PolicyOutcome, error) {
+	if p.cfg.AiDec == 0 || decision.Intent != contracts.IntentEntry {
+		return p.skip(decision), nil
+	}
+	if reason := p.admit(callCtx.CycleID); reason != "" {
+		result, err := p.gate.Reject(callCtx, decision, snapshot, reason, "policy_rejected")
+		return p.onError(decision, result, false), err
+	}
+	result, applied, err := p.gate.Evaluate(ctx, callCtx, decision, snapshot)
+	p.observe(result)
+	if result.Verdict == contracts.AIGateError {
+		return p.onError(decision, result, true), err
+	}
+	out := decision
+	if applied != nil {
+		out = *applied
+	}
+	resultCopy := result
+	out.AIGate = &resultCopy
+	action := PolicyProceed
+	if result.Verdict == contracts.AIGateBlock {
+		action = PolicyBlock
+	}
+	return PolicyOutcome{Action: action, Decision: out, Result: result, Called: true}, nil
+}
+
+func (p *Policy) CircuitOpen() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.circuitOpenUntilMs > p.now().UnixMilli()
+}
+
+func (p *Policy) skip(decision contracts.Decision) PolicyOutcome {
+	result := contracts.AIGateResult{
+		Enabled: false,
+		Verdict: contracts.AIGateAllow,
+		Reasons: []reasoncodes.ReasonCode{reasoncodes.AIGATE_SKIPPED},
+		Model:   "",
+	}
+	out := decision
+	resultCopy := result
+	out.AIGate = &resultCopy
+	return PolicyOutcome{Action: PolicyProceed, Decision: out, Result: result}
+}
+
+func (p *Policy) admit(cycleID string) reasoncodes.ReasonCode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.circuitOpenUntilMs > now.UnixMilli() {
+		return reasoncodes.AIGATE_CIRCUIT_OPEN
+	}
+	if cycleID != p.cycleID {
+		p.cycleID = cycleID
+		p.cycleCalls = 0
+	}
+	dayKey := now.UTC().Format("2006-01-02")
+	if dayKey != p.dayKey {
+		p.dayKey = dayKey
+		p.dayCalls = 0
+	}
+	if p.cycleCalls >= p.cfg.AIGateMaxCallsPerCycle || p.dayCalls >= p.cfg.AIGateMaxCallsPerDay {
+		return reasoncodes.AIGATE_BUDGET_EXHAUSTED
+	}
+	p.cycleCalls++
+	p.dayCalls++
+	return ""
+}
+
+func (p *Policy) observe(result contracts.AIGateResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !hasReason(result.Reasons, reasoncodes.AIGATE_TIMEOUT) {
+		p.consecutiveTimeouts = 0
+		return
+	}
+	p.consecutiveTimeouts++
+	if p.consecutiveTimeouts >= p.cfg.AIGateCircuitTimeouts {
+		p.circuitOpenUntilMs = p.now().UnixMilli() + int64(p.cfg.AIGateCircuitOpenMs)
+	}
+}
+
+func (p *Policy) onError(decision contracts.Decision, result contracts.AIGateResult, called bool) PolicyOutcome {
+	out := decision
+	resultCopy := result
+	resultCopy.Reasons = append([]reasoncodes.ReasonCode{}, result.Reasons...)
+	outcome := PolicyOutcome{Action: PolicyBlock, Decision: out, Result: resultCopy, Called: called}
+	if p.cfg.AiDec == 2 {
+		outcome.PauseRequested = true
+		outcome.Decision.AIGate = &resultCopy
+		return outcome
+	}
+	if p.cfg.AIGateErrorPolicy == ErrorPolicyTighten {
+		tightened, err := TightenDecision(decision, p.cfg.AIGateErrorTightenQtyPct)
+		if err == nil {
+			resultCopy.Reasons = append(resultCopy.Reasons, reasoncodes.AIGATE_ERROR_TIGHTENED)
+			tightened.AIGate = &resultCopy
+			outcome.Action = PolicyProceedTightened
+			outcome.Decision = tightened
+			outcome.Result = resultCopy
+			return outcome
+		}
+	}
+	outcome.Decision.AIGate = &resultCopy
+	return outcome
+}
+
+func TightenDecision(decision contracts.Decision, qtyPct int) (contracts.Decision, error) {
+	if decision.EntryPlan == nil {
+		return contracts.Decision{}, fmt.Errorf("entry plan missing")
+	}
+	if qtyPct <= 0 || qtyPct >= 100 {
+		return contracts.Decision{}, fmt.Errorf("tighten qty pct invalid")
+	}
+	qty, err := parseDecimal(decision.EntryPlan.Qty)
+	if err != nil {
+		return contracts.Decision{}, err
+	}
+	step, err := parseDecimal(decision.Constraints.StepSize)
+	if err != nil {
+		return contracts.Decision{}, err
+	}
+	if step.Sign() <= 0 {
+		return contracts.Decision{}, fmt.Errorf("step size invalid")
+	}
+	scaled := new(big.Rat).Mul(qty, big.NewRat(int64(qtyPct), 100))
+	steps := new(big.Rat).Quo(scaled, step)
+	whole := new(big.Int).Quo(steps.Num(), steps.Denom())
+	reduced := new(big.Rat).Mul(new(big.Rat).SetInt(whole), step)
+	if reduced.Sign() <= 0 {
+		return contracts.Decision{}, fmt.Errorf("tightened qty is zero")
+	}
+	modified := decision
+	entry := *decision.EntryPlan
+	entry.Qty = reduced.FloatString(decision.Constraints.QtyPrecision)
+	entry.Fallback.Enabled = false
+	modified.EntryPlan = &entry
+	modified.AIGate = nil
+	return ApplyModify(decision, modified)
+}
+
+func hasReason(reasons []reasoncodes.ReasonCode, target reasoncodes.ReasonCode) bool {
+	for _, reason := range reasons {
+		if reason == target {
+			return true
+		}
+	}
+	return false
+}
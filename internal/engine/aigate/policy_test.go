@@ -0,0 +1,148 @@
+package aigate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+)
+
+func TestPolicySkipsWhenDisabled(t *testing.T) {
+	cfg := config.Default()
+	cfg.AiDec = 0
+	policy, err := NewPolicy(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	outcome, err := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if outcome.Action != PolicyProceed || outcome.Called {
+		t.Fatalf("expected skip to proceed without call")
+	}
+	if outcome.Decision.AIGate == nil || outcome.Decision.AIGate.Enabled {
+		t.Fatalf("expected disabled ai_gate recorded on decision")
+	}
+	if err := outcome.Decision.AIGate.Validate(); err != nil {
+		t.Fatalf("expected valid ai_gate result: %v", err)
+	}
+}
+
+func TestPolicyMandatoryErrorPausesAndBlocks(t *testing.T) {
+	cfg := config.Default()
+	policy := newTestPolicy(t, cfg, StubRule{Error: "timeout"})
+	outcome, _ := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if outcome.Action != PolicyBlock || !outcome.PauseRequested {
+		t.Fatalf("expected BLOCK with pause, got %s pause=%v", outcome.Action, outcome.PauseRequested)
+	}
+	if outcome.Decision.AIGate == nil || outcome.Decision.AIGate.Verdict != contracts.AIGateError {
+		t.Fatalf("expected ERROR recorded on decision")
+	}
+}
+
+func TestPolicyOptionalErrorTightens(t *testing.T) {
+	cfg := config.Default()
+	cfg.AiDec = 1
+	cfg.AIGateErrorPolicy = ErrorPolicyTighten
+	policy := newTestPolicy(t, cfg, StubRule{Error: "upstream 503"})
+	outcome, _ := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if outcome.Action != PolicyProceedTightened || outcome.PauseRequested {
+		t.Fatalf("expected tightened proceed, got %s", outcome.Action)
+	}
+	if outcome.Decision.EntryPlan.Qty != "0.0050" {
+		t.Fatalf("expected qty halved, got %s", outcome.Decision.EntryPlan.Qty)
+	}
+	if !hasReason(outcome.Decision.AIGate.Reasons, reasoncodes.AIGATE_ERROR_TIGHTENED) {
+		t.Fatalf("expected AIGATE_ERROR_TIGHTENED reason")
+	}
+}
+
+func TestPolicyCycleBudget(t *testing.T) {
+	cfg := config.Default()
+	cfg.AIGateMaxCallsPerCycle = 1
+	policy := newTestPolicy(t, cfg, StubRule{Verdict: contracts.AIGateAllow, Reasons: []string{"STRAT_OK"}})
+	callCtx := CallContext{RunID: "run_1", CycleID: "cyc_1"}
+	first, _ := policy.Apply(context.Background(), callCtx, baseDecision(), stubSnapshot())
+	if first.Action != PolicyProceed || !first.Called {
+		t.Fatalf("expected first call to proceed")
+	}
+	second := baseDecision()
+	second.DecisionID = "dec_btc_2"
+	outcome, _ := policy.Apply(context.Background(), callCtx, second, stubSnapshot())
+	if outcome.Called || outcome.Action != PolicyBlock {
+		t.Fatalf("expected budget to block without call")
+	}
+	if !hasReason(outcome.Result.Reasons, reasoncodes.AIGATE_BUDGET_EXHAUSTED) {
+		t.Fatalf("expected AIGATE_BUDGET_EXHAUSTED, got %v", outcome.Result.Reasons)
+	}
+}
+
+func TestPolicyCircuitBreakerOpensAfterTimeouts(t *testing.T) {
+	cfg := config.Default()
+	cfg.AIGateCircuitTimeouts = 2
+	cfg.AIGateMaxCallsPerCycle = 10
+	policy := newTestPolicy(t, cfg, StubRule{Error: "timeout"})
+	for i, id := range []string{"dec_a", "dec_b"} {
+		decision := baseDecision()
+		decision.DecisionID = id
+		outcome, _ := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, decision, stubSnapshot())
+		if !outcome.Called {
+			t.Fatalf("expected call %d to reach provider", i)
+		}
+	}
+	if !policy.CircuitOpen() {
+		t.Fatalf("expected circuit open")
+	}
+	decision := baseDecision()
+	decision.DecisionID = "dec_c"
+	outcome, _ := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, decision, stubSnapshot())
+	if outcome.Called || !hasReason(outcome.Result.Reasons, reasoncodes.AIGATE_CIRCUIT_OPEN) {
+		t.Fatalf("expected AIGATE_CIRCUIT_OPEN without call")
+	}
+}
+
+func TestNewGatePolicyBuildsConfiguredGate(t *testing.T) {
+	db := openTestDB(t)
+	getenv := func(string) string { return "" }
+	cfg := config.Default()
+	if _, err := NewGatePolicy(cfg, getenv, db, nil, nil); err == nil {
+		t.Fatalf("expected openai without a key to fail")
+	}
+	cfg.AiDec = 0
+	if _, err := NewGatePolicy(cfg, getenv, db, nil, nil); err != nil {
+		t.Fatalf("expected ai_dec=0 to skip the provider: %v", err)
+	}
+	cfg.AiDec = 2
+	cfg.AIGateProvider = "stub"
+	policy, err := NewGatePolicy(cfg, getenv, db, nil, nil)
+	if err != nil {
+		t.Fatalf("new gate policy: %v", err)
+	}
+	outcome, err := policy.Apply(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !outcome.Called || outcome.Result.Model != StubModel {
+		t.Fatalf("expected stub gate call, got %+v", outcome.Result)
+	}
+}
+
+func newTestPolicy(t *testing.T, cfg config.Config, rule StubRule) *Policy {
+	t.Helper()
+	db := openTestDB(t)
+	now := func() time.Time { return time.UnixMilli(1706700000000) }
+	provider := NewStubProvider(StubTable{Default: &rule})
+	gate, err := NewGate(cfg, provider, NewRecorder(cfg, db, nil, now), now)
+	if err != nil {
+		t.Fatalf("new gate: %v", err)
+	}
+	policy, err := NewPolicy(cfg, gate, now)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return policy
+}
@@ -0,0 +1,104 @@
+package aigate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/hash"
+	"github.com/RodrigoBeloyanis/livespot/prompts"
+)
+
+const DefaultPromptVersion = "v1"
+
+type PromptSet struct {
+	Version      string
+	Hash         string
+	System       string
+	UserTemplate string
+	Schema       json.RawMessage
+}
+
+func LoadPromptSet(version string) (PromptSet, error) {
+	if version == "" {
+		return PromptSet{}, fmt.Errorf("prompt version missing")
+	}
+	systemName, templateName, schemaName := promptFileNames(version)
+	system, err := readPrompt(systemName)
+	if err != nil {
+		return PromptSet{}, err
+	}
+	userTemplate, err := readPrompt(templateName)
+	if err != nil {
+		return PromptSet{}, err
+	}
+	schema, err := readPrompt(schemaName)
+	if errors.Is(err, fs.ErrNotExist) {
+		schema, err = readPrompt("schemas/ai_gate_result.schema.json")
+	}
+	if err != nil {
+		return PromptSet{}, err
+	}
+	if !json.Valid(schema) {
+		return PromptSet{}, fmt.Errorf("prompt %s schema invalid json", version)
+	}
+	contentHash, err := hash.CanonicalHash(map[string]string{
+		"system":        string(system),
+		"user_template": string(userTemplate),
+		"schema":        string(schema),
+	})
+	if err != nil {
+		return PromptSet{}, fmt.Errorf("prompt %s hash: %w", version, err)
+	}
+	return PromptSet{
+		Version:      version,
+		Hash:         contentHash,
+		System:       string(system),
+		UserTemplate: string(userTemplate),
+		Schema:       json.RawMessage(schema),
+	}, nil
+}
+
+func VerifyPromptSet(set PromptSet, allowlist map[string]string) error {
+	expected, ok := allowlist[set.Version]
+	if !ok {
+		return fmt.Errorf("prompt version %s not in allowlist", set.Version)
+	}
+	if expected != set.Hash {
+		return fmt.Errorf("prompt version %s hash %s does not match allowlist", set.Version, set.Hash)
+	}
+	return nil
+}
+
+func AssignPromptVersion(decisionID string, primary string, challenger string, challengerPct int) string {
+	if challenger == "" || challengerPct <= 0 {
+		return primary
+	}
+	sum := sha256.Sum256([]byte(decisionID))
+	bucket := binary.BigEndian.Uint64(sum[:8]) % 100
+	if bucket < uint64(challengerPct) {
+		return challenger
+	}
+	return primary
+}
+
+func promptFileNames(version string) (string, string, string) {
+	if version == DefaultPromptVersion {
+		return "ai_gate_system.txt", "ai_gate_user_template.txt", "schemas/ai_gate_result.schema.json"
+	}
+	return "ai_gate_system." + version + ".txt",
+		"ai_gate_user_template." + version + ".txt",
+		"schemas/ai_gate_result." + version + ".schema.json"
+}
+
+func readPrompt(name string) ([]byte, error) {
+	buf, err := prompts.FS.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read prompt %s: %w", name, err)
+	}
+	return bytes.TrimPrefix(buf, []byte("\xef\xbb\xbf")), nil
+}
@@ -0,0 +1,62 @@
+package aigate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+func TestDefaultPromptSetMatchesAllowlist(t *testing.T) {
+	cfg := config.Default()
+	set, err := LoadPromptSet(cfg.AIGatePromptVersion)
+	if err != nil {
+		t.Fatalf("load prompt set: %v", err)
+	}
+	if err := VerifyPromptSet(set, cfg.AIGatePromptAllowlist); err != nil {
+		t.Fatalf("expected embedded prompts to match allowlist: %v", err)
+	}
+}
+
+func TestNewGateRejectsUnlistedPromptHash(t *testing.T) {
+	cfg := config.Default()
+	cfg.AIGatePromptAllowlist = map[string]string{"v1": "0000000000000000000000000000000000000000000000000000000000000000"}
+	if _, err := NewGate(cfg, NewStubProvider(StubTable{}), nil, nil); err == nil {
+		t.Fatalf("expected allowlist mismatch error")
+	}
+}
+
+func TestAssignPromptVersionDeterministic(t *testing.T) {
+	challenger := 0
+	for i := 0; i < 1000; i++ {
+		id := "dec_" + string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + string(rune('a'+i/676))
+		first := AssignPromptVersion(id, "v1", "v2", 30)
+		if first != AssignPromptVersion(id, "v1", "v2", 30) {
+			t.Fatalf("expected deterministic assignment for %s", id)
+		}
+		if first == "v2" {
+			challenger++
+		}
+	}
+	if challenger < 200 || challenger > 400 {
+		t.Fatalf("expected roughly 30%% challenger share, got %d/1000", challenger)
+	}
+	if AssignPromptVersion("dec_x", "v1", "", 50) != "v1" {
+		t.Fatalf("expected primary without challenger")
+	}
+}
+
+func TestGateRecordsPromptVersion(t *testing.T) {
+	db := openTestDB(t)
+	gate := newTestGate(t, db, NewStubProvider(StubTable{}))
+	if _, _, err := gate.Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, baseDecision(), stubSnapshot()); err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	var version, promptHash string
+	if err := db.QueryRow(`SELECT prompt_version, prompt_hash FROM ai_gate_events WHERE run_id = ?`, "run_1").Scan(&version, &promptHash); err != nil {
+		t.Fatalf("query prompt version: %v", err)
+	}
+	if version != "v1" || promptHash != config.Default().AIGatePromptAllowlist["v1"] {
+		t.Fatalf("unexpected prompt version %s hash %s", version, promptHash)
+	}
+}
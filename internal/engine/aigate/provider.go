@@ -0,0 +1,65 @@
+package aigate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/openai"
+)
+
+type ProviderRequest struct {
+	Model        string
+	SystemPrompt string
+	UserPrompt   string
+	Schema       openai.JSONSchema
+	InputHash    string
+}
+
+type ProviderResponse struct {
+	Content  string
+	Model    string
+	CacheHit bool
+}
+
+type Provider interface {
+	Complete(ctx context.Context, req ProviderRequest) (ProviderResponse, error)
+}
+
+var ErrEmptyResponse = errors.New("ai gate empty response")
+
+type OpenAIProvider struct {
+	client *openai.Client
+}
+
+func NewOpenAIProvider(client *openai.Client) *OpenAIProvider {
+	return &OpenAIProvider{client: client}
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req ProviderRequest) (ProviderResponse, error) {
+	if p.client == nil {
+		return ProviderResponse{}, fmt.Errorf("openai client missing")
+	}
+	schema := req.Schema
+	temp := 0.0
+	chatReq := openai.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openai.Message{
+			{Role: "system", Content: req.SystemPrompt},
+			{Role: "user", Content: req.UserPrompt},
+		},
+		ResponseFormat: &openai.ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &schema,
+		},
+		Temperature: &temp,
+	}
+	resp, _, err := p.client.ChatCompletion(ctx, chatReq)
+	if err != nil {
+		return ProviderResponse{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return ProviderResponse{}, ErrEmptyResponse
+	}
+	return ProviderResponse{Content: resp.Choices[0].Message.Content, Model: req.Model}, nil
+}
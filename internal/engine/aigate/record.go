@@ -29,10 +29,13 @@ type Event struct {
 	Model                 string
 	LatencyMs             int
 	RawHash               string
+	PromptVersion         string
+	PromptHash            string
 	RequestJSON           []byte
 	ResponseJSON          []byte
 	ModifiedDecisionPatch map[string]any
 	ModifyApplied         bool
+	CacheHit              bool
 	ErrorCode             string
 	ErrorDetailRedacted   string
 	ExchangeTimeMs        int64
@@ -92,8 +95,8 @@ func (r *Recorder) insertEvent(ctx context.Context, evt Event) error {
 	_, err = r.db.ExecContext(ctx, `INSERT INTO ai_gate_events (
   run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash,
   enabled, verdict, reasons_json, model, latency_ms, raw_hash, request_json_redacted, response_json_redacted,
-  modified_decision_json_redacted, modify_applied, error_code, error_detail_redacted, exchange_time_ms, local_received_ms, created_at_ms
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+  modified_decision_json_redacted, modify_applied, cache_hit, prompt_version, prompt_hash, error_code, error_detail_redacted, exchange_time_ms, local_received_ms, created_at_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		evt.RunID,
 		evt.CycleID,
 		evt.Mode,
@@ -113,6 +116,9 @@ func (r *Recorder) insertEvent(ctx context.Context, evt Event) error {
 		nullIfEmpty(responseRedacted),
 		nullIfEmpty(patchRedacted),
 		boolToInt(evt.ModifyApplied),
+		boolToInt(evt.CacheHit),
+		nullIfEmpty(evt.PromptVersion),
+		nullIfEmpty(evt.PromptHash),
 		nullIfEmpty(evt.ErrorCode),
 		nullIfEmpty(evt.ErrorDetailRedacted),
 		nullIfZero64(evt.ExchangeTimeMs),
@@ -152,6 +158,9 @@ func (r *Recorder) auditRecord(evt Event) audit.Record {
 			"ai_input_hash":            evt.InputHash,
 			"ai_snapshot_hash":         evt.SnapshotHash,
 			"ai_modify_applied":        evt.ModifyApplied,
+			"ai_cache_hit":             evt.CacheHit,
+			"ai_prompt_version":        evt.PromptVersion,
+			"ai_prompt_hash":           evt.PromptHash,
 			"ai_error_code":            evt.ErrorCode,
 			"ai_error_detail_redacted": evt.ErrorDetailRedacted,
 		},
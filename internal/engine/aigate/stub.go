@@ -0,0 +1,92 @@
+package aigate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+)
+
+const StubModel = "stub"
+
+type StubRule struct {
+	Verdict          contracts.AIGateVerdict `json:"verdict"`
+	Reasons          []string                `json:"reasons"`
+	ModifiedDecision *contracts.Decision     `json:"modified_decision,omitempty"`
+	Raw              string                  `json:"raw,omitempty"`
+	Error            string                  `json:"error,omitempty"`
+}
+
+type StubTable struct {
+	Default *StubRule           `json:"default,omitempty"`
+	Rules   map[string]StubRule `json:"rules"`
+}
+
+type StubProvider struct {
+	table StubTable
+}
+
+func NewStubProvider(table StubTable) *StubProvider {
+	if table.Rules == nil {
+		table.Rules = map[string]StubRule{}
+	}
+	return &StubProvider{table: table}
+}
+
+func LoadStubTable(path string) (StubTable, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return StubTable{}, fmt.Errorf("read ai gate stub table: %w", err)
+	}
+	var table StubTable
+	if err := json.Unmarshal(buf, &table); err != nil {
+		return StubTable{}, fmt.Errorf("parse ai gate stub table: %w", err)
+	}
+	return table, nil
+}
+
+func DefaultStubRule() StubRule {
+	return StubRule{
+		Verdict: contracts.AIGateAllow,
+		Reasons: []string{string(reasoncodes.OK)},
+	}
+}
+
+func (p *StubProvider) Complete(ctx context.Context, req ProviderRequest) (ProviderResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return ProviderResponse{}, err
+	}
+	rule, ok := p.table.Rules[req.InputHash]
+	if !ok {
+		if p.table.Default != nil {
+			rule = *p.table.Default
+		} else {
+			rule = DefaultStubRule()
+		}
+	}
+	switch rule.Error {
+	case "":
+	case "timeout":
+		return ProviderResponse{Model: StubModel}, context.DeadlineExceeded
+	case "empty":
+		return ProviderResponse{Model: StubModel}, ErrEmptyResponse
+	default:
+		return ProviderResponse{Model: StubModel}, errors.New(rule.Error)
+	}
+	if rule.Raw != "" {
+		return ProviderResponse{Content: rule.Raw, Model: StubModel}, nil
+	}
+	buf, err := json.Marshal(ModelResponse{
+		Verdict:          rule.Verdict,
+		Reasons:          rule.Reasons,
+		ModifiedDecision: rule.ModifiedDecision,
+	})
+	if err != nil {
+		return ProviderResponse{}, fmt.Errorf("stub response marshal: %w", err)
+	}
+	return ProviderResponse{Content: string(buf), Model: StubModel}, nil
+}
@@ -0,0 +1,166 @@
+package aigate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func TestGateStubVerdicts(t *testing.T) {
+	decision := baseDecision()
+	snapshot := stubSnapshot()
+	inputHash := testInputHash(t, decision, snapshot)
+	modified := baseDecision()
+	modified.EntryPlan.Qty = "0.005"
+
+	cases := []struct {
+		name     string
+		rule     StubRule
+		verdict  contracts.AIGateVerdict
+		reason   reasoncodes.ReasonCode
+		modified bool
+	}{
+		{name: "allow", rule: StubRule{Verdict: contracts.AIGateAllow, Reasons: []string{"STRAT_OK"}}, verdict: contracts.AIGateAllow, reason: reasoncodes.STRAT_OK},
+		{name: "block", rule: StubRule{Verdict: contracts.AIGateBlock, Reasons: []string{"REGIME_WEAK"}}, verdict: contracts.AIGateBlock, reason: reasoncodes.REGIME_WEAK},
+		{name: "modify", rule: StubRule{Verdict: contracts.AIGateModify, Reasons: []string{"SPREAD_OPENING"}, ModifiedDecision: &modified}, verdict: contracts.AIGateModify, reason: reasoncodes.SPREAD_OPENING, modified: true},
+		{name: "timeout", rule: StubRule{Error: "timeout"}, verdict: contracts.AIGateError, reason: reasoncodes.AIGATE_TIMEOUT},
+		{name: "raw", rule: StubRule{Raw: `{"verdict":"ALLOW","reasons":["NOT_A_CODE"]}`}, verdict: contracts.AIGateError, reason: reasoncodes.AIGATE_REASON_UNKNOWN},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openTestDB(t)
+			provider := NewStubProvider(StubTable{Rules: map[string]StubRule{inputHash: tc.rule}})
+			gate := newTestGate(t, db, provider)
+			result, applied, _ := gate.Evaluate(context.Background(), CallContext{RunID: "run_1", CycleID: "cyc_1"}, decision, snapshot)
+			if result.Verdict != tc.verdict {
+				t.Fatalf("expected verdict %s, got %s", tc.verdict, result.Verdict)
+			}
+			if len(result.Reasons) == 0 || result.Reasons[0] != tc.reason {
+				t.Fatalf("expected reason %s, got %v", tc.reason, result.Reasons)
+			}
+			if result.InputHash != inputHash {
+				t.Fatalf("expected input hash recorded")
+			}
+			if result.Model != StubModel {
+				t.Fatalf("expected stub model, got %s", result.Model)
+			}
+			if tc.modified && (applied == nil || applied.EntryPlan.Qty != "0.005") {
+				t.Fatalf("expected modified decision applied")
+			}
+			if !tc.modified && applied != nil {
+				t.Fatalf("expected no modified decision")
+			}
+			var count int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM ai_gate_events WHERE input_hash = ? AND verdict = ?`, inputHash, string(tc.verdict)).Scan(&count); err != nil {
+				t.Fatalf("query ai_gate_events: %v", err)
+			}
+			if count != 1 {
+				t.Fatalf("expected 1 recorded event, got %d", count)
+			}
+		})
+	}
+}
+
+func TestStubProviderDefaultsToAllow(t *testing.T) {
+	provider := NewStubProvider(StubTable{})
+	resp, err := provider.Complete(context.Background(), ProviderRequest{InputHash: "unknown"})
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	verdict, _, _, err := ParseModelResponse(resp.Content)
+	if err != nil {
+		t.Fatalf("parse stub response: %v", err)
+	}
+	if verdict != contracts.AIGateAllow {
+		t.Fatalf("expected ALLOW, got %s", verdict)
+	}
+}
+
+func newTestGate(t *testing.T, db *sql.DB, provider Provider) *Gate {
+	t.Helper()
+	cfg := config.Default()
+	cfg.AIGateProvider = "stub"
+	now := func() time.Time { return time.UnixMilli(1706700000000) }
+	gate, err := NewGate(cfg, provider, NewRecorder(cfg, db, nil, now), now)
+	if err != nil {
+		t.Fatalf("new gate: %v", err)
+	}
+	return gate
+}
+
+func testInputHash(t *testing.T, decision contracts.Decision, snapshot contracts.Snapshot) string {
+	t.Helper()
+	snapshotHash, err := snapshot.Hash()
+	if err != nil {
+		t.Fatalf("snapshot hash: %v", err)
+	}
+	payload, err := BuildPayload(decision, snapshot, snapshotHash)
+	if err != nil {
+		t.Fatalf("build payload: %v", err)
+	}
+	prompt, err := LoadPromptSet(DefaultPromptVersion)
+	if err != nil {
+		t.Fatalf("load prompt set: %v", err)
+	}
+	payload.PromptHash = prompt.Hash
+	inputHash, err := InputHash(payload)
+	if err != nil {
+		t.Fatalf("input hash: %v", err)
+	}
+	return inputHash
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	cfg := config.Default()
+	path := t.TempDir() + "/test.sqlite"
+	db, err := sqlite.Open(path, cfg)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	if err := sqlite.Migrate(db, time.UnixMilli(1706700000000)); err != nil {
+		t.Fatalf("migrate sqlite: %v", err)
+	}
+	return db
+}
+
+func stubSnapshot() contracts.Snapshot {
+	return contracts.Snapshot{
+		Symbol: "BTCUSDT",
+		Regime: contracts.RegimeSnapshot{
+			Label:            "TREND",
+			TrendScoreX10000: 7000,
+			RangeScoreX10000: 3000,
+		},
+		Prices: contracts.PricesSnapshot{
+			BestBid:   "100.0",
+			BestAsk:   "100.1",
+			MidPrice:  "100.05",
+			LastPrice: "100.0",
+		},
+		HealthFlags: contracts.HealthFlagsSnapshot{
+			FiltersOK:    true,
+			WSOK:         true,
+			SymbolStatus: "TRADING",
+		},
+		ReturnsSeries: contracts.ReturnsSeries{
+			Timeframe:    "5m",
+			WindowPoints: 2,
+			LogReturnBps: []int32{1, 2},
+		},
+		ConfigReference: contracts.ConfigurationReference{
+			ConfigHash:     "cfg",
+			ThresholdsHash: "thr",
+			FiltersHash:    "flt",
+		},
+	}
+}
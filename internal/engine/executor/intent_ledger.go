@@ -29,8 +29,16 @@ const (
 )
 
 type LedgerService struct {
-	DB    *sql.DB
-	Clock func() time.Time
+	DB       *sql.DB
+	Clock    func() time.Time
+	Outcomes OrderOutcomeObserver
+}
+
+// OrderOutcomeObserver is told how each order mutation ended, per symbol; the symbol quarantine uses it.
+type OrderOutcomeObserver interface {
+	OrderAccepted(symbol string)
+	OrderRejected(symbol string, errCode string)
+	OrderTimedOut(symbol string)
 }
 
 type RestClient interface {
@@ -70,6 +78,32 @@ func (l *LedgerService) MarkFailed(ctx context.Context, id string, errCode strin
 	return sqlite.UpdateOrderIntentState(ctx, l.DB, id, string(IntentFailed), "", "", errCode, errDetail, l.Clock().UnixMilli())
 }
 
+// RecordFills stores the trades an order response reported for intent; the realized outcomes
+// and the panel position book are built from them.
+func (l *LedgerService) RecordFills(ctx context.Context, intent sqlite.OrderIntentRecord, resp OrderResponse) error {
+	filledAtMs := resp.TransactTime
+	if filledAtMs == 0 {
+		filledAtMs = l.Clock().UnixMilli()
+	}
+	for _, fill := range resp.Fills {
+		err := sqlite.InsertOrderFill(ctx, l.DB, sqlite.OrderFillRecord{
+			OrderIntentID:   intent.OrderIntentID,
+			TradeID:         fill.TradeID,
+			Symbol:          intent.Symbol,
+			Side:            string(resp.Side),
+			Price:           fill.Price,
+			Qty:             fill.Qty,
+			Commission:      fill.Commission,
+			CommissionAsset: fill.CommissionAsset,
+			FilledAtMs:      filledAtMs,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (l *LedgerService) PendingSentUnknown(ctx context.Context, limit int) ([]sqlite.OrderIntentRecord, error) {
 	return sqlite.ListOrderIntentsByState(ctx, l.DB, string(IntentSentUnknown), limit)
 }
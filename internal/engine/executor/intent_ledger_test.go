@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -97,6 +98,68 @@ func TestResolveSentUnknownNotFound(t *testing.T) {
 	}
 }
 
+type fakeSubmitClient struct {
+	resp OrderResponse
+	err  error
+}
+
+func (f fakeSubmitClient) SubmitOrder(ctx context.Context, req OrderRequest) (OrderResponse, error) {
+	return f.resp, f.err
+}
+
+func (f fakeSubmitClient) CancelOrder(ctx context.Context, req CancelRequest) (OrderResponse, error) {
+	return f.resp, f.err
+}
+
+func (f fakeSubmitClient) CancelReplaceOrder(ctx context.Context, req CancelReplaceRequest) (OrderResponse, error) {
+	return f.resp, f.err
+}
+
+type recordingObserver struct {
+	rejects []string
+}
+
+func (r *recordingObserver) OrderAccepted(symbol string) {}
+
+func (r *recordingObserver) OrderRejected(symbol string, errCode string) {
+	r.rejects = append(r.rejects, symbol+":"+errCode)
+}
+
+func (r *recordingObserver) OrderTimedOut(symbol string) {}
+
+func TestSubmitCountsOnlyExchangeRejects(t *testing.T) {
+	db := openTestDB(t)
+	ledger := NewLedger(db, func() time.Time { return time.UnixMilli(1706700000000) })
+	observer := &recordingObserver{}
+	ledger.Outcomes = observer
+	ctx := context.Background()
+	intent := func(id string) sqlite.OrderIntentRecord {
+		return sqlite.OrderIntentRecord{
+			OrderIntentID:     id,
+			RunID:             "run_1",
+			CycleID:           "cyc_1",
+			Mode:              "LIVE",
+			DecisionID:        "dec_1",
+			Symbol:            "BTCUSDT",
+			Action:            string(IntentActionNewOrder),
+			ClientOrderID:     "client_" + id,
+			IntentPayloadJSON: "{}",
+		}
+	}
+	if _, err := SubmitWithIntent(ctx, ledger, fakeSubmitClient{err: errors.New("rate limited")}, intent("intent_3"), OrderRequest{}); err == nil {
+		t.Fatalf("expected submit error")
+	}
+	if len(observer.rejects) != 0 {
+		t.Fatalf("expected a local failure not counted as reject, got %v", observer.rejects)
+	}
+	if _, err := SubmitWithIntent(ctx, ledger, fakeSubmitClient{resp: OrderResponse{Rejected: true, ErrorCode: "-2010"}}, intent("intent_4"), OrderRequest{}); err != nil {
+		t.Fatalf("submit rejected: %v", err)
+	}
+	if len(observer.rejects) != 1 || observer.rejects[0] != "BTCUSDT:-2010" {
+		t.Fatalf("expected one -2010 reject, got %v", observer.rejects)
+	}
+}
+
 func openTestDB(t *testing.T) *sql.DB {
 	t.Helper()
 	cfg := config.Default()
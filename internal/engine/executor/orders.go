@@ -23,6 +23,7 @@ func SubmitWithIntent(ctx context.Context, ledger *LedgerService, rest OrderRest
 	if err != nil {
 		if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 			_ = ledger.MarkSentUnknown(ctx, intent.OrderIntentID, "TIMEOUT", "submit timeout")
+			ledger.observeTimeout(intent.Symbol)
 			return OrderResponse{}, ErrSentUnknown
 		}
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "submit failed")
@@ -30,11 +31,16 @@ func SubmitWithIntent(ctx context.Context, ledger *LedgerService, rest OrderRest
 	}
 	if resp.Rejected {
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "submit rejected")
+		ledger.observeReject(intent.Symbol, rejectCode(resp))
 		return resp, nil
 	}
+	ledger.observeAccepted(intent.Symbol)
 	if err := ledger.MarkConfirmed(ctx, intent.OrderIntentID, resp.OrderID, ""); err != nil {
 		return resp, err
 	}
+	if err := ledger.RecordFills(ctx, intent, resp); err != nil {
+		return resp, err
+	}
 	return resp, nil
 }
 
@@ -46,6 +52,7 @@ func CancelWithIntent(ctx context.Context, ledger *LedgerService, rest OrderRest
 	if err != nil {
 		if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 			_ = ledger.MarkSentUnknown(ctx, intent.OrderIntentID, "TIMEOUT", "cancel timeout")
+			ledger.observeTimeout(intent.Symbol)
 			return OrderResponse{}, ErrSentUnknown
 		}
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "cancel failed")
@@ -55,6 +62,7 @@ func CancelWithIntent(ctx context.Context, ledger *LedgerService, rest OrderRest
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "cancel rejected")
 		return resp, nil
 	}
+	ledger.observeAccepted(intent.Symbol)
 	if err := ledger.MarkConfirmed(ctx, intent.OrderIntentID, resp.OrderID, ""); err != nil {
 		return resp, err
 	}
@@ -69,6 +77,7 @@ func CancelReplaceWithIntent(ctx context.Context, ledger *LedgerService, rest Or
 	if err != nil {
 		if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 			_ = ledger.MarkSentUnknown(ctx, intent.OrderIntentID, "TIMEOUT", "cancel_replace timeout")
+			ledger.observeTimeout(intent.Symbol)
 			return OrderResponse{}, ErrSentUnknown
 		}
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "cancel_replace failed")
@@ -76,10 +85,43 @@ func CancelReplaceWithIntent(ctx context.Context, ledger *LedgerService, rest Or
 	}
 	if resp.Rejected {
 		_ = ledger.MarkFailed(ctx, intent.OrderIntentID, "REJECTED", "cancel_replace rejected")
+		ledger.observeReject(intent.Symbol, rejectCode(resp))
 		return resp, nil
 	}
+	ledger.observeAccepted(intent.Symbol)
 	if err := ledger.MarkConfirmed(ctx, intent.OrderIntentID, resp.OrderID, ""); err != nil {
 		return resp, err
 	}
+	if err := ledger.RecordFills(ctx, intent, resp); err != nil {
+		return resp, err
+	}
 	return resp, nil
 }
+
+func (l *LedgerService) observeAccepted(symbol string) {
+	if l.Outcomes != nil {
+		l.Outcomes.OrderAccepted(symbol)
+	}
+}
+
+func (l *LedgerService) observeReject(symbol string, errCode string) {
+	if l.Outcomes != nil {
+		l.Outcomes.OrderRejected(symbol, errCode)
+	}
+}
+
+// rejectCode returns the exchange error code the order client put on a reject (e.g. -2010),
+// or REJECTED when the exchange gave none. Only exchange rejects reach the quarantine counters;
+// transport, throttling and local failures do not.
+func rejectCode(resp OrderResponse) string {
+	if resp.ErrorCode != "" {
+		return resp.ErrorCode
+	}
+	return "REJECTED"
+}
+
+func (l *LedgerService) observeTimeout(symbol string) {
+	if l.Outcomes != nil {
+		l.Outcomes.OrderTimedOut(symbol)
+	}
+}
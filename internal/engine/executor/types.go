@@ -25,9 +25,22 @@ type OrderRequest struct {
 type OrderResponse struct {
 	Found         bool
 	Rejected      bool
+	ErrorCode     string
 	OrderID       string
 	ClientOrderID string
 	Status        string
+	Side          contracts.Side
+	TransactTime  int64
+	Fills         []Fill
+}
+
+// Fill is one exchange trade of an order; Commission is charged in CommissionAsset.
+type Fill struct {
+	TradeID         int64
+	Price           string
+	Qty             string
+	Commission      string
+	CommissionAsset string
 }
 
 type CancelRequest struct {
@@ -37,6 +50,9 @@ type CancelRequest struct {
 
 type CancelReplaceRequest struct {
 	Symbol        string
+	Side          contracts.Side
+	Type          OrderType
+	TimeInForce   contracts.TimeInForce
 	ClientOrderID string
 	NewClientID   string
 	NewPrice      string
@@ -17,14 +17,18 @@ const (
 )
 
 type Signals struct {
-	NowMs              int64
-	LastProgressMs     int64
-	WsLastMsgMs        int64
-	RestLastSuccessMs  int64
-	DiskFreeBytes      int64
-	AuditQueuePct      int
-	AuditWriterLagMs   int
-	ForceExitRequested bool
+	NowMs               int64
+	LastProgressMs      int64
+	WsLastMsgMs         int64
+	RestLastSuccessMs   int64
+	DiskFreeBytes       int64
+	AuditQueuePct       int
+	AuditWriterLagMs    int
+	ForceExitRequested  bool
+	OperatorPause       bool
+	FlattenPending      bool
+	LiveChecklistFailed bool
+	AIGatePause         bool
 }
 
 type Result struct {
@@ -108,6 +112,26 @@ func (e Evaluator) Evaluate(current SysMode, signals Signals) Result {
 		addReason(reason)
 	}
 
+	if signals.LiveChecklistFailed {
+		desired = SysModePause
+		addReason(reasoncodes.LIVE_CHECKLIST_FAILED)
+	}
+
+	if signals.AIGatePause {
+		desired = SysModePause
+		addReason(reasoncodes.AIGATE_PAUSE_REQUESTED)
+	}
+
+	if signals.OperatorPause {
+		desired = SysModePause
+		addReason(reasoncodes.OPERATOR_PAUSE)
+	} else if signals.FlattenPending {
+		if desired != SysModePause {
+			desired = SysModeDegrade
+		}
+		addReason(reasoncodes.OPERATOR_FLATTEN)
+	}
+
 	return Result{Mode: desired, Reasons: reasons}
 }
 
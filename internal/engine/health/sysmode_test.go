@@ -131,6 +131,31 @@ func TestEvaluatorWriterPressureTransitions(t *testing.T) {
 	}
 }
 
+func TestEvaluatorOperatorControls(t *testing.T) {
+	cfg := config.Default()
+	eval := NewEvaluator(cfg)
+	now := int64(300000)
+
+	signals := baseSignals(cfg, now)
+	signals.FlattenPending = true
+	result := eval.Evaluate(SysModeNormal, signals)
+	if result.Mode != SysModeDegrade || !containsReason(result.Reasons, reasoncodes.OPERATOR_FLATTEN) {
+		t.Fatalf("expected degrade with operator flatten, got %s %v", result.Mode, result.Reasons)
+	}
+
+	signals.OperatorPause = true
+	result = eval.Evaluate(SysModeDegrade, signals)
+	if result.Mode != SysModePause || !containsReason(result.Reasons, reasoncodes.OPERATOR_PAUSE) {
+		t.Fatalf("expected pause with operator pause, got %s %v", result.Mode, result.Reasons)
+	}
+
+	signals = baseSignals(cfg, now)
+	result = eval.Evaluate(SysModePause, signals)
+	if result.Mode != SysModeNormal {
+		t.Fatalf("expected normal after resume, got %s", result.Mode)
+	}
+}
+
 func baseSignals(cfg config.Config, now int64) Signals {
 	return Signals{
 		NowMs:             now,
@@ -0,0 +1,99 @@
+package persist
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/risk"
+)
+
+type QuarantineStore struct {
+	db *sql.DB
+}
+
+func NewQuarantineStore(db *sql.DB) *QuarantineStore {
+	return &QuarantineStore{db: db}
+}
+
+func (s *QuarantineStore) Load(ctx context.Context) ([]risk.SymbolHealth, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT symbol, quarantined_until_ms, last_error_code, updated_at_ms,
+  quarantine_trigger, reject_ts_json, ws_disconnect_ts_json, consecutive_timeouts
+FROM symbol_health ORDER BY symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("symbol health load: %w", err)
+	}
+	defer rows.Close()
+	var out []risk.SymbolHealth
+	for rows.Next() {
+		var h risk.SymbolHealth
+		var lastErr sql.NullString
+		var trigger, rejects, disconnects string
+		if err := rows.Scan(&h.Symbol, &h.QuarantinedUntilMs, &lastErr, &h.UpdatedAtMs, &trigger, &rejects, &disconnects, &h.ConsecutiveTimeouts); err != nil {
+			return nil, fmt.Errorf("symbol health scan: %w", err)
+		}
+		h.LastErrorCode = lastErr.String
+		h.Trigger = risk.QuarantineTrigger(trigger)
+		if err := json.Unmarshal([]byte(rejects), &h.RejectTsMs); err != nil {
+			return nil, fmt.Errorf("symbol health %s rejects: %w", h.Symbol, err)
+		}
+		if err := json.Unmarshal([]byte(disconnects), &h.WSDisconnectTsMs); err != nil {
+			return nil, fmt.Errorf("symbol health %s disconnects: %w", h.Symbol, err)
+		}
+		out = append(out, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("symbol health rows: %w", err)
+	}
+	return out, nil
+}
+
+func (s *QuarantineStore) Save(ctx context.Context, h risk.SymbolHealth) error {
+	rejects, err := json.Marshal(nonNil(h.RejectTsMs))
+	if err != nil {
+		return fmt.Errorf("symbol health rejects json: %w", err)
+	}
+	disconnects, err := json.Marshal(nonNil(h.WSDisconnectTsMs))
+	if err != nil {
+		return fmt.Errorf("symbol health disconnects json: %w", err)
+	}
+	var lastErr any
+	if h.LastErrorCode != "" {
+		lastErr = h.LastErrorCode
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO symbol_health (
+  symbol, quarantined_until_ms, recent_rejects_count, last_error_code, updated_at_ms,
+  quarantine_trigger, reject_ts_json, ws_disconnect_ts_json, consecutive_timeouts
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(symbol) DO UPDATE SET
+  quarantined_until_ms = excluded.quarantined_until_ms,
+  recent_rejects_count = excluded.recent_rejects_count,
+  last_error_code = excluded.last_error_code,
+  updated_at_ms = excluded.updated_at_ms,
+  quarantine_trigger = excluded.quarantine_trigger,
+  reject_ts_json = excluded.reject_ts_json,
+  ws_disconnect_ts_json = excluded.ws_disconnect_ts_json,
+  consecutive_timeouts = excluded.consecutive_timeouts`,
+		h.Symbol,
+		h.QuarantinedUntilMs,
+		len(h.RejectTsMs),
+		lastErr,
+		h.UpdatedAtMs,
+		string(h.Trigger),
+		string(rejects),
+		string(disconnects),
+		h.ConsecutiveTimeouts,
+	)
+	if err != nil {
+		return fmt.Errorf("symbol health save %s: %w", h.Symbol, err)
+	}
+	return nil
+}
+
+func nonNil(ts []int64) []int64 {
+	if ts == nil {
+		return []int64{}
+	}
+	return ts
+}
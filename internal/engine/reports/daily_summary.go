@@ -0,0 +1,637 @@
+package reports
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/health"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+const (
+	DayLayout  = "2006-01-02"
+	filePrefix = "daily-"
+)
+
+var (
+	dayPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+	sysModeByReason = map[string]health.SysMode{
+		string(reasoncodes.ENTER_NORMAL):  health.SysModeNormal,
+		string(reasoncodes.ENTER_DEGRADE): health.SysModeDegrade,
+		string(reasoncodes.ENTER_PAUSE):   health.SysModePause,
+		string(reasoncodes.ENTER_EXIT):    health.SysModeExit,
+	}
+	rateLimitReasons = map[string]struct{}{
+		string(reasoncodes.RATE_LIMIT_418):      {},
+		string(reasoncodes.RATE_LIMIT_429):      {},
+		string(reasoncodes.RETRY_AFTER_APPLIED): {},
+	}
+)
+
+type ReasonCount struct {
+	ReasonCode string `json:"reason_code"`
+	Count      int64  `json:"count"`
+}
+
+type TradeStats struct {
+	Entries    int64  `json:"entries"`
+	Exits      int64  `json:"exits"`
+	Wins       int64  `json:"wins"`
+	Losses     int64  `json:"losses"`
+	WinRatePct string `json:"win_rate_pct"`
+}
+
+type ReconcileStats struct {
+	Diffs               int64 `json:"diffs"`
+	DriftLimitExceeded  int64 `json:"drift_limit_exceeded"`
+	MaxDriftScoreX10000 int64 `json:"max_drift_score_x10000"`
+}
+
+type DailySummary struct {
+	Day                string           `json:"day"`
+	FromTsMs           int64            `json:"from_ts_ms"`
+	ToTsMs             int64            `json:"to_ts_ms"`
+	GeneratedAtMs      int64            `json:"generated_at_ms"`
+	Mode               string           `json:"mode"`
+	Trades             TradeStats       `json:"trades"`
+	RealizedPnLQuote   string           `json:"realized_pnl_quote"`
+	FeesQuote          string           `json:"fees_quote"`
+	NetPnLQuote        string           `json:"net_pnl_quote"`
+	MaxDrawdownQuote   string           `json:"max_drawdown_quote"`
+	BlockReasons       []ReasonCount    `json:"block_reasons"`
+	AIGateVerdicts     map[string]int64 `json:"aigate_verdicts"`
+	SysModeMs          map[string]int64 `json:"sys_mode_ms"`
+	ReconcileDrifts    ReconcileStats   `json:"reconcile_drifts"`
+	RateLimitIncidents []ReasonCount    `json:"rate_limit_incidents"`
+}
+
+type Result struct {
+	Summary      DailySummary
+	JSONPath     string
+	MarkdownPath string
+}
+
+type Generator struct {
+	cfg    config.Config
+	db     *sql.DB
+	writer *audit.Writer
+	dir    string
+	now    func() time.Time
+}
+
+func NewGenerator(cfg config.Config, db *sql.DB, writer *audit.Writer, now func() time.Time) (*Generator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("reports db missing")
+	}
+	if writer == nil {
+		return nil, fmt.Errorf("reports audit writer missing")
+	}
+	if cfg.ReportDir == "" {
+		return nil, fmt.Errorf("reports dir missing")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Generator{cfg: cfg, db: db, writer: writer, dir: cfg.ReportDir, now: now}, nil
+}
+
+func DayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func ParseDay(value string) (time.Time, error) {
+	if !dayPattern.MatchString(value) {
+		return time.Time{}, fmt.Errorf("invalid report day: %q", value)
+	}
+	day, err := time.Parse(DayLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid report day: %q", value)
+	}
+	return day, nil
+}
+
+func JSONPath(dir string, day time.Time) string {
+	return filepath.Join(dir, filePrefix+DayStart(day).Format(DayLayout)+".json")
+}
+
+func MarkdownPath(dir string, day time.Time) string {
+	return filepath.Join(dir, filePrefix+DayStart(day).Format(DayLayout)+".md")
+}
+
+func ListDays(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	days := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), ".json")
+		if _, err := ParseDay(day); err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	return days, nil
+}
+
+func (g *Generator) Exists(day time.Time) bool {
+	_, err := os.Stat(JSONPath(g.dir, day))
+	return err == nil
+}
+
+func (g *Generator) Generate(ctx context.Context, day time.Time, runID string, cycleID string) (Result, error) {
+	summary, err := ComputeDailySummary(ctx, g.db, g.cfg.Mode, day, g.now())
+	if err != nil {
+		return Result{}, fmt.Errorf("daily summary: %w", err)
+	}
+	buf, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return Result{}, fmt.Errorf("daily summary json: %w", err)
+	}
+	buf = append(buf, '\n')
+	if err := os.MkdirAll(g.dir, 0o750); err != nil {
+		return Result{}, fmt.Errorf("reports dir: %w", err)
+	}
+	out := Result{Summary: summary, JSONPath: JSONPath(g.dir, day), MarkdownPath: MarkdownPath(g.dir, day)}
+	if err := writeFileAtomic(out.JSONPath, buf); err != nil {
+		return Result{}, fmt.Errorf("daily summary json write: %w", err)
+	}
+	if err := writeFileAtomic(out.MarkdownPath, []byte(RenderMarkdown(summary))); err != nil {
+		return Result{}, fmt.Errorf("daily summary markdown write: %w", err)
+	}
+	sum := sha256.Sum256(buf)
+	now := g.now()
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            g.cfg.Mode,
+			Stage:           observability.REPORT_DAILY_SUMMARY,
+			EventType:       auditdomain.DAILY_SUMMARY,
+			Reasons:         []reasoncodes.ReasonCode{},
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"day":                summary.Day,
+			"json_path":          filepath.ToSlash(out.JSONPath),
+			"md_path":            filepath.ToSlash(out.MarkdownPath),
+			"json_sha256":        hex.EncodeToString(sum[:]),
+			"trades_entries":     summary.Trades.Entries,
+			"trades_exits":       summary.Trades.Exits,
+			"realized_pnl_quote": summary.RealizedPnLQuote,
+			"fees_quote":         summary.FeesQuote,
+			"net_pnl_quote":      summary.NetPnLQuote,
+			"max_drawdown_quote": summary.MaxDrawdownQuote,
+		},
+	}
+	if err := g.writer.WriteSync(record); err != nil {
+		return Result{}, fmt.Errorf("audit daily summary write: %w", err)
+	}
+	return out, nil
+}
+
+func ComputeDailySummary(ctx context.Context, db *sql.DB, mode string, day time.Time, now time.Time) (DailySummary, error) {
+	from := DayStart(day)
+	to := from.AddDate(0, 0, 1)
+	out := DailySummary{
+		Day:                from.Format(DayLayout),
+		FromTsMs:           from.UnixMilli(),
+		ToTsMs:             to.UnixMilli(),
+		GeneratedAtMs:      now.UnixMilli(),
+		Mode:               mode,
+		AIGateVerdicts:     map[string]int64{},
+		SysModeMs:          map[string]int64{},
+		BlockReasons:       []ReasonCount{},
+		RateLimitIncidents: []ReasonCount{},
+	}
+	if err := summarizeTrades(ctx, db, &out); err != nil {
+		return DailySummary{}, err
+	}
+	blocks := map[string]int64{}
+	if err := summarizeAIGate(ctx, db, &out, blocks); err != nil {
+		return DailySummary{}, err
+	}
+	if err := summarizeAudit(ctx, db, &out, blocks); err != nil {
+		return DailySummary{}, err
+	}
+	out.BlockReasons = sortedCounts(blocks)
+	return out, nil
+}
+
+func summarizeTrades(ctx context.Context, db *sql.DB, out *DailySummary) error {
+	rows, err := db.QueryContext(ctx, `SELECT symbol, intent_payload_json, updated_at_ms
+FROM order_intents WHERE action = ? AND state = ? AND updated_at_ms < ? ORDER BY updated_at_ms, order_intent_id`,
+		string(executor.IntentActionNewOrder), string(executor.IntentConfirmed), out.ToTsMs)
+	if err != nil {
+		return err
+	}
+	type fill struct {
+		symbol  string
+		payload executor.OrderIntentHashPayload
+		qty     *big.Rat
+		price   *big.Rat
+		ts      int64
+	}
+	var fills []fill
+	for rows.Next() {
+		var f fill
+		var payloadJSON string
+		if err := rows.Scan(&f.symbol, &payloadJSON, &f.ts); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if err := json.Unmarshal([]byte(payloadJSON), &f.payload); err != nil {
+			continue
+		}
+		qty, okQty := new(big.Rat).SetString(f.payload.EntryPlanQty)
+		price, okPrice := new(big.Rat).SetString(f.payload.EntryPlanPrice)
+		if !okQty || !okPrice {
+			continue
+		}
+		f.qty, f.price = qty, price
+		fills = append(fills, f)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	_ = rows.Close()
+
+	type position struct {
+		qty  *big.Rat
+		cost *big.Rat
+	}
+	positions := map[string]*position{}
+	realized := new(big.Rat)
+	fees := new(big.Rat)
+	net := new(big.Rat)
+	peak := new(big.Rat)
+	maxDrawdown := new(big.Rat)
+	for _, f := range fills {
+		pos, ok := positions[f.symbol]
+		if !ok {
+			pos = &position{qty: new(big.Rat), cost: new(big.Rat)}
+			positions[f.symbol] = pos
+		}
+		inDay := f.ts >= out.FromTsMs
+		notional := new(big.Rat).Mul(f.qty, f.price)
+		fee := new(big.Rat)
+		if inDay {
+			costs, err := costInputsAt(ctx, db, f.symbol, f.ts)
+			if err != nil {
+				return err
+			}
+			// Intents do not record whether a fill rested, so every fill is
+			// charged taker bps: the conservative side of the estimate.
+			fee.Mul(notional, big.NewRat(int64(costs.TakerFeeBps), 10000))
+			fees.Add(fees, fee)
+			net.Sub(net, fee)
+		}
+		if f.payload.Side == contracts.SideBuy {
+			pos.qty.Add(pos.qty, f.qty)
+			pos.cost.Add(pos.cost, notional)
+			if inDay && f.payload.Intent == contracts.IntentEntry {
+				out.Trades.Entries++
+			}
+		} else if pos.qty.Sign() > 0 {
+			sold := f.qty
+			if sold.Cmp(pos.qty) > 0 {
+				sold = new(big.Rat).Set(pos.qty)
+			}
+			avg := new(big.Rat).Quo(pos.cost, pos.qty)
+			pnl := new(big.Rat).Mul(sold, new(big.Rat).Sub(f.price, avg))
+			pos.cost.Sub(pos.cost, new(big.Rat).Mul(sold, avg))
+			pos.qty.Sub(pos.qty, sold)
+			if inDay {
+				out.Trades.Exits++
+				if pnl.Sign() > 0 {
+					out.Trades.Wins++
+				} else {
+					out.Trades.Losses++
+				}
+				realized.Add(realized, pnl)
+				net.Add(net, pnl)
+			}
+		}
+		if !inDay {
+			continue
+		}
+		if net.Cmp(peak) > 0 {
+			peak.Set(net)
+		}
+		drawdown := new(big.Rat).Sub(peak, net)
+		if drawdown.Cmp(maxDrawdown) > 0 {
+			maxDrawdown.Set(drawdown)
+		}
+	}
+	out.RealizedPnLQuote = realized.FloatString(2)
+	out.FeesQuote = fees.FloatString(2)
+	out.NetPnLQuote = net.FloatString(2)
+	out.MaxDrawdownQuote = maxDrawdown.FloatString(2)
+	out.Trades.WinRatePct = "0.00"
+	if decided := out.Trades.Wins + out.Trades.Losses; decided > 0 {
+		out.Trades.WinRatePct = big.NewRat(out.Trades.Wins*100, decided).FloatString(2)
+	}
+	return nil
+}
+
+func costInputsAt(ctx context.Context, db *sql.DB, symbol string, tsMs int64) (contracts.CostInputs, error) {
+	var snapshotJSON string
+	err := db.QueryRowContext(ctx, `SELECT snapshot_json FROM snapshots WHERE symbol = ? AND created_at_ms <= ?
+ORDER BY created_at_ms DESC LIMIT 1`, symbol, tsMs).Scan(&snapshotJSON)
+	if err == sql.ErrNoRows {
+		return contracts.CostInputs{}, nil
+	}
+	if err != nil {
+		return contracts.CostInputs{}, err
+	}
+	var snap contracts.Snapshot
+	if err := json.Unmarshal([]byte(snapshotJSON), &snap); err != nil {
+		return contracts.CostInputs{}, nil
+	}
+	return snap.CostInputs, nil
+}
+
+func summarizeAIGate(ctx context.Context, db *sql.DB, out *DailySummary, blocks map[string]int64) error {
+	rows, err := db.QueryContext(ctx, `SELECT verdict, reasons_json FROM ai_gate_events WHERE created_at_ms >= ? AND created_at_ms < ?`,
+		out.FromTsMs, out.ToTsMs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var verdict, reasonsJSON string
+		if err := rows.Scan(&verdict, &reasonsJSON); err != nil {
+			return err
+		}
+		out.AIGateVerdicts[verdict]++
+		if verdict != "BLOCK" {
+			continue
+		}
+		var reasons []string
+		_ = json.Unmarshal([]byte(reasonsJSON), &reasons)
+		for _, reason := range reasons {
+			blocks[reason]++
+		}
+	}
+	return rows.Err()
+}
+
+type runSpan struct {
+	firstMs int64
+	lastMs  int64
+	mode    health.SysMode
+	sinceMs int64
+	seen    bool
+}
+
+func summarizeAudit(ctx context.Context, db *sql.DB, out *DailySummary, blocks map[string]int64) error {
+	rows, err := db.QueryContext(ctx, `SELECT ts_ms, run_id, stage, event_type, reasons_json, data_json
+FROM audit_events WHERE ts_ms >= ? AND ts_ms < ? AND event_type != ? ORDER BY ts_ms, event_id`,
+		out.FromTsMs, out.ToTsMs, string(auditdomain.WEBUI_REQUEST))
+	if err != nil {
+		return err
+	}
+	rateLimits := map[string]int64{}
+	runs := map[string]*runSpan{}
+	var runOrder []string
+	type transition struct {
+		runID string
+		tsMs  int64
+		mode  health.SysMode
+	}
+	var transitions []transition
+	for rows.Next() {
+		var ts int64
+		var runID, stage, eventType, reasonsJSON, dataJSON string
+		if err := rows.Scan(&ts, &runID, &stage, &eventType, &reasonsJSON, &dataJSON); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		span, ok := runs[runID]
+		if !ok {
+			span = &runSpan{firstMs: ts}
+			runs[runID] = span
+			runOrder = append(runOrder, runID)
+		}
+		span.lastMs = ts
+		var reasons []string
+		_ = json.Unmarshal([]byte(reasonsJSON), &reasons)
+		for _, reason := range reasons {
+			if _, ok := rateLimitReasons[reason]; ok {
+				rateLimits[reason]++
+			}
+		}
+		switch auditdomain.AuditEventType(eventType) {
+		case auditdomain.STAGE_CHANGED:
+			if mode, ok := sysModeTransition(reasons); ok {
+				transitions = append(transitions, transition{runID: runID, tsMs: ts, mode: mode})
+			}
+			if stage == string(observability.RISK_VERDICT) {
+				for _, reason := range reasons {
+					if reason == string(reasoncodes.OK) || strings.HasSuffix(reason, "_OK") {
+						continue
+					}
+					blocks[reason]++
+				}
+			}
+		case auditdomain.RECONCILE_DIFF:
+			out.ReconcileDrifts.Diffs++
+			var data map[string]any
+			_ = json.Unmarshal([]byte(dataJSON), &data)
+			if score, ok := data["drift_score_x10000"].(float64); ok && int64(score) > out.ReconcileDrifts.MaxDriftScoreX10000 {
+				out.ReconcileDrifts.MaxDriftScoreX10000 = int64(score)
+			}
+		case auditdomain.ALERT_RAISED:
+			for _, reason := range reasons {
+				if reason == string(reasoncodes.DRIFT_LIMIT_EXCEEDED) {
+					out.ReconcileDrifts.DriftLimitExceeded++
+					break
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	_ = rows.Close()
+	out.RateLimitIncidents = sortedCounts(rateLimits)
+
+	for _, mode := range []health.SysMode{health.SysModeNormal, health.SysModeDegrade, health.SysModePause, health.SysModeExit} {
+		out.SysModeMs[string(mode)] = 0
+	}
+	for _, runID := range runOrder {
+		span := runs[runID]
+		mode, startedBefore, err := sysModeAtStart(ctx, db, runID, out.FromTsMs)
+		if err != nil {
+			return err
+		}
+		if startedBefore {
+			span.firstMs = out.FromTsMs
+		}
+		continues, err := runHasEvent(ctx, db, `SELECT 1 FROM audit_events WHERE run_id = ? AND ts_ms >= ? LIMIT 1`, runID, out.ToTsMs)
+		if err != nil {
+			return err
+		}
+		if continues {
+			span.lastMs = out.ToTsMs
+		}
+		span.mode = mode
+		span.sinceMs = span.firstMs
+	}
+	for _, t := range transitions {
+		span := runs[t.runID]
+		out.SysModeMs[string(span.mode)] += t.tsMs - span.sinceMs
+		span.mode = t.mode
+		span.sinceMs = t.tsMs
+	}
+	for _, runID := range runOrder {
+		span := runs[runID]
+		out.SysModeMs[string(span.mode)] += span.lastMs - span.sinceMs
+	}
+	return nil
+}
+
+func sysModeTransition(reasons []string) (health.SysMode, bool) {
+	for _, reason := range reasons {
+		if mode, ok := sysModeByReason[reason]; ok {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+func sysModeAtStart(ctx context.Context, db *sql.DB, runID string, fromMs int64) (health.SysMode, bool, error) {
+	startedBefore, err := runHasEvent(ctx, db, `SELECT 1 FROM audit_events WHERE run_id = ? AND ts_ms < ? LIMIT 1`, runID, fromMs)
+	if err != nil || !startedBefore {
+		return health.SysModeNormal, false, err
+	}
+	var reasonsJSON string
+	err = db.QueryRowContext(ctx, `SELECT reasons_json FROM audit_events
+WHERE run_id = ? AND ts_ms < ? AND event_type = ? AND reasons_json LIKE '%"ENTER_%'
+ORDER BY ts_ms DESC, event_id DESC LIMIT 1`, runID, fromMs, string(auditdomain.STAGE_CHANGED)).Scan(&reasonsJSON)
+	if err == sql.ErrNoRows {
+		return health.SysModeNormal, true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	var reasons []string
+	_ = json.Unmarshal([]byte(reasonsJSON), &reasons)
+	if mode, ok := sysModeTransition(reasons); ok {
+		return mode, true, nil
+	}
+	return health.SysModeNormal, true, nil
+}
+
+func runHasEvent(ctx context.Context, db *sql.DB, query string, runID string, tsMs int64) (bool, error) {
+	var one int
+	err := db.QueryRowContext(ctx, query, runID, tsMs).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func sortedCounts(counts map[string]int64) []ReasonCount {
+	out := make([]ReasonCount, 0, len(counts))
+	for reason, count := range counts {
+		out = append(out, ReasonCount{ReasonCode: reason, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].ReasonCode < out[j].ReasonCode
+	})
+	return out
+}
+
+func RenderMarkdown(s DailySummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# livespot daily summary %s (UTC)\n\n", s.Day)
+	fmt.Fprintf(&b, "- mode: %s\n", s.Mode)
+	fmt.Fprintf(&b, "- generated_at: %s\n\n", time.UnixMilli(s.GeneratedAtMs).UTC().Format(time.RFC3339))
+	b.WriteString("## Trades and PnL\n\n")
+	b.WriteString("| metric | value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| entries | %d |\n", s.Trades.Entries)
+	fmt.Fprintf(&b, "| exits | %d |\n", s.Trades.Exits)
+	fmt.Fprintf(&b, "| wins / losses | %d / %d |\n", s.Trades.Wins, s.Trades.Losses)
+	fmt.Fprintf(&b, "| win rate %% | %s |\n", s.Trades.WinRatePct)
+	fmt.Fprintf(&b, "| realized PnL (quote) | %s |\n", s.RealizedPnLQuote)
+	fmt.Fprintf(&b, "| fees (quote, estimated at taker bps for every fill) | %s |\n", s.FeesQuote)
+	fmt.Fprintf(&b, "| net PnL (quote) | %s |\n", s.NetPnLQuote)
+	fmt.Fprintf(&b, "| max intraday drawdown (quote) | %s |\n\n", s.MaxDrawdownQuote)
+	b.WriteString("## Block reasons\n\n")
+	writeCounts(&b, s.BlockReasons)
+	b.WriteString("## AI Gate verdicts\n\n")
+	writeCounts(&b, sortedCounts(s.AIGateVerdicts))
+	b.WriteString("## SysMode time\n\n")
+	b.WriteString("| mode | minutes |\n|---|---|\n")
+	for _, mode := range []health.SysMode{health.SysModeNormal, health.SysModeDegrade, health.SysModePause, health.SysModeExit} {
+		fmt.Fprintf(&b, "| %s | %s |\n", mode, big.NewRat(s.SysModeMs[string(mode)], 60000).FloatString(1))
+	}
+	b.WriteString("\n## Reconcile\n\n")
+	fmt.Fprintf(&b, "- diffs: %d\n", s.ReconcileDrifts.Diffs)
+	fmt.Fprintf(&b, "- drift limit exceeded: %d\n", s.ReconcileDrifts.DriftLimitExceeded)
+	fmt.Fprintf(&b, "- max drift score (x10000): %d\n\n", s.ReconcileDrifts.MaxDriftScoreX10000)
+	b.WriteString("## Rate-limit incidents\n\n")
+	writeCounts(&b, s.RateLimitIncidents)
+	return b.String()
+}
+
+func writeCounts(b *strings.Builder, counts []ReasonCount) {
+	if len(counts) == 0 {
+		b.WriteString("none\n\n")
+		return
+	}
+	b.WriteString("| code | count |\n|---|---|\n")
+	for _, c := range counts {
+		fmt.Fprintf(b, "| %s | %d |\n", c.ReasonCode, c.Count)
+	}
+	b.WriteString("\n")
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
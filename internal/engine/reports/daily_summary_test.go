@@ -0,0 +1,201 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func TestDailySummaryGenerate(t *testing.T) {
+	cfg := config.Default()
+	temp := t.TempDir()
+	cfg.ReportDir = filepath.Join(temp, "reports")
+	dbPath := filepath.Join(temp, "audit.sqlite")
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: temp, Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	defer writer.Close()
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("db open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, time.Now()); err != nil {
+		t.Fatalf("db migrate: %v", err)
+	}
+
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	insertSnapshot(t, db, "BTCUSDT", at(-2))
+	insertIntent(t, db, "oi_1", contracts.SideBuy, contracts.IntentEntry, "1", "100", at(-1))
+	insertIntent(t, db, "oi_2", contracts.SideSell, contracts.IntentExit, "1", "110", at(1))
+	insertIntent(t, db, "oi_3", contracts.SideBuy, contracts.IntentEntry, "2", "100", at(2))
+	insertIntent(t, db, "oi_4", contracts.SideSell, contracts.IntentExit, "2", "95", at(3))
+
+	insertGate(t, db, "ALLOW", `[]`, at(4))
+	insertGate(t, db, "BLOCK", `["REGIME_WEAK"]`, at(5))
+
+	insertAudit(t, db, "run_a", "DEGRADE", "STAGE_CHANGED", `["ENTER_DEGRADE"]`, `{}`, at(-1))
+	insertAudit(t, db, "run_a", "STATE_UPDATE", "STAGE_CHANGED", `["ENTER_NORMAL"]`, `{}`, at(6))
+	insertAudit(t, db, "run_a", "RISK_VERDICT", "STAGE_CHANGED", `["RISK_EXPOSURE_LIMIT","STRAT_OK"]`, `{}`, at(7))
+	insertAudit(t, db, "run_a", "RISK_VERDICT", "STAGE_CHANGED", `["RISK_EXPOSURE_LIMIT"]`, `{}`, at(8))
+	insertAudit(t, db, "run_a", "RECONCILE_REST", "RECONCILE_DIFF", `["RECONCILE_DIFF_DETECTED"]`, `{"drift_score_x10000":150}`, at(9))
+	insertAudit(t, db, "run_a", "RECONCILE_REST", "ALERT_RAISED", `["ALERT_RAISED","DRIFT_LIMIT_EXCEEDED"]`, `{}`, at(10))
+	insertAudit(t, db, "run_a", "UNIVERSE_SCAN", "STAGE_CHANGED", `["RATE_LIMIT_429","RETRY_AFTER_APPLIED"]`, `{}`, at(11))
+	insertAudit(t, db, "run_a", "UNIVERSE_SCAN", "WEBUI_REQUEST", `["RATE_LIMIT_429"]`, `{}`, at(11))
+	insertAudit(t, db, "run_a", "BOOT", "STAGE_CHANGED", `[]`, `{}`, at(12))
+	insertAudit(t, db, "run_b", "BOOT", "STAGE_CHANGED", `[]`, `{}`, at(18))
+	insertAudit(t, db, "run_b", "PAUSE", "STAGE_CHANGED", `["ENTER_PAUSE","WS_STALE_PAUSE"]`, `{}`, at(20))
+	insertAudit(t, db, "run_b", "PAUSE", "STAGE_CHANGED", `[]`, `{}`, at(25))
+
+	now := day.AddDate(0, 0, 1).Add(time.Minute)
+	gen, err := NewGenerator(cfg, db, writer, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	if gen.Exists(day) {
+		t.Fatalf("report should not exist yet")
+	}
+	result, err := gen.Generate(context.Background(), day, "run_b", "cycle_r")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	s := result.Summary
+	if s.Day != "2026-10-18" || s.Trades.Entries != 1 || s.Trades.Exits != 2 || s.Trades.Wins != 1 || s.Trades.Losses != 1 || s.Trades.WinRatePct != "50.00" {
+		t.Fatalf("unexpected trades: %+v", s.Trades)
+	}
+	if s.RealizedPnLQuote != "0.00" || s.FeesQuote != "1.00" || s.NetPnLQuote != "-1.00" || s.MaxDrawdownQuote != "10.78" {
+		t.Fatalf("unexpected pnl: realized=%s fees=%s net=%s dd=%s", s.RealizedPnLQuote, s.FeesQuote, s.NetPnLQuote, s.MaxDrawdownQuote)
+	}
+	if len(s.BlockReasons) != 2 || s.BlockReasons[0] != (ReasonCount{ReasonCode: "RISK_EXPOSURE_LIMIT", Count: 2}) || s.BlockReasons[1].ReasonCode != "REGIME_WEAK" {
+		t.Fatalf("unexpected block reasons: %+v", s.BlockReasons)
+	}
+	if s.AIGateVerdicts["ALLOW"] != 1 || s.AIGateVerdicts["BLOCK"] != 1 {
+		t.Fatalf("unexpected verdicts: %+v", s.AIGateVerdicts)
+	}
+	hour := int64(time.Hour / time.Millisecond)
+	if s.SysModeMs["NORMAL"] != 8*hour || s.SysModeMs["DEGRADE"] != 6*hour || s.SysModeMs["PAUSE"] != 4*hour || s.SysModeMs["EXIT"] != 0 {
+		t.Fatalf("unexpected sys mode time: %+v", s.SysModeMs)
+	}
+	if s.ReconcileDrifts != (ReconcileStats{Diffs: 1, DriftLimitExceeded: 1, MaxDriftScoreX10000: 150}) {
+		t.Fatalf("unexpected reconcile: %+v", s.ReconcileDrifts)
+	}
+	if len(s.RateLimitIncidents) != 2 || s.RateLimitIncidents[0] != (ReasonCount{ReasonCode: "RATE_LIMIT_429", Count: 1}) {
+		t.Fatalf("unexpected rate limits: %+v", s.RateLimitIncidents)
+	}
+
+	buf, err := os.ReadFile(result.JSONPath)
+	if err != nil {
+		t.Fatalf("read json: %v", err)
+	}
+	var decoded DailySummary
+	if err := json.Unmarshal(buf, &decoded); err != nil || decoded.NetPnLQuote != "-1.00" {
+		t.Fatalf("unexpected json report: %v %s", err, buf)
+	}
+	md, err := os.ReadFile(result.MarkdownPath)
+	if err != nil || !strings.Contains(string(md), "| RISK_EXPOSURE_LIMIT | 2 |") || !strings.Contains(string(md), "| PAUSE | 240.0 |") || !strings.Contains(string(md), "estimated at taker bps") {
+		t.Fatalf("unexpected markdown: %v %s", err, md)
+	}
+	days, err := ListDays(cfg.ReportDir)
+	if err != nil || len(days) != 1 || days[0] != "2026-10-18" || !gen.Exists(day) {
+		t.Fatalf("unexpected report list: %v %v", days, err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_events WHERE event_type = 'DAILY_SUMMARY' AND stage = 'REPORT_DAILY_SUMMARY'`).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("expected one DAILY_SUMMARY audit event, got %d (%v)", count, err)
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	if _, err := ParseDay("2026-10-18"); err != nil {
+		t.Fatalf("valid day rejected: %v", err)
+	}
+	for _, bad := range []string{"", "2026-13-01", "../2026-10-18", "2026-10-18.json"} {
+		if _, err := ParseDay(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func insertSnapshot(t *testing.T, db *sql.DB, symbol string, at time.Time) {
+	t.Helper()
+	snap := contracts.Snapshot{
+		Symbol:     symbol,
+		CostInputs: contracts.CostInputs{MakerFeeBps: 10, TakerFeeBps: 20},
+	}
+	buf, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("snapshot json: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES (?, ?, 'hash', ?, ?, ?, ?)`, "snap_"+symbol, symbol, at.UnixMilli(), at.UnixMilli(), string(buf), at.UnixMilli())
+	if err != nil {
+		t.Fatalf("snapshot insert: %v", err)
+	}
+}
+
+func insertIntent(t *testing.T, db *sql.DB, id string, side contracts.Side, intent contracts.Intent, qty string, price string, at time.Time) {
+	t.Helper()
+	payload, err := json.Marshal(executor.OrderIntentHashPayload{
+		Mode:           "LIVE",
+		Symbol:         "BTCUSDT",
+		Side:           side,
+		Intent:         intent,
+		EntryPlanQty:   qty,
+		EntryPlanPrice: price,
+	})
+	if err != nil {
+		t.Fatalf("payload json: %v", err)
+	}
+	rec := sqlite.OrderIntentRecord{
+		OrderIntentID:     id,
+		RunID:             "run_a",
+		CycleID:           "cycle_1",
+		Mode:              "LIVE",
+		DecisionID:        "dec_" + id,
+		Symbol:            "BTCUSDT",
+		Action:            string(executor.IntentActionNewOrder),
+		ClientOrderID:     executor.ClientOrderID(id),
+		IntentPayloadJSON: string(payload),
+		State:             string(executor.IntentConfirmed),
+		CreatedAtMs:       at.UnixMilli(),
+		UpdatedAtMs:       at.UnixMilli(),
+	}
+	if err := sqlite.InsertOrderIntent(context.Background(), db, rec); err != nil {
+		t.Fatalf("intent insert: %v", err)
+	}
+}
+
+func insertGate(t *testing.T, db *sql.DB, verdict string, reasonsJSON string, at time.Time) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO ai_gate_events (run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash, enabled, verdict,
+reasons_json, model, latency_ms, modify_applied, local_received_ms, created_at_ms)
+VALUES ('run_a', 'cycle_1', 'LIVE', 'AIGATE_CALL', 'AIGATE_CALL', 'snap', 'hash', ?, 'input', 1, ?, ?, 'stub', 40, 0, ?, ?)`,
+		"dec_"+verdict, verdict, reasonsJSON, at.UnixMilli(), at.UnixMilli())
+	if err != nil {
+		t.Fatalf("gate insert: %v", err)
+	}
+}
+
+func insertAudit(t *testing.T, db *sql.DB, runID string, stage string, eventType string, reasonsJSON string, dataJSON string, at time.Time) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO audit_events (ts_ms, run_id, cycle_id, mode, stage, event_type, reasons_json, snapshot_id, decision_id, order_intent_id,
+exchange_time_ms, local_received_ms, data_json, created_at_ms)
+VALUES (?, ?, 'cycle_1', 'LIVE', ?, ?, ?, '', '', '', 0, ?, ?, ?)`,
+		at.UnixMilli(), runID, stage, eventType, reasonsJSON, at.UnixMilli(), dataJSON, at.UnixMilli())
+	if err != nil {
+		t.Fatalf("audit insert: %v", err)
+	}
+}
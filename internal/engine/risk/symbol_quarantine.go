@@ -0,0 +1,218 @@
+package risk
+
+import (
+	"math"
+	"sort"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+// QuarantineIndefiniteMs marks a quarantine that lasts until an operator releases it.
+const QuarantineIndefiniteMs int64 = math.MaxInt64
+
+const (
+	rejectWindowMs       int64 = 60 * 60 * 1000
+	wsDisconnectWindowMs int64 = 10 * 60 * 1000
+)
+
+type QuarantineTrigger string
+
+const (
+	QuarantineTriggerRejects       QuarantineTrigger = "REJECTS"
+	QuarantineTriggerWSDisconnects QuarantineTrigger = "WS_DISCONNECTS"
+	QuarantineTriggerTimeouts      QuarantineTrigger = "TIMEOUTS"
+	QuarantineTriggerOperator      QuarantineTrigger = "OPERATOR"
+)
+
+type SymbolHealth struct {
+	Symbol              string
+	QuarantinedUntilMs  int64
+	Trigger             QuarantineTrigger
+	RejectTsMs          []int64
+	WSDisconnectTsMs    []int64
+	ConsecutiveTimeouts int
+	LastErrorCode       string
+	UpdatedAtMs         int64
+}
+
+func (h SymbolHealth) Quarantined() bool {
+	return h.QuarantinedUntilMs != 0
+}
+
+// SymbolQuarantine counts rejects, WS disconnects and consecutive timeouts per symbol and
+// quarantines a symbol for risk_quarantine_ttl_seconds once any threshold is reached.
+// It is not safe for concurrent use.
+type SymbolQuarantine struct {
+	cfg     config.Config
+	symbols map[string]*SymbolHealth
+}
+
+func NewSymbolQuarantine(cfg config.Config, states []SymbolHealth) *SymbolQuarantine {
+	q := &SymbolQuarantine{cfg: cfg, symbols: map[string]*SymbolHealth{}}
+	for _, state := range states {
+		if state.Symbol == "" {
+			continue
+		}
+		s := state
+		q.symbols[s.Symbol] = &s
+	}
+	return q
+}
+
+// RecordReject returns the updated state and true when this reject quarantined the symbol.
+func (q *SymbolQuarantine) RecordReject(symbol string, errCode string, nowMs int64) (SymbolHealth, bool) {
+	h := q.health(symbol)
+	h.RejectTsMs = append(pruneBefore(h.RejectTsMs, nowMs-rejectWindowMs), nowMs)
+	h.ConsecutiveTimeouts = 0
+	h.LastErrorCode = errCode
+	h.UpdatedAtMs = nowMs
+	if len(h.RejectTsMs) >= q.cfg.RiskQuarantineMaxRejectsPerHour {
+		return q.trip(h, QuarantineTriggerRejects, nowMs)
+	}
+	return *h, false
+}
+
+func (q *SymbolQuarantine) RecordWSDisconnect(symbol string, nowMs int64) (SymbolHealth, bool) {
+	h := q.health(symbol)
+	h.WSDisconnectTsMs = append(pruneBefore(h.WSDisconnectTsMs, nowMs-wsDisconnectWindowMs), nowMs)
+	h.UpdatedAtMs = nowMs
+	if len(h.WSDisconnectTsMs) >= q.cfg.RiskQuarantineMaxWSDisconnectsPer10Min {
+		return q.trip(h, QuarantineTriggerWSDisconnects, nowMs)
+	}
+	return *h, false
+}
+
+func (q *SymbolQuarantine) RecordTimeout(symbol string, nowMs int64) (SymbolHealth, bool) {
+	h := q.health(symbol)
+	h.ConsecutiveTimeouts++
+	h.LastErrorCode = "TIMEOUT"
+	h.UpdatedAtMs = nowMs
+	if h.ConsecutiveTimeouts >= q.cfg.RiskQuarantineMaxTimeoutsConsecutive {
+		return q.trip(h, QuarantineTriggerTimeouts, nowMs)
+	}
+	return *h, false
+}
+
+// RecordSuccess ends a timeout streak; it returns true when the state changed.
+func (q *SymbolQuarantine) RecordSuccess(symbol string, nowMs int64) (SymbolHealth, bool) {
+	h, ok := q.symbols[symbol]
+	if !ok || h.ConsecutiveTimeouts == 0 {
+		return SymbolHealth{Symbol: symbol}, false
+	}
+	h.ConsecutiveTimeouts = 0
+	h.UpdatedAtMs = nowMs
+	return *h, true
+}
+
+// Quarantine sets an explicit quarantine; untilMs 0 means until released.
+func (q *SymbolQuarantine) Quarantine(symbol string, untilMs int64, trigger QuarantineTrigger, nowMs int64) SymbolHealth {
+	h := q.health(symbol)
+	if untilMs == 0 {
+		untilMs = QuarantineIndefiniteMs
+	}
+	h.QuarantinedUntilMs = untilMs
+	h.Trigger = trigger
+	h.UpdatedAtMs = nowMs
+	return *h
+}
+
+// Release lifts the quarantine and clears every counter. It returns the state before release.
+func (q *SymbolQuarantine) Release(symbol string, nowMs int64) (SymbolHealth, bool) {
+	h, ok := q.symbols[symbol]
+	if !ok || !h.Quarantined() {
+		return SymbolHealth{Symbol: symbol}, false
+	}
+	prior := *h
+	*h = SymbolHealth{Symbol: symbol, UpdatedAtMs: nowMs}
+	return prior, true
+}
+
+// Expire releases every quarantine whose TTL has passed, when risk_quarantine_auto_release is on.
+// It returns the states before release.
+func (q *SymbolQuarantine) Expire(nowMs int64) []SymbolHealth {
+	if !q.cfg.RiskQuarantineAutoRelease {
+		return nil
+	}
+	var released []SymbolHealth
+	for _, symbol := range q.sortedSymbols() {
+		h := q.symbols[symbol]
+		if h.Quarantined() && h.QuarantinedUntilMs <= nowMs {
+			state, _ := q.Release(symbol, nowMs)
+			released = append(released, state)
+		}
+	}
+	return released
+}
+
+// HealthFlags returns the snapshot values for symbol. A quarantine past its TTL stays in force
+// without auto release and is then reported as indefinite.
+func (q *SymbolQuarantine) HealthFlags(symbol string, nowMs int64) (int64, int) {
+	h, ok := q.symbols[symbol]
+	if !ok {
+		return 0, 0
+	}
+	rejects := len(pruneBefore(h.RejectTsMs, nowMs-rejectWindowMs))
+	if !h.Quarantined() {
+		return 0, rejects
+	}
+	if h.QuarantinedUntilMs > nowMs {
+		return h.QuarantinedUntilMs, rejects
+	}
+	if !q.cfg.RiskQuarantineAutoRelease {
+		return QuarantineIndefiniteMs, rejects
+	}
+	return 0, rejects
+}
+
+func (q *SymbolQuarantine) State(symbol string) SymbolHealth {
+	h, ok := q.symbols[symbol]
+	if !ok {
+		return SymbolHealth{Symbol: symbol}
+	}
+	return *h
+}
+
+func (q *SymbolQuarantine) States() []SymbolHealth {
+	out := make([]SymbolHealth, 0, len(q.symbols))
+	for _, symbol := range q.sortedSymbols() {
+		out = append(out, *q.symbols[symbol])
+	}
+	return out
+}
+
+func (q *SymbolQuarantine) trip(h *SymbolHealth, trigger QuarantineTrigger, nowMs int64) (SymbolHealth, bool) {
+	if h.Quarantined() && h.QuarantinedUntilMs > nowMs {
+		return *h, false
+	}
+	h.QuarantinedUntilMs = nowMs + int64(q.cfg.RiskQuarantineTTLSeconds)*1000
+	h.Trigger = trigger
+	return *h, true
+}
+
+func (q *SymbolQuarantine) health(symbol string) *SymbolHealth {
+	h, ok := q.symbols[symbol]
+	if !ok {
+		h = &SymbolHealth{Symbol: symbol}
+		q.symbols[symbol] = h
+	}
+	return h
+}
+
+func (q *SymbolQuarantine) sortedSymbols() []string {
+	out := make([]string, 0, len(q.symbols))
+	for symbol := range q.symbols {
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func pruneBefore(ts []int64, cutoffMs int64) []int64 {
+	out := ts[:0:0]
+	for _, t := range ts {
+		if t > cutoffMs {
+			out = append(out, t)
+		}
+	}
+	return out
+}
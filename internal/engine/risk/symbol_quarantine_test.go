@@ -0,0 +1,87 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+func TestSymbolQuarantineTripsOnRejectsWithinHour(t *testing.T) {
+	cfg := config.Default()
+	q := NewSymbolQuarantine(cfg, nil)
+	nowMs := int64(10_000_000)
+	q.RecordReject("BTCUSDT", "-2010", nowMs-rejectWindowMs)
+	for i := 0; i < cfg.RiskQuarantineMaxRejectsPerHour-1; i++ {
+		if _, tripped := q.RecordReject("BTCUSDT", "-2010", nowMs); tripped {
+			t.Fatalf("tripped early on reject %d", i+1)
+		}
+	}
+	h, tripped := q.RecordReject("BTCUSDT", "-1013", nowMs)
+	if !tripped || h.Trigger != QuarantineTriggerRejects {
+		t.Fatalf("expected reject quarantine, got %+v", h)
+	}
+	if h.QuarantinedUntilMs != nowMs+int64(cfg.RiskQuarantineTTLSeconds)*1000 || h.LastErrorCode != "-1013" {
+		t.Fatalf("unexpected state: %+v", h)
+	}
+	if _, tripped := q.RecordReject("BTCUSDT", "-1013", nowMs+1); tripped {
+		t.Fatalf("expected no second trip while quarantined")
+	}
+}
+
+func TestSymbolQuarantineTripsOnDisconnectsAndTimeouts(t *testing.T) {
+	cfg := config.Default()
+	q := NewSymbolQuarantine(cfg, nil)
+	nowMs := int64(10_000_000)
+	var tripped bool
+	for i := 0; i < cfg.RiskQuarantineMaxWSDisconnectsPer10Min; i++ {
+		_, tripped = q.RecordWSDisconnect("ETHUSDT", nowMs+int64(i))
+	}
+	if !tripped {
+		t.Fatalf("expected ws disconnect quarantine")
+	}
+	for i := 0; i < cfg.RiskQuarantineMaxTimeoutsConsecutive-1; i++ {
+		q.RecordTimeout("SOLUSDT", nowMs)
+	}
+	if _, changed := q.RecordSuccess("SOLUSDT", nowMs); !changed {
+		t.Fatalf("expected success to reset the timeout streak")
+	}
+	for i := 0; i < cfg.RiskQuarantineMaxTimeoutsConsecutive-1; i++ {
+		q.RecordTimeout("SOLUSDT", nowMs)
+	}
+	h, tripped := q.RecordTimeout("SOLUSDT", nowMs)
+	if !tripped || h.Trigger != QuarantineTriggerTimeouts {
+		t.Fatalf("expected timeout quarantine, got %+v", h)
+	}
+}
+
+func TestSymbolQuarantineExpireHonoursAutoRelease(t *testing.T) {
+	cfg := config.Default()
+	cfg.RiskQuarantineTTLSeconds = 60
+	nowMs := int64(10_000_000)
+	for _, autoRelease := range []bool{true, false} {
+		cfg.RiskQuarantineAutoRelease = autoRelease
+		q := NewSymbolQuarantine(cfg, nil)
+		q.Quarantine("ADAUSDT", 0, QuarantineTriggerOperator, nowMs)
+		for i := 0; i < cfg.RiskQuarantineMaxTimeoutsConsecutive; i++ {
+			q.RecordTimeout("BTCUSDT", nowMs)
+		}
+		later := nowMs + 61_000
+		released := q.Expire(later)
+		untilMs, _ := q.HealthFlags("BTCUSDT", later)
+		if autoRelease {
+			if len(released) != 1 || released[0].Symbol != "BTCUSDT" || released[0].Trigger != QuarantineTriggerTimeouts {
+				t.Fatalf("expected BTCUSDT released, got %+v", released)
+			}
+			if untilMs != 0 || q.State("BTCUSDT").ConsecutiveTimeouts != 0 {
+				t.Fatalf("expected cleared state, got %+v", q.State("BTCUSDT"))
+			}
+		} else {
+			if len(released) != 0 || untilMs != QuarantineIndefiniteMs {
+				t.Fatalf("expected quarantine kept without auto release, got %v until=%d", released, untilMs)
+			}
+		}
+		if untilMs, _ := q.HealthFlags("ADAUSDT", later); untilMs != QuarantineIndefiniteMs {
+			t.Fatalf("expected operator quarantine kept, got %d", untilMs)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/universe"
 )
 
 const (
@@ -13,7 +14,9 @@ const (
 	defaultSlippageExitTakerBps  = 2
 )
 
-func BuildSnapshot(cfg config.Config, snapshot contracts.Snapshot, nowMs int64) (contracts.Snapshot, error) {
+// BuildSnapshot fills defaults, copies quarantine state from health (nil skips it) into the
+// health flags and hashes the result, so the scan and risk read the flags the hash covers.
+func BuildSnapshot(cfg config.Config, snapshot contracts.Snapshot, health universe.HealthSource, nowMs int64) (contracts.Snapshot, error) {
 	if snapshot.Metadata.CreatedTsMs == 0 {
 		snapshot.Metadata.CreatedTsMs = nowMs
 	}
@@ -27,6 +30,7 @@ func BuildSnapshot(cfg config.Config, snapshot contracts.Snapshot, nowMs int64)
 		snapshot.CostInputs.SlippageEntryTakerBps = defaultSlippageEntryTakerBps
 		snapshot.CostInputs.SlippageExitTakerBps = defaultSlippageExitTakerBps
 	}
+	snapshot = universe.ApplyHealth([]contracts.Snapshot{snapshot}, health, nowMs)[0]
 	hash, err := snapshot.Hash()
 	if err != nil {
 		return contracts.Snapshot{}, err
@@ -12,11 +12,11 @@ func TestBuildSnapshotHashDeterminism(t *testing.T) {
 	nowMs := int64(1700000000000)
 	input := baseSnapshot(nowMs)
 
-	first, err := BuildSnapshot(cfg, input, nowMs)
+	first, err := BuildSnapshot(cfg, input, nil, nowMs)
 	if err != nil {
 		t.Fatalf("build snapshot: %v", err)
 	}
-	second, err := BuildSnapshot(cfg, input, nowMs)
+	second, err := BuildSnapshot(cfg, input, nil, nowMs)
 	if err != nil {
 		t.Fatalf("build snapshot second: %v", err)
 	}
@@ -0,0 +1,23 @@
+package universe
+
+import "github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+
+type HealthSource interface {
+	HealthFlags(symbol string, nowMs int64) (quarantinedUntilMs int64, recentRejects int)
+}
+
+// ApplyHealth overwrites the quarantine and reject health flags of each snapshot from source,
+// so Scan and the risk engine see the same quarantine state.
+func ApplyHealth(snapshots []contracts.Snapshot, source HealthSource, nowMs int64) []contracts.Snapshot {
+	if source == nil {
+		return snapshots
+	}
+	out := make([]contracts.Snapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		untilMs, rejects := source.HealthFlags(snapshot.Symbol, nowMs)
+		snapshot.HealthFlags.QuarantinedUntilMs = untilMs
+		snapshot.HealthFlags.RecentRejectsWindowCount = rejects
+		out[i] = snapshot
+	}
+	return out
+}
@@ -8,9 +8,35 @@ type RateLimit struct {
 }
 
 type ExchangeInfo struct {
-	Timezone   string      `json:"timezone"`
-	ServerTime int64       `json:"serverTime"`
-	RateLimits []RateLimit `json:"rateLimits"`
+	Timezone   string       `json:"timezone"`
+	ServerTime int64        `json:"serverTime"`
+	RateLimits []RateLimit  `json:"rateLimits"`
+	Symbols    []SymbolInfo `json:"symbols"`
+}
+
+type SymbolInfo struct {
+	Symbol     string         `json:"symbol"`
+	Status     string         `json:"status"`
+	BaseAsset  string         `json:"baseAsset"`
+	QuoteAsset string         `json:"quoteAsset"`
+	Filters    []SymbolFilter `json:"filters"`
+}
+
+type SymbolFilter struct {
+	FilterType  string `json:"filterType"`
+	TickSize    string `json:"tickSize"`
+	StepSize    string `json:"stepSize"`
+	MinNotional string `json:"minNotional"`
+}
+
+type APIRestrictions struct {
+	IPRestrict                 bool  `json:"ipRestrict"`
+	EnableReading              bool  `json:"enableReading"`
+	EnableSpotAndMarginTrading bool  `json:"enableSpotAndMarginTrading"`
+	EnableWithdrawals          bool  `json:"enableWithdrawals"`
+	EnableMargin               bool  `json:"enableMargin"`
+	EnableFutures              bool  `json:"enableFutures"`
+	CreateTime                 int64 `json:"createTime"`
 }
 
 type DepthResponse struct {
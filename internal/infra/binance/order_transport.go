@@ -0,0 +1,63 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// OrderTransport sends order traffic over the WS API while its session is up and over REST otherwise.
+// Both paths return the same JSONResponse body and BinanceError values, so callers map results identically.
+type OrderTransport struct {
+	rest *Client
+	ws   *WSAPIClient
+}
+
+func NewOrderTransport(rest *Client, ws *WSAPIClient) *OrderTransport {
+	return &OrderTransport{rest: rest, ws: ws}
+}
+
+func (t *OrderTransport) NewOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return t.do(ctx, params, t.ws.PlaceOrder, t.rest.NewOrder)
+}
+
+func (t *OrderTransport) CancelOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return t.do(ctx, params, t.ws.CancelOrder, t.rest.CancelOrder)
+}
+
+func (t *OrderTransport) CancelReplaceOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return t.do(ctx, params, t.ws.CancelReplaceOrder, t.rest.CancelReplaceOrder)
+}
+
+func (t *OrderTransport) OrderListOCO(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return t.do(ctx, params, t.ws.PlaceOCO, t.rest.OrderListOCO)
+}
+
+func (t *OrderTransport) QueryOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return t.rest.QueryOrder(ctx, params)
+}
+
+type orderCall func(ctx context.Context, params url.Values) (JSONResponse, error)
+
+// do never falls back once a WS request was written: a lost response surfaces as a timeout
+// (SENT_UNKNOWN) instead of risking a duplicate order over REST.
+func (t *OrderTransport) do(ctx context.Context, params url.Values, wsCall orderCall, restCall orderCall) (JSONResponse, error) {
+	if t.ws == nil || !t.ws.Connected() {
+		return restCall(ctx, params)
+	}
+	resp, err := wsCall(ctx, params)
+	if errors.Is(err, ErrWSAPISessionDown) {
+		return restCall(ctx, params)
+	}
+	var bErr BinanceError
+	if errors.As(err, &bErr) && bErr.Code == -1021 {
+		if _, syncErr := t.rest.SyncTime(ctx); syncErr != nil {
+			return JSONResponse{}, syncErr
+		}
+		resp, err = wsCall(ctx, params)
+		if errors.Is(err, ErrWSAPISessionDown) {
+			return restCall(ctx, params)
+		}
+	}
+	return resp, err
+}
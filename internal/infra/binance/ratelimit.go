@@ -102,6 +102,16 @@ func (r *RateLimiter) Wait(now time.Time, weight int) time.Duration {
 	return delay
 }
 
+func (r *RateLimiter) Usage(now time.Time) (usedWeight1m int, weightLimit1m int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.weightWindowStart.IsZero() || r.weightWindowStart.Before(now.Truncate(time.Minute)) {
+		return 0, r.requestWeightLimit
+	}
+	return r.usedWeight1m, r.requestWeightLimit
+}
+
 func headerInt(h http.Header, key string) int {
 	raw := h.Get(key)
 	if raw == "" {
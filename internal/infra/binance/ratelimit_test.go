@@ -24,4 +24,10 @@ func TestRateLimiterDelayFromHeaders(t *testing.T) {
 	if delay <= 0 {
 		t.Fatalf("expected delay, got %v", delay)
 	}
+	if used, limit := limiter.Usage(now); used != 1200 || limit != 1200 {
+		t.Fatalf("unexpected usage: %d/%d", used, limit)
+	}
+	if used, _ := limiter.Usage(now.Add(time.Minute)); used != 0 {
+		t.Fatalf("expected usage reset in next window, got %d", used)
+	}
 }
@@ -2,9 +2,6 @@ package binance
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,6 +21,7 @@ type Options struct {
 	BaseURL    string
 	APIKey     string
 	APISecret  string
+	Signer     Signer
 	HTTPClient *http.Client
 	Now        func() time.Time
 }
@@ -32,7 +30,7 @@ type Client struct {
 	cfg        config.Config
 	baseURL    string
 	apiKey     string
-	apiSecret  string
+	signer     Signer
 	httpClient *http.Client
 	now        func() time.Time
 
@@ -55,11 +53,19 @@ func NewClient(cfg config.Config, opts Options) (*Client, error) {
 	if client == nil {
 		client = &http.Client{}
 	}
+	signer := opts.Signer
+	if signer == nil && opts.APISecret != "" {
+		hmacSigner, err := NewHMACSigner(opts.APISecret)
+		if err != nil {
+			return nil, err
+		}
+		signer = hmacSigner
+	}
 	return &Client{
 		cfg:        cfg,
 		baseURL:    baseURL,
 		apiKey:     opts.APIKey,
-		apiSecret:  opts.APISecret,
+		signer:     signer,
 		httpClient: client,
 		now:        opts.Now,
 		limiter:    NewRateLimiter(),
@@ -138,6 +144,10 @@ func (c *Client) CancelReplaceOrder(ctx context.Context, params url.Values) (JSO
 	return c.doRequest(ctx, http.MethodPost, "/api/v3/order/cancelReplace", params, true, 1, true)
 }
 
+func (c *Client) OrderListOCO(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return c.doRequest(ctx, http.MethodPost, "/api/v3/orderList/oco", params, true, 1, true)
+}
+
 func (c *Client) OpenOrders(ctx context.Context, params url.Values) (JSONResponse, error) {
 	return c.doRequest(ctx, http.MethodGet, "/api/v3/openOrders", params, true, 1, false)
 }
@@ -150,6 +160,18 @@ func (c *Client) Account(ctx context.Context) (JSONResponse, error) {
 	return c.doRequest(ctx, http.MethodGet, "/api/v3/account", url.Values{}, true, 1, false)
 }
 
+func (c *Client) APIRestrictions(ctx context.Context) (APIRestrictions, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/sapi/v1/account/apiRestrictions", url.Values{}, true, 1, true)
+	if err != nil {
+		return APIRestrictions{}, err
+	}
+	var out APIRestrictions
+	if err := json.Unmarshal(resp.Body, &out); err != nil {
+		return APIRestrictions{}, fmt.Errorf("apiRestrictions decode: %w", err)
+	}
+	return out, nil
+}
+
 func (c *Client) doRequest(ctx context.Context, method string, path string, params url.Values, signed bool, weight int, idempotent bool) (JSONResponse, error) {
 	if signed {
 		if err := c.ensureTimeSync(ctx); err != nil {
@@ -185,11 +207,11 @@ func (c *Client) doOnce(ctx context.Context, method string, path string, params
 	}
 	query := params.Encode()
 	if signed {
-		if c.apiSecret == "" || c.apiKey == "" {
+		if c.signer == nil || c.apiKey == "" {
 			return JSONResponse{}, fmt.Errorf("binance credentials missing")
 		}
 		timestamp := c.now().UnixMilli() + c.clockOffset()
-		signedQuery, err := buildSignedQuery(query, c.apiSecret, timestamp, c.cfg.TimeSyncRecvWindowMs)
+		signedQuery, err := buildSignedQuery(query, c.signer, timestamp, c.cfg.TimeSyncRecvWindowMs)
 		if err != nil {
 			return JSONResponse{}, err
 		}
@@ -245,13 +267,22 @@ func (c *Client) ensureTimeSync(ctx context.Context) error {
 	return nil
 }
 
+// RateLimitUsage returns the REST request weight used in the current minute and the limit.
+func (c *Client) RateLimitUsage() (usedWeight1m int, weightLimit1m int) {
+	return c.limiter.Usage(c.now())
+}
+
+func (c *Client) ClockOffsetMs() int64 {
+	return c.clockOffset()
+}
+
 func (c *Client) clockOffset() int64 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	return c.clockOffsetMs
 }
 
-func buildSignedQuery(query string, secret string, timestamp int64, recvWindowMs int) (string, error) {
+func buildSignedQuery(query string, signer Signer, timestamp int64, recvWindowMs int) (string, error) {
 	values, err := url.ParseQuery(query)
 	if err != nil {
 		return "", err
@@ -259,11 +290,10 @@ func buildSignedQuery(query string, secret string, timestamp int64, recvWindowMs
 	values.Set("timestamp", fmt.Sprintf("%d", timestamp))
 	values.Set("recvWindow", fmt.Sprintf("%d", recvWindowMs))
 	encoded := values.Encode()
-	mac := hmac.New(sha256.New, []byte(secret))
-	if _, err := mac.Write([]byte(encoded)); err != nil {
+	signature, err := signer.Sign([]byte(encoded))
+	if err != nil {
 		return "", err
 	}
-	signature := hex.EncodeToString(mac.Sum(nil))
 	values.Set("signature", signature)
 	return values.Encode(), nil
 }
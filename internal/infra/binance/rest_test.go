@@ -8,9 +8,13 @@ import (
 
 func TestBuildSignedQueryDoesNotLeakSecret(t *testing.T) {
 	secret := "supersecret"
+	signer, err := NewHMACSigner(secret)
+	if err != nil {
+		t.Fatalf("hmac signer: %v", err)
+	}
 	params := url.Values{}
 	params.Set("symbol", "BTCUSDT")
-	query, err := buildSignedQuery(params.Encode(), secret, 1700000000000, 5000)
+	query, err := buildSignedQuery(params.Encode(), signer, 1700000000000, 5000)
 	if err != nil {
 		t.Fatalf("build signed query: %v", err)
 	}
@@ -0,0 +1,173 @@
+package binance
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+type Signer interface {
+	Name() string
+	Sign(payload []byte) (string, error)
+}
+
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) (*HMACSigner, error) {
+	if secret == "" {
+		return nil, errors.New("hmac signer: secret missing")
+	}
+	return &HMACSigner{secret: []byte(secret)}, nil
+}
+
+func (s *HMACSigner) Name() string {
+	return "HMAC_SHA256"
+}
+
+func (s *HMACSigner) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	if _, err := mac.Write(payload); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+type Ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+func NewEd25519Signer(key ed25519.PrivateKey) (*Ed25519Signer, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("ed25519 signer: invalid key size %d", len(key))
+	}
+	return &Ed25519Signer{key: key}, nil
+}
+
+func (s *Ed25519Signer) Name() string {
+	return "ED25519"
+}
+
+func (s *Ed25519Signer) Sign(payload []byte) (string, error) {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)), nil
+}
+
+type RSASigner struct {
+	key *rsa.PrivateKey
+}
+
+func NewRSASigner(key *rsa.PrivateKey) (*RSASigner, error) {
+	if key == nil {
+		return nil, errors.New("rsa signer: key missing")
+	}
+	if key.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("rsa signer: key size %d below 2048 bits", key.N.BitLen())
+	}
+	return &RSASigner{key: key}, nil
+}
+
+func (s *RSASigner) Name() string {
+	return "RSA_PKCS1_SHA256"
+}
+
+func (s *RSASigner) Sign(payload []byte) (string, error) {
+	digest := sha256.Sum256(payload)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("rsa sign: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+func NewSigner(apiSecret string, privateKeyPath string) (Signer, error) {
+	if privateKeyPath != "" {
+		return LoadPrivateKeySigner(privateKeyPath)
+	}
+	if apiSecret == "" {
+		return nil, nil
+	}
+	signer, err := NewHMACSigner(apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+func LoadPrivateKeySigner(path string) (Signer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("private key: %s is a directory", path)
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0o077 != 0 {
+		return nil, fmt.Errorf("private key: %s permissions %o too open", path, perm)
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	signer, err := ParsePrivateKeyPEM(buf)
+	for i := range buf {
+		buf[i] = 0
+	}
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", path, err)
+	}
+	return signer, nil
+}
+
+func ParsePrivateKeyPEM(buf []byte) (Signer, error) {
+	block, _ := pem.Decode(buf)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs8 parse: %w", err)
+		}
+		switch k := key.(type) {
+		case ed25519.PrivateKey:
+			signer, err := NewEd25519Signer(k)
+			if err != nil {
+				return nil, err
+			}
+			return signer, nil
+		case *rsa.PrivateKey:
+			return rsaSigner(k)
+		default:
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs1 parse: %w", err)
+		}
+		return rsaSigner(key)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func rsaSigner(key *rsa.PrivateKey) (Signer, error) {
+	signer, err := NewRSASigner(key)
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
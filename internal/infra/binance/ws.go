@@ -17,16 +17,20 @@ type WSOptions struct {
 	BaseURL string
 	Dialer  *websocket.Dialer
 	Now     func() time.Time
+	// OnDisconnect is called with the stream's symbols when an open stream drops.
+	OnDisconnect func(symbols ...string)
 }
 
 type WSClient struct {
-	baseURL string
-	dialer  *websocket.Dialer
-	now     func() time.Time
+	baseURL      string
+	dialer       *websocket.Dialer
+	now          func() time.Time
+	onDisconnect func(symbols ...string)
 }
 
 type BookTickerEvent struct {
 	EventTime int64  `json:"E"`
+	UpdateID  int64  `json:"u"`
 	Symbol    string `json:"s"`
 }
 
@@ -49,9 +53,10 @@ func NewWSClient(opts WSOptions) *WSClient {
 		now = time.Now
 	}
 	return &WSClient{
-		baseURL: baseURL,
-		dialer:  dialer,
-		now:     now,
+		baseURL:      baseURL,
+		dialer:       dialer,
+		now:          now,
+		onDisconnect: opts.OnDisconnect,
 	}
 }
 
@@ -60,12 +65,14 @@ func (c *WSClient) Run(ctx context.Context, symbols []string, onEvent func(BookT
 		return fmt.Errorf("ws symbols missing")
 	}
 	streams := make([]string, 0, len(symbols))
+	names := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
 		name := strings.TrimSpace(symbol)
 		if name == "" {
 			continue
 		}
 		streams = append(streams, strings.ToLower(name)+"@bookTicker")
+		names = append(names, strings.ToUpper(name))
 	}
 	if len(streams) == 0 {
 		return fmt.Errorf("ws symbols missing")
@@ -84,6 +91,10 @@ func (c *WSClient) Run(ctx context.Context, symbols []string, onEvent func(BookT
 		return err
 	}
 	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
 
 	for {
 		select {
@@ -93,6 +104,12 @@ func (c *WSClient) Run(ctx context.Context, symbols []string, onEvent func(BookT
 		}
 		_, payload, err := conn.ReadMessage()
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if c.onDisconnect != nil {
+				c.onDisconnect(names...)
+			}
 			return err
 		}
 		var env streamEnvelope
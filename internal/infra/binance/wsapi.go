@@ -0,0 +1,340 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+const defaultWSAPIURL = "wss://ws-api.binance.com:443/ws-api/v3"
+
+// ErrWSAPISessionDown means the request never left the process (no session when it was made),
+// so it is safe to resend over REST.
+var ErrWSAPISessionDown = errors.New("ws api session down")
+
+// ErrWSAPIResponseLost means the request was (or may have been) written but its response never
+// arrived: the connection dropped or was closed, the write failed, or the caller gave up.
+// It wraps context.DeadlineExceeded so the executor records the intent as SENT_UNKNOWN.
+var ErrWSAPIResponseLost = fmt.Errorf("ws api response lost: %w", context.DeadlineExceeded)
+
+type WSAPIOptions struct {
+	URL           string
+	APIKey        string
+	Signer        Signer
+	Dialer        *websocket.Dialer
+	Now           func() time.Time
+	ClockOffsetMs func() int64
+}
+
+type WSAPIClient struct {
+	cfg           config.Config
+	url           string
+	apiKey        string
+	signer        Signer
+	dialer        *websocket.Dialer
+	now           func() time.Time
+	clockOffsetMs func() int64
+	timeout       time.Duration
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	loggedOn bool
+	nextID   uint64
+	pending  map[string]chan wsAPIResponse
+}
+
+type wsAPIRequest struct {
+	ID     string         `json:"id"`
+	Method string         `json:"method"`
+	Params map[string]any `json:"params,omitempty"`
+}
+
+type wsAPIResponse struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result"`
+	Error  *BinanceError   `json:"error"`
+	err    error
+}
+
+func NewWSAPIClient(cfg config.Config, opts WSAPIOptions) *WSAPIClient {
+	u := strings.TrimSpace(opts.URL)
+	if u == "" {
+		u = defaultWSAPIURL
+	}
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	offset := opts.ClockOffsetMs
+	if offset == nil {
+		offset = func() int64 { return 0 }
+	}
+	return &WSAPIClient{
+		cfg:           cfg,
+		url:           u,
+		apiKey:        opts.APIKey,
+		signer:        opts.Signer,
+		dialer:        dialer,
+		now:           now,
+		clockOffsetMs: offset,
+		timeout:       time.Duration(cfg.BinanceWSAPIRequestTimeoutMs) * time.Millisecond,
+		pending:       map[string]chan wsAPIResponse{},
+	}
+}
+
+// Connect dials the WS API and, for Ed25519 keys, authenticates the session with session.logon.
+// Other key types stay unauthenticated and sign every request instead.
+func (c *WSAPIClient) Connect(ctx context.Context) error {
+	if c.signer == nil || c.apiKey == "" {
+		return fmt.Errorf("binance credentials missing")
+	}
+	conn, _, err := c.dialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("ws api dial: %w", err)
+	}
+	c.mu.Lock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = conn
+	c.loggedOn = false
+	c.mu.Unlock()
+	go c.readLoop(conn)
+
+	if _, ok := c.signer.(*Ed25519Signer); !ok {
+		return nil
+	}
+	params := map[string]any{
+		"apiKey":    c.apiKey,
+		"timestamp": c.timestamp(),
+	}
+	if err := c.sign(params); err != nil {
+		c.drop(conn)
+		return err
+	}
+	if _, err := c.roundTrip(ctx, "session.logon", params); err != nil {
+		c.drop(conn)
+		return fmt.Errorf("ws api logon: %w", err)
+	}
+	c.mu.Lock()
+	if c.conn == conn {
+		c.loggedOn = true
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *WSAPIClient) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+func (c *WSAPIClient) LoggedOn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loggedOn
+}
+
+func (c *WSAPIClient) Close() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	c.drop(conn)
+	return nil
+}
+
+// Ping round-trips the unsigned ping method; a failure means the session is no longer usable.
+func (c *WSAPIClient) Ping(ctx context.Context) error {
+	_, err := c.roundTrip(ctx, "ping", nil)
+	return err
+}
+
+func (c *WSAPIClient) PlaceOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return c.signedCall(ctx, "order.place", params)
+}
+
+func (c *WSAPIClient) CancelOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return c.signedCall(ctx, "order.cancel", params)
+}
+
+func (c *WSAPIClient) CancelReplaceOrder(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return c.signedCall(ctx, "order.cancelReplace", params)
+}
+
+func (c *WSAPIClient) PlaceOCO(ctx context.Context, params url.Values) (JSONResponse, error) {
+	return c.signedCall(ctx, "orderList.place.oco", params)
+}
+
+func (c *WSAPIClient) signedCall(ctx context.Context, method string, params url.Values) (JSONResponse, error) {
+	out := make(map[string]any, len(params)+4)
+	for key := range params {
+		out[key] = params.Get(key)
+	}
+	out["timestamp"] = c.timestamp()
+	out["recvWindow"] = c.cfg.TimeSyncRecvWindowMs
+	if !c.LoggedOn() {
+		if c.signer == nil || c.apiKey == "" {
+			return JSONResponse{}, fmt.Errorf("binance credentials missing")
+		}
+		out["apiKey"] = c.apiKey
+		if err := c.sign(out); err != nil {
+			return JSONResponse{}, err
+		}
+	}
+	return c.roundTrip(ctx, method, out)
+}
+
+func (c *WSAPIClient) roundTrip(ctx context.Context, method string, params map[string]any) (JSONResponse, error) {
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return JSONResponse{}, ErrWSAPISessionDown
+	}
+	c.nextID++
+	id := "ls-" + strconv.FormatUint(c.nextID, 10)
+	ch := make(chan wsAPIResponse, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	payload, err := json.Marshal(wsAPIRequest{ID: id, Method: method, Params: params})
+	if err != nil {
+		c.forget(id)
+		return JSONResponse{}, err
+	}
+	c.writeMu.Lock()
+	err = conn.WriteMessage(websocket.TextMessage, payload)
+	c.writeMu.Unlock()
+	if err != nil {
+		// A failed write may still have put bytes on the wire, so the request
+		// is SENT_UNKNOWN rather than safe to resend.
+		c.forget(id)
+		c.drop(conn)
+		return JSONResponse{}, fmt.Errorf("ws api %s write: %v: %w", method, err, ErrWSAPIResponseLost)
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		return resp.toJSONResponse(method)
+	case <-ctx.Done():
+		c.forget(id)
+		return JSONResponse{}, fmt.Errorf("ws api %s %s: %v: %w", method, id, ctx.Err(), ErrWSAPIResponseLost)
+	case <-timer.C:
+		c.forget(id)
+		return JSONResponse{}, fmt.Errorf("ws api %s %s timeout: %w", method, id, context.DeadlineExceeded)
+	}
+}
+
+func (r wsAPIResponse) toJSONResponse(method string) (JSONResponse, error) {
+	if r.err != nil {
+		return JSONResponse{}, fmt.Errorf("ws api %s: %w", method, r.err)
+	}
+	if r.Status >= 400 || r.Error != nil {
+		bErr := BinanceError{Status: r.Status}
+		if r.Error != nil {
+			bErr.Code = r.Error.Code
+			bErr.Msg = r.Error.Msg
+		}
+		return JSONResponse{}, bErr
+	}
+	return JSONResponse{Status: r.Status, Body: r.Result}, nil
+}
+
+func (c *WSAPIClient) readLoop(conn *websocket.Conn) {
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			c.drop(conn)
+			return
+		}
+		var resp wsAPIResponse
+		if err := json.Unmarshal(payload, &resp); err != nil || resp.ID == "" {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// drop closes conn if it is still current and fails every in-flight request with
+// ErrWSAPIResponseLost: each one was registered right before its write, so it may be on the wire.
+func (c *WSAPIClient) drop(conn *websocket.Conn) {
+	c.mu.Lock()
+	if c.conn != conn {
+		c.mu.Unlock()
+		return
+	}
+	c.conn = nil
+	c.loggedOn = false
+	pending := c.pending
+	c.pending = map[string]chan wsAPIResponse{}
+	c.mu.Unlock()
+	_ = conn.Close()
+	for _, ch := range pending {
+		ch <- wsAPIResponse{err: ErrWSAPIResponseLost}
+	}
+}
+
+func (c *WSAPIClient) forget(id string) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *WSAPIClient) timestamp() int64 {
+	return c.now().UnixMilli() + c.clockOffsetMs()
+}
+
+func (c *WSAPIClient) sign(params map[string]any) error {
+	signature, err := c.signer.Sign([]byte(WSAPISignaturePayload(params)))
+	if err != nil {
+		return err
+	}
+	params["signature"] = signature
+	return nil
+}
+
+// WSAPISignaturePayload renders params as the WS API expects them signed:
+// key=value pairs sorted by key, joined by '&', without URL encoding and without signature.
+func WSAPISignaturePayload(params map[string]any) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "signature" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+fmt.Sprint(params[key]))
+	}
+	return strings.Join(parts, "&")
+}
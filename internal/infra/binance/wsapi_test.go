@@ -0,0 +1,374 @@
+package binance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+type wsAPIStandIn struct {
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+	handle   func(req map[string]any) (reply map[string]any, ok bool)
+
+	mu      sync.Mutex
+	methods []string
+	conns   []*websocket.Conn
+}
+
+func newWSAPIStandIn(t *testing.T, handle func(req map[string]any) (map[string]any, bool)) *wsAPIStandIn {
+	t.Helper()
+	s := &wsAPIStandIn{handle: handle}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		var writeMu sync.Mutex
+		for {
+			_, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req map[string]any
+			decoder := json.NewDecoder(bytes.NewReader(payload))
+			decoder.UseNumber()
+			if err := decoder.Decode(&req); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.methods = append(s.methods, req["method"].(string))
+			s.mu.Unlock()
+			go func() {
+				reply, ok := s.handle(req)
+				if !ok {
+					return
+				}
+				reply["id"] = req["id"]
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				_ = conn.WriteJSON(reply)
+			}()
+		}
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *wsAPIStandIn) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws-api/v3"
+}
+
+func (s *wsAPIStandIn) Methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+func (s *wsAPIStandIn) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.UnderlyingConn().Close()
+	}
+}
+
+func wsAPIParams(req map[string]any) map[string]any {
+	params, _ := req["params"].(map[string]any)
+	return params
+}
+
+func wsAPIOrderParams(clientOrderID string) url.Values {
+	params := url.Values{}
+	params.Set("symbol", "BTCUSDT")
+	params.Set("side", "BUY")
+	params.Set("type", "LIMIT")
+	params.Set("timeInForce", "GTC")
+	params.Set("price", "100")
+	params.Set("quantity", "1")
+	params.Set("newClientOrderId", clientOrderID)
+	return params
+}
+
+func testWSAPIClient(t *testing.T, cfg config.Config, wsURL string, signer Signer) *WSAPIClient {
+	t.Helper()
+	client := NewWSAPIClient(cfg, WSAPIOptions{URL: wsURL, APIKey: "key", Signer: signer})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func TestWSAPIEd25519LogonAndCorrelatesOutOfOrderResponses(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	key := ed25519.NewKeyFromSeed(seed)
+	signer, err := NewEd25519Signer(key)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	standIn := newWSAPIStandIn(t, func(req map[string]any) (map[string]any, bool) {
+		params := wsAPIParams(req)
+		switch req["method"] {
+		case "session.logon":
+			sig, _ := base64.StdEncoding.DecodeString(params["signature"].(string))
+			if params["apiKey"] != "key" || !ed25519.Verify(key.Public().(ed25519.PublicKey), []byte(WSAPISignaturePayload(params)), sig) {
+				return map[string]any{"status": 401, "error": map[string]any{"code": -1022, "msg": "Signature for this request is not valid."}}, true
+			}
+			return map[string]any{"status": 200, "result": map[string]any{"apiKey": "key"}}, true
+		case "order.place":
+			if _, signed := params["signature"]; signed {
+				return map[string]any{"status": 400, "error": map[string]any{"code": -1102, "msg": "signature not expected"}}, true
+			}
+			if params["newClientOrderId"] == "LS_slow" {
+				time.Sleep(50 * time.Millisecond)
+			}
+			return map[string]any{"status": 200, "result": map[string]any{"orderId": 1, "clientOrderId": params["newClientOrderId"], "status": "NEW"}}, true
+		}
+		return nil, false
+	})
+	client := testWSAPIClient(t, config.Default(), standIn.URL(), signer)
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if !client.LoggedOn() {
+		t.Fatalf("expected session.logon for ed25519 key")
+	}
+
+	var wg sync.WaitGroup
+	got := map[string]string{}
+	var mu sync.Mutex
+	for _, id := range []string{"LS_slow", "LS_fast"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.PlaceOrder(ctx, wsAPIOrderParams(id))
+			if err != nil {
+				t.Errorf("place %s: %v", id, err)
+				return
+			}
+			var order struct {
+				ClientOrderID string `json:"clientOrderId"`
+			}
+			_ = json.Unmarshal(resp.Body, &order)
+			mu.Lock()
+			got[id] = order.ClientOrderID
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for _, id := range []string{"LS_slow", "LS_fast"} {
+		if got[id] != id {
+			t.Fatalf("response for %s correlated to %q", id, got[id])
+		}
+	}
+}
+
+func TestWSAPISignsEachRequestAndMapsErrors(t *testing.T) {
+	signer, err := NewHMACSigner("secret")
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	standIn := newWSAPIStandIn(t, func(req map[string]any) (map[string]any, bool) {
+		params := wsAPIParams(req)
+		want, _ := signer.Sign([]byte(WSAPISignaturePayload(params)))
+		if params["apiKey"] != "key" || params["signature"] != want {
+			return map[string]any{"status": 400, "error": map[string]any{"code": -1022, "msg": "Signature for this request is not valid."}}, true
+		}
+		switch req["method"] {
+		case "order.place":
+			return map[string]any{"status": 400, "error": map[string]any{"code": -2010, "msg": "Account has insufficient balance for requested action."}}, true
+		case "order.cancel", "order.cancelReplace", "orderList.place.oco":
+			return map[string]any{"status": 200, "result": map[string]any{"symbol": params["symbol"]}}, true
+		}
+		return nil, false
+	})
+	client := testWSAPIClient(t, config.Default(), standIn.URL(), signer)
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if client.LoggedOn() {
+		t.Fatalf("hmac keys must not log on")
+	}
+	_, err = client.PlaceOrder(ctx, wsAPIOrderParams("LS_reject"))
+	var bErr BinanceError
+	if !errors.As(err, &bErr) || bErr.Code != -2010 || bErr.Status != 400 {
+		t.Fatalf("expected -2010 rejection, got %v", err)
+	}
+	params := url.Values{"symbol": {"BTCUSDT"}, "origClientOrderId": {"LS_1"}}
+	if _, err := client.CancelOrder(ctx, params); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := client.CancelReplaceOrder(ctx, params); err != nil {
+		t.Fatalf("cancel replace: %v", err)
+	}
+	if _, err := client.PlaceOCO(ctx, params); err != nil {
+		t.Fatalf("oco: %v", err)
+	}
+	want := []string{"order.place", "order.cancel", "order.cancelReplace", "orderList.place.oco"}
+	if got := standIn.Methods(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected methods: %v", got)
+	}
+}
+
+func TestWSAPITimeoutAndLostResponseAreSentUnknown(t *testing.T) {
+	signer, err := NewHMACSigner("secret")
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	standIn := newWSAPIStandIn(t, func(req map[string]any) (map[string]any, bool) {
+		return nil, false
+	})
+	cfg := config.Default()
+	cfg.BinanceWSAPIRequestTimeoutMs = 50
+	client := testWSAPIClient(t, cfg, standIn.URL(), signer)
+	ctx := context.Background()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	_, err = client.PlaceOrder(ctx, wsAPIOrderParams("LS_timeout"))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrWSAPISessionDown) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	cfg.BinanceWSAPIRequestTimeoutMs = 5000
+	client = testWSAPIClient(t, cfg, standIn.URL(), signer)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.PlaceOrder(ctx, wsAPIOrderParams("LS_lost"))
+		done <- err
+	}()
+	for len(standIn.Methods()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	standIn.DropConnections()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrWSAPIResponseLost) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected lost response, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("pending request not failed on disconnect")
+	}
+	if client.Connected() {
+		t.Fatalf("expected session down after disconnect")
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	client.mu.Lock()
+	_ = client.conn.SetWriteDeadline(time.Now().Add(-time.Second))
+	client.mu.Unlock()
+	_, err = client.PlaceOrder(ctx, wsAPIOrderParams("LS_write"))
+	if !errors.Is(err, ErrWSAPIResponseLost) || errors.Is(err, ErrWSAPISessionDown) {
+		t.Fatalf("expected a failed write to be SENT_UNKNOWN, got %v", err)
+	}
+	if client.Connected() {
+		t.Fatalf("expected session down after a failed write")
+	}
+
+	inFlight := func(ctx context.Context, clientOrderID string, seen int, abort func()) error {
+		if err := client.Connect(context.Background()); err != nil {
+			t.Fatalf("reconnect: %v", err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.PlaceOrder(ctx, wsAPIOrderParams(clientOrderID))
+			done <- err
+		}()
+		for len(standIn.Methods()) < seen {
+			time.Sleep(time.Millisecond)
+		}
+		abort()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s not failed", clientOrderID)
+			return nil
+		}
+	}
+	err = inFlight(ctx, "LS_closed", 3, func() { _ = client.Close() })
+	if !errors.Is(err, ErrWSAPIResponseLost) || errors.Is(err, ErrWSAPISessionDown) {
+		t.Fatalf("expected a written request failed by Close to be SENT_UNKNOWN, got %v", err)
+	}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	err = inFlight(cancelCtx, "LS_canceled", 4, cancel)
+	if !errors.Is(err, ErrWSAPIResponseLost) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a canceled written request to be SENT_UNKNOWN, got %v", err)
+	}
+}
+
+func TestOrderTransportFallsBackToRESTWhenSessionDown(t *testing.T) {
+	var restOrders int
+	var mu sync.Mutex
+	restServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/time":
+			_ = json.NewEncoder(w).Encode(TimeResponse{ServerTime: time.Now().UnixMilli()})
+		case "/api/v3/order":
+			mu.Lock()
+			restOrders++
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"orderId": 2, "clientOrderId": r.URL.Query().Get("newClientOrderId"), "status": "NEW"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer restServer.Close()
+	rest, err := NewClient(config.Default(), Options{BaseURL: restServer.URL, APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatalf("rest client: %v", err)
+	}
+	standIn := newWSAPIStandIn(t, func(req map[string]any) (map[string]any, bool) {
+		return map[string]any{"status": 200, "result": map[string]any{"orderId": 1, "status": "NEW"}}, true
+	})
+	signer, _ := NewHMACSigner("secret")
+	ws := testWSAPIClient(t, config.Default(), standIn.URL(), signer)
+	transport := NewOrderTransport(rest, ws)
+	ctx := context.Background()
+
+	if _, err := transport.NewOrder(ctx, wsAPIOrderParams("LS_rest_1")); err != nil {
+		t.Fatalf("order before connect: %v", err)
+	}
+	if err := ws.Connect(ctx); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := transport.NewOrder(ctx, wsAPIOrderParams("LS_ws")); err != nil {
+		t.Fatalf("order over ws: %v", err)
+	}
+	standIn.DropConnections()
+	for ws.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := transport.NewOrder(ctx, wsAPIOrderParams("LS_rest_2")); err != nil {
+		t.Fatalf("order after disconnect: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if restOrders != 2 || len(standIn.Methods()) != 1 {
+		t.Fatalf("expected 2 REST and 1 WS order, got %d and %d", restOrders, len(standIn.Methods()))
+	}
+}
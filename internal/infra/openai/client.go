@@ -58,6 +58,13 @@ func NewClient(baseURL string, apiKey string, timeout time.Duration) (*Client, e
 	if apiKey == "" {
 		return nil, fmt.Errorf("openai api key missing")
 	}
+	return NewCompatibleClient(baseURL, apiKey, timeout)
+}
+
+func NewCompatibleClient(baseURL string, apiKey string, timeout time.Duration) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai base url missing")
+	}
 	baseURL = strings.TrimRight(baseURL, "/")
 	return &Client{
 		baseURL:    baseURL,
@@ -76,7 +83,9 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 		return ChatCompletionResponse{}, nil, fmt.Errorf("openai request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return ChatCompletionResponse{}, nil, fmt.Errorf("openai request failed: %w", err)
@@ -27,7 +27,31 @@ func Open(path string, cfg config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// OpenReadOnly opens an existing database without creating, migrating or
+// writing to it.
+func OpenReadOnly(path string, cfg config.Config) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("sqlite open: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("sqlite open: %w", err)
+	}
+	if _, err := db.Exec("PRAGMA query_only=ON;"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite query_only: %w", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA busy_timeout=%d;", cfg.AuditWriterMaxLagMs)); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqlite busy_timeout: %w", err)
+	}
+	return db, nil
+}
+
 func applyPragmas(db *sql.DB, cfg config.Config) error {
+	if _, err := db.Exec("PRAGMA auto_vacuum=INCREMENTAL;"); err != nil {
+		return fmt.Errorf("sqlite auto_vacuum: %w", err)
+	}
 	if _, err := db.Exec("PRAGMA journal_mode=WAL;"); err != nil {
 		return fmt.Errorf("sqlite wal: %w", err)
 	}
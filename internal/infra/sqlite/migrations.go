@@ -1,7 +1,11 @@
 package sqlite
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"sort"
@@ -11,54 +15,254 @@ import (
 	"github.com/RodrigoBeloyanis/livespot/migrations"
 )
 
+const (
+	MigrationKindSQL = "sql"
+	MigrationKindGo  = "go"
+
+	MigrationApplied  = "applied"
+	MigrationAdopted  = "adopted"
+	MigrationPending  = "pending"
+	MigrationModified = "checksum_mismatch"
+	MigrationUnknown  = "unknown"
+)
+
+var (
+	ErrMigrationDrift    = errors.New("migration drift")
+	ErrMigrationsPending = errors.New("migrations pending")
+)
+
+type Migration struct {
+	Name     string
+	Kind     string
+	Checksum string
+	sqlText  string
+	apply    func(ctx context.Context, tx *sql.Tx) error
+}
+
+type MigrationStatus struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	State          string `json:"state"`
+	Checksum       string `json:"checksum"`
+	StoredChecksum string `json:"stored_checksum"`
+	AppliedAtMs    int64  `json:"applied_at_ms"`
+}
+
+type appliedMigration struct {
+	checksum    string
+	appliedAtMs int64
+}
+
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("migrations glob: %w", err)
+	}
+	var out []Migration
+	seen := make(map[string]bool)
+	for _, name := range entries {
+		buf, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			return nil, fmt.Errorf("migration read %s: %w", name, err)
+		}
+		sqlText := strings.TrimSpace(string(buf))
+		if sqlText == "" {
+			return nil, fmt.Errorf("migration empty: %s", name)
+		}
+		sum := sha256.Sum256(buf)
+		out = append(out, Migration{Name: name, Kind: MigrationKindSQL, Checksum: hex.EncodeToString(sum[:]), sqlText: sqlText})
+		seen[strings.TrimSuffix(name, ".sql")] = true
+	}
+	for _, goMigration := range migrations.Go {
+		if goMigration.Name == "" || goMigration.Apply == nil {
+			return nil, fmt.Errorf("go migration invalid: %q", goMigration.Name)
+		}
+		if seen[goMigration.Name] {
+			return nil, fmt.Errorf("migration name duplicated: %s", goMigration.Name)
+		}
+		seen[goMigration.Name] = true
+		sum := sha256.Sum256([]byte(fmt.Sprintf("go\n%s\n%d\n", goMigration.Name, goMigration.Revision)))
+		out = append(out, Migration{Name: goMigration.Name, Kind: MigrationKindGo, Checksum: hex.EncodeToString(sum[:]), apply: goMigration.Apply})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
 func Migrate(db *sql.DB, now time.Time) error {
-	if err := ensureMigrationsTable(db); err != nil {
+	ctx := context.Background()
+	if err := ensureMigrationsTable(ctx, db); err != nil {
 		return err
 	}
-	applied, err := appliedMigrations(db)
+	all, err := LoadMigrations()
 	if err != nil {
 		return err
 	}
-	entries, err := fs.Glob(migrations.FS, "*.sql")
+	statuses, err := migrationStatus(ctx, db, all)
 	if err != nil {
-		return fmt.Errorf("migrations glob: %w", err)
+		return err
 	}
-	sort.Strings(entries)
-	for _, name := range entries {
-		if applied[name] {
-			continue
-		}
-		if err := applyMigration(db, name, now); err != nil {
-			return err
+	if err := CheckMigrations(statuses); err != nil {
+		return err
+	}
+	for i, status := range statuses {
+		switch status.State {
+		case MigrationAdopted:
+			if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET checksum = ? WHERE name = ? AND checksum = ''`, status.Checksum, status.Name); err != nil {
+				return fmt.Errorf("migration adopt %s: %w", status.Name, err)
+			}
+		case MigrationPending:
+			if err := applyMigration(ctx, db, all[i], now); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func ensureMigrationsTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+func MigrationPlan(db *sql.DB) ([]MigrationStatus, error) {
+	all, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(context.Background(), db, all)
+}
+
+func PendingMigrations(statuses []MigrationStatus) []MigrationStatus {
+	var pending []MigrationStatus
+	for _, status := range statuses {
+		if status.State == MigrationPending {
+			pending = append(pending, status)
+		}
+	}
+	return pending
+}
+
+func CheckMigrations(statuses []MigrationStatus) error {
+	var drift []string
+	for _, status := range statuses {
+		switch status.State {
+		case MigrationModified:
+			drift = append(drift, status.Name+" changed after it was applied")
+		case MigrationUnknown:
+			drift = append(drift, status.Name+" applied but unknown to this build")
+		}
+	}
+	if len(drift) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrMigrationDrift, strings.Join(drift, "; "))
+}
+
+// RequireMigrated is the check for read-only tools: it never writes and
+// refuses a database that drifted or still has pending migrations.
+func RequireMigrated(db *sql.DB) error {
+	statuses, err := MigrationPlan(db)
+	if err != nil {
+		return err
+	}
+	if err := CheckMigrations(statuses); err != nil {
+		return err
+	}
+	pending := PendingMigrations(statuses)
+	if len(pending) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(pending))
+	for _, status := range pending {
+		names = append(names, status.Name)
+	}
+	return fmt.Errorf("%w: %s; run migrate first", ErrMigrationsPending, strings.Join(names, ", "))
+}
+
+func migrationStatus(ctx context.Context, db *sql.DB, all []Migration) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(all))
+	for _, migration := range all {
+		status := MigrationStatus{Name: migration.Name, Kind: migration.Kind, Checksum: migration.Checksum, State: MigrationPending}
+		if rec, ok := applied[migration.Name]; ok {
+			status.StoredChecksum = rec.checksum
+			status.AppliedAtMs = rec.appliedAtMs
+			switch rec.checksum {
+			case migration.Checksum:
+				status.State = MigrationApplied
+			case "":
+				status.State = MigrationAdopted
+			default:
+				status.State = MigrationModified
+			}
+			delete(applied, migration.Name)
+		}
+		statuses = append(statuses, status)
+	}
+	for name, rec := range applied {
+		statuses = append(statuses, MigrationStatus{Name: name, State: MigrationUnknown, StoredChecksum: rec.checksum, AppliedAtMs: rec.appliedAtMs})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
   name TEXT NOT NULL PRIMARY KEY,
-  applied_at_ms INTEGER NOT NULL
+  applied_at_ms INTEGER NOT NULL,
+  checksum TEXT NOT NULL DEFAULT ''
 );`)
 	if err != nil {
 		return fmt.Errorf("migrations table: %w", err)
 	}
+	hasChecksum, err := migrationsHaveChecksum(ctx, db)
+	if err != nil {
+		return err
+	}
+	if !hasChecksum {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("migrations checksum column: %w", err)
+		}
+	}
 	return nil
 }
 
-func appliedMigrations(db *sql.DB) (map[string]bool, error) {
-	rows, err := db.Query("SELECT name FROM schema_migrations")
+func migrationsHaveChecksum(ctx context.Context, db *sql.DB) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('schema_migrations') WHERE name = 'checksum'`).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("migrations table info: %w", err)
+	}
+	return n > 0, nil
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[string]appliedMigration, error) {
+	applied := make(map[string]appliedMigration)
+	var tables int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&tables); err != nil {
+		return nil, fmt.Errorf("migrations lookup: %w", err)
+	}
+	if tables == 0 {
+		return applied, nil
+	}
+	hasChecksum, err := migrationsHaveChecksum(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT name, applied_at_ms, '' FROM schema_migrations"
+	if hasChecksum {
+		query = "SELECT name, applied_at_ms, checksum FROM schema_migrations"
+	}
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("migrations query: %w", err)
 	}
 	defer rows.Close()
-	applied := make(map[string]bool)
 	for rows.Next() {
 		var name string
-		if err := rows.Scan(&name); err != nil {
+		var rec appliedMigration
+		if err := rows.Scan(&name, &rec.appliedAtMs, &rec.checksum); err != nil {
 			return nil, fmt.Errorf("migrations scan: %w", err)
 		}
-		applied[name] = true
+		applied[name] = rec
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("migrations rows: %w", err)
@@ -66,29 +270,27 @@ func appliedMigrations(db *sql.DB) (map[string]bool, error) {
 	return applied, nil
 }
 
-func applyMigration(db *sql.DB, name string, now time.Time) error {
-	buf, err := fs.ReadFile(migrations.FS, name)
+func applyMigration(ctx context.Context, db *sql.DB, migration Migration, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("migration read %s: %w", name, err)
+		return fmt.Errorf("migration begin %s: %w", migration.Name, err)
 	}
-	sqlText := strings.TrimSpace(string(buf))
-	if sqlText == "" {
-		return fmt.Errorf("migration empty: %s", name)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if migration.Kind == MigrationKindGo {
+		err = migration.apply(ctx, tx)
+	} else {
+		_, err = tx.ExecContext(ctx, migration.sqlText)
 	}
-	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("migration begin %s: %w", name, err)
-	}
-	if _, err := tx.Exec(sqlText); err != nil {
-		_ = tx.Rollback()
-		return fmt.Errorf("migration exec %s: %w", name, err)
+		return fmt.Errorf("migration exec %s: %w", migration.Name, err)
 	}
-	if _, err := tx.Exec("INSERT INTO schema_migrations(name, applied_at_ms) VALUES(?, ?)", name, now.UnixMilli()); err != nil {
-		_ = tx.Rollback()
-		return fmt.Errorf("migration mark %s: %w", name, err)
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations(name, applied_at_ms, checksum) VALUES(?, ?, ?)", migration.Name, now.UnixMilli(), migration.Checksum); err != nil {
+		return fmt.Errorf("migration mark %s: %w", migration.Name, err)
 	}
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("migration commit %s: %w", name, err)
+		return fmt.Errorf("migration commit %s: %w", migration.Name, err)
 	}
 	return nil
 }
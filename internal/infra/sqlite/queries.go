@@ -54,6 +54,40 @@ func InsertOrderIntent(ctx context.Context, db *sql.DB, rec OrderIntentRecord) e
 	return nil
 }
 
+type OrderFillRecord struct {
+	OrderIntentID   string
+	TradeID         int64
+	Symbol          string
+	Side            string
+	Price           string
+	Qty             string
+	Commission      string
+	CommissionAsset string
+	FilledAtMs      int64
+}
+
+// InsertOrderFill stores one exchange trade of an order; a trade already stored is ignored.
+func InsertOrderFill(ctx context.Context, db *sql.DB, rec OrderFillRecord) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO order_fills (
+  order_intent_id, trade_id, symbol, side, price, qty, commission, commission_asset, filled_at_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(order_intent_id, trade_id) DO NOTHING`,
+		rec.OrderIntentID,
+		rec.TradeID,
+		rec.Symbol,
+		rec.Side,
+		rec.Price,
+		rec.Qty,
+		rec.Commission,
+		rec.CommissionAsset,
+		rec.FilledAtMs,
+	)
+	if err != nil {
+		return fmt.Errorf("insert order_fill: %w", err)
+	}
+	return nil
+}
+
 func UpdateOrderIntentState(ctx context.Context, db *sql.DB, id string, state string, exchangeOrderID string, exchangeOCOID string, lastErrorCode string, lastErrorDetailRedacted string, updatedAtMs int64) error {
 	_, err := db.ExecContext(ctx, `UPDATE order_intents
 SET state = ?, exchange_order_id = ?, exchange_oco_id = ?, last_error_code = ?, last_error_detail_redacted = ?, updated_at_ms = ?
@@ -0,0 +1,348 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "INFO"
+	SeverityWarn     Severity = "WARN"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+const (
+	StatusSent       = "SENT"
+	StatusFailed     = "FAILED"
+	StatusSuppressed = "SUPPRESSED"
+	StatusDropped    = "DROPPED"
+
+	suppressDedupe    = "DEDUPE"
+	suppressRateLimit = "RATE_LIMIT"
+)
+
+type Alert struct {
+	TsMs     int64
+	RunID    string
+	CycleID  string
+	Mode     string
+	Stage    observability.StageName
+	Reasons  []reasoncodes.ReasonCode
+	Severity Severity
+	Summary  string
+	Data     map[string]any
+	Resolved bool
+}
+
+func (a Alert) Key() string {
+	codes := make([]string, 0, len(a.Reasons))
+	for _, reason := range a.Reasons {
+		if reason == reasoncodes.ALERT_RAISED {
+			continue
+		}
+		codes = append(codes, string(reason))
+	}
+	sort.Strings(codes)
+	return string(a.Stage) + ":" + strings.Join(codes, ",")
+}
+
+type Sink interface {
+	Name() string
+	MinSeverity() Severity
+	Send(ctx context.Context, alert Alert) error
+}
+
+type delivery struct {
+	sink  Sink
+	alert Alert
+}
+
+type activeAlert struct {
+	alert Alert
+	sent  bool
+}
+
+type Notifier struct {
+	cfg    config.Config
+	writer *audit.Writer
+	sinks  []Sink
+	now    func() time.Time
+
+	mu         sync.Mutex
+	active     map[string]*activeAlert
+	lastSentMs map[string]int64
+	sentTimes  []int64
+	queue      chan delivery
+	closed     bool
+	done       chan struct{}
+}
+
+func NewNotifier(cfg config.Config, writer *audit.Writer, sinks []Sink, now func() time.Time) (*Notifier, error) {
+	if writer == nil {
+		return nil, fmt.Errorf("alerts audit writer missing")
+	}
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("alerts sinks missing")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	n := &Notifier{
+		cfg:        cfg,
+		writer:     writer,
+		sinks:      sinks,
+		now:        now,
+		active:     map[string]*activeAlert{},
+		lastSentMs: map[string]int64{},
+		queue:      make(chan delivery, cfg.AlertQueueCapacity),
+		done:       make(chan struct{}),
+	}
+	go n.run()
+	return n, nil
+}
+
+func SeverityFor(cfg config.Config, reasons []reasoncodes.ReasonCode) Severity {
+	out := SeverityWarn
+	for _, reason := range reasons {
+		severity, ok := cfg.AlertSeverityByReason[string(reason)]
+		if !ok {
+			continue
+		}
+		if rank(Severity(severity)) > rank(out) {
+			out = Severity(severity)
+		}
+	}
+	return out
+}
+
+func (n *Notifier) Raise(alert Alert) {
+	if alert.Severity == "" {
+		alert.Severity = SeverityFor(n.cfg, alert.Reasons)
+	}
+	alert.Resolved = false
+	key := alert.Key()
+	nowMs := n.now().UnixMilli()
+
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	state, ok := n.active[key]
+	if !ok {
+		state = &activeAlert{}
+		n.active[key] = state
+	}
+	state.alert = alert
+	if last, seen := n.lastSentMs[key]; seen && nowMs-last < int64(n.cfg.AlertDedupeWindowMs) {
+		n.mu.Unlock()
+		n.auditSuppressed(alert, suppressDedupe)
+		return
+	}
+	if !n.allowLocked(nowMs) {
+		n.mu.Unlock()
+		n.auditSuppressed(alert, suppressRateLimit)
+		return
+	}
+	n.lastSentMs[key] = nowMs
+	state.sent = true
+	dropped := n.enqueueLocked(alert)
+	n.mu.Unlock()
+	for _, d := range dropped {
+		n.auditDelivery(d.sink, d.alert, StatusDropped, 0, "queue full")
+	}
+}
+
+func (n *Notifier) Resolve(summary string) {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	var dropped []delivery
+	keys := make([]string, 0, len(n.active))
+	for key := range n.active {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		state := n.active[key]
+		delete(n.active, key)
+		if !state.sent {
+			continue
+		}
+		resolved := state.alert
+		resolved.TsMs = n.now().UnixMilli()
+		resolved.Resolved = true
+		resolved.Summary = summary
+		dropped = append(dropped, n.enqueueLocked(resolved)...)
+	}
+	n.mu.Unlock()
+	for _, d := range dropped {
+		n.auditDelivery(d.sink, d.alert, StatusDropped, 0, "queue full")
+	}
+}
+
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+	<-n.done
+	return nil
+}
+
+func (n *Notifier) allowLocked(nowMs int64) bool {
+	cutoff := nowMs - 60000
+	kept := n.sentTimes[:0]
+	for _, ts := range n.sentTimes {
+		if ts > cutoff {
+			kept = append(kept, ts)
+		}
+	}
+	n.sentTimes = kept
+	if len(n.sentTimes) >= n.cfg.AlertRateLimitPerMin {
+		return false
+	}
+	n.sentTimes = append(n.sentTimes, nowMs)
+	return true
+}
+
+func (n *Notifier) enqueueLocked(alert Alert) []delivery {
+	var dropped []delivery
+	for _, sink := range n.sinks {
+		if rank(alert.Severity) < rank(sink.MinSeverity()) {
+			continue
+		}
+		d := delivery{sink: sink, alert: alert}
+		select {
+		case n.queue <- d:
+		default:
+			dropped = append(dropped, d)
+		}
+	}
+	return dropped
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for d := range n.queue {
+		n.deliver(d)
+	}
+}
+
+func (n *Notifier) deliver(d delivery) {
+	timeout := time.Duration(n.cfg.AlertSendTimeoutMs) * time.Millisecond
+	for attempt := 1; attempt <= n.cfg.AlertSendMaxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := d.sink.Send(ctx, d.alert)
+		cancel()
+		if err == nil {
+			n.auditDelivery(d.sink, d.alert, StatusSent, attempt, "")
+			return
+		}
+		n.auditDelivery(d.sink, d.alert, StatusFailed, attempt, err.Error())
+		if attempt < n.cfg.AlertSendMaxAttempts {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+	}
+}
+
+func (n *Notifier) auditSuppressed(alert Alert, why string) {
+	n.writeAudit(alert, map[string]any{
+		"alert_key": alert.Key(),
+		"sink":      "",
+		"severity":  string(alert.Severity),
+		"status":    StatusSuppressed,
+		"detail":    why,
+		"resolved":  alert.Resolved,
+		"attempt":   0,
+	})
+}
+
+func (n *Notifier) auditDelivery(sink Sink, alert Alert, status string, attempt int, detail string) {
+	n.writeAudit(alert, map[string]any{
+		"alert_key": alert.Key(),
+		"sink":      sink.Name(),
+		"severity":  string(alert.Severity),
+		"status":    status,
+		"detail":    detail,
+		"resolved":  alert.Resolved,
+		"attempt":   attempt,
+	})
+}
+
+func (n *Notifier) writeAudit(alert Alert, data map[string]any) {
+	now := n.now()
+	runID, cycleID := alert.RunID, alert.CycleID
+	if runID == "" {
+		runID = "unknown"
+	}
+	if cycleID == "" {
+		cycleID = "unknown"
+	}
+	reasons := alert.Reasons
+	if reasons == nil {
+		reasons = []reasoncodes.ReasonCode{}
+	}
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            n.cfg.Mode,
+			Stage:           alert.Stage,
+			EventType:       auditdomain.ALERT_NOTIFY,
+			Reasons:         reasons,
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: data,
+	}
+	_ = n.writer.Write(record)
+}
+
+func rank(severity Severity) int {
+	switch severity {
+	case SeverityCritical:
+		return 3
+	case SeverityWarn:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func formatText(alert Alert) string {
+	status := "[" + string(alert.Severity) + "]"
+	if alert.Resolved {
+		status = "[RESOLVED]"
+	}
+	codes := make([]string, 0, len(alert.Reasons))
+	for _, reason := range alert.Reasons {
+		codes = append(codes, string(reason))
+	}
+	return fmt.Sprintf("%s livespot %s stage=%s reasons=%s run_id=%s cycle_id=%s %s",
+		status, alert.Mode, alert.Stage, strings.Join(codes, ","), alert.RunID, alert.CycleID, alert.Summary)
+}
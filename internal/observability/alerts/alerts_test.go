@@ -0,0 +1,155 @@
+package alerts
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+
+	_ "modernc.org/sqlite"
+)
+
+type standIn struct {
+	mu       sync.Mutex
+	requests []string
+	paths    []string
+	auth     []string
+	fail     int
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	s.requests = append(s.requests, string(body))
+	s.paths = append(s.paths, r.URL.Path)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestNotifierRoutesDedupesAndResolves(t *testing.T) {
+	stand := &standIn{fail: 1}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.AlertRateLimitPerMin = 2
+	cfg.AlertSendMaxAttempts = 2
+	cfg.AlertSMTPFrom = "bot@example.test"
+	cfg.AlertSMTPTo = []string{"ops@example.test"}
+	tmp := t.TempDir()
+	dbPath := filepath.Join(tmp, "audit.sqlite")
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: tmp, Now: time.Now})
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+
+	var mails []string
+	smtpSink := NewSMTPSink("mail.example.test:25", cfg.AlertSMTPFrom, cfg.AlertSMTPTo, "", "", SeverityCritical, nil)
+	smtpSink.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		mails = append(mails, string(msg))
+		return nil
+	}
+	sinks := []Sink{
+		NewWebhookSink(srv.URL+"/hook", "hook-token", SeverityWarn, srv.Client()),
+		NewTelegramSink(srv.URL, "123:abc", "42", SeverityCritical, srv.Client()),
+		smtpSink,
+	}
+	now := time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)
+	notifier, err := NewNotifier(cfg, writer, sinks, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("notifier: %v", err)
+	}
+
+	degrade := Alert{Mode: "LIVE", Stage: observability.DEGRADE, Reasons: []reasoncodes.ReasonCode{reasoncodes.ALERT_RAISED, reasoncodes.ENTER_DEGRADE}, Summary: "degraded"}
+	pause := Alert{Mode: "LIVE", Stage: observability.PAUSE, Reasons: []reasoncodes.ReasonCode{reasoncodes.ALERT_RAISED, reasoncodes.ENTER_PAUSE}, Summary: "paused"}
+	exit := Alert{Mode: "LIVE", Stage: observability.SHUTDOWN, Reasons: []reasoncodes.ReasonCode{reasoncodes.ALERT_RAISED, reasoncodes.ENTER_EXIT}, Summary: "exit"}
+	notifier.Raise(degrade)
+	notifier.Raise(degrade)
+	notifier.Raise(pause)
+	notifier.Raise(exit)
+	notifier.Resolve("sys_mode back to NORMAL")
+	if err := notifier.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("writer close: %v", err)
+	}
+
+	if len(stand.requests) != 6 {
+		t.Fatalf("expected 6 stand-in deliveries, got %d: %v", len(stand.requests), stand.paths)
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(stand.requests[0]), &first); err != nil {
+		t.Fatalf("webhook json: %v", err)
+	}
+	if first["severity"] != "WARN" || first["status"] != "firing" || stand.auth[0] != "Bearer hook-token" {
+		t.Fatalf("unexpected webhook payload: %v %v", first, stand.auth[0])
+	}
+	if stand.paths[2] != "/bot123:abc/sendMessage" || !strings.Contains(stand.requests[2], "[CRITICAL]") {
+		t.Fatalf("unexpected telegram delivery: %s %s", stand.paths[2], stand.requests[2])
+	}
+	resolved := 0
+	for _, body := range stand.requests {
+		if strings.Contains(body, `"status":"resolved"`) || strings.Contains(body, "[RESOLVED]") {
+			resolved++
+		}
+	}
+	if resolved != 3 {
+		t.Fatalf("expected resolve notifications for degrade and pause, got %d: %v", resolved, stand.requests)
+	}
+	if len(mails) != 2 || !strings.Contains(mails[0], "Subject: [CRITICAL]") {
+		t.Fatalf("unexpected mails: %v", mails)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	counts := map[string]int{}
+	rows, err := db.Query(`SELECT data_json FROM audit_events WHERE event_type = 'ALERT_NOTIFY'`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var dataJSON string
+		if err := rows.Scan(&dataJSON); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		var data map[string]any
+		_ = json.Unmarshal([]byte(dataJSON), &data)
+		counts[data["status"].(string)+":"+data["detail"].(string)]++
+	}
+	if counts["FAILED:webhook send: http 503: unavailable"] != 1 || counts["SUPPRESSED:DEDUPE"] != 1 || counts["SUPPRESSED:RATE_LIMIT"] != 1 || counts["SENT:"] != 8 {
+		t.Fatalf("unexpected audit counts: %v", counts)
+	}
+}
+
+func TestSeverityFor(t *testing.T) {
+	cfg := config.Default()
+	if got := SeverityFor(cfg, []reasoncodes.ReasonCode{reasoncodes.ALERT_RAISED, reasoncodes.ENTER_DEGRADE}); got != SeverityWarn {
+		t.Fatalf("expected WARN, got %s", got)
+	}
+	if got := SeverityFor(cfg, []reasoncodes.ReasonCode{reasoncodes.ENTER_DEGRADE, reasoncodes.ENTER_PAUSE}); got != SeverityCritical {
+		t.Fatalf("expected CRITICAL, got %s", got)
+	}
+}
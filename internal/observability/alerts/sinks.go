@@ -0,0 +1,239 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+const (
+	WebhookTokenEnv  = "LIVESPOT_ALERT_WEBHOOK_TOKEN"
+	SMTPPasswordEnv  = "LIVESPOT_ALERT_SMTP_PASSWORD"
+	TelegramTokenEnv = "LIVESPOT_ALERT_TELEGRAM_TOKEN"
+
+	maxErrorBodyBytes = 256
+)
+
+type WebhookSink struct {
+	url         string
+	token       string
+	minSeverity Severity
+	client      *http.Client
+}
+
+type webhookPayload struct {
+	TsMs        int64          `json:"ts_ms"`
+	Key         string         `json:"key"`
+	Status      string         `json:"status"`
+	Severity    string         `json:"severity"`
+	Mode        string         `json:"mode"`
+	Stage       string         `json:"stage"`
+	RunID       string         `json:"run_id"`
+	CycleID     string         `json:"cycle_id"`
+	ReasonCodes []string       `json:"reason_codes"`
+	Summary     string         `json:"summary"`
+	Data        map[string]any `json:"data"`
+}
+
+func NewWebhookSink(rawURL string, token string, minSeverity Severity, client *http.Client) *WebhookSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookSink{url: rawURL, token: token, minSeverity: minSeverity, client: client}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) MinSeverity() Severity {
+	return s.minSeverity
+}
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	status := "firing"
+	if alert.Resolved {
+		status = "resolved"
+	}
+	codes := make([]string, 0, len(alert.Reasons))
+	for _, reason := range alert.Reasons {
+		codes = append(codes, string(reason))
+	}
+	data := alert.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+	body, err := json.Marshal(webhookPayload{
+		TsMs:        alert.TsMs,
+		Key:         alert.Key(),
+		Status:      status,
+		Severity:    string(alert.Severity),
+		Mode:        alert.Mode,
+		Stage:       string(alert.Stage),
+		RunID:       alert.RunID,
+		CycleID:     alert.CycleID,
+		ReasonCodes: codes,
+		Summary:     alert.Summary,
+		Data:        data,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return doPost(s.client, req, "webhook")
+}
+
+type TelegramSink struct {
+	baseURL     string
+	token       string
+	chatID      string
+	minSeverity Severity
+	client      *http.Client
+}
+
+func NewTelegramSink(baseURL string, token string, chatID string, minSeverity Severity, client *http.Client) *TelegramSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &TelegramSink{baseURL: strings.TrimRight(baseURL, "/"), token: token, chatID: chatID, minSeverity: minSeverity, client: client}
+}
+
+func (s *TelegramSink) Name() string {
+	return "telegram"
+}
+
+func (s *TelegramSink) MinSeverity() Severity {
+	return s.minSeverity
+}
+
+func (s *TelegramSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  s.chatID,
+		"text":                     formatText(alert),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("telegram payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/bot"+s.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram request: %w", stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doPost(s.client, req, "telegram")
+}
+
+type SMTPSink struct {
+	addr        string
+	from        string
+	to          []string
+	auth        smtp.Auth
+	minSeverity Severity
+	now         func() time.Time
+	sendMail    func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewSMTPSink(addr string, from string, to []string, username string, password string, minSeverity Severity, now func() time.Time) *SMTPSink {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &SMTPSink{addr: addr, from: from, to: to, auth: auth, minSeverity: minSeverity, now: now, sendMail: smtp.SendMail}
+}
+
+func (s *SMTPSink) Name() string {
+	return "smtp"
+}
+
+func (s *SMTPSink) MinSeverity() Severity {
+	return s.minSeverity
+}
+
+func (s *SMTPSink) Send(ctx context.Context, alert Alert) error {
+	text := formatText(alert)
+	subject := text
+	if len(subject) > 120 {
+		subject = subject[:120]
+	}
+	var msg strings.Builder
+	msg.WriteString("From: " + s.from + "\r\n")
+	msg.WriteString("To: " + strings.Join(s.to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("Date: " + s.now().UTC().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(text + "\r\n")
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.sendMail(s.addr, s.auth, s.from, s.to, []byte(msg.String()))
+	}()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("smtp send: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("smtp send: %w", ctx.Err())
+	}
+}
+
+func SinksFromConfig(cfg config.Config, getenv func(string) string, client *http.Client) []Sink {
+	var sinks []Sink
+	if cfg.AlertWebhookURL != "" {
+		sinks = append(sinks, NewWebhookSink(cfg.AlertWebhookURL, getenv(WebhookTokenEnv), Severity(cfg.AlertWebhookMinSeverity), client))
+	}
+	if cfg.AlertSMTPAddr != "" {
+		sinks = append(sinks, NewSMTPSink(cfg.AlertSMTPAddr, cfg.AlertSMTPFrom, cfg.AlertSMTPTo, cfg.AlertSMTPUsername, getenv(SMTPPasswordEnv), Severity(cfg.AlertSMTPMinSeverity), nil))
+	}
+	if cfg.AlertTelegramChatID != "" {
+		sinks = append(sinks, NewTelegramSink(cfg.AlertTelegramBaseURL, getenv(TelegramTokenEnv), cfg.AlertTelegramChatID, Severity(cfg.AlertTelegramMinSeverity), client))
+	}
+	return sinks
+}
+
+func doPost(client *http.Client, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s send: %w", name, stripURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		buf, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("%s send: http %d: %s", name, resp.StatusCode, strings.TrimSpace(string(buf)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
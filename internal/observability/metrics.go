@@ -0,0 +1,271 @@
+package observability
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+	SymbolLabel        = "symbol"
+)
+
+type metricKind string
+
+const (
+	kindGauge     metricKind = "gauge"
+	kindCounter   metricKind = "counter"
+	kindHistogram metricKind = "histogram"
+)
+
+type Registry struct {
+	mu        sync.Mutex
+	families  map[string]*metricFamily
+	watchlist map[string]struct{}
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	kind    metricKind
+	labels  []string
+	buckets []float64
+	series  map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labelValues []string
+	value       float64
+	bucketCount []uint64
+	sum         float64
+	count       uint64
+}
+
+type Gauge struct {
+	r *Registry
+	f *metricFamily
+}
+
+type Counter struct {
+	r *Registry
+	f *metricFamily
+}
+
+type Histogram struct {
+	r *Registry
+	f *metricFamily
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families:  map[string]*metricFamily{},
+		watchlist: map[string]struct{}{},
+	}
+}
+
+func (r *Registry) Gauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{r: r, f: r.family(name, help, kindGauge, nil, labels)}
+}
+
+func (r *Registry) Counter(name string, help string, labels ...string) *Counter {
+	return &Counter{r: r, f: r.family(name, help, kindCounter, nil, labels)}
+}
+
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{r: r, f: r.family(name, help, kindHistogram, sorted, labels)}
+}
+
+func (r *Registry) family(name string, help string, kind metricKind, buckets []float64, labels []string) *metricFamily {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &metricFamily{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  append([]string(nil), labels...),
+		buckets: buckets,
+		series:  map[string]*metricSeries{},
+	}
+	r.families[name] = f
+	return f
+}
+
+func (r *Registry) SetWatchlist(symbols []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.watchlist = make(map[string]struct{}, len(symbols))
+	for _, symbol := range symbols {
+		r.watchlist[symbol] = struct{}{}
+	}
+	for _, f := range r.families {
+		idx := f.symbolIndex()
+		if idx < 0 {
+			continue
+		}
+		for key, s := range f.series {
+			if _, ok := r.watchlist[s.labelValues[idx]]; !ok {
+				delete(f.series, key)
+			}
+		}
+	}
+}
+
+func (r *Registry) seriesFor(f *metricFamily, labelValues []string) *metricSeries {
+	if len(labelValues) != len(f.labels) {
+		return nil
+	}
+	if idx := f.symbolIndex(); idx >= 0 {
+		if _, ok := r.watchlist[labelValues[idx]]; !ok {
+			return nil
+		}
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &metricSeries{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == kindHistogram {
+			s.bucketCount = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *metricFamily) symbolIndex() int {
+	for i, label := range f.labels {
+		if label == SymbolLabel {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	if s := g.r.seriesFor(g.f, labelValues); s != nil {
+		s.value = value
+	}
+}
+
+func (g *Gauge) Reset() {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.series = map[string]*metricSeries{}
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	if s := c.r.seriesFor(c.f, labelValues); s != nil {
+		s.value += delta
+	}
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+	s := h.r.seriesFor(h.f, labelValues)
+	if s == nil {
+		return
+	}
+	for i, bound := range h.f.buckets {
+		if value <= bound {
+			s.bucketCount[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) Reset() {
+	h.r.mu.Lock()
+	defer h.r.mu.Unlock()
+	h.f.series = map[string]*metricSeries{}
+}
+
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.kind)
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			if f.kind != kindHistogram {
+				fmt.Fprintf(bw, "%s%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatFloat(s.value))
+				continue
+			}
+			for i, bound := range f.buckets {
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", formatFloat(bound)), s.bucketCount[i])
+			}
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "le", "+Inf"), s.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), formatFloat(s.sum))
+			fmt.Fprintf(bw, "%s_count%s %d\n", f.name, formatLabels(f.labels, s.labelValues, "", ""), s.count)
+		}
+	}
+	return bw.Flush()
+}
+
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		parts = append(parts, name+"=\""+escapeLabel(values[i])+"\"")
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+"=\""+extraValue+"\"")
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func escapeHelp(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
@@ -0,0 +1,55 @@
+package observability
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesTextFormat(t *testing.T) {
+	reg := NewRegistry()
+	gauge := reg.Gauge("test_exposure", "Exposure per symbol.", SymbolLabel)
+	counter := reg.Counter("test_reconnects_total", "Reconnects.")
+	hist := reg.Histogram("test_latency_seconds", "Latency.", []float64{1, 0.5}, "stage")
+
+	reg.SetWatchlist([]string{"BTCUSDT"})
+	gauge.Set(12.5, "BTCUSDT")
+	gauge.Set(3, "DOGEUSDT")
+	counter.Add(2)
+	counter.Add(-1)
+	hist.Observe(0.2, "RISK_VERDICT")
+	hist.Observe(0.7, "RISK_VERDICT")
+	hist.Observe(3, "RISK_VERDICT")
+
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE test_exposure gauge\n",
+		`test_exposure{symbol="BTCUSDT"} 12.5`,
+		"test_reconnects_total 2\n",
+		`test_latency_seconds_bucket{stage="RISK_VERDICT",le="0.5"} 1`,
+		`test_latency_seconds_bucket{stage="RISK_VERDICT",le="1"} 2`,
+		`test_latency_seconds_bucket{stage="RISK_VERDICT",le="+Inf"} 3`,
+		`test_latency_seconds_sum{stage="RISK_VERDICT"} 3.9`,
+		`test_latency_seconds_count{stage="RISK_VERDICT"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "DOGEUSDT") {
+		t.Fatalf("symbol outside watchlist exported:\n%s", out)
+	}
+
+	reg.SetWatchlist([]string{"ETHUSDT"})
+	buf.Reset()
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if strings.Contains(buf.String(), "BTCUSDT") {
+		t.Fatalf("stale watchlist symbol kept:\n%s", buf.String())
+	}
+}
@@ -1,45 +1,420 @@
 package ops
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+	"modernc.org/sqlite"
+)
+
+const (
+	backupTimeLayout      = "20060102-150405"
+	backupManifestVersion = 1
 )
 
+type TableDigest struct {
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+type BackupManifest struct {
+	Version     int                    `json:"version"`
+	Source      string                 `json:"source"`
+	File        string                 `json:"file"`
+	CreatedAtMs int64                  `json:"created_at_ms"`
+	Bytes       int64                  `json:"bytes"`
+	SHA256      string                 `json:"sha256"`
+	RawBytes    int64                  `json:"raw_bytes"`
+	RawSHA256   string                 `json:"raw_sha256"`
+	Integrity   string                 `json:"integrity"`
+	Tables      map[string]TableDigest `json:"tables"`
+}
+
 type BackupResult struct {
-	Paths []string
+	Path         string
+	ManifestPath string
+	Manifest     BackupManifest
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type backuper interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
 }
 
-func BackupSQLite(dbPath string, backupDir string, now time.Time) (BackupResult, error) {
-	if dbPath == "" || backupDir == "" {
+func BackupManifestPath(backupPath string) string {
+	return strings.TrimSuffix(backupPath, ".gz") + ".json"
+}
+
+func BackupSQLite(ctx context.Context, db *sql.DB, dbPath string, backupDir string, now time.Time) (BackupResult, error) {
+	if db == nil || dbPath == "" || backupDir == "" {
 		return BackupResult{}, fmt.Errorf("backup path missing")
 	}
 	if err := os.MkdirAll(backupDir, 0o750); err != nil {
 		return BackupResult{}, err
 	}
-	ts := now.UTC().Format("20060102-150405")
-	base := filepath.Base(dbPath)
-	destBase := fmt.Sprintf("%s.%s", base, ts)
-	var copied []string
-	mainDest := filepath.Join(backupDir, destBase)
-	if err := copyFile(dbPath, mainDest); err != nil {
+	destBase := fmt.Sprintf("%s.%s", filepath.Base(dbPath), now.UTC().Format(backupTimeLayout))
+	rawPath := filepath.Join(backupDir, destBase+".tmp")
+	defer func() {
+		_ = os.Remove(rawPath)
+	}()
+	tables, err := snapshotBackup(ctx, db, rawPath)
+	if err != nil {
 		return BackupResult{}, err
 	}
-	copied = append(copied, mainDest)
-	for _, suffix := range []string{"-wal", "-shm"} {
-		src := dbPath + suffix
-		if _, err := os.Stat(src); err == nil {
-			dst := filepath.Join(backupDir, destBase+suffix)
-			if err := copyFile(src, dst); err != nil {
-				return BackupResult{}, err
+	integrity, err := checkBackupFile(ctx, rawPath, tables)
+	if err != nil {
+		return BackupResult{}, err
+	}
+	out := BackupResult{Path: filepath.Join(backupDir, destBase+".gz")}
+	out.ManifestPath = BackupManifestPath(out.Path)
+	manifest := BackupManifest{
+		Version:     backupManifestVersion,
+		Source:      filepath.ToSlash(dbPath),
+		File:        filepath.Base(out.Path),
+		CreatedAtMs: now.UnixMilli(),
+		Integrity:   integrity,
+		Tables:      tables,
+	}
+	if err := compressBackup(rawPath, out.Path, &manifest); err != nil {
+		return BackupResult{}, fmt.Errorf("backup compress: %w", err)
+	}
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return BackupResult{}, fmt.Errorf("backup manifest: %w", err)
+	}
+	if err := writeFileAtomic(out.ManifestPath, append(buf, '\n')); err != nil {
+		return BackupResult{}, fmt.Errorf("backup manifest write: %w", err)
+	}
+	out.Manifest = manifest
+	return out, nil
+}
+
+func snapshotBackup(ctx context.Context, db *sql.DB, rawPath string) (map[string]TableDigest, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("backup conn: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return nil, fmt.Errorf("backup begin: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+	}()
+	tables, err := tableDigests(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Raw(func(driverConn any) error {
+		source, ok := driverConn.(backuper)
+		if !ok {
+			return fmt.Errorf("sqlite driver does not support online backup")
+		}
+		backup, err := source.NewBackup(rawPath)
+		if err != nil {
+			return err
+		}
+		for {
+			more, err := backup.Step(-1)
+			if err != nil {
+				_ = backup.Finish()
+				return err
+			}
+			if !more {
+				return backup.Finish()
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("backup copy: %w", err)
+	}
+	return tables, nil
+}
+
+func tableDigests(ctx context.Context, q queryer) (map[string]TableDigest, error) {
+	rows, err := q.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("backup tables: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("backup tables scan: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("backup tables rows: %w", err)
+	}
+	digests := make(map[string]TableDigest, len(names))
+	for _, name := range names {
+		digest, err := tableDigest(ctx, q, name)
+		if err != nil {
+			return nil, err
+		}
+		digests[name] = digest
+	}
+	return digests, nil
+}
+
+func tableDigest(ctx context.Context, q queryer, table string) (TableDigest, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(`SELECT * FROM "%s" ORDER BY rowid`, strings.ReplaceAll(table, `"`, `""`)))
+	if err != nil {
+		return TableDigest{}, fmt.Errorf("backup digest %s: %w", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return TableDigest{}, fmt.Errorf("backup digest %s: %w", table, err)
+	}
+	hasher := sha256.New()
+	var count int64
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return TableDigest{}, fmt.Errorf("backup digest %s: %w", table, err)
+		}
+		for i, value := range values {
+			if raw, ok := value.([]byte); ok {
+				values[i] = string(raw)
 			}
-			copied = append(copied, dst)
 		}
+		line, err := json.Marshal(values)
+		if err != nil {
+			return TableDigest{}, fmt.Errorf("backup digest %s: %w", table, err)
+		}
+		hasher.Write(line)
+		hasher.Write([]byte{'\n'})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return TableDigest{}, fmt.Errorf("backup digest %s: %w", table, err)
+	}
+	return TableDigest{Rows: count, SHA256: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+func checkBackupFile(ctx context.Context, rawPath string, want map[string]TableDigest) (string, error) {
+	db, err := sql.Open("sqlite", rawPath)
+	if err != nil {
+		return "", fmt.Errorf("backup verify open: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, "PRAGMA journal_mode=DELETE;"); err != nil {
+		return "", fmt.Errorf("backup verify journal: %w", err)
+	}
+	integrity, err := integrityCheck(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	if integrity != "ok" {
+		return "", fmt.Errorf("backup integrity_check failed: %s", integrity)
+	}
+	got, err := tableDigests(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	if err := compareDigests(want, got); err != nil {
+		return "", err
+	}
+	return integrity, nil
+}
+
+func integrityCheck(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return "", fmt.Errorf("backup integrity_check: %w", err)
+	}
+	defer rows.Close()
+	var messages []string
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return "", fmt.Errorf("backup integrity_check: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("backup integrity_check: %w", err)
+	}
+	return strings.Join(messages, "; "), nil
+}
+
+func compareDigests(want map[string]TableDigest, got map[string]TableDigest) error {
+	var diffs []string
+	for name, digest := range want {
+		other, ok := got[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, name+" missing")
+		case other.Rows != digest.Rows:
+			diffs = append(diffs, fmt.Sprintf("%s rows %d != %d", name, other.Rows, digest.Rows))
+		case other.SHA256 != digest.SHA256:
+			diffs = append(diffs, name+" content differs")
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			diffs = append(diffs, name+" unexpected")
+		}
+	}
+	if len(diffs) == 0 {
+		return nil
 	}
-	return BackupResult{Paths: copied}, nil
+	sort.Strings(diffs)
+	return fmt.Errorf("backup verification failed: %s", strings.Join(diffs, ", "))
+}
+
+func compressBackup(rawPath string, gzPath string, manifest *BackupManifest) error {
+	in, err := os.Open(rawPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := gzPath + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+	}()
+	gzHash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(out, gzHash)}
+	zw := gzip.NewWriter(counter)
+	rawHash := sha256.New()
+	rawBytes, err := io.Copy(zw, io.TeeReader(in, rawHash))
+	if err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, gzPath); err != nil {
+		return err
+	}
+	manifest.Bytes = counter.n
+	manifest.SHA256 = hex.EncodeToString(gzHash.Sum(nil))
+	manifest.RawBytes = rawBytes
+	manifest.RawSHA256 = hex.EncodeToString(rawHash.Sum(nil))
+	return nil
+}
+
+func ReadBackupManifest(backupPath string) (BackupManifest, error) {
+	buf, err := os.ReadFile(BackupManifestPath(backupPath))
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return BackupManifest{}, fmt.Errorf("backup manifest invalid: %w", err)
+	}
+	if manifest.Version != backupManifestVersion || manifest.File != filepath.Base(backupPath) {
+		return BackupManifest{}, fmt.Errorf("backup manifest does not describe %s", filepath.Base(backupPath))
+	}
+	return manifest, nil
+}
+
+func VerifyBackup(ctx context.Context, backupPath string, workDir string) (BackupManifest, string, error) {
+	manifest, err := ReadBackupManifest(backupPath)
+	if err != nil {
+		return BackupManifest{}, "", err
+	}
+	gzSum, gzBytes, err := fileSHA256(backupPath)
+	if err != nil {
+		return BackupManifest{}, "", err
+	}
+	if gzBytes != manifest.Bytes || gzSum != manifest.SHA256 {
+		return BackupManifest{}, "", fmt.Errorf("backup file differs from manifest")
+	}
+	if err := os.MkdirAll(workDir, 0o750); err != nil {
+		return BackupManifest{}, "", err
+	}
+	raw, err := os.CreateTemp(workDir, ".restore-*.sqlite")
+	if err != nil {
+		return BackupManifest{}, "", err
+	}
+	rawPath := raw.Name()
+	fail := func(err error) (BackupManifest, string, error) {
+		_ = raw.Close()
+		_ = os.Remove(rawPath)
+		return BackupManifest{}, "", err
+	}
+	in, err := os.Open(backupPath)
+	if err != nil {
+		return fail(err)
+	}
+	defer in.Close()
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return fail(fmt.Errorf("backup gzip: %w", err))
+	}
+	rawHash := sha256.New()
+	rawBytes, err := io.Copy(io.MultiWriter(raw, rawHash), zr)
+	if err != nil {
+		return fail(fmt.Errorf("backup gzip: %w", err))
+	}
+	if rawBytes != manifest.RawBytes || hex.EncodeToString(rawHash.Sum(nil)) != manifest.RawSHA256 {
+		return fail(fmt.Errorf("backup content differs from manifest"))
+	}
+	if err := raw.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := raw.Close(); err != nil {
+		return fail(err)
+	}
+	if _, err := checkBackupFile(ctx, rawPath, manifest.Tables); err != nil {
+		_ = os.Remove(rawPath)
+		return BackupManifest{}, "", err
+	}
+	return manifest, rawPath, nil
+}
+
+func fileSHA256(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), n, nil
 }
 
 func CleanupSQLiteBackups(backupDir string, keepDays int, now time.Time) ([]string, error) {
@@ -60,15 +435,15 @@ func CleanupSQLiteBackups(backupDir string, keepDays int, now time.Time) ([]stri
 			continue
 		}
 		name := entry.Name()
-		parts := strings.Split(name, ".")
+		trimmed := name
+		for _, suffix := range []string{".gz", ".json", "-wal", "-shm"} {
+			trimmed = strings.TrimSuffix(trimmed, suffix)
+		}
+		parts := strings.Split(trimmed, ".")
 		if len(parts) < 2 {
 			continue
 		}
-		tsPart := parts[len(parts)-1]
-		if strings.HasSuffix(name, ".sqlite-wal") || strings.HasSuffix(name, ".sqlite-shm") {
-			tsPart = parts[len(parts)-2]
-		}
-		t, err := time.Parse("20060102-150405", tsPart)
+		t, err := time.Parse(backupTimeLayout, parts[len(parts)-1])
 		if err != nil {
 			continue
 		}
@@ -83,21 +458,101 @@ func CleanupSQLiteBackups(backupDir string, keepDays int, now time.Time) ([]stri
 	return deleted, nil
 }
 
-func copyFile(src string, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
+func writeFileAtomic(path string, buf []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0o640); err != nil {
 		return err
 	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
 		return err
 	}
-	defer func() {
-		_ = out.Close()
-	}()
-	if _, err := io.Copy(out, in); err != nil {
-		return err
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type Backuper struct {
+	cfg    config.Config
+	db     *sql.DB
+	writer *audit.Writer
+	dbPath string
+	now    func() time.Time
+}
+
+func NewBackuper(cfg config.Config, db *sql.DB, writer *audit.Writer, dbPath string, now func() time.Time) (*Backuper, error) {
+	if db == nil {
+		return nil, fmt.Errorf("backup db missing")
+	}
+	if writer == nil {
+		return nil, fmt.Errorf("backup audit writer missing")
+	}
+	if cfg.BackupDir == "" || dbPath == "" {
+		return nil, fmt.Errorf("backup path missing")
+	}
+	if cfg.BackupKeepDays <= 0 {
+		return nil, fmt.Errorf("backup keep days invalid")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Backuper{cfg: cfg, db: db, writer: writer, dbPath: dbPath, now: now}, nil
+}
+
+func (b *Backuper) Run(ctx context.Context, runID string, cycleID string) (BackupResult, error) {
+	started := b.now()
+	result, err := BackupSQLite(ctx, b.db, b.dbPath, b.cfg.BackupDir, started)
+	if err != nil {
+		return BackupResult{}, err
+	}
+	deleted, err := CleanupSQLiteBackups(b.cfg.BackupDir, b.cfg.BackupKeepDays, started)
+	if err != nil {
+		return BackupResult{}, fmt.Errorf("backup cleanup: %w", err)
+	}
+	var rows int64
+	for _, table := range result.Manifest.Tables {
+		rows += table.Rows
+	}
+	now := b.now()
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            b.cfg.Mode,
+			Stage:           observability.REPORT_DAILY_SUMMARY,
+			EventType:       auditdomain.DB_BACKUP,
+			Reasons:         []reasoncodes.ReasonCode{},
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"path":            filepath.ToSlash(result.Path),
+			"manifest_path":   filepath.ToSlash(result.ManifestPath),
+			"bytes":           result.Manifest.Bytes,
+			"sha256":          result.Manifest.SHA256,
+			"raw_bytes":       result.Manifest.RawBytes,
+			"integrity":       result.Manifest.Integrity,
+			"tables":          len(result.Manifest.Tables),
+			"rows":            rows,
+			"deleted_backups": len(deleted),
+			"duration_ms":     now.Sub(started).Milliseconds(),
+		},
+	}
+	if err := b.writer.WriteSync(record); err != nil {
+		return BackupResult{}, fmt.Errorf("audit backup write: %w", err)
 	}
-	return out.Sync()
+	return result, nil
 }
@@ -1,33 +1,129 @@
 package ops
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
 )
 
-func TestBackupSQLiteCopiesFiles(t *testing.T) {
+func TestBackupSQLiteVerifiesAndRestores(t *testing.T) {
 	dir := t.TempDir()
-	dbPath := filepath.Join(dir, "audit.sqlite")
-	if err := os.WriteFile(dbPath, []byte("db"), 0o640); err != nil {
-		t.Fatalf("write db: %v", err)
+	cfg := config.Default()
+	cfg.RunLockPath = filepath.Join(dir, "livespot.lock")
+	dbPath := filepath.Join(dir, "data", "audit.sqlite")
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
 	}
-	if err := os.WriteFile(dbPath+"-wal", []byte("wal"), 0o640); err != nil {
-		t.Fatalf("write wal: %v", err)
+	defer db.Close()
+	now := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	if err := sqlite.Migrate(db, now); err != nil {
+		t.Fatalf("migrate: %v", err)
 	}
+	insertSnapshot(t, db, "snap_1")
 	backupDir := filepath.Join(dir, "backups")
+	ctx := context.Background()
+	res, err := BackupSQLite(ctx, db, dbPath, backupDir, now)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if res.Manifest.Integrity != "ok" || res.Manifest.Tables["snapshots"].Rows != 1 || filepath.Base(res.Path) != "audit.sqlite.20260201-100000.gz" {
+		t.Fatalf("unexpected manifest: %+v", res.Manifest)
+	}
+	if _, err := os.Stat(res.ManifestPath); err != nil {
+		t.Fatalf("manifest missing: %v", err)
+	}
+	insertSnapshot(t, db, "snap_2")
+
+	lock, err := AcquireRunLock(cfg.RunLockPath, now)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := RestoreSQLite(ctx, cfg, res.Path, dbPath, now); !errors.Is(err, ErrRunLockHeld) {
+		t.Fatalf("expected restore to refuse while running, got %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	restored, err := RestoreSQLite(ctx, cfg, res.Path, dbPath, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := os.Stat(restored.PreviousPath); err != nil {
+		t.Fatalf("previous database should be kept: %v", err)
+	}
+	check, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer check.Close()
+	var snapshots int
+	if err := check.QueryRow(`SELECT COUNT(*) FROM snapshots`).Scan(&snapshots); err != nil || snapshots != 1 {
+		t.Fatalf("expected restored snapshot count 1, got %d (%v)", snapshots, err)
+	}
+}
+
+func TestVerifyBackupRejectsTampering(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Default()
+	dbPath := filepath.Join(dir, "audit.sqlite")
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
 	now := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
-	res, err := BackupSQLite(dbPath, backupDir, now)
+	if err := sqlite.Migrate(db, now); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	insertSnapshot(t, db, "snap_1")
+	res, err := BackupSQLite(context.Background(), db, dbPath, filepath.Join(dir, "backups"), now)
 	if err != nil {
 		t.Fatalf("backup: %v", err)
 	}
-	if len(res.Paths) != 2 {
-		t.Fatalf("expected 2 files, got %d", len(res.Paths))
+	buf, err := os.ReadFile(res.Path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	buf[len(buf)/2] ^= 0xff
+	if err := os.WriteFile(res.Path, buf, 0o640); err != nil {
+		t.Fatalf("write: %v", err)
 	}
-	for _, path := range res.Paths {
-		if _, err := os.Stat(path); err != nil {
-			t.Fatalf("backup missing: %v", err)
+	if _, _, err := VerifyBackup(context.Background(), res.Path, dir); err == nil {
+		t.Fatalf("expected tampered backup to be rejected")
+	}
+}
+
+func TestCleanupSQLiteBackups(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"audit.sqlite.20260101-000000.gz", "audit.sqlite.20260101-000000.json", "audit.sqlite.20260101-000000-wal", "audit.sqlite.20260131-000000.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o640); err != nil {
+			t.Fatalf("write: %v", err)
 		}
 	}
+	deleted, err := CleanupSQLiteBackups(dir, 7, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if len(deleted) != 3 {
+		t.Fatalf("expected 3 deletes, got %v", deleted)
+	}
+}
+
+func insertSnapshot(t *testing.T, db *sql.DB, id string) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES (?, 'BTCUSDT', 'hash', 0, 0, '{}', 1)`, id); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
 }
@@ -0,0 +1,37 @@
+package ops
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+type MigrateResult struct {
+	Applied []sqlite.MigrationStatus
+	Backup  *BackupResult
+}
+
+func MigrateWithBackup(ctx context.Context, db *sql.DB, dbPath string, backupDir string, now time.Time) (MigrateResult, error) {
+	statuses, err := sqlite.MigrationPlan(db)
+	if err != nil {
+		return MigrateResult{}, err
+	}
+	if err := sqlite.CheckMigrations(statuses); err != nil {
+		return MigrateResult{}, err
+	}
+	out := MigrateResult{Applied: sqlite.PendingMigrations(statuses)}
+	if len(out.Applied) > 0 && len(out.Applied) < len(statuses) && backupDir != "" {
+		backup, err := BackupSQLite(ctx, db, dbPath, backupDir, now)
+		if err != nil {
+			return MigrateResult{}, fmt.Errorf("pre-migration backup: %w", err)
+		}
+		out.Backup = &backup
+	}
+	if err := sqlite.Migrate(db, now); err != nil {
+		return MigrateResult{}, err
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+func TestMigrateWithBackupChecksumsAndGoMigrations(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Default()
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	backupDir := filepath.Join(tmp, "backups")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	first, err := MigrateWithBackup(ctx, db, dbPath, backupDir, now)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	all, err := sqlite.LoadMigrations()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if first.Backup != nil || len(first.Applied) != len(all) {
+		t.Fatalf("fresh database should migrate fully without backup: %+v", first)
+	}
+
+	if _, err := db.Exec(`INSERT INTO ai_gate_events (run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash, enabled, verdict, reasons_json, modify_applied, local_received_ms, created_at_ms)
+VALUES ('run_m', 'cyc_m', 'LIVE', 'AI_GATE', 'AI_GATE_RESULT', 'snap_m', 'hash', 'dec_legacy', 'input', 1, 'ALLOW', '[]', 0, 0, 0)`); err != nil {
+		t.Fatalf("insert legacy ai gate row: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM schema_migrations WHERE name = '0009_backfill_ai_gate_prompt_version'`); err != nil {
+		t.Fatalf("reset go migration: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE schema_migrations SET checksum = '' WHERE name = '0001_init.sql'`); err != nil {
+		t.Fatalf("reset checksum: %v", err)
+	}
+	statuses, err := sqlite.MigrationPlan(db)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	pending := sqlite.PendingMigrations(statuses)
+	if len(pending) != 1 || pending[0].Kind != sqlite.MigrationKindGo || statuses[0].State != sqlite.MigrationAdopted {
+		t.Fatalf("unexpected plan: %+v", statuses)
+	}
+
+	second, err := MigrateWithBackup(ctx, db, dbPath, backupDir, now)
+	if err != nil {
+		t.Fatalf("migrate pending: %v", err)
+	}
+	if second.Backup == nil || len(second.Applied) != 1 {
+		t.Fatalf("expected pre-migration backup and one applied migration: %+v", second)
+	}
+	if _, err := os.Stat(second.Backup.Path); err != nil {
+		t.Fatalf("backup missing: %v", err)
+	}
+	var version string
+	if err := db.QueryRow(`SELECT prompt_version FROM ai_gate_events WHERE decision_id = 'dec_legacy'`).Scan(&version); err != nil || version != "v1" {
+		t.Fatalf("expected backfilled prompt_version v1, got %q (%v)", version, err)
+	}
+	var stored string
+	if err := db.QueryRow(`SELECT checksum FROM schema_migrations WHERE name = '0001_init.sql'`).Scan(&stored); err != nil || stored != all[0].Checksum {
+		t.Fatalf("expected adopted checksum, got %q (%v)", stored, err)
+	}
+
+	if _, err := db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE name = '0002_snapshots.sql'`); err != nil {
+		t.Fatalf("tamper checksum: %v", err)
+	}
+	if _, err := MigrateWithBackup(ctx, db, dbPath, backupDir, now); !errors.Is(err, sqlite.ErrMigrationDrift) {
+		t.Fatalf("expected checksum mismatch refusal, got %v", err)
+	}
+	if err := sqlite.Migrate(db, now); !errors.Is(err, sqlite.ErrMigrationDrift) {
+		t.Fatalf("expected Migrate to refuse drift, got %v", err)
+	}
+}
+
+func TestRequireMigratedRefusesPendingReadOnly(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Default()
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, now); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM schema_migrations WHERE name = '0009_backfill_ai_gate_prompt_version'`); err != nil {
+		t.Fatalf("reset go migration: %v", err)
+	}
+
+	ro, err := sqlite.OpenReadOnly(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	defer ro.Close()
+	if err := sqlite.RequireMigrated(ro); !errors.Is(err, sqlite.ErrMigrationsPending) {
+		t.Fatalf("expected pending migrations refused, got %v", err)
+	}
+	if _, err := ro.Exec(`DELETE FROM schema_migrations`); err == nil {
+		t.Fatalf("expected read-only handle to refuse writes")
+	}
+	if err := sqlite.Migrate(db, now); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := sqlite.RequireMigrated(ro); err != nil {
+		t.Fatalf("expected migrated database accepted, got %v", err)
+	}
+	if _, err := sqlite.OpenReadOnly(filepath.Join(tmp, "missing.sqlite"), cfg); err == nil {
+		t.Fatalf("expected missing database refused")
+	}
+}
@@ -0,0 +1,84 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+)
+
+type RestoreResult struct {
+	Manifest     BackupManifest
+	DBPath       string
+	PreviousPath string
+}
+
+func RestoreSQLite(ctx context.Context, cfg config.Config, backupPath string, dbPath string, now time.Time) (RestoreResult, error) {
+	if backupPath == "" || dbPath == "" {
+		return RestoreResult{}, fmt.Errorf("restore path missing")
+	}
+	lock, err := AcquireRunLock(cfg.RunLockPath, now)
+	if err != nil {
+		return RestoreResult{}, fmt.Errorf("restore requires livespot to be stopped: %w", err)
+	}
+	defer func() {
+		_ = lock.Release()
+	}()
+	manifest, rawPath, err := VerifyBackup(ctx, backupPath, filepath.Dir(dbPath))
+	if err != nil {
+		return RestoreResult{}, err
+	}
+	defer func() {
+		_ = os.Remove(rawPath)
+	}()
+	if err := migrateRestored(ctx, rawPath, cfg, now); err != nil {
+		return RestoreResult{}, err
+	}
+	out := RestoreResult{Manifest: manifest, DBPath: dbPath}
+	if _, err := os.Stat(dbPath); err == nil {
+		out.PreviousPath = fmt.Sprintf("%s.pre-restore-%s", dbPath, now.UTC().Format(backupTimeLayout))
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if _, err := os.Stat(dbPath + suffix); err != nil {
+				continue
+			}
+			if err := os.Rename(dbPath+suffix, out.PreviousPath+suffix); err != nil {
+				return RestoreResult{}, fmt.Errorf("restore move current: %w", err)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return RestoreResult{}, err
+	}
+	if err := os.Rename(rawPath, dbPath); err != nil {
+		return RestoreResult{}, fmt.Errorf("restore swap: %w", err)
+	}
+	return out, nil
+}
+
+func migrateRestored(ctx context.Context, path string, cfg config.Config, now time.Time) error {
+	db, err := sqlite.Open(path, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := sqlite.Migrate(db, now); err != nil {
+		return fmt.Errorf("restore migrate: %w", err)
+	}
+	integrity, err := integrityCheck(ctx, db)
+	if err != nil {
+		return err
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("restore integrity_check failed after migrate: %s", integrity)
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE);"); err != nil {
+		return fmt.Errorf("restore checkpoint: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, "PRAGMA journal_mode=DELETE;"); err != nil {
+		return fmt.Errorf("restore journal: %w", err)
+	}
+	return nil
+}
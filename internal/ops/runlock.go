@@ -0,0 +1,49 @@
+package ops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrRunLockHeld = errors.New("run lock held by another process")
+
+type RunLock struct {
+	file *os.File
+	path string
+}
+
+func AcquireRunLock(path string, now time.Time) (*RunLock, error) {
+	if path == "" {
+		return nil, fmt.Errorf("run lock path missing")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("run lock mkdir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("run lock open: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%w: %s", ErrRunLockHeld, path)
+	}
+	if err := file.Truncate(0); err == nil {
+		_, _ = fmt.Fprintf(file, "pid=%d started_at_ms=%d\n", os.Getpid(), now.UnixMilli())
+	}
+	return &RunLock{file: file, path: path}, nil
+}
+
+func (l *RunLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,357 @@
+package ops
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+const retentionDayLayout = "2006-01-02"
+
+type RetentionTable struct {
+	Name     string
+	TsColumn string
+	KeepDays int
+}
+
+type RetentionArchive struct {
+	Table string `json:"table"`
+	Day   string `json:"day"`
+	Path  string `json:"path"`
+	Rows  int64  `json:"rows"`
+}
+
+type RetentionResult struct {
+	Skipped              string             `json:"skipped,omitempty"`
+	CutoffMs             map[string]int64   `json:"cutoff_ms"`
+	RowsDeleted          map[string]int64   `json:"rows_deleted"`
+	Archives             []RetentionArchive `json:"archives"`
+	FreelistBefore       int64              `json:"freelist_before"`
+	FreelistAfter        int64              `json:"freelist_after"`
+	VacuumIncremental    bool               `json:"vacuum_incremental"`
+	CheckpointBusy       bool               `json:"checkpoint_busy"`
+	CheckpointLogPages   int64              `json:"checkpoint_log_pages"`
+	CheckpointMovedPages int64              `json:"checkpoint_moved_pages"`
+	DurationMs           int64              `json:"duration_ms"`
+}
+
+type Retainer struct {
+	cfg    config.Config
+	db     *sql.DB
+	writer *audit.Writer
+	dir    string
+	now    func() time.Time
+}
+
+type retainedRow struct {
+	rowID int64
+	ts    int64
+	row   map[string]any
+}
+
+func NewRetainer(cfg config.Config, db *sql.DB, writer *audit.Writer, now func() time.Time) (*Retainer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("retention db missing")
+	}
+	if writer == nil {
+		return nil, fmt.Errorf("retention audit writer missing")
+	}
+	if cfg.RetentionArchiveDir == "" {
+		return nil, fmt.Errorf("retention archive dir missing")
+	}
+	if cfg.RetentionAuditKeepDays <= 0 || cfg.RetentionDataKeepDays <= 0 || cfg.RetentionBatchRows <= 0 {
+		return nil, fmt.Errorf("retention limits invalid")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Retainer{cfg: cfg, db: db, writer: writer, dir: cfg.RetentionArchiveDir, now: now}, nil
+}
+
+func (r *Retainer) Tables() []RetentionTable {
+	return []RetentionTable{
+		{Name: "audit_events", TsColumn: "ts_ms", KeepDays: r.cfg.RetentionAuditKeepDays},
+		{Name: "snapshots", TsColumn: "created_at_ms", KeepDays: r.cfg.RetentionDataKeepDays},
+		{Name: "cycle_rankings", TsColumn: "created_at_ms", KeepDays: r.cfg.RetentionDataKeepDays},
+		{Name: "health_samples", TsColumn: "created_at_ms", KeepDays: r.cfg.RetentionDataKeepDays},
+	}
+}
+
+func ArchivePath(dir string, table string, day string) string {
+	return filepath.Join(dir, table, fmt.Sprintf("%s-%s.jsonl.gz", table, day))
+}
+
+func (r *Retainer) Run(ctx context.Context, runID string, cycleID string) (RetentionResult, error) {
+	started := r.now()
+	result := RetentionResult{CutoffMs: map[string]int64{}, RowsDeleted: map[string]int64{}, Archives: []RetentionArchive{}}
+	var inFlight int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM order_intents WHERE state IN ('CREATED', 'SENT_UNKNOWN')`).Scan(&inFlight); err != nil {
+		return RetentionResult{}, fmt.Errorf("retention intents check: %w", err)
+	}
+	if inFlight > 0 {
+		result.Skipped = fmt.Sprintf("%d order intents in flight", inFlight)
+		return result, nil
+	}
+	today := started.UTC().Truncate(24 * time.Hour)
+	archives := map[string]*RetentionArchive{}
+	for _, table := range r.Tables() {
+		cutoff := today.AddDate(0, 0, -table.KeepDays).UnixMilli()
+		result.CutoffMs[table.Name] = cutoff
+		deleted, err := r.archiveTable(ctx, table, cutoff, archives)
+		result.RowsDeleted[table.Name] = deleted
+		if err != nil {
+			return RetentionResult{}, err
+		}
+	}
+	for _, archive := range archives {
+		result.Archives = append(result.Archives, *archive)
+	}
+	sort.Slice(result.Archives, func(i, j int) bool {
+		if result.Archives[i].Table != result.Archives[j].Table {
+			return result.Archives[i].Table < result.Archives[j].Table
+		}
+		return result.Archives[i].Day < result.Archives[j].Day
+	})
+	if err := r.compact(ctx, &result); err != nil {
+		return RetentionResult{}, err
+	}
+	result.DurationMs = r.now().Sub(started).Milliseconds()
+	if err := r.audit(runID, cycleID, result); err != nil {
+		return RetentionResult{}, err
+	}
+	return result, nil
+}
+
+func (r *Retainer) archiveTable(ctx context.Context, table RetentionTable, cutoffMs int64, archives map[string]*RetentionArchive) (int64, error) {
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		rows, maxRowID, err := r.selectBatch(ctx, table, cutoffMs)
+		if err != nil {
+			return deleted, err
+		}
+		if len(rows) == 0 {
+			return deleted, nil
+		}
+		byDay := map[string][]retainedRow{}
+		for _, row := range rows {
+			day := time.UnixMilli(row.ts).UTC().Format(retentionDayLayout)
+			byDay[day] = append(byDay[day], row)
+		}
+		for day, dayRows := range byDay {
+			if err := appendArchive(ArchivePath(r.dir, table.Name, day), dayRows); err != nil {
+				return deleted, fmt.Errorf("retention archive %s: %w", table.Name, err)
+			}
+		}
+		n, err := r.deleteBatch(ctx, table, cutoffMs, maxRowID, byDay)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+		for day, dayRows := range byDay {
+			key := table.Name + "/" + day
+			if archives[key] == nil {
+				archives[key] = &RetentionArchive{Table: table.Name, Day: day, Path: filepath.ToSlash(ArchivePath(r.dir, table.Name, day))}
+			}
+			archives[key].Rows += int64(len(dayRows))
+		}
+		if len(rows) < r.cfg.RetentionBatchRows {
+			return deleted, nil
+		}
+	}
+}
+
+func (r *Retainer) selectBatch(ctx context.Context, table RetentionTable, cutoffMs int64) ([]retainedRow, int64, error) {
+	query := fmt.Sprintf(`SELECT rowid, * FROM %s WHERE %s < ? ORDER BY rowid LIMIT ?`, table.Name, table.TsColumn)
+	rows, err := r.db.QueryContext(ctx, query, cutoffMs, r.cfg.RetentionBatchRows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("retention select %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, fmt.Errorf("retention columns %s: %w", table.Name, err)
+	}
+	var out []retainedRow
+	var maxRowID int64
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, 0, fmt.Errorf("retention scan %s: %w", table.Name, err)
+		}
+		row := retainedRow{row: make(map[string]any, len(columns)-1)}
+		row.rowID, _ = values[0].(int64)
+		for i := 1; i < len(columns); i++ {
+			value := values[i]
+			if raw, ok := value.([]byte); ok {
+				value = string(raw)
+			}
+			row.row[columns[i]] = value
+			if columns[i] == table.TsColumn {
+				row.ts, _ = value.(int64)
+			}
+		}
+		if row.rowID > maxRowID {
+			maxRowID = row.rowID
+		}
+		out = append(out, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("retention rows %s: %w", table.Name, err)
+	}
+	return out, maxRowID, nil
+}
+
+func (r *Retainer) deleteBatch(ctx context.Context, table RetentionTable, cutoffMs int64, maxRowID int64, byDay map[string][]retainedRow) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("retention begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if table.Name == "audit_events" {
+		_, err := tx.ExecContext(ctx, `INSERT INTO audit_chain_anchors (run_id, record_hash, event_id, ts_ms)
+SELECT run_id, record_hash, event_id, ts_ms FROM audit_events
+WHERE event_id IN (
+  SELECT MAX(event_id) FROM audit_events
+  WHERE event_id <= ? AND ts_ms < ? AND record_hash != ''
+  GROUP BY run_id
+)
+ON CONFLICT(run_id) DO UPDATE SET record_hash = excluded.record_hash, event_id = excluded.event_id, ts_ms = excluded.ts_ms
+WHERE excluded.event_id > audit_chain_anchors.event_id`, maxRowID, cutoffMs)
+		if err != nil {
+			return 0, fmt.Errorf("retention chain anchors: %w", err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE rowid <= ? AND %s < ?`, table.Name, table.TsColumn), maxRowID, cutoffMs)
+	if err != nil {
+		return 0, fmt.Errorf("retention delete %s: %w", table.Name, err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("retention delete %s: %w", table.Name, err)
+	}
+	nowMs := r.now().UnixMilli()
+	for day, dayRows := range byDay {
+		_, err := tx.ExecContext(ctx, `INSERT INTO retention_archives (table_name, day, archive_path, rows, cutoff_ms, created_at_ms)
+VALUES (?, ?, ?, ?, ?, ?)`, table.Name, day, filepath.ToSlash(ArchivePath(r.dir, table.Name, day)), len(dayRows), cutoffMs, nowMs)
+		if err != nil {
+			return 0, fmt.Errorf("retention archive record: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("retention commit %s: %w", table.Name, err)
+	}
+	return deleted, nil
+}
+
+func appendArchive(path string, rows []retainedRow) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	zw := gzip.NewWriter(file)
+	encoder := json.NewEncoder(zw)
+	for _, row := range rows {
+		if err := encoder.Encode(row.row); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (r *Retainer) compact(ctx context.Context, result *RetentionResult) error {
+	if err := r.db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&result.FreelistBefore); err != nil {
+		return fmt.Errorf("retention freelist: %w", err)
+	}
+	var autoVacuum int
+	if err := r.db.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		return fmt.Errorf("retention auto_vacuum: %w", err)
+	}
+	if autoVacuum == 2 && r.cfg.RetentionVacuumMaxPages > 0 && result.FreelistBefore > 0 {
+		if _, err := r.db.ExecContext(ctx, fmt.Sprintf(`PRAGMA incremental_vacuum(%d)`, r.cfg.RetentionVacuumMaxPages)); err != nil {
+			return fmt.Errorf("retention incremental vacuum: %w", err)
+		}
+		result.VacuumIncremental = true
+	}
+	var busy int
+	if err := r.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &result.CheckpointLogPages, &result.CheckpointMovedPages); err != nil {
+		return fmt.Errorf("retention wal checkpoint: %w", err)
+	}
+	result.CheckpointBusy = busy != 0
+	if err := r.db.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&result.FreelistAfter); err != nil {
+		return fmt.Errorf("retention freelist: %w", err)
+	}
+	return nil
+}
+
+func (r *Retainer) audit(runID string, cycleID string, result RetentionResult) error {
+	now := r.now()
+	archives := make([]map[string]any, 0, len(result.Archives))
+	for _, archive := range result.Archives {
+		archives = append(archives, map[string]any{"table": archive.Table, "day": archive.Day, "path": archive.Path, "rows": archive.Rows})
+	}
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            r.cfg.Mode,
+			Stage:           observability.REPORT_DAILY_SUMMARY,
+			EventType:       auditdomain.DB_RETENTION,
+			Reasons:         []reasoncodes.ReasonCode{},
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"cutoff_ms":              result.CutoffMs,
+			"rows_deleted":           result.RowsDeleted,
+			"archives":               archives,
+			"freelist_before":        result.FreelistBefore,
+			"freelist_after":         result.FreelistAfter,
+			"vacuum_incremental":     result.VacuumIncremental,
+			"checkpoint_busy":        result.CheckpointBusy,
+			"checkpoint_log_pages":   result.CheckpointLogPages,
+			"checkpoint_moved_pages": result.CheckpointMovedPages,
+			"duration_ms":            result.DurationMs,
+		},
+	}
+	if err := r.writer.WriteSync(record); err != nil {
+		return fmt.Errorf("audit retention write: %w", err)
+	}
+	return nil
+}
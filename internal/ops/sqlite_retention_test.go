@@ -0,0 +1,151 @@
+package ops
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+func TestRetainerArchivesDeletesAndKeepsChainVerifiable(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Default()
+	cfg.AuditManifestKeyPath = filepath.Join(tmp, "secrets", "manifest.key")
+	cfg.RetentionArchiveDir = filepath.Join(tmp, "archive")
+	cfg.RetentionAuditKeepDays = 7
+	cfg.RetentionDataKeepDays = 3
+	cfg.RetentionBatchRows = 2
+	dbPath := filepath.Join(tmp, "data", "audit.sqlite")
+	jsonlDir := filepath.Join(tmp, "logs")
+	old := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	writer, err := audit.NewWriter(cfg, audit.WriterOptions{DBPath: dbPath, JSONLDir: jsonlDir, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+	defer writer.Close()
+	for i, at := range []time.Time{old, old.Add(time.Minute), old.Add(2 * time.Minute), now.Add(-time.Hour)} {
+		err := writer.Write(audit.Record{
+			Event: auditdomain.AuditEvent{
+				TsMs:            at.UnixMilli(),
+				RunID:           "run_r",
+				CycleID:         "cyc_r",
+				Mode:            "LIVE",
+				Stage:           observability.STATE_UPDATE,
+				EventType:       auditdomain.STAGE_CHANGED,
+				Reasons:         []reasoncodes.ReasonCode{},
+				LocalReceivedMs: at.UnixMilli(),
+			},
+			Data: map[string]any{"n": i},
+		})
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	db, err := sqlite.Open(dbPath, cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	for id, at := range map[string]time.Time{"snap_old": now.AddDate(0, 0, -5), "snap_new": now.AddDate(0, 0, -1)} {
+		if _, err := db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES (?, 'BTCUSDT', 'hash', 0, 0, '{"bids":[]}', ?)`, id, at.UnixMilli()); err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+	}
+
+	retainer, err := NewRetainer(cfg, db, writer, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("retainer: %v", err)
+	}
+	ctx := context.Background()
+	err = sqlite.InsertOrderIntent(ctx, db, sqlite.OrderIntentRecord{
+		OrderIntentID: "oi_r", RunID: "run_r", CycleID: "cyc_r", Mode: "LIVE", DecisionID: "dec_r", Symbol: "BTCUSDT",
+		Action: "NEW_ORDER", ClientOrderID: "LS_oi_r", IntentPayloadJSON: "{}", State: "SENT_UNKNOWN",
+		CreatedAtMs: now.UnixMilli(), UpdatedAtMs: now.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("intent: %v", err)
+	}
+	skipped, err := retainer.Run(ctx, "run_r", "cyc_r")
+	if err != nil || skipped.Skipped == "" || countRows(t, db, "audit_events") != 4 {
+		t.Fatalf("expected retention to wait for in-flight intents: %+v (%v)", skipped, err)
+	}
+	if _, err := db.Exec(`UPDATE order_intents SET state = 'CONFIRMED'`); err != nil {
+		t.Fatalf("confirm intent: %v", err)
+	}
+
+	result, err := retainer.Run(ctx, "run_r", "cyc_r")
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.RowsDeleted["audit_events"] != 3 || result.RowsDeleted["snapshots"] != 1 || len(result.Archives) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if countRows(t, db, "snapshots") != 1 || countRows(t, db, "retention_archives") != 3 {
+		t.Fatalf("unexpected rows left after retention")
+	}
+	if lines := archiveLines(t, ArchivePath(cfg.RetentionArchiveDir, "audit_events", "2026-01-01")); lines != 3 {
+		t.Fatalf("expected 3 archived audit rows, got %d", lines)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	var retentionEvents int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM audit_events WHERE event_type = 'DB_RETENTION'`).Scan(&retentionEvents); err != nil || retentionEvents != 1 {
+		t.Fatalf("expected one DB_RETENTION event, got %d (%v)", retentionEvents, err)
+	}
+
+	report, err := audit.Verify(ctx, audit.VerifyOptions{DB: db, JSONLDir: jsonlDir})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !report.OK() || report.SQLiteRecords != 2 || report.JSONLRecords != 5 {
+		t.Fatalf("expected archived chain to verify: %+v", report)
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
+
+func archiveLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer file.Close()
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	scanner := bufio.NewScanner(zr)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan archive: %v", err)
+	}
+	return lines
+}
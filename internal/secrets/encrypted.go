@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// legacyEncryptedHeader marks files from the pre-age format, which are refused
+// with a hint to re-encrypt.
+const legacyEncryptedHeader = "livespot-secrets-v1"
+
+// Identity is an age X25519 identity. The identity file is the standard age
+// key file, so the age CLI can decrypt or re-encrypt the secrets file too.
+type Identity struct {
+	key *age.X25519Identity
+}
+
+func GenerateIdentity(path string) (*Identity, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("secrets identity already exists: %s", path)
+	}
+	key, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	buf := fmt.Sprintf("# public key: %s\n%s\n", key.Recipient(), key)
+	if err := os.WriteFile(path, []byte(buf), 0o600); err != nil {
+		return nil, err
+	}
+	return &Identity{key: key}, nil
+}
+
+func LoadIdentity(path string) (*Identity, error) {
+	buf, err := readSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(buf)
+	identities, err := age.ParseIdentities(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("secrets identity invalid: %s", path)
+	}
+	if len(identities) != 1 {
+		return nil, fmt.Errorf("secrets identity invalid: %s holds %d keys, want 1", path, len(identities))
+	}
+	key, ok := identities[0].(*age.X25519Identity)
+	if !ok {
+		return nil, fmt.Errorf("secrets identity invalid: %s is not an X25519 key", path)
+	}
+	return &Identity{key: key}, nil
+}
+
+func (id *Identity) Recipient() *age.X25519Recipient {
+	return id.key.Recipient()
+}
+
+func LoadEncryptedFile(path string, identityPath string) (*FileBackend, error) {
+	buf, err := readSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	id, err := LoadIdentity(identityPath)
+	if err != nil {
+		return nil, fmt.Errorf("secrets identity: %w", err)
+	}
+	plain, err := Decrypt(buf, id)
+	if err != nil {
+		return nil, fmt.Errorf("secrets %s: %w", path, err)
+	}
+	defer zero(plain)
+	values, err := ParseDotEnv(plain)
+	if err != nil {
+		return nil, fmt.Errorf("secrets %s: %w", path, err)
+	}
+	return &FileBackend{name: "encrypted:" + path, values: values}, nil
+}
+
+// Encrypt seals plaintext as an ASCII-armored age file for recipient.
+func Encrypt(plaintext []byte, recipient age.Recipient) ([]byte, error) {
+	var out bytes.Buffer
+	armored := armor.NewWriter(&out)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Decrypt opens an age file, armored or binary, with id.
+func Decrypt(buf []byte, id *Identity) ([]byte, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte(legacyEncryptedHeader)) {
+		return nil, fmt.Errorf("%s file no longer supported: re-encrypt it with secrets -keygen and -encrypt", legacyEncryptedHeader)
+	}
+	var src io.Reader = bytes.NewReader(buf)
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(buf)))
+	}
+	r, err := age.Decrypt(src, id.key)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, errors.New("encrypted file does not match the identity")
+		}
+		return nil, fmt.Errorf("encrypted file: %s", strings.TrimPrefix(err.Error(), "age: "))
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		zero(plain)
+		return nil, fmt.Errorf("encrypted body: %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type FileBackend struct {
+	name   string
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func LoadDotEnv(path string) (*FileBackend, error) {
+	buf, err := readSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(buf)
+	values, err := ParseDotEnv(buf)
+	if err != nil {
+		return nil, fmt.Errorf("secrets %s: %w", path, err)
+	}
+	return &FileBackend{name: "dotenv:" + path, values: values}, nil
+}
+
+func ParseDotEnv(buf []byte) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected NAME=value", line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if _, dup := values[name]; dup {
+			return nil, fmt.Errorf("line %d: duplicate %s", line, name)
+		}
+		values[name] = []byte(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (b *FileBackend) Name() string {
+	return b.name
+}
+
+func (b *FileBackend) Lookup(name string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	value, ok := b.values[name]
+	if !ok || len(value) == 0 {
+		return nil, false
+	}
+	return append([]byte(nil), value...), true
+}
+
+func (b *FileBackend) Scrub() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, value := range b.values {
+		zero(value)
+		delete(b.values, name)
+	}
+}
+
+func CheckFilePermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("secret file not a regular file: %s", path)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("secret file permissions too open: %s %s", path, info.Mode().Perm())
+	}
+	return nil
+}
+
+func readSecretFile(path string) ([]byte, error) {
+	if err := CheckFilePermissions(path); err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("secret file read: %w", err)
+	}
+	return buf, nil
+}
+
+func zero(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
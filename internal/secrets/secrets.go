@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+const (
+	BinanceAPIKey    = "BINANCE_API_KEY"
+	BinanceAPISecret = "BINANCE_API_SECRET"
+	OpenAIAPIKey     = "OPENAI_API_KEY"
+)
+
+// KnownNames are the secrets the bot reads; env values under these names are
+// registered for redaction even though the environment cannot be enumerated.
+var KnownNames = []string{BinanceAPIKey, BinanceAPISecret, OpenAIAPIKey}
+
+var ErrNotFound = errors.New("secret not found")
+
+type Backend interface {
+	Name() string
+	Lookup(name string) ([]byte, bool)
+	Scrub()
+}
+
+type Provider struct {
+	mu       sync.Mutex
+	backends []Backend
+}
+
+type EnvBackend struct {
+	lookup func(string) (string, bool)
+}
+
+// NewProvider registers every value the backends hold with the audit
+// redactor up front, so a secret is masked before anything reads it.
+func NewProvider(backends ...Backend) *Provider {
+	for _, backend := range backends {
+		registerBackendValues(backend)
+	}
+	return &Provider{backends: backends}
+}
+
+func registerBackendValues(backend Backend) {
+	if file, ok := backend.(*FileBackend); ok {
+		file.mu.Lock()
+		defer file.mu.Unlock()
+		for _, value := range file.values {
+			audit.RegisterSecretValue(string(value))
+		}
+		return
+	}
+	for _, name := range KnownNames {
+		if value, ok := backend.Lookup(name); ok {
+			audit.RegisterSecretValue(string(value))
+			zero(value)
+		}
+	}
+}
+
+func Load(cfg config.Config) (*Provider, error) {
+	backends := []Backend{NewEnvBackend(nil)}
+	dotEnv, err := LoadDotEnv(cfg.SecretsDotEnvPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		backends = append(backends, dotEnv)
+	}
+	encrypted, err := LoadEncryptedFile(cfg.SecretsFilePath, cfg.SecretsIdentityPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		backends = append(backends, encrypted)
+	}
+	return NewProvider(backends...), nil
+}
+
+func (p *Provider) Get(name string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, backend := range p.backends {
+		value, ok := backend.Lookup(name)
+		if !ok {
+			continue
+		}
+		return string(value), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+func (p *Provider) Getenv(name string) string {
+	value, err := p.Get(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func (p *Provider) Sources() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, 0, len(p.backends))
+	for _, backend := range p.backends {
+		names = append(names, backend.Name())
+	}
+	return names
+}
+
+func (p *Provider) Scrub() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, backend := range p.backends {
+		backend.Scrub()
+	}
+}
+
+func NewEnvBackend(lookup func(string) (string, bool)) *EnvBackend {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	return &EnvBackend{lookup: lookup}
+}
+
+func (b *EnvBackend) Name() string {
+	return "env"
+}
+
+func (b *EnvBackend) Lookup(name string) ([]byte, bool) {
+	value, ok := b.lookup(name)
+	if !ok || value == "" {
+		return nil, false
+	}
+	return []byte(value), true
+}
+
+func (b *EnvBackend) Scrub() {}
@@ -0,0 +1,144 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+)
+
+func TestLoadLayersBackendsAndRegistersValues(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := config.Default()
+	cfg.SecretsDotEnvPath = filepath.Join(tmp, ".env")
+	cfg.SecretsFilePath = filepath.Join(tmp, "secrets", "secrets.enc")
+	cfg.SecretsIdentityPath = filepath.Join(tmp, "secrets", "secrets.key")
+
+	provider, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("load without files: %v", err)
+	}
+	if len(provider.Sources()) != 1 {
+		t.Fatalf("expected env only, got %v", provider.Sources())
+	}
+
+	dotEnv := "# local\nexport BINANCE_API_KEY=dotenv-key-0001\nOPENAI_API_KEY=\"sk-dotenv-000000000000\"\nWEBHOOK_TOKEN=dotenv-webhook-0001\n"
+	if err := os.WriteFile(cfg.SecretsDotEnvPath, []byte(dotEnv), 0o600); err != nil {
+		t.Fatalf("write .env: %v", err)
+	}
+	id, err := GenerateIdentity(cfg.SecretsIdentityPath)
+	if err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+	if _, err := GenerateIdentity(cfg.SecretsIdentityPath); err == nil {
+		t.Fatalf("expected existing identity to be kept")
+	}
+	sealed, err := Encrypt([]byte("BINANCE_API_KEY=encrypted-key-01\nBINANCE_API_SECRET=encrypted-secret-01\n"), id.Recipient())
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if strings.Contains(string(sealed), "encrypted-secret-01") {
+		t.Fatalf("plaintext leaked into the encrypted file")
+	}
+	if err := os.WriteFile(cfg.SecretsFilePath, sealed, 0o600); err != nil {
+		t.Fatalf("write encrypted: %v", err)
+	}
+
+	provider, err = Load(cfg)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for _, leaked := range []string{"encrypted-secret-01", "dotenv-webhook-0001"} {
+		payload := []byte(`{"note":"leaked ` + leaked + ` here"}`)
+		if _, err := audit.RedactAndTruncateJSON(payload, 1024, audit.DefaultRedactionPolicy()); err == nil {
+			t.Fatalf("expected redaction to reject %s before any Get", leaked)
+		}
+	}
+	env := map[string]string{OpenAIAPIKey: "sk-env-0000000000000000"}
+	provider.backends[0] = NewEnvBackend(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	cases := map[string]string{
+		OpenAIAPIKey:     "sk-env-0000000000000000",
+		BinanceAPIKey:    "dotenv-key-0001",
+		BinanceAPISecret: "encrypted-secret-01",
+	}
+	for name, want := range cases {
+		got, err := provider.Get(name)
+		if err != nil || got != want {
+			t.Fatalf("%s: got %q %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := provider.Get("MISSING_SECRET"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	provider.Scrub()
+	if _, err := provider.Get(BinanceAPISecret); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected scrubbed file secrets, got %v", err)
+	}
+	if got := provider.Getenv(OpenAIAPIKey); got != "sk-env-0000000000000000" {
+		t.Fatalf("expected env to survive scrub, got %q", got)
+	}
+}
+
+func TestSecretFilesRefusedWhenReadableByOthers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits not enforced on windows")
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("OPENAI_API_KEY=sk-0000000000000000\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadDotEnv(path); err == nil || !strings.Contains(err.Error(), "permissions too open") {
+		t.Fatalf("expected world-readable .env to be refused, got %v", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if _, err := LoadDotEnv(path); err != nil {
+		t.Fatalf("expected 0600 .env to load, got %v", err)
+	}
+}
+
+func TestDecryptRejectsWrongIdentity(t *testing.T) {
+	tmp := t.TempDir()
+	owner, err := GenerateIdentity(filepath.Join(tmp, "owner.key"))
+	if err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	other, err := GenerateIdentity(filepath.Join(tmp, "other.key"))
+	if err != nil {
+		t.Fatalf("other: %v", err)
+	}
+	sealed, err := Encrypt([]byte("OPENAI_API_KEY=sk-0000000000000000\n"), owner.Recipient())
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := Decrypt(sealed, other); err == nil {
+		t.Fatalf("expected wrong identity to fail")
+	}
+	reloaded, err := LoadIdentity(filepath.Join(tmp, "owner.key"))
+	if err != nil {
+		t.Fatalf("reload identity: %v", err)
+	}
+	plain, err := Decrypt(sealed, reloaded)
+	if err != nil || string(plain) != "OPENAI_API_KEY=sk-0000000000000000\n" {
+		t.Fatalf("round trip failed: %q %v", plain, err)
+	}
+	if !strings.HasPrefix(string(sealed), "-----BEGIN AGE ENCRYPTED FILE-----") {
+		t.Fatalf("expected an armored age file, got %q", sealed)
+	}
+	if _, err := Decrypt([]byte("livespot-secrets-v1\nAAAA\n"), reloaded); err == nil || !strings.Contains(err.Error(), "re-encrypt") {
+		t.Fatalf("expected legacy format refused, got %v", err)
+	}
+	if _, err := ParseDotEnv([]byte("NOT A PAIR\n")); err == nil {
+		t.Fatalf("expected malformed line to fail")
+	}
+}
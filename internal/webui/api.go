@@ -3,9 +3,11 @@ package webui
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
@@ -17,29 +19,31 @@ import (
 const (
 	defaultOrdersLimit = 100
 	maxOrdersLimit     = 500
+	decisionAPIPrefix  = "/api/decision/"
 )
 
 type DashboardSnapshot struct {
-	TsMs              int64               `json:"ts_ms"`
-	Mode              string              `json:"mode"`
-	SysMode           string              `json:"sys_mode"`
-	SysModeSinceTsMs  int64               `json:"sys_mode_since_ts_ms"`
-	ActiveReasonCodes []string            `json:"active_reason_codes"`
-	UptimeS           int64               `json:"uptime_s"`
-	RunID             string              `json:"run_id"`
-	CycleID           string              `json:"cycle_id"`
-	DecisionID        string              `json:"decision_id"`
-	Stage             string              `json:"stage"`
-	StageSinceTsMs    int64               `json:"stage_since_ts_ms"`
-	AlertsActive      []AlertAggregateRow `json:"alerts_active"`
-	StageHistory      []StageHistoryRow   `json:"stage_history"`
-	Health            HealthSnapshot      `json:"health"`
-	Intents           IntentsSnapshot     `json:"intents"`
-	Reconcile         ReconcileSnapshot   `json:"reconcile"`
-	Market            MarketSnapshot      `json:"market"`
-	Risk              RiskSnapshot        `json:"risk"`
-	AIGate            AIGateSnapshot      `json:"aigate"`
-	TopK              TopKSnapshot        `json:"topk"`
+	TsMs              int64                 `json:"ts_ms"`
+	Mode              string                `json:"mode"`
+	SysMode           string                `json:"sys_mode"`
+	SysModeSinceTsMs  int64                 `json:"sys_mode_since_ts_ms"`
+	ActiveReasonCodes []string              `json:"active_reason_codes"`
+	UptimeS           int64                 `json:"uptime_s"`
+	RunID             string                `json:"run_id"`
+	CycleID           string                `json:"cycle_id"`
+	DecisionID        string                `json:"decision_id"`
+	Stage             string                `json:"stage"`
+	StageSinceTsMs    int64                 `json:"stage_since_ts_ms"`
+	AlertsActive      []AlertAggregateRow   `json:"alerts_active"`
+	StageHistory      []StageHistoryRow     `json:"stage_history"`
+	Health            HealthSnapshot        `json:"health"`
+	Intents           IntentsSnapshot       `json:"intents"`
+	Reconcile         ReconcileSnapshot     `json:"reconcile"`
+	Market            MarketSnapshot        `json:"market"`
+	Risk              RiskSnapshot          `json:"risk"`
+	AIGate            AIGateSnapshot        `json:"aigate"`
+	TopK              TopKSnapshot          `json:"topk"`
+	LiveChecklist     LiveChecklistSnapshot `json:"live_checklist"`
 }
 
 type AlertAggregateRow struct {
@@ -125,6 +129,8 @@ type MarketSymbolRow struct {
 	SpreadP90Bps       int32            `json:"spread_p90_bps"`
 	DeltaSpreadP90_10s int32            `json:"delta_spread_bps_p90_10s"`
 	ImbalanceX10000    int32            `json:"imbalance_x10000"`
+	ATR14_5mBps        int32            `json:"atr14_5m_bps"`
+	ATR14_15mBps       int32            `json:"atr14_15m_bps"`
 	EdgeBpsExpected    int32            `json:"edge_bps_expected"`
 	CostsBps           map[string]int32 `json:"costs_bps"`
 	BlockedReasonCode  string           `json:"blocked_reason_code"`
@@ -157,6 +163,14 @@ type AIGateSnapshot struct {
 	ErrorKind     string   `json:"error_kind"`
 }
 
+type LiveChecklistSnapshot struct {
+	Enabled bool              `json:"enabled"`
+	TsMs    int64             `json:"ts_ms"`
+	OK      bool              `json:"ok"`
+	Missing []string          `json:"missing"`
+	Errors  map[string]string `json:"errors"`
+}
+
 type TopKSnapshot struct {
 	CycleID               string           `json:"cycle_id"`
 	Items                 []TopKItemRow    `json:"items"`
@@ -165,10 +179,15 @@ type TopKSnapshot struct {
 }
 
 type TopKItemRow struct {
-	Symbol            string             `json:"symbol"`
-	ScoreX10000       int32              `json:"score_x10000"`
-	FeaturesTop       []map[string]int32 `json:"features_top"`
-	ChurnGuardApplied bool               `json:"churn_guard_applied"`
+	Symbol            string            `json:"symbol"`
+	ScoreX10000       int32             `json:"score_x10000"`
+	FeaturesTop       []FeatureValueRow `json:"features_top"`
+	ChurnGuardApplied bool              `json:"churn_guard_applied"`
+}
+
+type FeatureValueRow struct {
+	Name        string `json:"name"`
+	ValueX10000 int32  `json:"value_x10000"`
 }
 
 func (s *Server) registerRoutes() {
@@ -176,6 +195,8 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/dashboard", s.wrap(s.dashboardAPIHandler))
 	s.mux.HandleFunc("/api/orders", s.wrap(s.ordersHandler))
 	s.mux.HandleFunc("/api/stream", s.wrap(s.streamHandler))
+	s.mux.HandleFunc("/decision", s.wrap(s.decisionPageHandler))
+	s.mux.HandleFunc(decisionAPIPrefix, s.wrap(s.decisionAPIHandler))
 	s.mux.HandleFunc("/", s.wrap(s.notFoundHandler))
 }
 
@@ -197,10 +218,10 @@ func (s *Server) wrap(fn http.HandlerFunc) http.HandlerFunc {
 
 func isAllowedPath(path string) bool {
 	switch path {
-	case "/dashboard", "/api/dashboard", "/api/orders", "/api/stream", "/":
+	case "/dashboard", "/api/dashboard", "/api/orders", "/api/stream", "/decision", "/metrics", reportsAPIPath, "/":
 		return true
 	default:
-		return false
+		return strings.HasPrefix(path, decisionAPIPrefix) || strings.HasPrefix(path, reportsAPIPrefix)
 	}
 }
 
@@ -244,6 +265,35 @@ func (s *Server) ordersHandler(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(rows)
 }
 
+func (s *Server) decisionPageHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(decisionHTML))
+}
+
+func (s *Server) decisionAPIHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, decisionAPIPrefix)
+	if !ValidDecisionID(id) {
+		s.writeAudit(r, http.StatusBadRequest)
+		http.Error(w, "invalid decision id", http.StatusBadRequest)
+		return
+	}
+	detail, err := QueryDecision(r.Context(), s.db, id)
+	if errors.Is(err, ErrDecisionNotFound) {
+		s.writeAudit(r, http.StatusNotFound)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.writeAudit(r, http.StatusInternalServerError)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(detail)
+}
+
 func (s *Server) streamHandler(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -298,6 +348,10 @@ func (s *Server) buildDashboardSnapshot(ctx context.Context) (DashboardSnapshot,
 	intents, _ := QueryIntents(ctx, s.db, s.cfg.WebuiIntentsRecentLimit)
 	reconcile, _ := QueryReconcile(ctx, s.db, s.cfg.WebuiReconcileDiffsRecentLimit)
 	aigate, _ := QueryAIGate(ctx, s.db)
+	market, _ := QueryMarket(ctx, s.db, s.now().UnixMilli(), s.cfg.WebuiMarketSymbolsLimit)
+	risk, _ := QueryRisk(ctx, s.db, s.cfg, s.now())
+	topK, _ := QueryTopK(ctx, s.db)
+	liveChecklist, _ := QueryLiveChecklist(ctx, s.db)
 	sysMode, sysSince, reasons := DeriveSysMode(alerts)
 
 	health := BuildHealthSnapshot(s.cfg, s.start, s.writer)
@@ -320,31 +374,16 @@ func (s *Server) buildDashboardSnapshot(ctx context.Context) (DashboardSnapshot,
 		Health:            health,
 		Intents:           intents,
 		Reconcile:         reconcile,
-		Market:            MarketSnapshot{Symbols: []MarketSymbolRow{}},
-		Risk:              RiskSnapshot{ExposureTotalQuote: "0", ExposureBySymbolQuote: []map[string]string{}, LockedQuote: "0", FreeQuote: "0", DailyPnLRealizedQuote: "0", DailyPnLUnrealizedQuote: "0", DailyLossLimitRemainingQuote: "0", DrawdownQuote: "0", TradesToday: 0, Cooldowns: []map[string]any{}},
+		Market:            market,
+		Risk:              risk,
 		AIGate:            aigate,
-		TopK:              TopKSnapshot{Items: []TopKItemRow{}, PairsOverLimit: []map[string]any{}},
+		TopK:              topK,
+		LiveChecklist:     liveChecklist,
 	}, nil
 }
 
 func (s *Server) writeAudit(r *http.Request, status int) {
-	ctx := r.Context()
-	runID := "unknown"
-	cycleID := "unknown"
-	stage := observability.STATE_UPDATE
-	if s.db != nil {
-		if latest, err := QueryLatestStage(ctx, s.db); err == nil {
-			if latest.RunID != "" {
-				runID = latest.RunID
-			}
-			if latest.CycleID != "" {
-				cycleID = latest.CycleID
-			}
-			if latest.Stage != "" {
-				stage = observability.StageName(latest.Stage)
-			}
-		}
-	}
+	runID, cycleID, stage := s.auditContext(r)
 	record := audit.Record{
 		Event: auditdomain.AuditEvent{
 			TsMs:            s.now().UnixMilli(),
@@ -371,6 +410,27 @@ func (s *Server) writeAudit(r *http.Request, status int) {
 	}
 }
 
+func (s *Server) auditContext(r *http.Request) (string, string, observability.StageName) {
+	ctx := r.Context()
+	runID := "unknown"
+	cycleID := "unknown"
+	stage := observability.STATE_UPDATE
+	if s.db != nil {
+		if latest, err := QueryLatestStage(ctx, s.db); err == nil {
+			if latest.RunID != "" {
+				runID = latest.RunID
+			}
+			if latest.CycleID != "" {
+				cycleID = latest.CycleID
+			}
+			if latest.Stage != "" {
+				stage = observability.StageName(latest.Stage)
+			}
+		}
+	}
+	return runID, cycleID, stage
+}
+
 const dashboardHTML = `<!doctype html>
 <html lang="en">
 <head>
@@ -448,6 +508,9 @@ const dashboardHTML = `<!doctype html>
     .list li:last-child {
       border-bottom: none;
     }
+    .list a {
+      color: var(--muted);
+    }
   </style>
 </head>
 <body>
@@ -461,6 +524,11 @@ const dashboardHTML = `<!doctype html>
       <div class="value" id="stage">BOOT</div>
       <div class="muted" id="cycle">cycle</div>
     </div>
+    <div class="card">
+      <h3>Live checklist</h3>
+      <div class="value" id="checklist">n/a</div>
+      <ul class="list" id="checklistMissing"></ul>
+    </div>
     <div class="card">
       <h3>Alerts</h3>
       <ul class="list" id="alerts"></ul>
@@ -474,6 +542,20 @@ const dashboardHTML = `<!doctype html>
       <div class="value" id="reconcile">OK</div>
       <div class="muted" id="drift">drift</div>
     </div>
+    <div class="card">
+      <h3>Market</h3>
+      <ul class="list" id="market"></ul>
+    </div>
+    <div class="card">
+      <h3>Risk</h3>
+      <div class="value" id="riskPnl">0.00</div>
+      <div class="muted" id="riskHeadroom">headroom</div>
+      <ul class="list" id="risk"></ul>
+    </div>
+    <div class="card">
+      <h3>TopK</h3>
+      <ul class="list" id="topk"></ul>
+    </div>
   </main>
   <script>
     const alertsEl = document.getElementById('alerts');
@@ -483,6 +565,22 @@ const dashboardHTML = `<!doctype html>
     const cycleEl = document.getElementById('cycle');
     const reconcileEl = document.getElementById('reconcile');
     const driftEl = document.getElementById('drift');
+    const marketEl = document.getElementById('market');
+    const riskPnlEl = document.getElementById('riskPnl');
+    const riskHeadroomEl = document.getElementById('riskHeadroom');
+    const riskEl = document.getElementById('risk');
+    const topkEl = document.getElementById('topk');
+    const checklistEl = document.getElementById('checklist');
+    const checklistMissingEl = document.getElementById('checklistMissing');
+
+    function fill(el, lines) {
+      el.innerHTML = '';
+      lines.forEach(text => {
+        const li = document.createElement('li');
+        li.textContent = text;
+        el.appendChild(li);
+      });
+    }
 
     function render(snapshot) {
       sysModeEl.textContent = snapshot.sys_mode || 'NORMAL';
@@ -490,6 +588,10 @@ const dashboardHTML = `<!doctype html>
       cycleEl.textContent = snapshot.cycle_id || '';
       reconcileEl.textContent = snapshot.reconcile.drift_label || 'OK';
       driftEl.textContent = snapshot.reconcile.drift_score_x10000 || 0;
+      const checklist = snapshot.live_checklist || {};
+      checklistEl.textContent = !checklist.enabled ? 'n/a' : (checklist.ok ? 'OK' : 'BLOCKED');
+      fill(checklistMissingEl, (checklist.missing || []).map(m =>
+        m + ((checklist.errors || {})[m] ? ': ' + checklist.errors[m] : '')));
       alertsEl.innerHTML = '';
       (snapshot.alerts_active || []).forEach(a => {
         const li = document.createElement('li');
@@ -499,9 +601,22 @@ const dashboardHTML = `<!doctype html>
       intentsEl.innerHTML = '';
       (snapshot.intents.recent || []).forEach(i => {
         const li = document.createElement('li');
-        li.textContent = i.symbol + ' ' + i.state;
+        const a = document.createElement('a');
+        a.href = '/decision?id=' + encodeURIComponent(i.decision_id);
+        a.textContent = i.symbol + ' ' + i.state;
+        li.appendChild(a);
         intentsEl.appendChild(li);
       });
+      fill(marketEl, (snapshot.market.symbols || []).map(m =>
+        m.symbol + ' ' + m.regime + ' spread ' + m.spread_current_bps + 'bps atr ' + m.atr14_5m_bps + 'bps' +
+        (m.blocked_reason_code ? ' ' + m.blocked_reason_code : '')));
+      const risk = snapshot.risk;
+      riskPnlEl.textContent = risk.daily_pnl_realized_quote + ' / ' + risk.daily_pnl_unrealized_quote;
+      riskHeadroomEl.textContent = 'loss headroom ' + risk.daily_loss_limit_remaining_quote + ' trades ' + risk.trades_today;
+      fill(riskEl, (risk.exposure_by_symbol_quote || []).map(e => e.symbol + ' ' + e.quote)
+        .concat((risk.cooldowns || []).map(c => c.symbol + ' cooldown')));
+      fill(topkEl, (snapshot.topk.items || []).map(i => i.symbol + ' ' + i.score_x10000)
+        .concat((snapshot.topk.pairs_over_limit || []).map(p => p.a + '/' + p.b + ' corr ' + p.corr_x10000)));
     }
 
     function connect() {
@@ -527,3 +642,118 @@ const dashboardHTML = `<!doctype html>
   </script>
 </body>
 </html>`
+
+const decisionHTML = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>LiveSpot Decision</title>
+  <style>
+    body {
+      margin: 0;
+      font-family: "IBM Plex Sans", Arial, sans-serif;
+      background: #0f1c1f;
+      color: #e8f1f2;
+    }
+    header {
+      padding: 16px 24px;
+      border-bottom: 1px solid #213235;
+    }
+    header a {
+      color: #e3b23c;
+    }
+    main {
+      padding: 16px 24px;
+      display: grid;
+      gap: 16px;
+      grid-template-columns: repeat(auto-fit, minmax(280px, 1fr));
+    }
+    .card {
+      background: #16262a;
+      border: 1px solid #213235;
+      border-radius: 12px;
+      padding: 12px;
+    }
+    .card h3 {
+      margin: 0 0 8px 0;
+      font-size: 14px;
+      text-transform: uppercase;
+      letter-spacing: 1px;
+      color: #8aa6a3;
+    }
+    .wide {
+      grid-column: 1 / -1;
+    }
+    .list {
+      list-style: none;
+      padding: 0;
+      margin: 0;
+      font-size: 13px;
+      color: #8aa6a3;
+    }
+    .list li {
+      padding: 4px 0;
+      border-bottom: 1px solid #1f2f32;
+    }
+    pre {
+      white-space: pre-wrap;
+      font-size: 12px;
+      color: #8aa6a3;
+    }
+  </style>
+</head>
+<body>
+  <header>
+    <a href="/dashboard">dashboard</a> / <span id="title">decision</span>
+  </header>
+  <main>
+    <div class="card"><h3>Snapshot</h3><ul class="list" id="snapshot"></ul></div>
+    <div class="card"><h3>Edge</h3><ul class="list" id="edge"></ul></div>
+    <div class="card"><h3>AI Gate</h3><ul class="list" id="gate"></ul><pre id="patch"></pre></div>
+    <div class="card"><h3>Risk</h3><ul class="list" id="risk"></ul></div>
+    <div class="card"><h3>Intents</h3><ul class="list" id="intents"></ul></div>
+    <div class="card"><h3>Fills</h3><ul class="list" id="fills"></ul></div>
+    <div class="card wide"><h3>Timeline</h3><ul class="list" id="timeline"></ul></div>
+  </main>
+  <script>
+    function fill(id, lines) {
+      const el = document.getElementById(id);
+      el.innerHTML = '';
+      lines.forEach(text => {
+        const li = document.createElement('li');
+        li.textContent = text;
+        el.appendChild(li);
+      });
+    }
+
+    function render(d) {
+      document.getElementById('title').textContent = d.decision_id + ' ' + d.symbol + ' ' + d.cycle_id;
+      const s = d.snapshot;
+      fill('snapshot', s ? [d.snapshot_id, s.regime + ' mid ' + s.mid_price, 'bid ' + s.best_bid + ' ask ' + s.best_ask,
+        'spread ' + s.spread_current_bps + 'bps atr ' + s.atr14_5m_bps + 'bps', 'hash ' + s.snapshot_hash] : [d.snapshot_id || 'no snapshot']);
+      const e = d.edge;
+      const c = e.costs;
+      fill('edge', ['score ' + e.edge_score_x10000, 'gross ' + e.gross_edge_bps + 'bps', 'costs ' + e.cost_total_bps + 'bps',
+        'net ' + e.edge_bps_expected + 'bps', 'fees ' + c.maker_fee_bps + '+' + c.taker_fee_bps + 'bps',
+        'slippage ' + c.slippage_est_entry_maker_bps + '+' + c.slippage_est_exit_taker_bps + 'bps',
+        'spread ' + c.spread_current_bps + '+' + c.delta_spread_bps_p90_10s + 'bps', 'tp ' + e.tp_price + ' sl ' + e.sl_price]);
+      const g = d.aigate;
+      fill('gate', g ? [g.verdict + ' ' + (g.reason_codes || []).join(','), g.model + ' ' + g.latency_ms + 'ms' + (g.cache_hit ? ' cache' : ''),
+        'prompt ' + g.prompt_version, 'input ' + g.input_hash, 'modify_applied ' + g.modify_applied] : ['not called']);
+      document.getElementById('patch').textContent = g && g.patch ? JSON.stringify(g.patch, null, 2) : '';
+      fill('risk', d.risk_reasons || []);
+      fill('intents', (d.intents || []).map(i => i.order_intent_id + ' ' + i.action + ' ' + i.side + ' ' + i.qty + ' @ ' + i.price + ' ' + i.state));
+      fill('fills', (d.fills || []).map(f => f.side + ' ' + f.qty + ' @ ' + f.price + ' fee ' + f.commission + ' ' + f.commission_asset + ' ' + new Date(f.ts_ms).toISOString()));
+      fill('timeline', (d.timeline || []).map(t => new Date(t.ts_ms).toISOString() + ' ' + t.source + ' ' + t.stage + ' ' + t.event_type +
+        (t.order_intent_id ? ' ' + t.order_intent_id : '') + ' ' + (t.reason_codes || []).join(',') + ' ' + t.summary));
+    }
+
+    const id = new URLSearchParams(window.location.search).get('id') || '';
+    fetch('/api/decision/' + encodeURIComponent(id))
+      .then(r => r.ok ? r.json() : Promise.reject(r.status))
+      .then(render)
+      .catch(status => { document.getElementById('title').textContent = 'decision ' + id + ' unavailable (' + status + ')'; });
+  </script>
+</body>
+</html>`
@@ -0,0 +1,325 @@
+package webui
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+)
+
+const (
+	controlTokenMinLen  = 32
+	controlCSRFHeader   = "X-CSRF-Token"
+	controlMaxBodyBytes = 4096
+	flattenAllSymbols   = "*"
+)
+
+type Controller interface {
+	OperatorPause(operator string) error
+	OperatorResume(operator string) error
+	OperatorExit(operator string) error
+	OperatorFlatten(operator string, symbol string) error
+	OperatorQuarantine(operator string, symbol string, untilMs int64) error
+	OperatorRelease(operator string, symbol string) error
+}
+
+type ControlRequest struct {
+	Symbol string `json:"symbol"`
+	All    bool   `json:"all"`
+	TTLS   int64  `json:"ttl_s"`
+}
+
+type ControlResponse struct {
+	Action   string `json:"action"`
+	Operator string `json:"operator"`
+	Symbol   string `json:"symbol,omitempty"`
+	Status   string `json:"status"`
+}
+
+type controlHandlerFunc func(w http.ResponseWriter, r *http.Request, operator string)
+
+var controlActions = []string{"pause", "resume", "exit", "flatten", "quarantine", "release"}
+
+func LoadOperatorTokens(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("operator token file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("operator token file permissions too open: %s", info.Mode().Perm())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("operator token file: %w", err)
+	}
+	defer f.Close()
+	tokens := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("operator token file line %d: expected \"<operator> <token>\"", line)
+		}
+		if len(fields[1]) < controlTokenMinLen {
+			return nil, fmt.Errorf("operator token file line %d: token shorter than %d", line, controlTokenMinLen)
+		}
+		if _, dup := tokens[fields[1]]; dup {
+			return nil, fmt.Errorf("operator token file line %d: duplicate token", line)
+		}
+		tokens[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("operator token file: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("operator token file has no operators")
+	}
+	return tokens, nil
+}
+
+func (s *Server) EnableControl(controller Controller) error {
+	if controller == nil {
+		return fmt.Errorf("webui controller missing")
+	}
+	tokens, err := LoadOperatorTokens(s.cfg.WebuiControlTokenPath)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("csrf key: %w", err)
+	}
+	s.controller = controller
+	s.operators = tokens
+	s.csrfKey = key
+	s.mux.HandleFunc("/api/control/csrf", s.wrapControl(http.MethodGet, s.csrfHandler))
+	for _, action := range controlActions {
+		s.mux.HandleFunc("/api/control/"+action, s.wrapControl(http.MethodPost, s.controlHandler(action)))
+	}
+	return nil
+}
+
+func (s *Server) wrapControl(method string, fn controlHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			s.writeAudit(r, http.StatusMethodNotAllowed)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.sameOrigin(r) {
+			s.writeAudit(r, http.StatusForbidden)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		operator, ok := s.authenticate(r)
+		if !ok {
+			s.writeAudit(r, http.StatusUnauthorized)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if method != http.MethodGet {
+			expected := s.csrfToken(operator)
+			got := r.Header.Get(controlCSRFHeader)
+			if got == "" || !hmac.Equal([]byte(got), []byte(expected)) {
+				s.writeAudit(r, http.StatusForbidden)
+				http.Error(w, "csrf token invalid", http.StatusForbidden)
+				return
+			}
+		}
+		fn(w, r, operator)
+	}
+}
+
+func (s *Server) csrfHandler(w http.ResponseWriter, r *http.Request, operator string) {
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]string{"csrf_token": s.csrfToken(operator)})
+}
+
+func (s *Server) controlHandler(action string) controlHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, operator string) {
+		var req ControlRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, controlMaxBodyBytes)).Decode(&req); err != nil {
+				s.rejectControl(w, r, operator, action, "", http.StatusBadRequest, "invalid body")
+				return
+			}
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+		if action == "flatten" && req.All {
+			symbol = flattenAllSymbols
+		}
+		untilMs := int64(0)
+		switch action {
+		case "flatten":
+			if symbol != flattenAllSymbols && !validControlSymbol(symbol) {
+				s.rejectControl(w, r, operator, action, symbol, http.StatusBadRequest, "invalid symbol")
+				return
+			}
+		case "quarantine":
+			if !validControlSymbol(symbol) || req.TTLS < 0 {
+				s.rejectControl(w, r, operator, action, symbol, http.StatusBadRequest, "invalid symbol or ttl")
+				return
+			}
+			if req.TTLS > 0 {
+				untilMs = s.now().UnixMilli() + req.TTLS*1000
+			}
+		case "release":
+			if !validControlSymbol(symbol) {
+				s.rejectControl(w, r, operator, action, symbol, http.StatusBadRequest, "invalid symbol")
+				return
+			}
+		}
+		// The action is audited before it is applied: an operator action that cannot be
+		// committed to the audit trail is not carried out.
+		if err := s.writeOperatorAudit(r, operator, action, symbol, http.StatusOK, "requested"); err != nil {
+			s.writeAudit(r, http.StatusServiceUnavailable)
+			http.Error(w, "operator audit write failed", http.StatusServiceUnavailable)
+			return
+		}
+		var err error
+		switch action {
+		case "pause":
+			err = s.controller.OperatorPause(operator)
+		case "resume":
+			err = s.controller.OperatorResume(operator)
+		case "exit":
+			err = s.controller.OperatorExit(operator)
+		case "flatten":
+			err = s.controller.OperatorFlatten(operator, symbol)
+		case "quarantine":
+			err = s.controller.OperatorQuarantine(operator, symbol, untilMs)
+		case "release":
+			err = s.controller.OperatorRelease(operator, symbol)
+		}
+		if err != nil {
+			s.rejectControl(w, r, operator, action, symbol, http.StatusConflict, err.Error())
+			return
+		}
+		status, result := http.StatusOK, "accepted"
+		if action == "flatten" {
+			// Flatten only holds DEGRADE and audits the request; positions are not closed by
+			// the engine, so the caller gets a non-2xx advisory answer.
+			status, result = http.StatusNotImplemented, "advisory: close positions manually"
+		}
+		s.writeAudit(r, status)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(ControlResponse{Action: action, Operator: operator, Symbol: symbol, Status: result})
+	}
+}
+
+func (s *Server) rejectControl(w http.ResponseWriter, r *http.Request, operator string, action string, symbol string, status int, detail string) {
+	s.writeAudit(r, status)
+	if err := s.writeOperatorAudit(r, operator, action, symbol, status, detail); err != nil {
+		detail += "; operator audit write failed"
+	}
+	http.Error(w, detail, status)
+}
+
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	for candidate, operator := range s.operators {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) csrfToken(operator string) string {
+	mac := hmac.New(sha256.New, s.csrfKey)
+	mac.Write([]byte(operator))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	return origin == fmt.Sprintf("http://127.0.0.1:%d", s.cfg.WebuiPort) || origin == fmt.Sprintf("http://localhost:%d", s.cfg.WebuiPort)
+}
+
+func (s *Server) writeOperatorAudit(r *http.Request, operator string, action string, symbol string, status int, detail string) error {
+	runID, cycleID, stage := s.auditContext(r)
+	reasons := []reasoncodes.ReasonCode{}
+	switch action {
+	case "pause":
+		reasons = append(reasons, reasoncodes.OPERATOR_PAUSE)
+	case "exit":
+		reasons = append(reasons, reasoncodes.ENTER_EXIT)
+	case "flatten":
+		reasons = append(reasons, reasoncodes.OPERATOR_FLATTEN)
+	case "quarantine":
+		reasons = append(reasons, reasoncodes.OPERATOR_QUARANTINE)
+	}
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            s.now().UnixMilli(),
+			RunID:           runID,
+			CycleID:         cycleID,
+			Mode:            s.cfg.Mode,
+			Stage:           stage,
+			EventType:       auditdomain.OPERATOR_ACTION,
+			Reasons:         reasons,
+			SnapshotID:      "",
+			DecisionID:      "",
+			OrderIntentID:   "",
+			ExchangeTimeMs:  0,
+			LocalReceivedMs: s.now().UnixMilli(),
+		},
+		Data: map[string]any{
+			"operator": operator,
+			"action":   action,
+			"symbol":   symbol,
+			"status":   status,
+			"detail":   detail,
+		},
+	}
+	if s.writer == nil {
+		return fmt.Errorf("operator audit writer missing")
+	}
+	if err := s.writer.WriteSync(record); err != nil {
+		return fmt.Errorf("operator audit write: %w", err)
+	}
+	return nil
+}
+
+func validControlSymbol(symbol string) bool {
+	if len(symbol) < 5 || len(symbol) > 20 {
+		return false
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testOperatorToken = "0123456789abcdef0123456789abcdef"
+
+type fakeController struct {
+	calls []string
+}
+
+func (f *fakeController) OperatorPause(operator string) error {
+	f.calls = append(f.calls, operator+":pause")
+	return nil
+}
+
+func (f *fakeController) OperatorResume(operator string) error {
+	f.calls = append(f.calls, operator+":resume")
+	return nil
+}
+
+func (f *fakeController) OperatorExit(operator string) error {
+	f.calls = append(f.calls, operator+":exit")
+	return nil
+}
+
+func (f *fakeController) OperatorFlatten(operator string, symbol string) error {
+	f.calls = append(f.calls, operator+":flatten:"+symbol)
+	return nil
+}
+
+func (f *fakeController) OperatorQuarantine(operator string, symbol string, untilMs int64) error {
+	f.calls = append(f.calls, operator+":quarantine:"+symbol)
+	return nil
+}
+
+func (f *fakeController) OperatorRelease(operator string, symbol string) error {
+	f.calls = append(f.calls, operator+":release:"+symbol)
+	return nil
+}
+
+func TestControlRequiresTokenAndCSRF(t *testing.T) {
+	server := newTestServer(t)
+	server.cfg.WebuiControlTokenPath = writeTokenFile(t, "alice "+testOperatorToken+"\n", 0o600)
+	controller := &fakeController{}
+	if err := server.EnableControl(controller); err != nil {
+		t.Fatalf("enable control: %v", err)
+	}
+	handler := server.Handler()
+
+	rr := doControl(handler, http.MethodPost, "/api/control/pause", "", "", "", nil)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rr.Code)
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/pause", testOperatorToken, "", "", nil)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without csrf, got %d", rr.Code)
+	}
+
+	rr = doControl(handler, http.MethodGet, "/api/control/csrf", testOperatorToken, "", "", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for csrf, got %d", rr.Code)
+	}
+	var csrf map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &csrf); err != nil {
+		t.Fatalf("csrf json: %v", err)
+	}
+	token := csrf["csrf_token"]
+
+	rr = doControl(handler, http.MethodPost, "/api/control/pause", testOperatorToken, token, "http://evil.example", nil)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 cross origin, got %d", rr.Code)
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/pause", testOperatorToken, token, "", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for pause, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/flatten", testOperatorToken, token, "", map[string]any{"all": true})
+	if rr.Code != http.StatusNotImplemented || !strings.Contains(rr.Body.String(), "advisory") {
+		t.Fatalf("expected 501 advisory for flatten all, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/quarantine", testOperatorToken, token, "", map[string]any{"symbol": "btcusdt", "ttl_s": 600})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for quarantine, got %d", rr.Code)
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/release", testOperatorToken, token, "", map[string]any{"symbol": "bad symbol"})
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid symbol, got %d", rr.Code)
+	}
+	rr = doControl(handler, http.MethodGet, "/api/control/pause", testOperatorToken, token, "", nil)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET pause, got %d", rr.Code)
+	}
+
+	want := []string{"alice:pause", "alice:flatten:*", "alice:quarantine:BTCUSDT"}
+	if strings.Join(controller.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected controller calls: %v", controller.calls)
+	}
+}
+
+func TestControlFailsWhenOperatorAuditFails(t *testing.T) {
+	server := newTestServer(t)
+	server.cfg.WebuiControlTokenPath = writeTokenFile(t, "alice "+testOperatorToken+"\n", 0o600)
+	controller := &fakeController{}
+	if err := server.EnableControl(controller); err != nil {
+		t.Fatalf("enable control: %v", err)
+	}
+	handler := server.Handler()
+	rr := doControl(handler, http.MethodGet, "/api/control/csrf", testOperatorToken, "", "", nil)
+	var csrf map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &csrf); err != nil {
+		t.Fatalf("csrf json: %v", err)
+	}
+	if err := server.writer.Close(); err != nil {
+		t.Fatalf("writer close: %v", err)
+	}
+	rr = doControl(handler, http.MethodPost, "/api/control/pause", testOperatorToken, csrf["csrf_token"], "", nil)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when the audit write fails, got %d", rr.Code)
+	}
+	if len(controller.calls) != 0 {
+		t.Fatalf("expected no action without an audit record, got %v", controller.calls)
+	}
+}
+
+func TestControlDisabledRejectsPost(t *testing.T) {
+	server := newTestServer(t)
+	rr := doControl(server.Handler(), http.MethodPost, "/api/control/pause", testOperatorToken, "", "", nil)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when control disabled, got %d", rr.Code)
+	}
+}
+
+func TestLoadOperatorTokensRejectsOpenPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits not enforced on windows")
+	}
+	path := writeTokenFile(t, "alice "+testOperatorToken+"\n", 0o644)
+	if _, err := LoadOperatorTokens(path); err == nil {
+		t.Fatalf("expected error for world-readable token file")
+	}
+	path = writeTokenFile(t, "alice short\n", 0o600)
+	if _, err := LoadOperatorTokens(path); err == nil {
+		t.Fatalf("expected error for short token")
+	}
+}
+
+func writeTokenFile(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "operators")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatalf("chmod token file: %v", err)
+	}
+	return path
+}
+
+func doControl(handler http.Handler, method string, path string, token string, csrf string, origin string, body map[string]any) *httptest.ResponseRecorder {
+	var payload string
+	if body != nil {
+		buf, _ := json.Marshal(body)
+		payload = string(buf)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(payload))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if csrf != "" {
+		req.Header.Set(controlCSRFHeader, csrf)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,423 @@
+package webui
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+const (
+	decisionIDMaxLen     = 128
+	decisionTimelineMax  = 500
+	timelineSourceAudit  = "audit"
+	timelineSourceSnap   = "snapshot"
+	timelineSourceIntent = "order_intent"
+)
+
+var ErrDecisionNotFound = errors.New("decision not found")
+
+type DecisionDetail struct {
+	DecisionID  string                `json:"decision_id"`
+	RunID       string                `json:"run_id"`
+	CycleID     string                `json:"cycle_id"`
+	SnapshotID  string                `json:"snapshot_id"`
+	Symbol      string                `json:"symbol"`
+	Snapshot    *DecisionSnapshotRow  `json:"snapshot"`
+	Edge        DecisionEdge          `json:"edge"`
+	AIGate      *DecisionGateRow      `json:"aigate"`
+	RiskReasons []string              `json:"risk_reasons"`
+	Intents     []DecisionIntentRow   `json:"intents"`
+	Fills       []DecisionFillRow     `json:"fills"`
+	Timeline    []DecisionTimelineRow `json:"timeline"`
+}
+
+type DecisionSnapshotRow struct {
+	SnapshotHash     string `json:"snapshot_hash"`
+	ExchangeTimeMs   int64  `json:"exchange_time_ms"`
+	LocalReceivedMs  int64  `json:"local_received_ms"`
+	Regime           string `json:"regime"`
+	MidPrice         string `json:"mid_price"`
+	BestBid          string `json:"best_bid"`
+	BestAsk          string `json:"best_ask"`
+	SpreadCurrentBps int    `json:"spread_current_bps"`
+	ATR14_5mBps      int    `json:"atr14_5m_bps"`
+}
+
+type DecisionEdge struct {
+	EdgeScoreX10000 int                     `json:"edge_score_x10000"`
+	EdgeBpsExpected int                     `json:"edge_bps_expected"`
+	GrossEdgeBps    int                     `json:"gross_edge_bps"`
+	CostTotalBps    int                     `json:"cost_total_bps"`
+	Costs           contracts.CostsSnapshot `json:"costs"`
+	TPPrice         string                  `json:"tp_price"`
+	SLPrice         string                  `json:"sl_price"`
+}
+
+type DecisionGateRow struct {
+	TsMs          int64           `json:"ts_ms"`
+	Verdict       string          `json:"verdict"`
+	ReasonCodes   []string        `json:"reason_codes"`
+	Model         string          `json:"model"`
+	LatencyMs     int             `json:"latency_ms"`
+	InputHash     string          `json:"input_hash"`
+	SnapshotHash  string          `json:"snapshot_hash"`
+	RawHash       string          `json:"raw_hash"`
+	PromptVersion string          `json:"prompt_version"`
+	CacheHit      bool            `json:"cache_hit"`
+	ModifyApplied bool            `json:"modify_applied"`
+	Patch         json.RawMessage `json:"patch"`
+	ErrorCode     string          `json:"error_code"`
+}
+
+type DecisionIntentRow struct {
+	OrderIntentID   string `json:"order_intent_id"`
+	Action          string `json:"action"`
+	ClientOrderID   string `json:"client_order_id"`
+	Side            string `json:"side"`
+	Qty             string `json:"qty"`
+	Price           string `json:"price"`
+	State           string `json:"state"`
+	ExchangeOrderID string `json:"exchange_order_id"`
+	LastErrorCode   string `json:"last_error_code"`
+	CreatedAtMs     int64  `json:"created_at_ms"`
+	UpdatedAtMs     int64  `json:"updated_at_ms"`
+}
+
+type DecisionFillRow struct {
+	TsMs            int64  `json:"ts_ms"`
+	OrderIntentID   string `json:"order_intent_id"`
+	TradeID         int64  `json:"trade_id"`
+	Side            string `json:"side"`
+	Qty             string `json:"qty"`
+	Price           string `json:"price"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commission_asset"`
+}
+
+type DecisionTimelineRow struct {
+	TsMs          int64    `json:"ts_ms"`
+	Source        string   `json:"source"`
+	Stage         string   `json:"stage"`
+	EventType     string   `json:"event_type"`
+	OrderIntentID string   `json:"order_intent_id"`
+	ReasonCodes   []string `json:"reason_codes"`
+	Summary       string   `json:"summary"`
+}
+
+func ValidDecisionID(id string) bool {
+	if id == "" || len(id) > decisionIDMaxLen {
+		return false
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func QueryDecision(ctx context.Context, db *sql.DB, decisionID string) (DecisionDetail, error) {
+	out := DecisionDetail{
+		DecisionID:  decisionID,
+		RiskReasons: []string{},
+		Intents:     []DecisionIntentRow{},
+		Fills:       []DecisionFillRow{},
+		Timeline:    []DecisionTimelineRow{},
+	}
+	gate, payload, err := decisionGate(ctx, db, decisionID, &out)
+	if err != nil {
+		return DecisionDetail{}, err
+	}
+	out.AIGate = gate
+	if err := decisionIntents(ctx, db, &out); err != nil {
+		return DecisionDetail{}, err
+	}
+	if err := decisionFills(ctx, db, &out); err != nil {
+		return DecisionDetail{}, err
+	}
+	if err := decisionAuditEvents(ctx, db, &out); err != nil {
+		return DecisionDetail{}, err
+	}
+	if out.AIGate == nil && len(out.Intents) == 0 && len(out.Timeline) == 0 {
+		return DecisionDetail{}, ErrDecisionNotFound
+	}
+	var snap *contracts.Snapshot
+	if out.SnapshotID != "" {
+		snap, err = decisionSnapshot(ctx, db, &out)
+		if err != nil {
+			return DecisionDetail{}, err
+		}
+	}
+	out.Edge = decisionEdge(payload, snap)
+	sort.SliceStable(out.Timeline, func(i, j int) bool {
+		return out.Timeline[i].TsMs < out.Timeline[j].TsMs
+	})
+	return out, nil
+}
+
+func decisionGate(ctx context.Context, db *sql.DB, decisionID string, out *DecisionDetail) (*DecisionGateRow, *aigate.Payload, error) {
+	row := db.QueryRowContext(ctx, `SELECT run_id, cycle_id, snapshot_id, created_at_ms, verdict, reasons_json, model, latency_ms, input_hash, snapshot_hash, raw_hash,
+prompt_version, cache_hit, modify_applied, modified_decision_json_redacted, request_json_redacted, error_code
+FROM ai_gate_events WHERE decision_id = ? ORDER BY created_at_ms DESC LIMIT 1`, decisionID)
+	var gate DecisionGateRow
+	var runID, cycleID, snapshotID, reasonsJSON string
+	var model, rawHash, promptVersion, patchJSON, requestJSON, errorCode sql.NullString
+	var latency sql.NullInt64
+	var cacheHit, modifyApplied int64
+	err := row.Scan(&runID, &cycleID, &snapshotID, &gate.TsMs, &gate.Verdict, &reasonsJSON, &model, &latency, &gate.InputHash, &gate.SnapshotHash, &rawHash,
+		&promptVersion, &cacheHit, &modifyApplied, &patchJSON, &requestJSON, &errorCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("decision ai gate: %w", err)
+	}
+	out.RunID, out.CycleID, out.SnapshotID = runID, cycleID, snapshotID
+	gate.ReasonCodes = []string{}
+	_ = json.Unmarshal([]byte(reasonsJSON), &gate.ReasonCodes)
+	gate.Model = model.String
+	gate.LatencyMs = int(latency.Int64)
+	gate.RawHash = rawHash.String
+	gate.PromptVersion = promptVersion.String
+	gate.CacheHit = cacheHit == 1
+	gate.ModifyApplied = modifyApplied == 1
+	gate.ErrorCode = errorCode.String
+	if patchJSON.Valid && json.Valid([]byte(patchJSON.String)) {
+		gate.Patch = json.RawMessage(patchJSON.String)
+	}
+	var payload *aigate.Payload
+	if requestJSON.Valid {
+		var decoded aigate.Payload
+		if err := json.Unmarshal([]byte(requestJSON.String), &decoded); err == nil {
+			payload = &decoded
+			out.Symbol = decoded.Symbol
+		}
+	}
+	return &gate, payload, nil
+}
+
+func decisionIntents(ctx context.Context, db *sql.DB, out *DecisionDetail) error {
+	rows, err := db.QueryContext(ctx, `SELECT order_intent_id, run_id, cycle_id, symbol, action, client_order_id, intent_payload_json, state,
+exchange_order_id, last_error_code, created_at_ms, updated_at_ms
+FROM order_intents WHERE decision_id = ? ORDER BY created_at_ms, order_intent_id`, out.DecisionID)
+	if err != nil {
+		return fmt.Errorf("decision intents: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var intent DecisionIntentRow
+		var runID, cycleID, symbol, payloadJSON string
+		var exchangeOrderID, lastErrorCode sql.NullString
+		if err := rows.Scan(&intent.OrderIntentID, &runID, &cycleID, &symbol, &intent.Action, &intent.ClientOrderID, &payloadJSON, &intent.State,
+			&exchangeOrderID, &lastErrorCode, &intent.CreatedAtMs, &intent.UpdatedAtMs); err != nil {
+			return fmt.Errorf("decision intents: %w", err)
+		}
+		var payload executor.OrderIntentHashPayload
+		_ = json.Unmarshal([]byte(payloadJSON), &payload)
+		intent.Side = string(payload.Side)
+		intent.Qty = payload.EntryPlanQty
+		intent.Price = payload.EntryPlanPrice
+		intent.ExchangeOrderID = exchangeOrderID.String
+		intent.LastErrorCode = lastErrorCode.String
+		if out.RunID == "" {
+			out.RunID, out.CycleID = runID, cycleID
+		}
+		if out.Symbol == "" {
+			out.Symbol = symbol
+		}
+		out.Intents = append(out.Intents, intent)
+		out.Timeline = append(out.Timeline, DecisionTimelineRow{
+			TsMs:          intent.CreatedAtMs,
+			Source:        timelineSourceIntent,
+			Stage:         string(observability.EXECUTE_INTENT),
+			EventType:     string(executor.IntentCreated),
+			OrderIntentID: intent.OrderIntentID,
+			ReasonCodes:   []string{},
+			Summary:       strings.TrimSpace(intent.Action + " " + intent.Side + " " + intent.Qty + " @ " + intent.Price),
+		})
+		if intent.State != string(executor.IntentCreated) {
+			summary := intent.State
+			if intent.LastErrorCode != "" {
+				summary += " " + intent.LastErrorCode
+			}
+			out.Timeline = append(out.Timeline, DecisionTimelineRow{
+				TsMs:          intent.UpdatedAtMs,
+				Source:        timelineSourceIntent,
+				Stage:         string(observability.EXECUTE_INTENT),
+				EventType:     intent.State,
+				OrderIntentID: intent.OrderIntentID,
+				ReasonCodes:   []string{},
+				Summary:       summary,
+			})
+		}
+	}
+	return rows.Err()
+}
+
+func decisionFills(ctx context.Context, db *sql.DB, out *DecisionDetail) error {
+	rows, err := db.QueryContext(ctx, `SELECT f.filled_at_ms, f.order_intent_id, f.trade_id, f.side, f.qty, f.price, f.commission, f.commission_asset
+FROM order_fills f
+JOIN order_intents i ON i.order_intent_id = f.order_intent_id
+WHERE i.decision_id = ? ORDER BY f.filled_at_ms, f.order_intent_id, f.trade_id`, out.DecisionID)
+	if err != nil {
+		return fmt.Errorf("decision fills: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fill DecisionFillRow
+		if err := rows.Scan(&fill.TsMs, &fill.OrderIntentID, &fill.TradeID, &fill.Side, &fill.Qty, &fill.Price, &fill.Commission, &fill.CommissionAsset); err != nil {
+			return fmt.Errorf("decision fills: %w", err)
+		}
+		out.Fills = append(out.Fills, fill)
+	}
+	return rows.Err()
+}
+
+func decisionAuditEvents(ctx context.Context, db *sql.DB, out *DecisionDetail) error {
+	query := `SELECT ts_ms, run_id, cycle_id, stage, event_type, reasons_json, snapshot_id, order_intent_id, data_json
+FROM audit_events WHERE event_type != ? AND (decision_id = ?`
+	args := []any{auditdomain.WEBUI_REQUEST, out.DecisionID}
+	if len(out.Intents) > 0 {
+		query += ` OR order_intent_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(out.Intents)), ",") + `)`
+		for _, intent := range out.Intents {
+			args = append(args, intent.OrderIntentID)
+		}
+	}
+	query += `) ORDER BY ts_ms, event_id LIMIT ?`
+	args = append(args, decisionTimelineMax)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("decision audit events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry DecisionTimelineRow
+		var runID, cycleID, reasonsJSON, snapshotID, dataJSON string
+		if err := rows.Scan(&entry.TsMs, &runID, &cycleID, &entry.Stage, &entry.EventType, &reasonsJSON, &snapshotID, &entry.OrderIntentID, &dataJSON); err != nil {
+			return fmt.Errorf("decision audit events: %w", err)
+		}
+		entry.Source = timelineSourceAudit
+		entry.ReasonCodes = []string{}
+		_ = json.Unmarshal([]byte(reasonsJSON), &entry.ReasonCodes)
+		var data map[string]any
+		_ = json.Unmarshal([]byte(dataJSON), &data)
+		entry.Summary, _ = data["summary"].(string)
+		if entry.Summary == "" {
+			if verdict, ok := data["ai_verdict"].(string); ok {
+				entry.Summary = verdict
+			}
+		}
+		if out.RunID == "" {
+			out.RunID, out.CycleID = runID, cycleID
+		}
+		if out.SnapshotID == "" && snapshotID != "" {
+			out.SnapshotID = snapshotID
+		}
+		if out.Symbol == "" {
+			out.Symbol, _ = data["symbol"].(string)
+		}
+		if entry.Stage == string(observability.RISK_VERDICT) {
+			out.RiskReasons = appendUnique(out.RiskReasons, entry.ReasonCodes...)
+		}
+		out.Timeline = append(out.Timeline, entry)
+	}
+	return rows.Err()
+}
+
+func decisionSnapshot(ctx context.Context, db *sql.DB, out *DecisionDetail) (*contracts.Snapshot, error) {
+	row := db.QueryRowContext(ctx, `SELECT symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json
+FROM snapshots WHERE snapshot_id = ?`, out.SnapshotID)
+	var symbol, snapshotJSON string
+	var detail DecisionSnapshotRow
+	if err := row.Scan(&symbol, &detail.SnapshotHash, &detail.ExchangeTimeMs, &detail.LocalReceivedMs, &snapshotJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("decision snapshot: %w", err)
+	}
+	var snap contracts.Snapshot
+	if err := json.Unmarshal([]byte(snapshotJSON), &snap); err != nil {
+		return nil, fmt.Errorf("decision snapshot json: %w", err)
+	}
+	detail.Regime = snap.Regime.Label
+	detail.MidPrice = snap.Prices.MidPrice
+	detail.BestBid = snap.Prices.BestBid
+	detail.BestAsk = snap.Prices.BestAsk
+	detail.SpreadCurrentBps = snap.Microstructure60s.SpreadCurrentBps
+	detail.ATR14_5mBps = snap.Volatility.ATR14_5mBps
+	out.Snapshot = &detail
+	if out.Symbol == "" {
+		out.Symbol = symbol
+	}
+	out.Timeline = append(out.Timeline, DecisionTimelineRow{
+		TsMs:        detail.LocalReceivedMs,
+		Source:      timelineSourceSnap,
+		Stage:       string(observability.STATE_UPDATE),
+		EventType:   "SNAPSHOT",
+		ReasonCodes: []string{},
+		Summary:     fmt.Sprintf("%s mid %s spread %dbps", detail.Regime, detail.MidPrice, detail.SpreadCurrentBps),
+	})
+	return &snap, nil
+}
+
+func decisionEdge(payload *aigate.Payload, snap *contracts.Snapshot) DecisionEdge {
+	var edge DecisionEdge
+	costs := contracts.CostInputs{}
+	micro := contracts.Microstructure60s{}
+	switch {
+	case payload != nil:
+		costs = payload.Costs
+		micro = payload.Microstructure
+		edge.EdgeScoreX10000 = payload.EdgeScore
+		edge.EdgeBpsExpected = payload.EdgeBps
+		edge.TPPrice = payload.ExitPlan.TPPrice
+		edge.SLPrice = payload.ExitPlan.SLPrice
+	case snap != nil:
+		costs = snap.CostInputs
+		micro = snap.Microstructure60s
+	}
+	edge.Costs = contracts.CostsSnapshot{
+		MakerFeeBps:           costs.MakerFeeBps,
+		TakerFeeBps:           costs.TakerFeeBps,
+		SlippageEntryMakerBps: costs.SlippageEntryMakerBps,
+		SlippageEntryTakerBps: costs.SlippageEntryTakerBps,
+		SlippageExitTakerBps:  costs.SlippageExitTakerBps,
+		SpreadCurrentBps:      micro.SpreadCurrentBps,
+		DeltaSpreadBpsP90_10s: micro.DeltaSpreadBpsP90_10s,
+	}
+	deltaSpread := micro.DeltaSpreadBpsP90_10s
+	if deltaSpread < 0 {
+		deltaSpread = 0
+	}
+	edge.CostTotalBps = costs.MakerFeeBps + costs.TakerFeeBps + costs.SlippageEntryMakerBps + costs.SlippageExitTakerBps + micro.SpreadCurrentBps + deltaSpread
+	if payload != nil {
+		edge.GrossEdgeBps = edge.EdgeBpsExpected + edge.CostTotalBps
+	}
+	return edge
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
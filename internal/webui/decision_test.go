@@ -0,0 +1,88 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/aigate"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+)
+
+func TestDecisionDrillDown(t *testing.T) {
+	server := newTestServer(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	decisionID := "dec_oi_1"
+
+	insertTestSnapshot(t, server, "BTCUSDT", "100", now.Add(-3*time.Second))
+	payload, err := json.Marshal(aigate.Payload{
+		DecisionID:     decisionID,
+		SnapshotID:     "snap_BTCUSDT",
+		Symbol:         "BTCUSDT",
+		EdgeScore:      6200,
+		EdgeBps:        18,
+		Microstructure: contracts.Microstructure60s{SpreadCurrentBps: 4, DeltaSpreadBpsP90_10s: 1},
+		Costs:          contracts.CostInputs{MakerFeeBps: 2, TakerFeeBps: 4, SlippageEntryMakerBps: 1, SlippageExitTakerBps: 3},
+		ExitPlan:       contracts.ExitPlan{TPPrice: "101", SLPrice: "99.5"},
+	})
+	if err != nil {
+		t.Fatalf("payload json: %v", err)
+	}
+	_, err = server.db.Exec(`INSERT INTO ai_gate_events (run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash, enabled, verdict,
+reasons_json, model, latency_ms, request_json_redacted, modified_decision_json_redacted, modify_applied, local_received_ms, created_at_ms)
+VALUES ('run', 'cycle_1', 'LIVE', 'AIGATE_CALL', 'AIGATE_CALL', 'snap_BTCUSDT', 'hash', ?, 'input', 1, 'MODIFY', '["AIGATE_MODIFY"]', 'stub', 40, ?, '{"qty":"0.5"}', 1, ?, ?)`,
+		decisionID, string(payload), now.Add(-2*time.Second).UnixMilli(), now.Add(-2*time.Second).UnixMilli())
+	if err != nil {
+		t.Fatalf("gate insert: %v", err)
+	}
+	_, err = server.db.Exec(`INSERT INTO audit_events (ts_ms, run_id, cycle_id, mode, stage, event_type, reasons_json, snapshot_id, decision_id, order_intent_id,
+exchange_time_ms, local_received_ms, data_json, created_at_ms)
+VALUES (?, 'run', 'cycle_1', 'LIVE', 'RISK_VERDICT', 'STAGE_CHANGED', '["RISK_OK"]', 'snap_BTCUSDT', ?, '', 0, ?, '{"summary":"approved"}', ?)`,
+		now.Add(-time.Second).UnixMilli(), decisionID, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		t.Fatalf("audit insert: %v", err)
+	}
+	insertTestIntent(t, server, "oi_1", "BTCUSDT", contracts.SideBuy, contracts.IntentEntry, "0.5", "100", executor.IntentConfirmed, now)
+	insertTestFill(t, server, "oi_1", 7, "BTCUSDT", contracts.SideBuy, "100.01", "0.5", "0.0005", "BTC", now)
+
+	detail, err := QueryDecision(context.Background(), server.db, decisionID)
+	if err != nil {
+		t.Fatalf("query decision: %v", err)
+	}
+	if detail.Symbol != "BTCUSDT" || detail.Snapshot == nil || detail.Snapshot.MidPrice != "100" {
+		t.Fatalf("unexpected snapshot: %+v", detail)
+	}
+	if detail.Edge.CostTotalBps != 15 || detail.Edge.GrossEdgeBps != 33 || detail.Edge.EdgeBpsExpected != 18 {
+		t.Fatalf("unexpected edge: %+v", detail.Edge)
+	}
+	if detail.AIGate == nil || detail.AIGate.Verdict != "MODIFY" || !detail.AIGate.ModifyApplied || string(detail.AIGate.Patch) != `{"qty":"0.5"}` {
+		t.Fatalf("unexpected gate: %+v", detail.AIGate)
+	}
+	if len(detail.RiskReasons) != 1 || detail.RiskReasons[0] != "RISK_OK" {
+		t.Fatalf("unexpected risk reasons: %+v", detail.RiskReasons)
+	}
+	if len(detail.Intents) != 1 || len(detail.Fills) != 1 || detail.Fills[0].Qty != "0.5" || detail.Fills[0].Price != "100.01" || detail.Fills[0].CommissionAsset != "BTC" {
+		t.Fatalf("unexpected intents/fills: %+v %+v", detail.Intents, detail.Fills)
+	}
+	if len(detail.Timeline) != 4 || detail.Timeline[0].Source != timelineSourceSnap || detail.Timeline[1].Stage != "RISK_VERDICT" || detail.Timeline[3].EventType != "CONFIRMED" {
+		t.Fatalf("unexpected timeline: %+v", detail.Timeline)
+	}
+
+	handler := server.Handler()
+	for path, want := range map[string]int{
+		"/api/decision/" + decisionID: http.StatusOK,
+		"/api/decision/dec_missing":   http.StatusNotFound,
+		"/api/decision/bad%20id":      http.StatusBadRequest,
+		"/decision":                   http.StatusOK,
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package webui
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+var aiGateLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 16}
+
+type storeMetrics struct {
+	intents         *observability.Gauge
+	gateVerdicts    *observability.Gauge
+	gateLatency     *observability.Histogram
+	driftScore      *observability.Gauge
+	exposureSymbol  *observability.Gauge
+	exposureTotal   *observability.Gauge
+	dailyPnL        *observability.Gauge
+	watchlistSymbol *observability.Gauge
+}
+
+type AIGateDayStats struct {
+	Verdicts  map[string]int64
+	LatencyMs []int64
+}
+
+func (s *Server) EnableMetrics(reg *observability.Registry) {
+	s.metrics = reg
+	s.store = &storeMetrics{
+		intents:         reg.Gauge("livespot_intents", "Order intents by state.", "state"),
+		gateVerdicts:    reg.Gauge("livespot_aigate_verdicts_today", "AI Gate verdicts since 00:00 UTC.", "verdict"),
+		gateLatency:     reg.Histogram("livespot_aigate_latency_seconds", "AI Gate call latency since 00:00 UTC.", aiGateLatencyBuckets),
+		driftScore:      reg.Gauge("livespot_reconcile_drift_score_x10000", "Latest reconcile drift score (x10000)."),
+		exposureSymbol:  reg.Gauge("livespot_exposure_quote", "Open exposure in quote per watchlist symbol.", observability.SymbolLabel),
+		exposureTotal:   reg.Gauge("livespot_exposure_total_quote", "Total open exposure in quote."),
+		dailyPnL:        reg.Gauge("livespot_daily_pnl_quote", "Daily PnL in quote since 00:00 UTC.", "kind"),
+		watchlistSymbol: reg.Gauge("livespot_watchlist_symbol", "Symbols in the current watchlist (TopK selection).", observability.SymbolLabel),
+	}
+	s.mux.HandleFunc("/metrics", s.wrap(s.metricsHandler))
+}
+
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.collectStoreMetrics(r.Context()); err != nil {
+		s.writeAudit(r, http.StatusInternalServerError)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := s.metrics.WriteText(&buf); err != nil {
+		s.writeAudit(r, http.StatusInternalServerError)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", observability.MetricsContentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) collectStoreMetrics(ctx context.Context) error {
+	m := s.store
+	topK, err := QueryTopK(ctx, s.db)
+	if err != nil {
+		return err
+	}
+	watchlist := make([]string, 0, len(topK.Items))
+	for _, item := range topK.Items {
+		watchlist = append(watchlist, item.Symbol)
+	}
+	s.metrics.SetWatchlist(watchlist)
+	m.watchlistSymbol.Reset()
+	for _, symbol := range watchlist {
+		m.watchlistSymbol.Set(1, symbol)
+	}
+
+	intents, err := QueryIntents(ctx, s.db, 1)
+	if err != nil {
+		return err
+	}
+	m.intents.Reset()
+	for state, count := range intents.PendingByState {
+		m.intents.Set(float64(count), state)
+	}
+
+	now := s.now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	gate, err := QueryAIGateDay(ctx, s.db, dayStart.UnixMilli())
+	if err != nil {
+		return err
+	}
+	m.gateVerdicts.Reset()
+	for verdict, count := range gate.Verdicts {
+		m.gateVerdicts.Set(float64(count), verdict)
+	}
+	m.gateLatency.Reset()
+	for _, latency := range gate.LatencyMs {
+		m.gateLatency.Observe(float64(latency) / 1000)
+	}
+
+	reconcile, err := QueryReconcile(ctx, s.db, 1)
+	if err != nil {
+		return err
+	}
+	m.driftScore.Set(float64(reconcile.DriftScoreX10000))
+
+	risk, err := QueryRisk(ctx, s.db, s.cfg, s.now())
+	if err != nil {
+		return err
+	}
+	m.exposureSymbol.Reset()
+	for _, entry := range risk.ExposureBySymbolQuote {
+		m.exposureSymbol.Set(quoteFloat(entry["quote"]), entry["symbol"])
+	}
+	m.exposureTotal.Set(quoteFloat(risk.ExposureTotalQuote))
+	m.dailyPnL.Set(quoteFloat(risk.DailyPnLRealizedQuote), "realized")
+	m.dailyPnL.Set(quoteFloat(risk.DailyPnLUnrealizedQuote), "unrealized")
+	return nil
+}
+
+func QueryAIGateDay(ctx context.Context, db *sql.DB, sinceMs int64) (AIGateDayStats, error) {
+	out := AIGateDayStats{Verdicts: map[string]int64{}, LatencyMs: []int64{}}
+	rows, err := db.QueryContext(ctx, `SELECT verdict, latency_ms FROM ai_gate_events WHERE created_at_ms >= ?`, sinceMs)
+	if err != nil {
+		return AIGateDayStats{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var verdict string
+		var latency sql.NullInt64
+		if err := rows.Scan(&verdict, &latency); err != nil {
+			return AIGateDayStats{}, err
+		}
+		out.Verdicts[verdict]++
+		if latency.Valid {
+			out.LatencyMs = append(out.LatencyMs, latency.Int64)
+		}
+	}
+	return out, rows.Err()
+}
+
+func quoteFloat(value string) float64 {
+	r, err := parseQuote(value)
+	if err != nil {
+		return 0
+	}
+	f, _ := r.Float64()
+	return f
+}
@@ -0,0 +1,74 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/persist"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/rank"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := newTestServer(t)
+	now := time.Now()
+	insertTestSnapshot(t, server, "BTCUSDT", "120", now)
+	insertTestIntent(t, server, "oi_1", "BTCUSDT", contracts.SideBuy, contracts.IntentEntry, "1", "100", executor.IntentConfirmed, now)
+	insertTestFill(t, server, "oi_1", 1, "BTCUSDT", contracts.SideBuy, "100", "1", "0", "USDT", now)
+	insertTestIntent(t, server, "oi_2", "ETHUSDT", contracts.SideBuy, contracts.IntentEntry, "1", "50", executor.IntentConfirmed, now)
+	insertTestFill(t, server, "oi_2", 2, "ETHUSDT", contracts.SideBuy, "50", "1", "0", "USDT", now)
+	insertTestIntent(t, server, "oi_3", "ETHUSDT", contracts.SideSell, contracts.IntentExit, "1", "50", executor.IntentState("RETIRED"), now)
+	store := persist.NewSelectionStore(server.db)
+	if err := store.InsertRankings("run", "cycle_1", "TOPN", []rank.RankedSymbol{{Symbol: "BTCUSDT", ScoreX10000: 9000}}, now); err != nil {
+		t.Fatalf("rankings: %v", err)
+	}
+	if err := store.InsertSelection("run", "cycle_1", []string{"BTCUSDT"}, []string{"BTCUSDT"}, []string{"BTCUSDT"}, false, true, 0, nil, "cfg", now); err != nil {
+		t.Fatalf("selection: %v", err)
+	}
+	_, err := server.db.Exec(`INSERT INTO ai_gate_events (run_id, cycle_id, mode, stage, event_type, snapshot_id, snapshot_hash, decision_id, input_hash, enabled, verdict,
+reasons_json, latency_ms, modify_applied, local_received_ms, created_at_ms)
+VALUES ('run', 'cycle_1', 'LIVE', 'AIGATE_CALL', 'AIGATE_CALL', 'snap', 'hash', 'dec_1', 'input', 1, 'BLOCK', '[]', 300, 0, ?, ?)`, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		t.Fatalf("gate insert: %v", err)
+	}
+
+	server.EnableMetrics(observability.NewRegistry())
+	rr := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Type") != observability.MetricsContentType {
+		t.Fatalf("unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		`livespot_intents{state="CONFIRMED"} 2`,
+		`livespot_aigate_verdicts_today{verdict="BLOCK"} 1`,
+		`livespot_aigate_latency_seconds_bucket{le="0.5"} 1`,
+		`livespot_exposure_quote{symbol="BTCUSDT"} 120`,
+		`livespot_exposure_total_quote 170`,
+		`livespot_watchlist_symbol{symbol="BTCUSDT"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `symbol="ETHUSDT"`) {
+		t.Fatalf("symbol outside watchlist exported:\n%s", body)
+	}
+
+	if _, err := server.db.Exec(`DELETE FROM order_intents`); err != nil {
+		t.Fatalf("drain intents: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `livespot_intents{state="CONFIRMED"} 0`) || strings.Contains(rr.Body.String(), `state="RETIRED"`) {
+		t.Fatalf("expected drained intent states reset:\n%s", rr.Body.String())
+	}
+}
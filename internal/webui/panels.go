@@ -0,0 +1,540 @@
+package webui
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/topk"
+)
+
+const (
+	topKFeaturesLimit = 3
+	quoteAsset        = "USDT"
+)
+
+func QueryMarket(ctx context.Context, db *sql.DB, nowMs int64, limit int) (MarketSnapshot, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	out := MarketSnapshot{Symbols: []MarketSymbolRow{}}
+	snapshots, err := latestSnapshots(ctx, db)
+	if err != nil {
+		return out, err
+	}
+	blocked, err := latestUniverseBlocks(ctx, db)
+	if err != nil {
+		return out, err
+	}
+	scores, err := latestRankingScores(ctx, db)
+	if err != nil {
+		return out, err
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return scores[snapshots[i].Symbol] > scores[snapshots[j].Symbol]
+	})
+	if len(snapshots) > limit {
+		snapshots = snapshots[:limit]
+	}
+	for _, snap := range snapshots {
+		micro := snap.Microstructure60s
+		out.Symbols = append(out.Symbols, MarketSymbolRow{
+			Symbol:             snap.Symbol,
+			Regime:             snap.Regime.Label,
+			TrendScoreX10000:   int32(snap.Regime.TrendScoreX10000),
+			RangeScoreX10000:   int32(snap.Regime.RangeScoreX10000),
+			SpreadCurrentBps:   int32(micro.SpreadCurrentBps),
+			SpreadP50Bps:       int32(micro.SpreadBpsP50_60s),
+			SpreadP90Bps:       int32(micro.SpreadBpsP90_60s),
+			DeltaSpreadP90_10s: int32(micro.DeltaSpreadBpsP90_10s),
+			ImbalanceX10000:    int32(micro.BidAskImbalanceP50_10sX10000),
+			ATR14_5mBps:        int32(snap.Volatility.ATR14_5mBps),
+			ATR14_15mBps:       int32(snap.Volatility.ATR14_15mBps),
+			EdgeBpsExpected:    0,
+			CostsBps: map[string]int32{
+				"fees_bps":     int32(snap.CostInputs.MakerFeeBps + snap.CostInputs.TakerFeeBps),
+				"slippage_bps": int32(snap.CostInputs.SlippageEntryMakerBps + snap.CostInputs.SlippageExitTakerBps),
+				"spread_bps":   int32(micro.SpreadCurrentBps),
+			},
+			BlockedReasonCode: blocked[snap.Symbol],
+			SignalFlags:       signalFlags(snap, nowMs),
+		})
+	}
+	return out, nil
+}
+
+func QueryTopK(ctx context.Context, db *sql.DB) (TopKSnapshot, error) {
+	out := TopKSnapshot{Items: []TopKItemRow{}, PairsOverLimit: []map[string]any{}}
+	row := db.QueryRowContext(ctx, `SELECT run_id, cycle_id, topk_final_symbols_json, churn_guard_applied, max_pairwise_corr_x10000, corr_pairs_json
+FROM cycle_selections ORDER BY created_at_ms DESC LIMIT 1`)
+	var runID, cycleID, finalJSON string
+	var churnApplied int
+	var maxCorr sql.NullInt64
+	var pairsJSON sql.NullString
+	if err := row.Scan(&runID, &cycleID, &finalJSON, &churnApplied, &maxCorr, &pairsJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return out, nil
+		}
+		return out, err
+	}
+	out.CycleID = cycleID
+	out.MaxPairwiseCorrX10000 = int32(maxCorr.Int64)
+	var symbols []string
+	if err := json.Unmarshal([]byte(finalJSON), &symbols); err != nil {
+		return out, err
+	}
+	if pairsJSON.Valid && pairsJSON.String != "" {
+		var pairs []topk.PairOverLimit
+		if err := json.Unmarshal([]byte(pairsJSON.String), &pairs); err != nil {
+			return out, err
+		}
+		for _, pair := range pairs {
+			out.PairsOverLimit = append(out.PairsOverLimit, map[string]any{
+				"a":           pair.A,
+				"b":           pair.B,
+				"corr_x10000": pair.CorrX10000,
+				"action":      pair.Action,
+			})
+		}
+	}
+	rankings, err := cycleRankings(ctx, db, runID, cycleID)
+	if err != nil {
+		return out, err
+	}
+	for _, symbol := range symbols {
+		ranking := rankings[symbol]
+		out.Items = append(out.Items, TopKItemRow{
+			Symbol:            symbol,
+			ScoreX10000:       int32(ranking.score),
+			FeaturesTop:       topFeatures(ranking.features, topKFeaturesLimit),
+			ChurnGuardApplied: churnApplied == 1,
+		})
+	}
+	return out, nil
+}
+
+func QueryRisk(ctx context.Context, db *sql.DB, cfg config.Config, now time.Time) (RiskSnapshot, error) {
+	out := RiskSnapshot{
+		ExposureTotalQuote:           "0.00",
+		ExposureBySymbolQuote:        []map[string]string{},
+		LockedQuote:                  "0.00",
+		FreeQuote:                    "0.00",
+		DailyPnLRealizedQuote:        "0.00",
+		DailyPnLUnrealizedQuote:      "0.00",
+		DailyLossLimitRemainingQuote: "0.00",
+		DrawdownQuote:                "0.00",
+		Cooldowns:                    []map[string]any{},
+	}
+	book, err := buildPositionBook(ctx, db, now)
+	if err != nil {
+		return out, err
+	}
+	marks, err := latestMidPrices(ctx, db)
+	if err != nil {
+		return out, err
+	}
+	exposureTotal := new(big.Rat)
+	unrealized := new(big.Rat)
+	symbols := make([]string, 0, len(book.positions))
+	for symbol := range book.positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		pos := book.positions[symbol]
+		if pos.qty.Sign() <= 0 {
+			continue
+		}
+		avg := new(big.Rat).Quo(pos.cost, pos.qty)
+		mark, ok := marks[symbol]
+		if !ok {
+			mark = avg
+		}
+		exposure := new(big.Rat).Mul(pos.qty, mark)
+		exposureTotal.Add(exposureTotal, exposure)
+		unrealized.Add(unrealized, new(big.Rat).Mul(pos.qty, new(big.Rat).Sub(mark, avg)))
+		out.ExposureBySymbolQuote = append(out.ExposureBySymbolQuote, map[string]string{
+			"symbol": symbol,
+			"quote":  exposure.FloatString(2),
+		})
+	}
+	total := new(big.Rat).Add(book.realizedToday, unrealized)
+	limit, err := parseQuote(cfg.RiskMaxDailyLossUSDT)
+	if err != nil {
+		return out, err
+	}
+	remaining := new(big.Rat).Sub(total, limit)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	drawdown := new(big.Rat).Sub(book.peakToday, total)
+	if drawdown.Sign() < 0 {
+		drawdown.SetInt64(0)
+	}
+	out.ExposureTotalQuote = exposureTotal.FloatString(2)
+	out.LockedQuote = book.locked.FloatString(2)
+	out.DailyPnLRealizedQuote = book.realizedToday.FloatString(2)
+	out.DailyPnLUnrealizedQuote = unrealized.FloatString(2)
+	out.DailyLossLimitRemainingQuote = remaining.FloatString(2)
+	out.DrawdownQuote = drawdown.FloatString(2)
+	out.TradesToday = book.tradesToday
+	cooldownMs := int64(cfg.RiskCooldownSeconds) * 1000
+	exitSymbols := make([]string, 0, len(book.lastExitMs))
+	for symbol := range book.lastExitMs {
+		exitSymbols = append(exitSymbols, symbol)
+	}
+	sort.Strings(exitSymbols)
+	for _, symbol := range exitSymbols {
+		until := book.lastExitMs[symbol] + cooldownMs
+		if until <= now.UnixMilli() {
+			continue
+		}
+		out.Cooldowns = append(out.Cooldowns, map[string]any{
+			"symbol":               symbol,
+			"cooldown_until_ts_ms": until,
+			"trades_per_hour":      book.entriesLastHour[symbol],
+			"loss_streak":          book.lossStreak[symbol],
+		})
+	}
+	return out, nil
+}
+
+type positionEntry struct {
+	qty  *big.Rat
+	cost *big.Rat
+}
+
+type positionBook struct {
+	positions       map[string]*positionEntry
+	locked          *big.Rat
+	realizedToday   *big.Rat
+	peakToday       *big.Rat
+	tradesToday     int64
+	lastExitMs      map[string]int64
+	entriesLastHour map[string]int64
+	lossStreak      map[string]int64
+}
+
+// buildPositionBook rebuilds positions and today's realized PnL from order_fills, at fill prices
+// net of commissions charged in the quote or the base asset. Unfilled NEW_ORDER buys that are
+// still CREATED or SENT_UNKNOWN count as locked quote at their planned notional.
+func buildPositionBook(ctx context.Context, db *sql.DB, now time.Time) (positionBook, error) {
+	book := positionBook{
+		positions:       map[string]*positionEntry{},
+		locked:          new(big.Rat),
+		realizedToday:   new(big.Rat),
+		peakToday:       new(big.Rat),
+		lastExitMs:      map[string]int64{},
+		entriesLastHour: map[string]int64{},
+		lossStreak:      map[string]int64{},
+	}
+	if err := addLockedQuote(ctx, db, &book); err != nil {
+		return book, err
+	}
+	dayStart := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC).UnixMilli()
+	rows, err := db.QueryContext(ctx, `SELECT f.order_intent_id, f.symbol, f.side, f.price, f.qty, f.commission, f.commission_asset,
+  f.filled_at_ms, i.intent_payload_json
+FROM order_fills f
+JOIN order_intents i ON i.order_intent_id = f.order_intent_id
+ORDER BY f.filled_at_ms, f.order_intent_id, f.trade_id`)
+	if err != nil {
+		return book, err
+	}
+	defer rows.Close()
+	type exitOrder struct {
+		symbol   string
+		ts       int64
+		realized *big.Rat
+	}
+	entries := map[string]bool{}
+	exits := map[string]*exitOrder{}
+	var exitOrderIDs []string
+	for rows.Next() {
+		var intentID, symbol, side, priceText, qtyText, commissionText, commissionAsset, payloadJSON string
+		var ts int64
+		if err := rows.Scan(&intentID, &symbol, &side, &priceText, &qtyText, &commissionText, &commissionAsset, &ts, &payloadJSON); err != nil {
+			return book, err
+		}
+		price, errPrice := parseQuote(priceText)
+		qty, errQty := parseQuote(qtyText)
+		commission, errCommission := parseQuote(commissionText)
+		if errPrice != nil || errQty != nil || errCommission != nil {
+			continue
+		}
+		base := strings.TrimSuffix(symbol, quoteAsset)
+		fee := new(big.Rat)
+		switch commissionAsset {
+		case quoteAsset:
+			fee.Set(commission)
+		case base:
+			fee.Mul(commission, price)
+		}
+		pos, ok := book.positions[symbol]
+		if !ok {
+			pos = &positionEntry{qty: new(big.Rat), cost: new(big.Rat)}
+			book.positions[symbol] = pos
+		}
+		if contracts.Side(side) == contracts.SideBuy {
+			pos.cost.Add(pos.cost, new(big.Rat).Mul(qty, price))
+			if commissionAsset == base {
+				pos.qty.Add(pos.qty, new(big.Rat).Sub(qty, commission))
+			} else {
+				pos.qty.Add(pos.qty, qty)
+				pos.cost.Add(pos.cost, fee)
+			}
+			var payload executor.OrderIntentHashPayload
+			if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil || payload.Intent != contracts.IntentEntry || entries[intentID] {
+				continue
+			}
+			entries[intentID] = true
+			if ts >= dayStart {
+				book.tradesToday++
+			}
+			if ts > now.Add(-time.Hour).UnixMilli() {
+				book.entriesLastHour[symbol]++
+			}
+			continue
+		}
+		exit, ok := exits[intentID]
+		if !ok {
+			exit = &exitOrder{symbol: symbol, realized: new(big.Rat)}
+			exits[intentID] = exit
+			exitOrderIDs = append(exitOrderIDs, intentID)
+		}
+		exit.ts = ts
+		book.lastExitMs[symbol] = ts
+		exit.realized.Sub(exit.realized, fee)
+		if pos.qty.Sign() <= 0 {
+			continue
+		}
+		sold := qty
+		if sold.Cmp(pos.qty) > 0 {
+			sold = new(big.Rat).Set(pos.qty)
+		}
+		avg := new(big.Rat).Quo(pos.cost, pos.qty)
+		exit.realized.Add(exit.realized, new(big.Rat).Mul(sold, new(big.Rat).Sub(price, avg)))
+		pos.cost.Sub(pos.cost, new(big.Rat).Mul(sold, avg))
+		pos.qty.Sub(pos.qty, sold)
+	}
+	if err := rows.Err(); err != nil {
+		return book, err
+	}
+	for _, intentID := range exitOrderIDs {
+		exit := exits[intentID]
+		if exit.realized.Sign() < 0 {
+			book.lossStreak[exit.symbol]++
+		} else {
+			book.lossStreak[exit.symbol] = 0
+		}
+		if exit.ts >= dayStart {
+			book.realizedToday.Add(book.realizedToday, exit.realized)
+			if book.realizedToday.Cmp(book.peakToday) > 0 {
+				book.peakToday.Set(book.realizedToday)
+			}
+		}
+	}
+	return book, nil
+}
+
+func addLockedQuote(ctx context.Context, db *sql.DB, book *positionBook) error {
+	rows, err := db.QueryContext(ctx, `SELECT intent_payload_json FROM order_intents
+WHERE action = ? AND state IN (?, ?) ORDER BY updated_at_ms, order_intent_id`,
+		string(executor.IntentActionNewOrder), string(executor.IntentCreated), string(executor.IntentSentUnknown))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var payloadJSON string
+		if err := rows.Scan(&payloadJSON); err != nil {
+			return err
+		}
+		var payload executor.OrderIntentHashPayload
+		if err := json.Unmarshal([]byte(payloadJSON), &payload); err != nil || payload.Side != contracts.SideBuy {
+			continue
+		}
+		qty, errQty := parseQuote(payload.EntryPlanQty)
+		price, errPrice := parseQuote(payload.EntryPlanPrice)
+		if errQty != nil || errPrice != nil {
+			continue
+		}
+		book.locked.Add(book.locked, new(big.Rat).Mul(qty, price))
+	}
+	return rows.Err()
+}
+
+// latestSnapshots returns the newest snapshot of every symbol, ordered by symbol.
+func latestSnapshots(ctx context.Context, db *sql.DB) ([]contracts.Snapshot, error) {
+	rows, err := db.QueryContext(ctx, `SELECT symbol, snapshot_json FROM (
+  SELECT symbol, snapshot_json,
+    ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY created_at_ms DESC, snapshot_id DESC) AS rn
+  FROM snapshots
+) WHERE rn = 1 ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []contracts.Snapshot
+	for rows.Next() {
+		var symbol, snapshotJSON string
+		if err := rows.Scan(&symbol, &snapshotJSON); err != nil {
+			return nil, err
+		}
+		var snap contracts.Snapshot
+		if err := json.Unmarshal([]byte(snapshotJSON), &snap); err != nil {
+			continue
+		}
+		snap.Symbol = symbol
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
+func latestMidPrices(ctx context.Context, db *sql.DB) (map[string]*big.Rat, error) {
+	snapshots, err := latestSnapshots(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]*big.Rat{}
+	for _, snap := range snapshots {
+		mid, err := parseQuote(snap.Prices.MidPrice)
+		if err != nil || mid.Sign() <= 0 {
+			continue
+		}
+		out[snap.Symbol] = mid
+	}
+	return out, nil
+}
+
+func latestUniverseBlocks(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	out := map[string]string{}
+	rows, err := db.QueryContext(ctx, `SELECT symbol, reasons_json FROM universe_scans
+WHERE eligible = 0 AND (run_id, cycle_id) = (SELECT run_id, cycle_id FROM universe_scans ORDER BY created_at_ms DESC LIMIT 1)`)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var symbol, reasonsJSON string
+		if err := rows.Scan(&symbol, &reasonsJSON); err != nil {
+			return out, err
+		}
+		var reasons []string
+		_ = json.Unmarshal([]byte(reasonsJSON), &reasons)
+		if len(reasons) > 0 {
+			out[symbol] = reasons[0]
+		}
+	}
+	return out, rows.Err()
+}
+
+func latestRankingScores(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	out := map[string]int{}
+	rows, err := db.QueryContext(ctx, `SELECT symbol, MAX(score) FROM cycle_rankings
+WHERE (run_id, cycle_id) = (SELECT run_id, cycle_id FROM cycle_rankings ORDER BY created_at_ms DESC LIMIT 1)
+GROUP BY symbol`)
+	if err != nil {
+		return out, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var symbol string
+		var score int
+		if err := rows.Scan(&symbol, &score); err != nil {
+			return out, err
+		}
+		out[symbol] = score
+	}
+	return out, rows.Err()
+}
+
+type rankingRow struct {
+	stage    string
+	score    int
+	features map[string]any
+}
+
+func cycleRankings(ctx context.Context, db *sql.DB, runID string, cycleID string) (map[string]rankingRow, error) {
+	rows, err := db.QueryContext(ctx, `SELECT symbol, ranking_stage, score, features_json
+FROM cycle_rankings WHERE run_id = ? AND cycle_id = ? ORDER BY created_at_ms`, runID, cycleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]rankingRow{}
+	for rows.Next() {
+		var symbol string
+		var row rankingRow
+		var featuresJSON string
+		if err := rows.Scan(&symbol, &row.stage, &row.score, &featuresJSON); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(featuresJSON), &row.features)
+		if existing, ok := out[symbol]; ok && existing.stage == "DEEP" && row.stage != "DEEP" {
+			continue
+		}
+		out[symbol] = row
+	}
+	return out, rows.Err()
+}
+
+func topFeatures(features map[string]any, limit int) []FeatureValueRow {
+	list := []FeatureValueRow{}
+	for name, raw := range features {
+		value, ok := raw.(float64)
+		if !ok {
+			continue
+		}
+		list = append(list, FeatureValueRow{Name: name, ValueX10000: int32(value)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		ai, aj := absInt32(list[i].ValueX10000), absInt32(list[j].ValueX10000)
+		if ai != aj {
+			return ai > aj
+		}
+		return list[i].Name < list[j].Name
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+func absInt32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func signalFlags(snap contracts.Snapshot, nowMs int64) []string {
+	out := []string{}
+	flags := snap.HealthFlags
+	if flags.QuarantinedUntilMs > nowMs || !flags.FiltersOK || (flags.SymbolStatus != "" && flags.SymbolStatus != "TRADING") {
+		out = append(out, string(reasoncodes.SYMBOL_QUARANTINED))
+	}
+	if !flags.WSOK {
+		out = append(out, string(reasoncodes.WS_STALE_DEGRADE))
+	}
+	if snap.Microstructure60s.OutOfOrderDrops > 0 {
+		out = append(out, string(reasoncodes.WS_OOO_EVENT))
+	}
+	return out
+}
+
+func parseQuote(value string) (*big.Rat, error) {
+	r := new(big.Rat)
+	if _, ok := r.SetString(value); !ok {
+		return nil, fmt.Errorf("invalid decimal: %s", value)
+	}
+	return r, nil
+}
@@ -0,0 +1,219 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/audit"
+	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/contracts"
+	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/persist"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/rank"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/topk"
+	"github.com/RodrigoBeloyanis/livespot/internal/infra/sqlite"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
+)
+
+func TestDashboardPanelsFromTables(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	insertTestSnapshot(t, server, "BTCUSDT", "120", now.Add(-30*time.Second))
+	insertTestSnapshot(t, server, "AAAUSDT", "1", now.Add(-20*time.Second))
+	insertTestIntent(t, server, "oi_1", "BTCUSDT", contracts.SideBuy, contracts.IntentEntry, "1", "99", executor.IntentConfirmed, now.Add(-2*time.Hour))
+	insertTestFill(t, server, "oi_1", 1, "BTCUSDT", contracts.SideBuy, "100", "1", "0.1", "USDT", now.Add(-2*time.Hour))
+	insertTestIntent(t, server, "oi_2", "BTCUSDT", contracts.SideSell, contracts.IntentExit, "0.5", "111", executor.IntentConfirmed, now.Add(-time.Minute))
+	insertTestFill(t, server, "oi_2", 2, "BTCUSDT", contracts.SideSell, "110", "0.5", "0.05", "USDT", now.Add(-time.Minute))
+	insertTestIntent(t, server, "oi_3", "ETHUSDT", contracts.SideBuy, contracts.IntentEntry, "2", "50", executor.IntentSentUnknown, now.Add(-10*time.Second))
+	insertTestIntent(t, server, "oi_4", "SOLUSDT", contracts.SideBuy, contracts.IntentEntry, "3", "20", executor.IntentConfirmed, now.Add(-3*time.Hour))
+
+	store := persist.NewSelectionStore(server.db)
+	ranked := []rank.RankedSymbol{
+		{Symbol: "BTCUSDT", ScoreX10000: 9100, Features: map[string]any{"edge": 40, "spread": -12, "volume": 3, "trend": 1}},
+		{Symbol: "ETHUSDT", ScoreX10000: 8700, Features: map[string]any{"edge": 25}},
+	}
+	if err := store.InsertRankings("run", "cycle_1", "TOPN", ranked, now); err != nil {
+		t.Fatalf("rankings: %v", err)
+	}
+	pairs := []topk.PairOverLimit{{A: "BTCUSDT", B: "ETHUSDT", CorrX10000: 9200, Action: "DROP"}}
+	if err := store.InsertSelection("run", "cycle_1", []string{"BTCUSDT", "ETHUSDT"}, []string{"BTCUSDT", "ETHUSDT"}, []string{"BTCUSDT"}, false, true, 9200, pairs, "cfg", now); err != nil {
+		t.Fatalf("selection: %v", err)
+	}
+
+	market, err := QueryMarket(ctx, server.db, now.UnixMilli(), 0)
+	if err != nil {
+		t.Fatalf("market: %v", err)
+	}
+	if len(market.Symbols) != 2 || market.Symbols[0].Symbol != "BTCUSDT" || market.Symbols[0].Regime != "TREND" || market.Symbols[0].SpreadCurrentBps != 4 || market.Symbols[0].ATR14_5mBps != 35 {
+		t.Fatalf("unexpected market: %+v", market.Symbols)
+	}
+	limited, err := QueryMarket(ctx, server.db, now.UnixMilli(), 1)
+	if err != nil {
+		t.Fatalf("market limit: %v", err)
+	}
+	if len(limited.Symbols) != 1 || limited.Symbols[0].Symbol != "BTCUSDT" {
+		t.Fatalf("expected the top ranked symbol kept by the limit, got %+v", limited.Symbols)
+	}
+
+	risk, err := QueryRisk(ctx, server.db, server.cfg, now)
+	if err != nil {
+		t.Fatalf("risk: %v", err)
+	}
+	if risk.ExposureTotalQuote != "60.00" || risk.DailyPnLRealizedQuote != "4.90" || risk.DailyPnLUnrealizedQuote != "9.95" {
+		t.Fatalf("unexpected risk pnl: %+v", risk)
+	}
+	if risk.DailyLossLimitRemainingQuote != "514.85" || risk.LockedQuote != "100.00" || risk.TradesToday != 1 {
+		t.Fatalf("unexpected risk limits: %+v", risk)
+	}
+	if len(risk.Cooldowns) != 1 || risk.Cooldowns[0]["symbol"] != "BTCUSDT" || risk.Cooldowns[0]["loss_streak"] != int64(0) {
+		t.Fatalf("unexpected cooldowns: %+v", risk.Cooldowns)
+	}
+
+	top, err := QueryTopK(ctx, server.db)
+	if err != nil {
+		t.Fatalf("topk: %v", err)
+	}
+	if top.CycleID != "cycle_1" || len(top.Items) != 1 || top.Items[0].ScoreX10000 != 9100 || len(top.Items[0].FeaturesTop) != 3 {
+		t.Fatalf("unexpected topk: %+v", top)
+	}
+	if top.Items[0].FeaturesTop[0].Name != "edge" || top.Items[0].FeaturesTop[0].ValueX10000 != 40 || top.MaxPairwiseCorrX10000 != 9200 || len(top.PairsOverLimit) != 1 {
+		t.Fatalf("unexpected topk detail: %+v", top)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders?limit=2", nil)
+	rr := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	var orders []OrderRow
+	if err := json.Unmarshal(rr.Body.Bytes(), &orders); err != nil {
+		t.Fatalf("orders json: %v", err)
+	}
+	if len(orders) != 2 || orders[0].Symbol != "ETHUSDT" || orders[0].Side != "BUY" || orders[0].Qty != "2" || orders[0].Status != "SENT_UNKNOWN" {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+}
+
+func insertTestSnapshot(t *testing.T, server *Server, symbol string, mid string, at time.Time) {
+	t.Helper()
+	snap := contracts.Snapshot{
+		Symbol:            symbol,
+		Regime:            contracts.RegimeSnapshot{Label: "TREND", TrendScoreX10000: 7000, RangeScoreX10000: 3000},
+		Microstructure60s: contracts.Microstructure60s{SpreadCurrentBps: 4, SpreadBpsP50_60s: 3, SpreadBpsP90_60s: 6},
+		Volatility:        contracts.VolatilitySnapshot{ATR14_5mBps: 35, ATR14_15mBps: 60},
+		Prices:            contracts.PricesSnapshot{MidPrice: mid},
+		HealthFlags:       contracts.HealthFlagsSnapshot{FiltersOK: true, WSOK: true, SymbolStatus: "TRADING"},
+	}
+	buf, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("snapshot json: %v", err)
+	}
+	_, err = server.db.Exec(`INSERT INTO snapshots (snapshot_id, symbol, snapshot_hash, exchange_time_ms, local_received_ms, snapshot_json, created_at_ms)
+VALUES (?, ?, ?, ?, ?, ?, ?)`, "snap_"+symbol, symbol, "hash", at.UnixMilli(), at.UnixMilli(), string(buf), at.UnixMilli())
+	if err != nil {
+		t.Fatalf("snapshot insert: %v", err)
+	}
+}
+
+func insertTestFill(t *testing.T, server *Server, id string, tradeID int64, symbol string, side contracts.Side, price string, qty string, commission string, asset string, at time.Time) {
+	t.Helper()
+	rec := sqlite.OrderFillRecord{
+		OrderIntentID:   id,
+		TradeID:         tradeID,
+		Symbol:          symbol,
+		Side:            string(side),
+		Price:           price,
+		Qty:             qty,
+		Commission:      commission,
+		CommissionAsset: asset,
+		FilledAtMs:      at.UnixMilli(),
+	}
+	if err := sqlite.InsertOrderFill(context.Background(), server.db, rec); err != nil {
+		t.Fatalf("fill insert: %v", err)
+	}
+}
+
+func insertTestIntent(t *testing.T, server *Server, id string, symbol string, side contracts.Side, intent contracts.Intent, qty string, price string, state executor.IntentState, at time.Time) {
+	t.Helper()
+	payload, err := json.Marshal(executor.OrderIntentHashPayload{
+		Mode:           "LIVE",
+		Symbol:         symbol,
+		Side:           side,
+		Intent:         intent,
+		EntryPlanQty:   qty,
+		EntryPlanPrice: price,
+	})
+	if err != nil {
+		t.Fatalf("payload json: %v", err)
+	}
+	rec := sqlite.OrderIntentRecord{
+		OrderIntentID:     id,
+		RunID:             "run",
+		CycleID:           "cycle_1",
+		Mode:              "LIVE",
+		DecisionID:        "dec_" + id,
+		Symbol:            symbol,
+		Action:            string(executor.IntentActionNewOrder),
+		ClientOrderID:     executor.ClientOrderID(id),
+		IntentPayloadJSON: string(payload),
+		State:             string(state),
+		CreatedAtMs:       at.UnixMilli(),
+		UpdatedAtMs:       at.UnixMilli(),
+	}
+	if err := sqlite.InsertOrderIntent(context.Background(), server.db, rec); err != nil {
+		t.Fatalf("intent insert: %v", err)
+	}
+}
+
+func TestDashboardLiveChecklist(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+	empty, err := QueryLiveChecklist(ctx, server.db)
+	if err != nil {
+		t.Fatalf("empty checklist: %v", err)
+	}
+	if empty.Enabled {
+		t.Fatalf("expected checklist disabled without events")
+	}
+	now := time.Now()
+	record := audit.Record{
+		Event: auditdomain.AuditEvent{
+			TsMs:            now.UnixMilli(),
+			RunID:           "run",
+			CycleID:         "cycle",
+			Mode:            "LIVE",
+			Stage:           observability.DOCTOR_CHECKS,
+			EventType:       auditdomain.LIVE_CHECKLIST,
+			Reasons:         []reasoncodes.ReasonCode{reasoncodes.LIVE_CHECKLIST_FAILED, reasoncodes.ENTER_PAUSE},
+			LocalReceivedMs: now.UnixMilli(),
+		},
+		Data: map[string]any{
+			"ok":          false,
+			"missing":     []string{"ws_ok"},
+			"errors":      map[string]string{"ws_ok": "dial refused"},
+			"interval_ms": 60000,
+		},
+	}
+	if err := server.writer.Write(record); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := server.writer.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	snapshot, err := server.buildDashboardSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	checklist := snapshot.LiveChecklist
+	if !checklist.Enabled || checklist.OK || len(checklist.Missing) != 1 || checklist.Missing[0] != "ws_ok" || checklist.Errors["ws_ok"] != "dial refused" {
+		t.Fatalf("unexpected checklist: %+v", checklist)
+	}
+}
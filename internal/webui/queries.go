@@ -14,6 +14,7 @@ import (
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
 	auditdomain "github.com/RodrigoBeloyanis/livespot/internal/domain/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/domain/reasoncodes"
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/executor"
 )
 
 type LatestStage struct {
@@ -264,6 +265,38 @@ FROM ai_gate_events ORDER BY created_at_ms DESC LIMIT 1`)
 	}, nil
 }
 
+func QueryLiveChecklist(ctx context.Context, db *sql.DB) (LiveChecklistSnapshot, error) {
+	row := db.QueryRowContext(ctx, `SELECT ts_ms, data_json
+FROM audit_events WHERE event_type = ? ORDER BY ts_ms DESC LIMIT 1`, auditdomain.LIVE_CHECKLIST)
+	var tsMs int64
+	var dataJSON string
+	if err := row.Scan(&tsMs, &dataJSON); err != nil {
+		if err == sql.ErrNoRows {
+			return LiveChecklistSnapshot{Missing: []string{}, Errors: map[string]string{}}, nil
+		}
+		return LiveChecklistSnapshot{}, err
+	}
+	var payload struct {
+		OK      bool              `json:"ok"`
+		Missing []string          `json:"missing"`
+		Errors  map[string]string `json:"errors"`
+	}
+	_ = json.Unmarshal([]byte(dataJSON), &payload)
+	if payload.Missing == nil {
+		payload.Missing = []string{}
+	}
+	if payload.Errors == nil {
+		payload.Errors = map[string]string{}
+	}
+	return LiveChecklistSnapshot{
+		Enabled: true,
+		TsMs:    tsMs,
+		OK:      payload.OK,
+		Missing: payload.Missing,
+		Errors:  payload.Errors,
+	}, nil
+}
+
 type OrderRow struct {
 	TsMs   int64  `json:"ts_ms"`
 	Symbol string `json:"symbol"`
@@ -274,7 +307,30 @@ type OrderRow struct {
 }
 
 func QueryRecentOrders(ctx context.Context, db *sql.DB, limit int) ([]OrderRow, error) {
-	return []OrderRow{}, nil
+	if limit <= 0 {
+		limit = defaultOrdersLimit
+	}
+	rows, err := db.QueryContext(ctx, `SELECT updated_at_ms, symbol, intent_payload_json, state
+FROM order_intents ORDER BY updated_at_ms DESC, order_intent_id LIMIT ?`, limit)
+	if err != nil {
+		return []OrderRow{}, err
+	}
+	defer rows.Close()
+	out := []OrderRow{}
+	for rows.Next() {
+		var row OrderRow
+		var payloadJSON string
+		if err := rows.Scan(&row.TsMs, &row.Symbol, &payloadJSON, &row.Status); err != nil {
+			return []OrderRow{}, err
+		}
+		var payload executor.OrderIntentHashPayload
+		_ = json.Unmarshal([]byte(payloadJSON), &payload)
+		row.Side = string(payload.Side)
+		row.Qty = payload.EntryPlanQty
+		row.Price = payload.EntryPlanPrice
+		out = append(out, row)
+	}
+	return out, rows.Err()
 }
 
 func DeriveSysMode(alerts []AlertAggregateRow) (string, int64, []string) {
@@ -297,8 +353,10 @@ func DeriveSysMode(alerts []AlertAggregateRow) (string, int64, []string) {
 
 func BuildHealthSnapshot(cfg config.Config, start time.Time, writer *audit.Writer) HealthSnapshot {
 	queueLen, queueCap := 0, 1
+	stats := audit.WriterStats{}
 	if writer != nil {
-		queueLen, queueCap = writer.QueueStats()
+		stats = writer.Stats()
+		queueLen, queueCap = stats.QueueLen, stats.QueueCap
 	}
 	queuePct := int32(0)
 	if queueCap > 0 {
@@ -318,12 +376,12 @@ func BuildHealthSnapshot(cfg config.Config, start time.Time, writer *audit.Write
 		ProcessCPUPctX10000:       0,
 		AuditWriterQueuePctX10000: queuePct,
 		AuditWriterQueueLen:       int64(queueLen),
-		AuditWriterLagMs:          0,
+		AuditWriterLagMs:          stats.LagMs,
 		AuditWriterPressure:       pressure,
 		EventsPerMin:              0,
 		DropsPerMin:               0,
 		LastCycleTsMs:             0,
-		LastWriterCommitTsMs:      0,
+		LastWriterCommitTsMs:      stats.LastCommitMs,
 	}
 }
 
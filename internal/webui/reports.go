@@ -0,0 +1,68 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/RodrigoBeloyanis/livespot/internal/engine/reports"
+)
+
+const (
+	reportsAPIPath   = "/api/reports"
+	reportsAPIPrefix = "/api/reports/"
+)
+
+type ReportsIndex struct {
+	Days []string `json:"days"`
+}
+
+func (s *Server) EnableReports(dir string) {
+	s.reportDir = dir
+	s.mux.HandleFunc(reportsAPIPath, s.wrap(s.reportsIndexHandler))
+	s.mux.HandleFunc(reportsAPIPrefix, s.wrap(s.reportHandler))
+}
+
+func (s *Server) reportsIndexHandler(w http.ResponseWriter, r *http.Request) {
+	days, err := reports.ListDays(s.reportDir)
+	if err != nil {
+		s.writeAudit(r, http.StatusInternalServerError)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ReportsIndex{Days: days})
+}
+
+func (s *Server) reportHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, reportsAPIPrefix)
+	markdown := strings.HasSuffix(name, ".md")
+	day, err := reports.ParseDay(strings.TrimSuffix(name, ".md"))
+	if err != nil {
+		s.writeAudit(r, http.StatusBadRequest)
+		http.Error(w, "invalid report day", http.StatusBadRequest)
+		return
+	}
+	path := reports.JSONPath(s.reportDir, day)
+	contentType := "application/json"
+	if markdown {
+		path = reports.MarkdownPath(s.reportDir, day)
+		contentType = "text/markdown; charset=utf-8"
+	}
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.writeAudit(r, http.StatusNotFound)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.writeAudit(r, http.StatusInternalServerError)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	s.writeAudit(r, http.StatusOK)
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf)
+}
@@ -0,0 +1,48 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReportsRoutes(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "daily-2026-10-18.json"), []byte(`{"day":"2026-10-18"}`), 0o600); err != nil {
+		t.Fatalf("write json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "daily-2026-10-18.md"), []byte("# report\n"), 0o600); err != nil {
+		t.Fatalf("write md: %v", err)
+	}
+	server.EnableReports(dir)
+	handler := server.Handler()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	var index ReportsIndex
+	if err := json.Unmarshal(rr.Body.Bytes(), &index); err != nil || rr.Code != http.StatusOK || len(index.Days) != 1 || index.Days[0] != "2026-10-18" {
+		t.Fatalf("unexpected index: %d %s", rr.Code, rr.Body.String())
+	}
+	for path, want := range map[string]int{
+		"/api/reports/2026-10-18":      http.StatusOK,
+		"/api/reports/2026-10-18.md":   http.StatusOK,
+		"/api/reports/2026-10-17":      http.StatusNotFound,
+		"/api/reports/..%2Fsecret":     http.StatusBadRequest,
+		"/api/reports/2026-10-18.json": http.StatusBadRequest,
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, rr.Code)
+		}
+	}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/reports/2026-10-18.md", nil))
+	if rr.Header().Get("Content-Type") != "text/markdown; charset=utf-8" || rr.Body.String() != "# report\n" {
+		t.Fatalf("unexpected markdown response: %q %q", rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/RodrigoBeloyanis/livespot/internal/audit"
 	"github.com/RodrigoBeloyanis/livespot/internal/config"
+	"github.com/RodrigoBeloyanis/livespot/internal/observability"
 )
 
 type Server struct {
@@ -19,6 +20,15 @@ type Server struct {
 	start  time.Time
 	mux    *http.ServeMux
 	server *http.Server
+
+	controller Controller
+	operators  map[string]string
+	csrfKey    []byte
+
+	metrics *observability.Registry
+	store   *storeMetrics
+
+	reportDir string
 }
 
 func NewServer(cfg config.Config, db *sql.DB, writer *audit.Writer, now func() time.Time) (*Server, error) {
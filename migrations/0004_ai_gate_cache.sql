@@ -0,0 +1,4 @@
+ALTER TABLE ai_gate_events ADD COLUMN cache_hit INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_ai_gate_events_input_model
+  ON ai_gate_events (input_hash, model, created_at_ms);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS ai_gate_outcomes (
+  decision_id TEXT NOT NULL PRIMARY KEY,
+  source TEXT NOT NULL,
+  realized_pnl_usdt TEXT NULL,
+  counterfactual_pnl_usdt TEXT NULL,
+  created_at_ms INTEGER NOT NULL
+);
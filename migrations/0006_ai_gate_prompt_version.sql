@@ -0,0 +1,3 @@
+ALTER TABLE ai_gate_events ADD COLUMN prompt_version TEXT NULL;
+
+ALTER TABLE ai_gate_events ADD COLUMN prompt_hash TEXT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE audit_events ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_events ADD COLUMN record_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_run_event
+  ON audit_events (run_id, event_id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS retention_archives (
+  table_name TEXT NOT NULL,
+  day TEXT NOT NULL,
+  archive_path TEXT NOT NULL,
+  rows INTEGER NOT NULL,
+  cutoff_ms INTEGER NOT NULL,
+  created_at_ms INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_archives_table_day
+  ON retention_archives (table_name, day);
+
+CREATE TABLE IF NOT EXISTS audit_chain_anchors (
+  run_id TEXT NOT NULL PRIMARY KEY,
+  record_hash TEXT NOT NULL,
+  event_id INTEGER NOT NULL,
+  ts_ms INTEGER NOT NULL
+);
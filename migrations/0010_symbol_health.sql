@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS symbol_health (
+  symbol TEXT NOT NULL PRIMARY KEY,
+  quarantined_until_ms INTEGER NOT NULL,
+  recent_rejects_count INTEGER NOT NULL,
+  last_error_code TEXT NULL,
+  updated_at_ms INTEGER NOT NULL,
+  quarantine_trigger TEXT NOT NULL DEFAULT '',
+  reject_ts_json TEXT NOT NULL DEFAULT '[]',
+  ws_disconnect_ts_json TEXT NOT NULL DEFAULT '[]',
+  consecutive_timeouts INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS order_fills (
+  order_intent_id TEXT NOT NULL,
+  trade_id INTEGER NOT NULL,
+  symbol TEXT NOT NULL,
+  side TEXT NOT NULL,
+  price TEXT NOT NULL,
+  qty TEXT NOT NULL,
+  commission TEXT NOT NULL,
+  commission_asset TEXT NOT NULL,
+  filled_at_ms INTEGER NOT NULL,
+  PRIMARY KEY (order_intent_id, trade_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_fills_symbol_filled
+  ON order_fills (symbol, filled_at_ms);
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type GoMigration struct {
+	Name     string
+	Revision int
+	Apply    func(ctx context.Context, tx *sql.Tx) error
+}
+
+var Go = []GoMigration{
+	{Name: "0009_backfill_ai_gate_prompt_version", Revision: 1, Apply: backfillAIGatePromptVersion},
+}
+
+func backfillAIGatePromptVersion(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `UPDATE ai_gate_events SET prompt_version = 'v1'
+WHERE prompt_version IS NULL AND enabled = 1`)
+	if err != nil {
+		return fmt.Errorf("backfill prompt_version: %w", err)
+	}
+	return nil
+}
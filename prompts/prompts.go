@@ -0,0 +1,6 @@
+package prompts
+
+import "embed"
+
+//go:embed *.txt schemas/*.json
+var FS embed.FS
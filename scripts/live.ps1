@@ -17,5 +17,9 @@ if ($RequireOkFile) {
 }
 
 Write-Host "LIVE lock ok."
-& "$PSScriptRoot\\run.ps1" @Args
+$runArgs = @("--live")
+if ($Args) {
+    $runArgs += $Args
+}
+& "$PSScriptRoot\\run.ps1" -Args $runArgs
 exit $LASTEXITCODE